@@ -12,9 +12,12 @@ import (
 	"html"
 	"io"
 	"io/ioutil"
+	"mime"
 	"net/http"
+	"path"
 	"path/filepath"
 	"runtime/debug"
+	"sort"
 	"strings"
 	"time"
 
@@ -22,6 +25,7 @@ import (
 	"github.com/containerd/containerd/remotes"
 	"github.com/docker/distribution/reference"
 	"github.com/gorilla/mux"
+	"github.com/opencontainers/go-digest"
 	"golang.org/x/xerrors"
 
 	"github.com/gitpod-io/gitpod/common-go/log"
@@ -56,11 +60,22 @@ type Config struct {
 	} `json:"repos"`
 	// AllowAnyRepo enables users to access any repo/image, irregardles if they're listed in the
 	// ref config or not.
-	AllowAnyRepo bool `json:"allowAnyRepo"`
-	BlobSpace    struct {
-		Location string `json:"location"`
-		MaxSize  int64  `json:"maxSizeBytes,omitempty"`
-	} `json:"blobSpace"`
+	AllowAnyRepo bool            `json:"allowAnyRepo"`
+	BlobSpace    BlobSpaceConfig `json:"blobSpace"`
+	// AdminAddr is the address of the admin endpoint which lists the content of the blobspace.
+	// The endpoint is disabled if this is empty.
+	AdminAddr string `json:"adminAddr,omitempty"`
+}
+
+// BlobSpaceConfig configures where blobs are stored and when they're removed
+type BlobSpaceConfig struct {
+	Location string `json:"location"`
+	MaxSize  int64  `json:"maxSizeBytes,omitempty"`
+	// EvictionPolicy determines which blobs are removed first once the blobspace exceeds MaxSize: lru (default) or lfu.
+	// The blobs of the refs listed as PrePull are never removed.
+	EvictionPolicy EvictionPolicy `json:"evictionPolicy,omitempty"`
+	// RepoQuotas limits the size of the blobs of a repository, in bytes
+	RepoQuotas map[string]int64 `json:"repoQuotas,omitempty"`
 }
 
 type StringReplacement struct {
@@ -111,6 +126,10 @@ func (reg *Server) Serve() error {
 		http.Error(resp, http.StatusText(http.StatusNotFound), http.StatusNotFound)
 	})
 
+	if reg.Config.AdminAddr != "" {
+		go reg.serveAdmin()
+	}
+
 	var h http.Handler = r
 	if reg.Config.Timeout > 0 {
 		h = http.TimeoutHandler(h, time.Duration(reg.Config.Timeout), "timeout")
@@ -120,6 +139,48 @@ func (reg *Server) Serve() error {
 	return http.ListenAndServe(fmt.Sprintf(":%d", reg.Config.Port), h)
 }
 
+// serveAdmin serves the admin endpoint which lists the content of the blobspace
+func (reg *Server) serveAdmin() {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/blobspace", reg.listBlobspace)
+
+	log.WithField("addr", reg.Config.AdminAddr).Info("blobserve admin server listening")
+	err := http.ListenAndServe(reg.Config.AdminAddr, mux)
+	if err != nil {
+		log.WithError(err).Error("blobserve admin server failed")
+	}
+}
+
+type blobspaceListing struct {
+	TotalSize int64      `json:"totalSize"`
+	MaxSize   int64      `json:"maxSize"`
+	Blobs     []blobInfo `json:"blobs"`
+}
+
+func (reg *Server) listBlobspace(w http.ResponseWriter, req *http.Request) {
+	blobs, err := reg.refstore.blobspace.List()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("cannot list blobspace: %q", err), http.StatusInternalServerError)
+		return
+	}
+
+	// most recently used first
+	sort.Slice(blobs, func(i, j int) bool { return blobs[i].LastUsed.After(blobs[j].LastUsed) })
+	res := blobspaceListing{
+		MaxSize: reg.Config.BlobSpace.MaxSize,
+		Blobs:   blobs,
+	}
+	for _, b := range blobs {
+		res.TotalSize += b.Size
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(res)
+	if err != nil {
+		log.WithError(err).Warn("cannot write blobspace listing")
+	}
+}
+
 // MustServe calls serve and logs any error as Fatal
 func (reg *Server) MustServe() {
 	err := reg.Serve()
@@ -183,13 +244,14 @@ func (reg *Server) serve(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	reg.refstore.blobspace.MarkUsed(hash)
+
 	log.WithField("path", req.URL.Path).Debug("handling blobserve")
 	pathPrefix := fmt.Sprintf("/%s:%s", repo, tag)
 	if req.URL.Path == pathPrefix {
 		req.URL.Path += "/"
 	}
 
-	w.Header().Set("ETag", hash)
 	w.Header().Set("Cache-Control", "no-cache")
 
 	// http.FileServer has a special case where ServeFile redirects any request where r.URL.Path
@@ -216,10 +278,21 @@ func (reg *Server) serve(w http.ResponseWriter, req *http.Request) {
 		if err != nil {
 			log.WithError(err).Error()
 		}
+		// the inlined vars are part of the content
+		w.Header().Set("ETag", etag(hash, "/index.html"+req.Header.Get("X-BlobServe-InlineVars"), ""))
 		http.ServeContent(w, req, "index.html", modTime, content)
 		return
 	}
 
+	// The content of a file is determined by the layer digest and its path. Clients revalidate
+	// using that ETag and get a 304 unless the image changed.
+	imagePath = path.Clean("/" + imagePath)
+	w.Header().Set("ETag", etag(hash, imagePath, ""))
+	w.Header().Add("Vary", "Accept-Encoding")
+	if vfs, ok := blob.(variantFileSystem); ok && serveVariant(w, req, vfs, filepath.Join(workdir, imagePath), hash, imagePath) {
+		return
+	}
+
 	var fs http.FileSystem
 	fs = blob
 	if workdir != "" {
@@ -228,6 +301,49 @@ func (reg *Server) serve(w http.ResponseWriter, req *http.Request) {
 	http.StripPrefix(pathPrefix, http.FileServer(fs)).ServeHTTP(w, req)
 }
 
+// variantFileSystem is a filesystem which contains precompressed variants of its files
+type variantFileSystem interface {
+	OpenVariant(name string, encoding string) (http.File, error)
+}
+
+// serveVariant serves the precompressed variant of a file if the client accepts one and it exists.
+// Returns false if the file has to be served uncompressed.
+func serveVariant(w http.ResponseWriter, req *http.Request, fs variantFileSystem, fn, hash, imagePath string) bool {
+	encoding := negotiateEncoding(req.Header.Get("Accept-Encoding"))
+	if encoding == "" {
+		return false
+	}
+	f, err := fs.OpenVariant(fn, encoding)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil || stat.IsDir() {
+		return false
+	}
+
+	ctype := mime.TypeByExtension(filepath.Ext(fn))
+	if ctype == "" {
+		ctype = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", ctype)
+	w.Header().Set("Content-Encoding", encoding)
+	w.Header().Set("ETag", etag(hash, imagePath, encoding))
+	http.ServeContent(w, req, fn, stat.ModTime(), f)
+	return true
+}
+
+// etag produces a strong ETag for a file of a blob. Each content encoding is a different representation
+// of the file, hence gets its own ETag.
+func etag(hash, imagePath, encoding string) string {
+	tag := digest.FromString(hash + ":" + imagePath).Encoded()
+	if encoding != "" {
+		tag += "-" + encoding
+	}
+	return fmt.Sprintf(`"%s"`, tag)
+}
+
 func inlineVars(req *http.Request, r io.ReadSeeker, inlineReplacements []InlineReplacement) (io.ReadSeeker, error) {
 	inlineVarsValue := req.Header.Get("X-BlobServe-InlineVars")
 	if len(inlineReplacements) == 0 || inlineVarsValue == "" {
@@ -17,6 +17,8 @@ import (
 	"path/filepath"
 	"sort"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/containerd/containerd/errdefs"
@@ -31,28 +33,69 @@ type FileModifier func(in io.Reader, out io.Writer) error
 type blobspace interface {
 	Get(name string) (fs http.FileSystem, state blobstate)
 	AddFromTarGzip(ctx context.Context, name string, in io.Reader, modifications []blobModifier) (err error)
+	// Annotate records the repository a blob was pulled from and if it must not be evicted
+	Annotate(name string, repo string, pinned bool)
+	// MarkUsed records that a blob was served
+	MarkUsed(name string)
+	// List describes all blobs in the blobspace
+	List() ([]blobInfo, error)
 }
 
+// EvictionPolicy determines which blobs are removed first when the blobspace needs space
+type EvictionPolicy string
+
+const (
+	// EvictionPolicyLRU removes the least recently used blobs first
+	EvictionPolicyLRU EvictionPolicy = "lru"
+	// EvictionPolicyLFU removes the least frequently used blobs first
+	EvictionPolicyLFU EvictionPolicy = "lfu"
+)
+
 type diskBlobspace struct {
-	Location string
-	MaxSize  int64
+	Location       string
+	MaxSize        int64
+	EvictionPolicy EvictionPolicy
+	RepoQuotas     map[string]int64
+
+	mu     sync.Mutex
+	hits   map[string]blobHits
+	pinned map[string]struct{}
+}
+
+// blobHits counts how often a blob was served until it was last used
+type blobHits struct {
+	Hits     int64
+	LastUsed time.Time
 }
 
-func newBlobSpace(loc string, maxSize int64, housekeepingInterval time.Duration) (bs *diskBlobspace, err error) {
+func newBlobSpace(cfg BlobSpaceConfig, housekeepingInterval time.Duration) (bs *diskBlobspace, err error) {
+	loc := cfg.Location
 	if tproot := os.Getenv("TELEPRESENCE_ROOT"); tproot != "" {
 		loc = filepath.Join(tproot, loc)
 	}
 
+	switch cfg.EvictionPolicy {
+	case "":
+		cfg.EvictionPolicy = EvictionPolicyLRU
+	case EvictionPolicyLRU, EvictionPolicyLFU:
+	default:
+		return nil, xerrors.Errorf("unknown eviction policy: %s", cfg.EvictionPolicy)
+	}
+
 	err = os.MkdirAll(loc, 0755)
 	if err != nil {
 		return
 	}
 
 	bs = &diskBlobspace{
-		Location: loc,
-		MaxSize:  maxSize,
+		Location:       loc,
+		MaxSize:        cfg.MaxSize,
+		EvictionPolicy: cfg.EvictionPolicy,
+		RepoQuotas:     cfg.RepoQuotas,
+		hits:           make(map[string]blobHits),
+		pinned:         make(map[string]struct{}),
 	}
-	if maxSize > 0 {
+	if bs.MaxSize > 0 || len(bs.RepoQuotas) > 0 {
 		go bs.collectGarbage(housekeepingInterval)
 	}
 	return
@@ -68,95 +111,180 @@ const (
 
 const (
 	minBlobAge = 20 * time.Minute
+
+	// hitsHalfLife is the time after which the hits of a blob that is no longer used count only half.
+	// Without aging, blobs which were popular once would never be evicted under the LFU policy.
+	hitsHalfLife = 24 * time.Hour
 )
 
 func (b *diskBlobspace) collectGarbage(interval time.Duration) {
 	t := time.NewTicker(interval)
 	defer t.Stop()
 
-	for {
-		log.Debug("starting blobspace GC")
-		var (
-			blobs     []gcBlob
-			totalSize int64
-		)
-
-		files, err := os.ReadDir(b.Location)
-		if err != nil {
-			log.WithError(err).WithField("location", b.Location).Error("blobspace cannot list files in working area")
-		}
+	// We wait an interval before the first collection, so that the pre-pulled blobs are pinned by then.
+	for range t.C {
+		b.collect()
+	}
+}
 
-		for _, f := range files {
-			if !f.IsDir() {
-				continue
-			}
+func (b *diskBlobspace) collect() {
+	log.Debug("starting blobspace GC")
+	blobs, err := b.List()
+	if err != nil {
+		log.WithError(err).WithField("location", b.Location).Error("blobspace cannot list files in working area")
+	}
 
-			blob := getGCBlob(b.Location, f)
-			if blob.Size == 0 && time.Since(blob.LastUsed) > minBlobAge {
-				// this blob has neither been used nor ready for long enough
-				// let's remove it
+	var candidates []blobInfo
+	for _, blob := range blobs {
+		if !blob.Ready && time.Since(blob.LastUsed) > minBlobAge {
+			// this blob has neither been used nor ready for long enough
+			// let's remove it
 
-				// TODO: also remove this blob if we're not aware of it being initialized at the moment
-				log.WithField("location", blob.F).Info("removing too old unready blob")
+			// TODO: also remove this blob if we're not aware of it being initialized at the moment
+			log.WithField("location", blob.F).Info("removing too old unready blob")
 
-				err = os.RemoveAll(blob.F)
-				if err != nil {
-					log.WithError(err).WithField("location", blob.F).Error("cannot remove blob")
-				}
-				continue
+			os.Remove(fmt.Sprintf("%s.repo", blob.F))
+			os.RemoveAll(variantsLocation(blob.F))
+			err = os.RemoveAll(blob.F)
+			if err != nil {
+				log.WithError(err).WithField("location", blob.F).Error("cannot remove blob")
 			}
+			continue
+		}
+		candidates = append(candidates, blob)
+	}
 
-			blobs = append(blobs, blob)
-			totalSize += blob.Size
+	var spaceFreed int64
+	for _, blob := range selectEvictions(candidates, b.MaxSize, b.RepoQuotas, b.EvictionPolicy) {
+		log.WithField("location", blob.F).WithField("repo", blob.Repo).WithField("lastUsed", blob.LastUsed.Format(time.RFC3339Nano)).WithField("hits", blob.Hits).Info("removing blob to make some space")
+
+		os.Remove(fmt.Sprintf("%s.ready", blob.F))
+		os.Remove(fmt.Sprintf("%s.size", blob.F))
+		os.Remove(fmt.Sprintf("%s.used", blob.F))
+		os.Remove(fmt.Sprintf("%s.hits", blob.F))
+		os.Remove(fmt.Sprintf("%s.repo", blob.F))
+		os.RemoveAll(variantsLocation(blob.F))
+		err = os.RemoveAll(blob.F)
+		if err != nil {
+			log.WithError(err).WithField("location", blob.F).Error("cannot remove blob")
+			continue
 		}
 
-		var spaceFreed int64
-		if totalSize > b.MaxSize {
-			// oldest first
-			sort.Slice(blobs, func(i, j int) bool { return blobs[j].LastUsed.After(blobs[i].LastUsed) })
-
-			for totalSize > b.MaxSize && len(blobs) > 0 {
-				blob := blobs[0]
-				blobs = blobs[1:]
-
-				log.WithField("location", blob.F).WithField("lastUsed", blob.LastUsed.Format(time.RFC3339Nano)).Info("removing old blob to make some space")
-
-				os.Remove(fmt.Sprintf("%s.ready", blob.F))
-				os.Remove(fmt.Sprintf("%s.size", blob.F))
-				os.Remove(fmt.Sprintf("%s.used", blob.F))
-				err = os.RemoveAll(blob.F)
-				if err != nil {
-					log.WithError(err).WithField("location", blob.F).Error("cannot remove blob")
-					continue
-				}
-				totalSize -= blob.Size
-				spaceFreed += blob.Size
-			}
+		b.mu.Lock()
+		delete(b.hits, blob.Name)
+		b.mu.Unlock()
+		spaceFreed += blob.Size
+	}
+	log.WithField("spaceFreed", spaceFreed).Info("blobspace GC complete")
+}
+
+// selectEvictions selects the blobs which have to be removed so that no repository exceeds its quota and
+// the blobspace does not exceed maxSize. Pinned blobs are never selected.
+func selectEvictions(blobs []blobInfo, maxSize int64, quotas map[string]int64, policy EvictionPolicy) (res []blobInfo) {
+	blobs = append([]blobInfo{}, blobs...)
+	sort.SliceStable(blobs, func(i, j int) bool {
+		if policy == EvictionPolicyLFU && blobs[i].Hits != blobs[j].Hits {
+			return blobs[i].Hits < blobs[j].Hits
 		}
-		log.WithField("spaceFreed", spaceFreed).Info("blobspace GC complete")
+		// oldest first
+		return blobs[j].LastUsed.After(blobs[i].LastUsed)
+	})
+
+	var (
+		totalSize int64
+		repoSize  = make(map[string]int64)
+		evicted   = make([]bool, len(blobs))
+	)
+	for _, blob := range blobs {
+		totalSize += blob.Size
+		repoSize[blob.Repo] += blob.Size
+	}
+	evict := func(i int) {
+		evicted[i] = true
+		totalSize -= blobs[i].Size
+		repoSize[blobs[i].Repo] -= blobs[i].Size
+		res = append(res, blobs[i])
+	}
 
-		<-t.C
+	for i, blob := range blobs {
+		if blob.Pinned || blob.Repo == "" {
+			continue
+		}
+		if quota, ok := quotas[blob.Repo]; ok && repoSize[blob.Repo] > quota {
+			evict(i)
+		}
 	}
+
+	if maxSize <= 0 {
+		return res
+	}
+	for i, blob := range blobs {
+		if totalSize <= maxSize {
+			break
+		}
+		if blob.Pinned || evicted[i] {
+			continue
+		}
+		evict(i)
+	}
+	return res
 }
 
-type gcBlob struct {
-	F        string
-	LastUsed time.Time
-	Size     int64
+// blobInfo describes a blob in the blobspace
+type blobInfo struct {
+	F        string    `json:"-"`
+	Name     string    `json:"name"`
+	Repo     string    `json:"repo,omitempty"`
+	Ready    bool      `json:"ready"`
+	Size     int64     `json:"size"`
+	LastUsed time.Time `json:"lastUsed"`
+	Hits     int64     `json:"hits"`
+	Pinned   bool      `json:"pinned"`
 }
 
-func getGCBlob(wd string, f os.DirEntry) (blob gcBlob) {
-	finfo, _ := f.Info()
+// List describes all blobs in the blobspace
+func (b *diskBlobspace) List() ([]blobInfo, error) {
+	files, err := os.ReadDir(b.Location)
+	if err != nil {
+		return nil, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
 
+	var res []blobInfo
+	for _, f := range files {
+		if !f.IsDir() {
+			continue
+		}
+		if strings.HasSuffix(f.Name(), variantsSuffix) {
+			// variants are removed together with their blob
+			continue
+		}
+
+		blob := getBlobInfo(b.Location, f)
+		_, blob.Pinned = b.pinned[blob.Name]
+		res = append(res, blob)
+	}
+	return res, nil
+}
+
+func getBlobInfo(wd string, f os.DirEntry) (blob blobInfo) {
 	fn := filepath.Join(wd, f.Name())
-	blob = gcBlob{
-		F:        fn,
-		LastUsed: finfo.ModTime(),
-		Size:     0,
+	blob = blobInfo{
+		F:    fn,
+		Name: f.Name(),
+	}
+	if finfo, err := f.Info(); err == nil {
+		blob.LastUsed = finfo.ModTime()
+	}
+	if repo, err := os.ReadFile(fmt.Sprintf("%s.repo", fn)); err == nil {
+		blob.Repo = string(repo)
 	}
 	if _, err := os.Stat(fmt.Sprintf("%s.ready", fn)); os.IsNotExist(err) {
 		return
 	}
+	blob.Ready = true
 
 	if rawSize, err := os.ReadFile(fmt.Sprintf("%s.size", fn)); err == nil {
 		if size, err := strconv.ParseInt(string(rawSize), 10, 64); err == nil {
@@ -167,10 +295,53 @@ func getGCBlob(wd string, f os.DirEntry) (blob gcBlob) {
 	if stat, err := os.Stat(fmt.Sprintf("%s.used", fn)); err == nil {
 		blob.LastUsed = stat.ModTime()
 	}
+	blob.Hits = agedHits(readHits(fn), blob.LastUsed, time.Now())
 
 	return
 }
 
+// readHits reads the number of times a blob was served until it was last used
+func readHits(fn string) int64 {
+	rawHits, err := os.ReadFile(fmt.Sprintf("%s.hits", fn))
+	if err != nil {
+		return 0
+	}
+	hits, err := strconv.ParseInt(string(rawHits), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return hits
+}
+
+// agedHits halves the hits of a blob for every hitsHalfLife that has passed since it was last used
+func agedHits(hits int64, lastUsed, now time.Time) int64 {
+	if lastUsed.IsZero() || !now.After(lastUsed) {
+		return hits
+	}
+	halfLifes := now.Sub(lastUsed) / hitsHalfLife
+	if halfLifes >= 63 {
+		return 0
+	}
+	return hits >> uint(halfLifes)
+}
+
+// Annotate records the repository a blob was pulled from and if it must not be evicted.
+// Pins are not persisted - the refstore pins the blobs of the pre-pulled refs whenever it starts.
+func (b *diskBlobspace) Annotate(name string, repo string, pinned bool) {
+	fn := filepath.Join(b.Location, name)
+	err := os.WriteFile(fmt.Sprintf("%s.repo", fn), []byte(repo), 0644)
+	if err != nil {
+		log.WithError(err).WithField("location", fn).Warn("cannot record blob repository - quotas won't apply to it")
+	}
+
+	if !pinned {
+		return
+	}
+	b.mu.Lock()
+	b.pinned[name] = struct{}{}
+	b.mu.Unlock()
+}
+
 func (b *diskBlobspace) Get(name string) (fs http.FileSystem, state blobstate) {
 	fn := filepath.Join(b.Location, name)
 	if _, err := os.Stat(fn); os.IsNotExist(err) {
@@ -180,8 +351,30 @@ func (b *diskBlobspace) Get(name string) (fs http.FileSystem, state blobstate) {
 		return nil, blobUnready
 	}
 
+	return blobFS{Dir: http.Dir(fn), variants: http.Dir(variantsLocation(fn))}, blobReady
+}
+
+// MarkUsed records that a blob was served. The hits are persisted next to the time the blob was
+// last used, so that the LFU policy survives restarts.
+func (b *diskBlobspace) MarkUsed(name string) {
+	fn := filepath.Join(b.Location, name)
+	now := time.Now()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	hits, ok := b.hits[name]
+	if !ok {
+		hits.Hits = readHits(fn)
+		if stat, err := os.Stat(fmt.Sprintf("%s.used", fn)); err == nil {
+			hits.LastUsed = stat.ModTime()
+		}
+	}
+	hits = blobHits{Hits: agedHits(hits.Hits, hits.LastUsed, now) + 1, LastUsed: now}
+	b.hits[name] = hits
+
 	os.WriteFile(fmt.Sprintf("%s.used", fn), nil, 0644)
-	return http.Dir(fn), blobReady
+	os.WriteFile(fmt.Sprintf("%s.hits", fn), []byte(strconv.FormatInt(hits.Hits, 10)), 0644)
 }
 
 // AddFromTar adds content to this store under the given name.
@@ -220,7 +413,13 @@ func (b *diskBlobspace) AddFromTar(ctx context.Context, name string, in io.Reade
 		}
 	}
 
-	os.WriteFile(fmt.Sprintf("%s.size", fn), []byte(fmt.Sprintf("%d", cw.C)), 0644)
+	// precompressing text assets once is much cheaper than compressing them whenever they're served
+	variantsSize, err := b.compressVariants(ctx, name)
+	if err != nil {
+		return xerrors.Errorf("cannot compress blob: %w", err)
+	}
+
+	os.WriteFile(fmt.Sprintf("%s.size", fn), []byte(fmt.Sprintf("%d", cw.C+variantsSize)), 0644)
 	os.WriteFile(fmt.Sprintf("%s.used", fn), nil, 0644)
 	os.WriteFile(fmt.Sprintf("%s.ready", fn), nil, 0644)
 
@@ -13,6 +13,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 )
@@ -181,3 +182,108 @@ func Test_inlineVars(t *testing.T) {
 		})
 	}
 }
+
+func Test_selectEvictions(t *testing.T) {
+	var (
+		now     = time.Now()
+		ide     = blobInfo{Name: "ide", Repo: "gitpod/ide", Size: 50, LastUsed: now.Add(-3 * time.Hour), Hits: 100, Pinned: true}
+		popular = blobInfo{Name: "popular", Repo: "gitpod/ide", Size: 20, LastUsed: now.Add(-2 * time.Hour), Hits: 50}
+		recent  = blobInfo{Name: "recent", Repo: "gitpod/ide", Size: 20, LastUsed: now.Add(-1 * time.Minute), Hits: 1}
+		other   = blobInfo{Name: "other", Repo: "gitpod/other", Size: 20, LastUsed: now.Add(-1 * time.Hour), Hits: 5}
+		unknown = blobInfo{Name: "unknown", Size: 20, LastUsed: now.Add(-4 * time.Hour)}
+		blobs   = []blobInfo{ide, popular, recent, other, unknown}
+	)
+
+	tests := []struct {
+		Name        string
+		MaxSize     int64
+		Quotas      map[string]int64
+		Policy      EvictionPolicy
+		Expectation []string
+	}{
+		{Name: "fits", MaxSize: 200, Policy: EvictionPolicyLRU},
+		{Name: "no limit", Policy: EvictionPolicyLRU},
+		{Name: "lru", MaxSize: 100, Policy: EvictionPolicyLRU, Expectation: []string{"unknown", "popular"}},
+		{Name: "lfu", MaxSize: 100, Policy: EvictionPolicyLFU, Expectation: []string{"unknown", "recent"}},
+		{Name: "pinned blobs stay", MaxSize: 10, Policy: EvictionPolicyLRU, Expectation: []string{"unknown", "popular", "other", "recent"}},
+		{Name: "repo quota", MaxSize: 200, Quotas: map[string]int64{"gitpod/ide": 75}, Policy: EvictionPolicyLRU, Expectation: []string{"popular"}},
+		{Name: "repo quota without max size", Quotas: map[string]int64{"gitpod/other": 10}, Policy: EvictionPolicyLRU, Expectation: []string{"other"}},
+		{Name: "repo quota and max size", MaxSize: 80, Quotas: map[string]int64{"gitpod/other": 10}, Policy: EvictionPolicyLRU, Expectation: []string{"other", "unknown", "popular"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			var act []string
+			for _, b := range selectEvictions(blobs, tt.MaxSize, tt.Quotas, tt.Policy) {
+				act = append(act, b.Name)
+			}
+			if diff := cmp.Diff(tt.Expectation, act); diff != "" {
+				t.Errorf("selectEvictions() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_diskBlobspace_MarkUsed(t *testing.T) {
+	loc := t.TempDir()
+	err := os.Mkdir(filepath.Join(loc, "blob"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(loc, "blob.ready"), nil, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bs, err := newBlobSpace(BlobSpaceConfig{Location: loc}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		// polling for a blob must not count as a hit
+		bs.Get("blob")
+	}
+	bs.MarkUsed("blob")
+	bs.MarkUsed("blob")
+
+	// hits must survive a restart
+	bs, err = newBlobSpace(BlobSpaceConfig{Location: loc}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blobs, err := bs.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var hits []int64
+	for _, b := range blobs {
+		hits = append(hits, b.Hits)
+	}
+	if diff := cmp.Diff([]int64{2}, hits); diff != "" {
+		t.Errorf("unexpected hits (-want +got):\n%s", diff)
+	}
+}
+
+func Test_agedHits(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		Name     string
+		Hits     int64
+		LastUsed time.Time
+		Expected int64
+	}{
+		{Name: "never used", Hits: 10, Expected: 10},
+		{Name: "just used", Hits: 10, LastUsed: now, Expected: 10},
+		{Name: "within half life", Hits: 10, LastUsed: now.Add(-hitsHalfLife + time.Minute), Expected: 10},
+		{Name: "one half life", Hits: 10, LastUsed: now.Add(-hitsHalfLife), Expected: 5},
+		{Name: "two half lifes", Hits: 10, LastUsed: now.Add(-2 * hitsHalfLife), Expected: 2},
+		{Name: "forgotten", Hits: 10, LastUsed: now.Add(-100 * hitsHalfLife), Expected: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			act := agedHits(tt.Hits, tt.LastUsed, now)
+			if act != tt.Expected {
+				t.Errorf("agedHits() = %d, expected %d", act, tt.Expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,213 @@
+// Copyright (c) 2021 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package blobserve
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+
+	"github.com/gitpod-io/gitpod/common-go/log"
+)
+
+const (
+	// minCompressSize is the size below which compressing a file isn't worth it
+	minCompressSize = 1024
+	// maxCompressSize is the size above which we don't precompute variants of a file
+	maxCompressSize = 64 << 20
+
+	// brotliLevel trades compression ratio for the time it takes to add an IDE image,
+	// which has a couple of thousand files, to the blobspace.
+	brotliLevel = 9
+
+	// variantsSuffix is the suffix of the directory next to a blob which contains the variants of its files
+	variantsSuffix = ".variants"
+)
+
+// contentEncoding is an encoding we precompute variants of text assets in
+type contentEncoding struct {
+	Name      string
+	Ext       string
+	NewWriter func(w io.Writer) io.WriteCloser
+}
+
+// contentEncodings are the encodings we precompute, in order of preference
+var contentEncodings = []contentEncoding{
+	{Name: "br", Ext: ".br", NewWriter: func(w io.Writer) io.WriteCloser { return brotli.NewWriterLevel(w, brotliLevel) }},
+	{Name: "gzip", Ext: ".gz", NewWriter: func(w io.Writer) io.WriteCloser {
+		zw, _ := gzip.NewWriterLevel(w, gzip.BestCompression)
+		return zw
+	}},
+}
+
+// compressibleExtensions are the extensions of the text assets we precompute variants of
+var compressibleExtensions = map[string]struct{}{
+	".css":  {},
+	".html": {},
+	".js":   {},
+	".json": {},
+	".map":  {},
+	".md":   {},
+	".mjs":  {},
+	".svg":  {},
+	".txt":  {},
+	".wasm": {},
+	".xml":  {},
+}
+
+func variantsLocation(fn string) string {
+	return fn + variantsSuffix
+}
+
+// compressVariants precomputes the compressed variants of all text assets in a blob.
+// Variants are stored next to the blob, not in it, so that they're not served as files.
+func (b *diskBlobspace) compressVariants(ctx context.Context, name string) (size int64, err error) {
+	var (
+		fn       = filepath.Join(b.Location, name)
+		variants = variantsLocation(fn)
+	)
+	err = os.RemoveAll(variants)
+	if err != nil {
+		return 0, err
+	}
+
+	err = filepath.WalkDir(fn, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		if _, ok := compressibleExtensions[strings.ToLower(filepath.Ext(path))]; !ok {
+			return nil
+		}
+		stat, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if stat.Size() < minCompressSize || stat.Size() > maxCompressSize {
+			return nil
+		}
+
+		rel, err := filepath.Rel(fn, path)
+		if err != nil {
+			return err
+		}
+		for _, enc := range contentEncodings {
+			n, err := compressFile(path, filepath.Join(variants, rel+enc.Ext), stat.Size(), enc)
+			if err != nil {
+				log.WithError(err).WithField("fn", path).WithField("encoding", enc.Name).Warn("cannot compress file - serving it uncompressed")
+				continue
+			}
+			size += n
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return size, nil
+}
+
+// compressFile compresses src to dst unless that doesn't make it meaningfully smaller
+func compressFile(src, dst string, srcSize int64, enc contentEncoding) (size int64, err error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return 0, err
+	}
+	defer in.Close()
+
+	err = os.MkdirAll(filepath.Dir(dst), 0755)
+	if err != nil {
+		return 0, err
+	}
+	out, err := os.Create(dst)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		out.Close()
+		if err != nil || size == 0 {
+			os.Remove(dst)
+		}
+	}()
+
+	var cw countingWriter
+	zw := enc.NewWriter(io.MultiWriter(out, &cw))
+	_, err = io.Copy(zw, in)
+	if err != nil {
+		return 0, err
+	}
+	err = zw.Close()
+	if err != nil {
+		return 0, err
+	}
+
+	if cw.C >= srcSize*9/10 {
+		return 0, nil
+	}
+	return cw.C, nil
+}
+
+// blobFS serves the files of a blob and their precompressed variants
+type blobFS struct {
+	http.Dir
+
+	variants http.Dir
+}
+
+// OpenVariant opens the precompressed variant of a file in the given content encoding
+func (b blobFS) OpenVariant(name string, encoding string) (http.File, error) {
+	for _, enc := range contentEncodings {
+		if enc.Name == encoding {
+			return b.variants.Open(name + enc.Ext)
+		}
+	}
+	return nil, os.ErrNotExist
+}
+
+// negotiateEncoding picks the most preferred of our content encodings that the Accept-Encoding header allows.
+// It returns an empty string if the client does not accept any of them.
+func negotiateEncoding(acceptEncoding string) string {
+	accepted := make(map[string]bool)
+	for _, e := range strings.Split(acceptEncoding, ",") {
+		var (
+			segs = strings.Split(e, ";")
+			name = strings.ToLower(strings.TrimSpace(segs[0]))
+			ok   = true
+		)
+		for _, param := range segs[1:] {
+			param = strings.TrimSpace(param)
+			if !strings.HasPrefix(param, "q=") {
+				continue
+			}
+			q, err := strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64)
+			ok = err == nil && q > 0
+		}
+		accepted[name] = ok
+	}
+
+	for _, enc := range contentEncodings {
+		ok, listed := accepted[enc.Name]
+		if !listed {
+			ok = accepted["*"]
+		}
+		if ok {
+			return enc.Name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,150 @@
+// Copyright (c) 2021 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package blobserve
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	tests := []struct {
+		AcceptEncoding string
+		Expectation    string
+	}{
+		{AcceptEncoding: "", Expectation: ""},
+		{AcceptEncoding: "gzip, deflate, br", Expectation: "br"},
+		{AcceptEncoding: "gzip", Expectation: "gzip"},
+		{AcceptEncoding: "br;q=0, gzip;q=0.5", Expectation: "gzip"},
+		{AcceptEncoding: "*", Expectation: "br"},
+		{AcceptEncoding: "*, br;q=0", Expectation: "gzip"},
+		{AcceptEncoding: "identity", Expectation: ""},
+		{AcceptEncoding: "GZIP", Expectation: "gzip"},
+	}
+	for _, test := range tests {
+		t.Run(test.AcceptEncoding, func(t *testing.T) {
+			if act := negotiateEncoding(test.AcceptEncoding); act != test.Expectation {
+				t.Errorf("expected %q, got %q", test.Expectation, act)
+			}
+		})
+	}
+}
+
+func newTestTarGzip(t *testing.T, files map[string]string) []byte {
+	var (
+		buf bytes.Buffer
+		zw  = gzip.NewWriter(&buf)
+		tw  = tar.NewWriter(zw)
+	)
+	for name, content := range files {
+		err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = tw.Write([]byte(content))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := tw.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = zw.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestServeVariant(t *testing.T) {
+	var (
+		script = strings.Repeat("console.log('hello world');\n", 100)
+		image  = strings.Repeat("\x00", 2048)
+	)
+	bs, err := newBlobSpace(BlobSpaceConfig{Location: t.TempDir()}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = bs.AddFromTarGzip(context.Background(), "blob", bytes.NewReader(newTestTarGzip(t, map[string]string{
+		"ide/main.js":   script,
+		"ide/small.js":  "console.log('hello world');",
+		"ide/image.png": image,
+	})), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs, state := bs.Get("blob")
+	if state != blobReady {
+		t.Fatalf("blob is not ready: %v", state)
+	}
+	vfs := fs.(variantFileSystem)
+
+	serve := func(fn, acceptEncoding, ifNoneMatch string) (*httptest.ResponseRecorder, bool) {
+		req := httptest.NewRequest(http.MethodGet, "/image:latest"+fn, nil)
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		rec := httptest.NewRecorder()
+		ok := serveVariant(rec, req, vfs, "/ide"+fn, "hash", fn)
+		return rec, ok
+	}
+
+	decoders := map[string]func(io.Reader) (io.Reader, error){
+		"br":   func(r io.Reader) (io.Reader, error) { return brotli.NewReader(r), nil },
+		"gzip": func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) },
+	}
+	for encoding, decode := range decoders {
+		rec, ok := serve("/main.js", encoding, "")
+		if !ok || rec.Code != http.StatusOK {
+			t.Fatalf("%s: variant was not served: %d", encoding, rec.Code)
+		}
+		if act := rec.Header().Get("Content-Encoding"); act != encoding {
+			t.Errorf("%s: unexpected content encoding %q", encoding, act)
+		}
+		if act := rec.Header().Get("Content-Type"); !strings.HasPrefix(act, "text/javascript") && !strings.HasPrefix(act, "application/javascript") {
+			t.Errorf("%s: unexpected content type %q", encoding, act)
+		}
+		r, err := decode(rec.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(content) != script {
+			t.Errorf("%s: variant does not decode to the original file", encoding)
+		}
+
+		etag := rec.Header().Get("ETag")
+		if etag != `"`+strings.Trim(etag, `"`)+`"` || !strings.HasSuffix(etag, "-"+encoding+`"`) {
+			t.Errorf("%s: unexpected ETag %q", encoding, etag)
+		}
+		rec, ok = serve("/main.js", encoding, etag)
+		if !ok || rec.Code != http.StatusNotModified {
+			t.Errorf("%s: expected %d for conditional request, got %d", encoding, http.StatusNotModified, rec.Code)
+		}
+	}
+
+	for _, fn := range []string{"/small.js", "/image.png", "/missing.js"} {
+		if _, ok := serve(fn, "br, gzip", ""); ok {
+			t.Errorf("served a variant of %s", fn)
+		}
+	}
+	if _, ok := serve("/main.js", "identity", ""); ok {
+		t.Error("served a variant to a client which does not accept one")
+	}
+}
@@ -48,18 +48,22 @@ type refstore struct {
 	requests  chan downloadRequest
 	blobspace blobspace
 	config    map[string]blobConfig
+	pinned    map[string]struct{}
 
 	close chan struct{}
 	once  *sync.Once
 }
 
 func newRefStore(cfg Config, resolver ResolverProvider) (*refstore, error) {
-	bs, err := newBlobSpace(cfg.BlobSpace.Location, cfg.BlobSpace.MaxSize, 10*time.Minute)
+	bs, err := newBlobSpace(cfg.BlobSpace, 10*time.Minute)
 	if err != nil {
 		return nil, err
 	}
 
-	config := make(map[string]blobConfig)
+	var (
+		config = make(map[string]blobConfig)
+		pinned = make(map[string]struct{})
+	)
 	for ref, repo := range cfg.Repos {
 		mods := make([]blobModifier, 0, len(repo.Replacements))
 		for _, mod := range repo.Replacements {
@@ -70,12 +74,18 @@ func newRefStore(cfg Config, resolver ResolverProvider) (*refstore, error) {
 			Workdir:  repo.Workdir,
 			Modifier: mods,
 		}
+
+		// we never evict what we pre-pull - those are the blobs everyone uses
+		for _, ver := range repo.PrePull {
+			pinned[ref+":"+ver] = struct{}{}
+		}
 	}
 
 	res := &refstore{
 		Resolver:  resolver,
 		blobspace: bs,
 		config:    config,
+		pinned:    pinned,
 		refcache:  make(map[string]*refstate),
 		requests:  make(chan downloadRequest),
 		once:      &sync.Once{},
@@ -255,6 +265,14 @@ func (store *refstore) handleRequest(ctx context.Context, ref string, force bool
 		return err
 	}
 
+	pref, err := reference.ParseNamed(ref)
+	if err != nil {
+		return err
+	}
+	repo := reference.Domain(pref) + "/" + reference.Path(pref)
+	_, pinned := store.pinned[ref]
+	store.blobspace.Annotate(digest, repo, pinned)
+
 	for {
 		_, state := store.blobspace.Get(digest)
 		switch state {
@@ -264,13 +282,8 @@ func (store *refstore) handleRequest(ctx context.Context, ref string, force bool
 				return err
 			}
 
-			pref, err := reference.ParseNamed(ref)
-			if err != nil {
-				return err
-			}
-
 			var mods []blobModifier
-			cfg, ok := store.config[reference.Domain(pref)+"/"+reference.Path(pref)]
+			cfg, ok := store.config[repo]
 			if ok {
 				mods = cfg.Modifier
 			}
@@ -447,6 +447,14 @@ func (s *inMemoryBlobspace) AddFromTarGzip(ctx context.Context, name string, in
 	return s.Adder(ctx, name, in)
 }
 
+func (s *inMemoryBlobspace) Annotate(name string, repo string, pinned bool) {}
+
+func (s *inMemoryBlobspace) MarkUsed(name string) {}
+
+func (s *inMemoryBlobspace) List() ([]blobInfo, error) {
+	return nil, nil
+}
+
 type provider func() ([]byte, error)
 
 type fakeFetcher struct {
@@ -57,6 +57,10 @@ const (
 	// ContainerIsGoneAnnotation is used as workaround for containerd https://github.com/containerd/containerd/pull/4214
 	// which might cause workspace container status propagation to fail, which in turn would keep a workspace running indefinitely.
 	ContainerIsGoneAnnotation = "gitpod.io/containerIsGone"
+
+	// StopRequestedAnnotation asks ws-manager to stop a workspace (including its final backup). The value is the reason for the request.
+	// ws-daemon sets this annotation e.g. when the node runs out of disk space.
+	StopRequestedAnnotation = "gitpod.io/stopRequested"
 )
 
 // WorkspaceSupervisorEndpoint produces the supervisor endpoint of a workspace.
@@ -25,9 +25,10 @@ import (
 
 // TarConfig configures tarbal creation/extraction
 type TarConfig struct {
-	MaxSizeBytes int64
-	UIDMaps      []IDMapping
-	GIDMaps      []IDMapping
+	MaxSizeBytes  int64
+	UIDMaps       []IDMapping
+	GIDMaps       []IDMapping
+	ExcludedFiles []string
 }
 
 // BuildTarbalOption configures the tarbal creation
@@ -61,6 +62,13 @@ func WithGIDMapping(mappings []IDMapping) TarOption {
 	}
 }
 
+// WithExcludedFiles leaves files out during archive creation. The paths are relative to the archive root.
+func WithExcludedFiles(paths ...string) TarOption {
+	return func(o *TarConfig) {
+		o.ExcludedFiles = append(o.ExcludedFiles, paths...)
+	}
+}
+
 // ExtractTarbal extracts an OCI compatible tar file src to the folder dst, expecting the overlay whiteout format
 func ExtractTarbal(ctx context.Context, src io.Reader, dst string, opts ...TarOption) (err error) {
 	//nolint:staticcheck,ineffassign
@@ -0,0 +1,474 @@
+// Copyright (c) 2021 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package initializer
+
+import (
+	"archive/tar"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/containerd/containerd/archive/compression"
+	"github.com/containerd/containerd/images"
+	dockerref "github.com/containerd/containerd/reference/docker"
+	"github.com/containerd/containerd/remotes"
+	"github.com/containerd/containerd/remotes/docker"
+	"github.com/opencontainers/go-digest"
+	ociv1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/opentracing/opentracing-go"
+	"golang.org/x/xerrors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/gitpod-io/gitpod/common-go/log"
+	"github.com/gitpod-io/gitpod/common-go/tracing"
+	csapi "github.com/gitpod-io/gitpod/content-service/api"
+	"github.com/gitpod-io/gitpod/content-service/pkg/archive"
+)
+
+const (
+	// ociWorkspaceDir is the directory in the artifact layers whose content becomes the workspace content
+	ociWorkspaceDir = "workspace"
+
+	// maxOCIManifestSize is the maximum size of manifests and configs we're willing to download
+	maxOCIManifestSize = 4 * 1024 * 1024
+
+	ociWhiteoutPrefix       = ".wh."
+	ociWhiteoutOpaqueMarker = ".wh..wh..opq"
+)
+
+// newOCIInitializer creates an OCI artifact initializer for a request.
+// Returns gRPC errors.
+func newOCIInitializer(loc string, req *csapi.OCIInitializer) (*ociInitializer, error) {
+	if req == nil || req.Ref == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing OCI artifact ref")
+	}
+
+	return &ociInitializer{
+		Location: loc,
+		Request:  req,
+	}, nil
+}
+
+// ociInitializer initializes a workspace from the layers of an OCI artifact
+type ociInitializer struct {
+	Location string
+	Request  *csapi.OCIInitializer
+}
+
+// Run downloads the artifact's layers and extracts them into the workspace
+func (oi *ociInitializer) Run(ctx context.Context, mappings []archive.IDMapping) (src csapi.WorkspaceInitSource, err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "OCIInitializer.Run")
+	span.SetTag("ref", oi.Request.Ref)
+	defer tracing.FinishSpan(span, &err)
+
+	art, err := ResolveOCIArtifact(ctx, oi.Request)
+	if err != nil {
+		return src, err
+	}
+	span.LogKV("artifact", art.Name, "layers", len(art.Manifest.Layers))
+
+	for _, desc := range art.Manifest.Layers {
+		err = oi.extractLayer(ctx, art, desc, mappings)
+		if err != nil {
+			return src, xerrors.Errorf("cannot extract layer %s of %s: %w", desc.Digest, art.Name, err)
+		}
+	}
+
+	return csapi.WorkspaceInitFromOther, nil
+}
+
+func (oi *ociInitializer) extractLayer(ctx context.Context, art *OCIArtifact, desc ociv1.Descriptor, mappings []archive.IDMapping) (err error) {
+	//nolint:ineffassign
+	span, ctx := opentracing.StartSpanFromContext(ctx, "extractLayer")
+	span.SetTag("digest", desc.Digest)
+	defer tracing.FinishSpan(span, &err)
+
+	rc, err := art.Fetch(ctx, desc)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	verifier := desc.Digest.Verifier()
+	blob := io.TeeReader(rc, verifier)
+	dec, err := compression.DecompressStream(blob)
+	if err != nil {
+		return err
+	}
+	defer dec.Close()
+
+	var (
+		pr, pw    = io.Pipe()
+		changes   *layerChanges
+		rewritten = make(chan struct{})
+	)
+	go func() {
+		defer close(rewritten)
+		var rerr error
+		changes, rerr = rewriteWorkspaceLayer(dec, pw)
+		pw.CloseWithError(rerr)
+	}()
+	err = archive.ExtractTarbal(ctx, pr, oi.Location, archive.WithUIDMapping(mappings), archive.WithGIDMapping(mappings))
+	pr.Close()
+	<-rewritten
+	if err != nil {
+		return err
+	}
+	err = changes.ApplyWhiteouts(oi.Location)
+	if err != nil {
+		return xerrors.Errorf("cannot apply whiteouts: %w", err)
+	}
+
+	// tar stops reading at the end-of-archive marker - the digest covers everything though
+	_, err = io.Copy(io.Discard, blob)
+	if err != nil {
+		return err
+	}
+	if !verifier.Verified() {
+		return xerrors.Errorf("digest mismatch")
+	}
+	return nil
+}
+
+// layerChanges records what a layer changed in the workspace
+type layerChanges struct {
+	// Written are the paths the layer added
+	Written map[string]struct{}
+	// Whiteouts are the paths the layer removed
+	Whiteouts []string
+	// Opaque are the directories whose content from previous layers the layer removed
+	Opaque []string
+}
+
+// ApplyWhiteouts removes the content of previous layers which the layer deleted.
+// This has to happen after the layer was extracted because whiteouts may appear anywhere in a layer.
+// Whiteouts below symlinks are refused, as they could remove content outside of the workspace.
+func (c *layerChanges) ApplyWhiteouts(loc string) error {
+	for _, p := range c.Whiteouts {
+		exists, err := checkNoSymlinkParents(loc, p)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			continue
+		}
+		// RemoveAll removes a symlink itself, not what it points to
+		err = os.RemoveAll(filepath.Join(loc, p))
+		if err != nil {
+			return err
+		}
+	}
+	for _, p := range c.Opaque {
+		err := c.removeUnwritten(loc, p)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *layerChanges) removeUnwritten(loc, dir string) error {
+	exists, err := checkNoSymlinkParents(loc, dir)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return nil
+	}
+	if dir != "." {
+		stat, err := os.Lstat(filepath.Join(loc, dir))
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !stat.IsDir() {
+			return xerrors.Errorf("opaque whiteout in %s which is no directory", dir)
+		}
+	}
+
+	fs, err := os.ReadDir(filepath.Join(loc, dir))
+	if err != nil {
+		return err
+	}
+	for _, f := range fs {
+		p := path.Join(dir, f.Name())
+		if _, written := c.Written[p]; !written {
+			err = os.RemoveAll(filepath.Join(loc, p))
+		} else if f.IsDir() {
+			// the dir entry type does not follow symlinks, hence we never descend into a symlinked directory
+			err = c.removeUnwritten(loc, p)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkNoSymlinkParents makes sure that none of the parent directories of a workspace relative path is a symlink,
+// so that operating on the path cannot affect anything outside of the workspace. Returns false if a parent does not exist.
+func checkNoSymlinkParents(loc, p string) (exists bool, err error) {
+	var parents []string
+	for d := path.Dir(p); d != "."; d = path.Dir(d) {
+		parents = append(parents, d)
+	}
+	for i := len(parents) - 1; i >= 0; i-- {
+		stat, err := os.Lstat(filepath.Join(loc, parents[i]))
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		if stat.Mode()&os.ModeSymlink != 0 {
+			return false, xerrors.Errorf("%s is below the symlink %s", p, parents[i])
+		}
+	}
+	return true, nil
+}
+
+// rewriteWorkspaceLayer copies the part of an image layer tar stream that lives in the workspace directory
+// to out, such that it can be extracted in the workspace location directly.
+func rewriteWorkspaceLayer(in io.Reader, out io.Writer) (*layerChanges, error) {
+	var (
+		tr  = tar.NewReader(in)
+		tw  = tar.NewWriter(out)
+		res = &layerChanges{Written: make(map[string]struct{})}
+	)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		name, ok := workspaceRelativePath(hdr.Name)
+		if !ok {
+			continue
+		}
+
+		// the paths are cleaned and relative, hence cannot escape the workspace when joined
+		if base := path.Base(name); base == ociWhiteoutOpaqueMarker {
+			res.Opaque = append(res.Opaque, path.Dir(name))
+			continue
+		} else if strings.HasPrefix(base, ociWhiteoutPrefix) {
+			res.Whiteouts = append(res.Whiteouts, path.Join(path.Dir(name), strings.TrimPrefix(base, ociWhiteoutPrefix)))
+			continue
+		}
+
+		if hdr.Typeflag == tar.TypeLink {
+			hdr.Linkname, ok = workspaceRelativePath(hdr.Linkname)
+			if !ok {
+				log.WithField("name", hdr.Name).Debug("skipping hard link pointing outside of the workspace")
+				continue
+			}
+		}
+
+		hdr.Name = name
+		err = tw.WriteHeader(hdr)
+		if err != nil {
+			return nil, err
+		}
+		_, err = io.Copy(tw, tr)
+		if err != nil {
+			return nil, err
+		}
+		// parent directories need not have entries of their own, but count as written nonetheless
+		for p := name; p != "."; p = path.Dir(p) {
+			res.Written[p] = struct{}{}
+		}
+	}
+
+	return res, tw.Close()
+}
+
+// CheckWorkspaceLayer verifies that all entries of an uncompressed image layer tar stream live within the workspace
+// directory, such that the layer can be served as content layer as is. Besides entries outside the workspace directory
+// it refuses hard links pointing outside of it and entries below symlinks, as those could escape the workspace when applied.
+func CheckWorkspaceLayer(in io.Reader) error {
+	var (
+		tr       = tar.NewReader(in)
+		symlinks = make(map[string]struct{})
+	)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if strings.TrimPrefix(path.Clean("/"+hdr.Name), "/") == ociWorkspaceDir {
+			if hdr.Typeflag != tar.TypeDir {
+				return xerrors.Errorf("%s is not a directory", hdr.Name)
+			}
+			continue
+		}
+		name, ok := workspaceRelativePath(hdr.Name)
+		if !ok {
+			return xerrors.Errorf("%s is outside of the workspace", hdr.Name)
+		}
+		for p := path.Dir(name); p != "."; p = path.Dir(p) {
+			if _, isSymlink := symlinks[p]; isSymlink {
+				return xerrors.Errorf("%s is below the symlink %s", hdr.Name, p)
+			}
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeLink:
+			if _, ok := workspaceRelativePath(hdr.Linkname); !ok {
+				return xerrors.Errorf("hard link %s points outside of the workspace", hdr.Name)
+			}
+		case tar.TypeSymlink:
+			symlinks[name] = struct{}{}
+		default:
+			delete(symlinks, name)
+		}
+	}
+}
+
+// workspaceRelativePath returns the path of a layer entry relative to the workspace directory,
+// or false if the entry is not within the workspace directory.
+func workspaceRelativePath(name string) (string, bool) {
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	if !strings.HasPrefix(name, ociWorkspaceDir+"/") {
+		return "", false
+	}
+	return strings.TrimPrefix(name, ociWorkspaceDir+"/"), true
+}
+
+// OCIRegistryHosts produces the registry hosts used to pull the artifact of an OCI initializer
+func OCIRegistryHosts(req *csapi.OCIInitializer) docker.RegistryHosts {
+	authorizer := docker.NewDockerAuthorizer(docker.WithAuthCreds(func(host string) (user, pwd string, err error) {
+		return req.AuthUser, req.AuthPassword, nil
+	}))
+	return docker.ConfigureDefaultRegistries(
+		docker.WithAuthorizer(authorizer),
+		docker.WithPlainHTTP(docker.MatchLocalhost),
+	)
+}
+
+// OCIArtifact is an OCI artifact resolved to its manifest
+type OCIArtifact struct {
+	// Name is the normalized name of the artifact, e.g. docker.io/library/foo:latest
+	Name string
+	// Manifest is the image manifest of the artifact
+	Manifest ociv1.Manifest
+	// DiffIDs are the digests of the uncompressed layers if the artifact has an image config, nil otherwise
+	DiffIDs []digest.Digest
+
+	fetcher remotes.Fetcher
+}
+
+// Fetch downloads a blob of the artifact
+func (a *OCIArtifact) Fetch(ctx context.Context, desc ociv1.Descriptor) (io.ReadCloser, error) {
+	return a.fetcher.Fetch(ctx, desc)
+}
+
+// ResolveOCIArtifact resolves the ref of an OCI initializer and downloads its manifest.
+// If the ref points to an index, the linux/amd64 manifest is used.
+func ResolveOCIArtifact(ctx context.Context, req *csapi.OCIInitializer) (res *OCIArtifact, err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "ResolveOCIArtifact")
+	span.SetTag("ref", req.Ref)
+	defer tracing.FinishSpan(span, &err)
+
+	pref, err := dockerref.ParseDockerRef(req.Ref)
+	if err != nil {
+		return nil, xerrors.Errorf("cannot parse artifact ref %s: %w", req.Ref, err)
+	}
+
+	resolver := docker.NewResolver(docker.ResolverOptions{Hosts: OCIRegistryHosts(req)})
+	name, desc, err := resolver.Resolve(ctx, pref.String())
+	if err != nil {
+		return nil, xerrors.Errorf("cannot resolve %s: %w", pref.String(), err)
+	}
+	fetcher, err := resolver.Fetcher(ctx, name)
+	if err != nil {
+		return nil, xerrors.Errorf("cannot fetch %s: %w", name, err)
+	}
+	res = &OCIArtifact{
+		Name:    name,
+		fetcher: fetcher,
+	}
+
+	if images.IsIndexType(desc.MediaType) {
+		var idx ociv1.Index
+		err = res.fetchJSON(ctx, desc, &idx)
+		if err != nil {
+			return nil, xerrors.Errorf("cannot download index of %s: %w", name, err)
+		}
+		desc, err = pickManifest(idx)
+		if err != nil {
+			return nil, xerrors.Errorf("cannot use index of %s: %w", name, err)
+		}
+	}
+	if !images.IsManifestType(desc.MediaType) {
+		return nil, xerrors.Errorf("%s is neither a manifest nor an index: %s", name, desc.MediaType)
+	}
+
+	err = res.fetchJSON(ctx, desc, &res.Manifest)
+	if err != nil {
+		return nil, xerrors.Errorf("cannot download manifest of %s: %w", name, err)
+	}
+
+	if images.IsConfigType(res.Manifest.Config.MediaType) {
+		var cfg ociv1.Image
+		err = res.fetchJSON(ctx, res.Manifest.Config, &cfg)
+		if err != nil {
+			return nil, xerrors.Errorf("cannot download config of %s: %w", name, err)
+		}
+		if len(cfg.RootFS.DiffIDs) == len(res.Manifest.Layers) {
+			res.DiffIDs = cfg.RootFS.DiffIDs
+		}
+	}
+
+	return res, nil
+}
+
+func (a *OCIArtifact) fetchJSON(ctx context.Context, desc ociv1.Descriptor, dst interface{}) error {
+	if desc.Size > maxOCIManifestSize {
+		return xerrors.Errorf("%s is too large: %d bytes", desc.Digest, desc.Size)
+	}
+
+	rc, err := a.fetcher.Fetch(ctx, desc)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	buf, err := io.ReadAll(io.LimitReader(rc, maxOCIManifestSize))
+	if err != nil {
+		return err
+	}
+	if dgst := digest.FromBytes(buf); dgst != desc.Digest {
+		return xerrors.Errorf("digest mismatch: expected %s, got %s", desc.Digest, dgst)
+	}
+	return json.Unmarshal(buf, dst)
+}
+
+func pickManifest(idx ociv1.Index) (ociv1.Descriptor, error) {
+	for _, m := range idx.Manifests {
+		if m.Platform == nil {
+			continue
+		}
+		if m.Platform.OS == "linux" && m.Platform.Architecture == "amd64" {
+			return m, nil
+		}
+	}
+	if len(idx.Manifests) == 1 && idx.Manifests[0].Platform == nil {
+		return idx.Manifests[0], nil
+	}
+	return ociv1.Descriptor{}, xerrors.Errorf("no linux/amd64 manifest found")
+}
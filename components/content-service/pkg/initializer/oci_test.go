@@ -0,0 +1,313 @@
+// Copyright (c) 2021 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package initializer
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/opencontainers/go-digest"
+	ociv1 "github.com/opencontainers/image-spec/specs-go/v1"
+
+	csapi "github.com/gitpod-io/gitpod/content-service/api"
+)
+
+type testFile struct {
+	Name    string
+	Content string
+	// Symlink makes the file a symlink to this target
+	Symlink string
+}
+
+func testLayer(t *testing.T, compress bool, files ...testFile) []byte {
+	buf := bytes.NewBuffer(nil)
+	tw := tar.NewWriter(buf)
+	for _, f := range files {
+		hdr := &tar.Header{Name: f.Name, Mode: 0644, Size: int64(len(f.Content)), Uid: os.Getuid(), Gid: os.Getgid()}
+		if strings.HasSuffix(f.Name, "/") {
+			hdr.Typeflag, hdr.Mode = tar.TypeDir, 0755
+		} else if f.Symlink != "" {
+			hdr.Typeflag, hdr.Linkname, hdr.Mode = tar.TypeSymlink, f.Symlink, 0777
+		}
+		err := tw.WriteHeader(hdr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = tw.Write([]byte(f.Content))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := tw.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !compress {
+		return buf.Bytes()
+	}
+
+	gzbuf := bytes.NewBuffer(nil)
+	gw := gzip.NewWriter(gzbuf)
+	_, err = gw.Write(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = gw.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return gzbuf.Bytes()
+}
+
+// testRegistry serves a single artifact under repo:latest
+type testRegistry struct {
+	Manifest []byte
+	Blobs    map[digest.Digest][]byte
+}
+
+func newTestRegistry(t *testing.T, layers ...[]byte) *testRegistry {
+	reg := &testRegistry{Blobs: make(map[digest.Digest][]byte)}
+	mf := ociv1.Manifest{
+		Config: ociv1.Descriptor{
+			MediaType: "application/vnd.gitpod.dataset.config.v1+json",
+			Digest:    reg.add([]byte("{}")),
+			Size:      2,
+		},
+	}
+	mf.SchemaVersion = 2
+	for _, l := range layers {
+		mt := ociv1.MediaTypeImageLayer
+		if bytes.HasPrefix(l, []byte{0x1f, 0x8b}) {
+			mt = ociv1.MediaTypeImageLayerGzip
+		}
+		mf.Layers = append(mf.Layers, ociv1.Descriptor{MediaType: mt, Digest: reg.add(l), Size: int64(len(l))})
+	}
+
+	var err error
+	reg.Manifest, err = json.Marshal(mf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return reg
+}
+
+func (reg *testRegistry) add(blob []byte) digest.Digest {
+	dgst := digest.FromBytes(blob)
+	reg.Blobs[dgst] = blob
+	return dgst
+}
+
+func (reg *testRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	var (
+		mfdgst = digest.FromBytes(reg.Manifest)
+		p      = req.URL.Path
+	)
+	switch {
+	case p == "/v2/":
+		w.WriteHeader(http.StatusOK)
+	case p == "/v2/repo/manifests/latest" || p == "/v2/repo/manifests/"+mfdgst.String():
+		w.Header().Set("Content-Type", ociv1.MediaTypeImageManifest)
+		w.Header().Set("Docker-Content-Digest", mfdgst.String())
+		w.Header().Set("Content-Length", strconv.Itoa(len(reg.Manifest)))
+		if req.Method == http.MethodHead {
+			return
+		}
+		_, _ = w.Write(reg.Manifest)
+	case strings.HasPrefix(p, "/v2/repo/blobs/"):
+		blob, ok := reg.Blobs[digest.Digest(strings.TrimPrefix(p, "/v2/repo/blobs/"))]
+		if !ok {
+			http.NotFound(w, req)
+			return
+		}
+		_, _ = w.Write(blob)
+	default:
+		http.NotFound(w, req)
+	}
+}
+
+func TestOCIInitializer(t *testing.T) {
+	reg := newTestRegistry(t,
+		testLayer(t, false,
+			testFile{Name: "workspace/"},
+			testFile{Name: "workspace/README.md", Content: "readme"},
+			testFile{Name: "workspace/data/a.csv", Content: "a"},
+			testFile{Name: "workspace/data/b.csv", Content: "b"},
+			testFile{Name: "workspace/old/c.csv", Content: "c"},
+			testFile{Name: "etc/passwd", Content: "not part of the workspace"},
+		),
+		testLayer(t, true,
+			testFile{Name: "./workspace/data/.wh.a.csv"},
+			testFile{Name: "./workspace/old/new.csv", Content: "new"},
+			testFile{Name: "./workspace/old/.wh..wh..opq"},
+		),
+	)
+	srv := httptest.NewServer(reg)
+	defer srv.Close()
+
+	loc := t.TempDir()
+	ini, err := newOCIInitializer(loc, &csapi.OCIInitializer{
+		Ref: strings.TrimPrefix(srv.URL, "http://") + "/repo:latest",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := ini.Run(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if src != csapi.WorkspaceInitFromOther {
+		t.Errorf("unexpected init source: %s", src)
+	}
+
+	var files []string
+	err = filepath.Walk(loc, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(loc, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, rel)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(files)
+	if diff := cmp.Diff([]string{"README.md", "data/b.csv", "old/new.csv"}, files); diff != "" {
+		t.Errorf("unexpected workspace content (-want +got):\n%s", diff)
+	}
+}
+
+func TestOCIInitializerDigestMismatch(t *testing.T) {
+	reg := newTestRegistry(t, testLayer(t, false, testFile{Name: "workspace/README.md", Content: "readme"}))
+	for dgst := range reg.Blobs {
+		if dgst != digest.FromString("{}") {
+			reg.Blobs[dgst] = testLayer(t, false, testFile{Name: "workspace/README.md", Content: "tampered"})
+		}
+	}
+	srv := httptest.NewServer(reg)
+	defer srv.Close()
+
+	ini, err := newOCIInitializer(t.TempDir(), &csapi.OCIInitializer{
+		Ref: strings.TrimPrefix(srv.URL, "http://") + "/repo:latest",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = ini.Run(context.Background(), nil)
+	if err == nil || !strings.Contains(err.Error(), "digest mismatch") {
+		t.Errorf("expected digest mismatch, got %v", err)
+	}
+}
+
+func TestCheckWorkspaceLayer(t *testing.T) {
+	tests := []struct {
+		Name  string
+		Files []testFile
+		Error string
+	}{
+		{
+			Name: "workspace content",
+			Files: []testFile{
+				{Name: "workspace/"},
+				{Name: "./workspace/README.md", Content: "readme"},
+				{Name: "workspace/data/.wh.a.csv"},
+				{Name: "workspace/latest", Symlink: "data/b.csv"},
+				{Name: "workspace/latest", Content: "replaces the symlink"},
+				{Name: "workspace/latest/../README.md", Content: "readme"},
+			},
+		},
+		{
+			Name:  "outside of the workspace",
+			Files: []testFile{{Name: "etc/passwd", Content: "not part of the workspace"}},
+			Error: "etc/passwd is outside of the workspace",
+		},
+		{
+			Name:  "escaping the workspace",
+			Files: []testFile{{Name: "workspace/../etc/passwd", Content: "not part of the workspace"}},
+			Error: "workspace/../etc/passwd is outside of the workspace",
+		},
+		{
+			Name:  "workspace is no directory",
+			Files: []testFile{{Name: "workspace", Symlink: "/etc"}},
+			Error: "workspace is not a directory",
+		},
+		{
+			Name: "below a symlink",
+			Files: []testFile{
+				{Name: "workspace/data", Symlink: "/etc"},
+				{Name: "workspace/data/passwd", Content: "not part of the workspace"},
+			},
+			Error: "workspace/data/passwd is below the symlink data",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			var errmsg string
+			err := CheckWorkspaceLayer(bytes.NewReader(testLayer(t, false, test.Files...)))
+			if err != nil {
+				errmsg = err.Error()
+			}
+			if errmsg != test.Error {
+				t.Errorf("unexpected error: want %q, got %q", test.Error, errmsg)
+			}
+		})
+	}
+}
+
+func TestApplyWhiteoutsBelowSymlink(t *testing.T) {
+	var (
+		loc     = t.TempDir()
+		outside = t.TempDir()
+		secret  = filepath.Join(outside, "secret")
+	)
+	err := os.WriteFile(secret, []byte("not part of the workspace"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Symlink(outside, filepath.Join(loc, "link"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		Name    string
+		Changes layerChanges
+		Error   bool
+	}{
+		{Name: "whiteout below symlink", Changes: layerChanges{Whiteouts: []string{"link/secret"}}, Error: true},
+		{Name: "opaque symlink", Changes: layerChanges{Opaque: []string{"link"}}, Error: true},
+		{Name: "opaque below symlink", Changes: layerChanges{Opaque: []string{"link/dir"}}, Error: true},
+		{Name: "whiteout of symlink", Changes: layerChanges{Whiteouts: []string{"link"}}},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			err := test.Changes.ApplyWhiteouts(loc)
+			if (err != nil) != test.Error {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if _, err := os.Stat(secret); err != nil {
+				t.Errorf("content outside of the workspace was removed: %v", err)
+			}
+		})
+	}
+	if _, err := os.Lstat(filepath.Join(loc, "link")); !os.IsNotExist(err) {
+		t.Errorf("symlink was not removed: %v", err)
+	}
+}
@@ -12,9 +12,16 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"path"
 	"strings"
 
+	"github.com/containerd/containerd/archive/compression"
+	"github.com/containerd/containerd/images"
+	"github.com/containerd/containerd/reference"
+	"github.com/containerd/containerd/remotes/docker"
 	"github.com/opencontainers/go-digest"
+	ociv1 "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/opentracing/opentracing-go"
 	"golang.org/x/xerrors"
 
@@ -189,6 +196,27 @@ func (s *Provider) GetContentLayer(ctx context.Context, owner, workspaceID strin
 			return
 		}
 	}
+	if ois := initializer.GetOci(); ois != nil {
+		l, err = s.getOCIContentLayer(ctx, ois)
+		if err == nil {
+			return l, nil, nil
+		}
+
+		// we cannot serve the artifact as image layers, hence the workspace must pull it during content init
+		log.WithError(err).WithFields(log.OWI(owner, workspaceID, "")).Warn("cannot serve OCI artifact as content layer - falling back to content init")
+		span.LogKV("fallback-to-content-init", err.Error())
+
+		cdesc, err := executor.Prepare(initializer, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		layer, err = contentDescriptorToLayer(cdesc)
+		if err != nil {
+			return nil, nil, err
+		}
+		return []Layer{*layer}, nil, nil
+	}
 	if gis := initializer.GetGit(); gis != nil {
 		span.LogKV("initializer", "Git")
 
@@ -315,6 +343,145 @@ func (s *Provider) getPrebuildContentLayer(ctx context.Context, pb *csapi.Prebui
 	return l, manifest, nil
 }
 
+// getOCIContentLayer serves the layers of an OCI artifact as content layers. This is only possible if the layers
+// are regular image layers whose diffIDs we know, which live entirely within the workspace directory and which we
+// can download without credentials. To verify the latter two we download each layer once.
+func (s *Provider) getOCIContentLayer(ctx context.Context, req *csapi.OCIInitializer) (l []Layer, err error) {
+	span, ctx := tracing.FromContext(ctx, "getOCIContentLayer")
+	defer tracing.FinishSpan(span, &err)
+
+	art, err := initializer.ResolveOCIArtifact(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	hosts := initializer.OCIRegistryHosts(req)
+
+	for i, desc := range art.Manifest.Layers {
+		var (
+			mediaType string
+			diffID    = desc.Digest
+		)
+		compression, err := images.DiffCompression(ctx, desc.MediaType)
+		if err != nil {
+			return nil, xerrors.Errorf("layer %s: %w", desc.Digest, err)
+		}
+		switch compression {
+		case "":
+			mediaType = ociv1.MediaTypeImageLayer
+		case "gzip":
+			mediaType = ociv1.MediaTypeImageLayerGzip
+			if art.DiffIDs == nil {
+				return nil, xerrors.Errorf("layer %s is compressed but %s has no image config", desc.Digest, art.Name)
+			}
+			diffID = art.DiffIDs[i]
+		default:
+			return nil, xerrors.Errorf("layer %s has unsupported compression %s", desc.Digest, compression)
+		}
+
+		blobURL, err := ociBlobURL(hosts, art.Name, desc.Digest)
+		if err != nil {
+			return nil, xerrors.Errorf("layer %s: %w", desc.Digest, err)
+		}
+		err = s.checkOCIContentLayer(ctx, blobURL, desc.Digest, diffID)
+		if err != nil {
+			return nil, xerrors.Errorf("layer %s: %w", desc.Digest, err)
+		}
+
+		l = append(l, Layer{
+			DiffID:    diffID.String(),
+			Digest:    desc.Digest.String(),
+			MediaType: mediaType,
+			URL:       blobURL,
+			Size:      desc.Size,
+		})
+	}
+
+	rl, err := workspaceReadyLayer(csapi.WorkspaceInitFromOther)
+	if err != nil {
+		return nil, err
+	}
+	l = append(l, *rl)
+
+	return l, nil
+}
+
+// ociBlobURL produces the registry URL of a blob. Unlike the storage URLs registries may redirect to,
+// this URL does not expire and hence can be part of manifests which are cached.
+func ociBlobURL(hosts docker.RegistryHosts, name string, dgst digest.Digest) (string, error) {
+	spec, err := reference.Parse(name)
+	if err != nil {
+		return "", err
+	}
+	hs, err := hosts(spec.Hostname())
+	if err != nil {
+		return "", err
+	}
+	if len(hs) == 0 {
+		return "", xerrors.Errorf("no registry host for %s", spec.Hostname())
+	}
+	host := hs[0]
+
+	blobURL := url.URL{
+		Scheme: host.Scheme,
+		Host:   host.Host,
+		Path:   path.Join(host.Path, strings.TrimPrefix(spec.Locator, spec.Hostname()+"/"), "blobs", dgst.String()),
+	}
+	return blobURL.String(), nil
+}
+
+// checkOCIContentLayer downloads a layer without credentials, just like registry-facade does when serving it, and
+// verifies its digests and that it lives within the workspace directory.
+func (s *Provider) checkOCIContentLayer(ctx context.Context, blobURL string, dgst, diffID digest.Digest) (err error) {
+	//nolint:ineffassign
+	span, ctx := opentracing.StartSpanFromContext(ctx, "checkOCIContentLayer")
+	span.SetTag("digest", dgst)
+	defer tracing.FinishSpan(span, &err)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, blobURL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return xerrors.Errorf("cannot download without credentials: %s", resp.Status)
+	}
+
+	var (
+		verifier       = dgst.Verifier()
+		diffIDVerifier = diffID.Verifier()
+	)
+	dec, err := compression.DecompressStream(io.TeeReader(resp.Body, verifier))
+	if err != nil {
+		return err
+	}
+	defer dec.Close()
+
+	err = initializer.CheckWorkspaceLayer(io.TeeReader(dec, diffIDVerifier))
+	if err != nil {
+		return err
+	}
+	// tar stops reading at the end-of-archive marker - the digests cover everything though
+	_, err = io.Copy(diffIDVerifier, dec)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(verifier, resp.Body)
+	if err != nil {
+		return err
+	}
+	if !verifier.Verified() {
+		return xerrors.Errorf("digest mismatch")
+	}
+	if !diffIDVerifier.Verified() {
+		return xerrors.Errorf("diffID mismatch")
+	}
+	return nil
+}
+
 func (s *Provider) layerFromContentManifest(ctx context.Context, mf *csapi.WorkspaceContentManifest, initsrc csapi.WorkspaceInitSource, ready bool) (l []Layer, err error) {
 	// we have a valid full workspace backup
 	l = make([]Layer, len(mf.Layers))
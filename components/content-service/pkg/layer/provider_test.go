@@ -5,18 +5,23 @@
 package layer
 
 import (
+	"archive/tar"
 	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"io"
 	"net/http"
+	"net/http/httptest"
 	"os"
+	"strconv"
 	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/opencontainers/go-digest"
 	ociv1 "github.com/opencontainers/image-spec/specs-go/v1"
 
@@ -262,3 +267,177 @@ type roundTripFunc func(req *http.Request) *http.Response
 func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
 	return f(req), nil
 }
+
+func TestGetOCIContentLayer(t *testing.T) {
+	tarball := func(names ...string) []byte {
+		buf := bytes.NewBuffer(nil)
+		tw := tar.NewWriter(buf)
+		for _, n := range names {
+			err := tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: n, Mode: 0644})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		err := tw.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+	gzipped := func(content []byte) []byte {
+		buf := bytes.NewBuffer(nil)
+		gw := gzip.NewWriter(buf)
+		_, err := gw.Write(content)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = gw.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+
+	var (
+		workspaceLayer = tarball("workspace/README.md")
+		foreignLayer   = tarball("workspace/README.md", "etc/passwd")
+	)
+	tests := []struct {
+		Name          string
+		Layer         []byte
+		LayerType     string
+		DiffID        digest.Digest
+		Anonymous     bool
+		ExpectedLayer bool
+	}{
+		{
+			Name:          "image layer",
+			Layer:         gzipped(workspaceLayer),
+			LayerType:     ociv1.MediaTypeImageLayerGzip,
+			DiffID:        digest.FromBytes(workspaceLayer),
+			Anonymous:     true,
+			ExpectedLayer: true,
+		},
+		{
+			Name:      "artifact layer",
+			Layer:     workspaceLayer,
+			LayerType: "application/vnd.gitpod.dataset.layer.v1",
+			DiffID:    digest.FromBytes(workspaceLayer),
+			Anonymous: true,
+		},
+		{
+			Name:      "image layer outside of the workspace",
+			Layer:     gzipped(foreignLayer),
+			LayerType: ociv1.MediaTypeImageLayerGzip,
+			DiffID:    digest.FromBytes(foreignLayer),
+			Anonymous: true,
+		},
+		{
+			Name:      "image layer with wrong diffID",
+			Layer:     gzipped(workspaceLayer),
+			LayerType: ociv1.MediaTypeImageLayerGzip,
+			DiffID:    digest.FromBytes(foreignLayer),
+			Anonymous: true,
+		},
+		{
+			Name:      "image layer requiring authentication",
+			Layer:     gzipped(workspaceLayer),
+			LayerType: ociv1.MediaTypeImageLayerGzip,
+			DiffID:    digest.FromBytes(workspaceLayer),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			var (
+				blobs = make(map[digest.Digest][]byte)
+				add   = func(blob []byte) ociv1.Descriptor {
+					dgst := digest.FromBytes(blob)
+					blobs[dgst] = blob
+					return ociv1.Descriptor{Digest: dgst, Size: int64(len(blob))}
+				}
+			)
+			cfg, err := json.Marshal(ociv1.Image{RootFS: ociv1.RootFS{Type: "layers", DiffIDs: []digest.Digest{test.DiffID}}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			mf := ociv1.Manifest{Config: add(cfg), Layers: []ociv1.Descriptor{add(test.Layer)}}
+			mf.SchemaVersion = 2
+			mf.Config.MediaType = ociv1.MediaTypeImageConfig
+			mf.Layers[0].MediaType = test.LayerType
+			mfc, err := json.Marshal(mf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			mfdgst := digest.FromBytes(mfc)
+			layerDgst := mf.Layers[0].Digest.String()
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				p := req.URL.Path
+				switch {
+				case p == "/v2/":
+				case p == "/v2/repo/manifests/latest" || p == "/v2/repo/manifests/"+mfdgst.String():
+					w.Header().Set("Content-Type", ociv1.MediaTypeImageManifest)
+					w.Header().Set("Docker-Content-Digest", mfdgst.String())
+					w.Header().Set("Content-Length", strconv.Itoa(len(mfc)))
+					if req.Method != http.MethodHead {
+						_, _ = w.Write(mfc)
+					}
+				case p == "/v2/repo/blobs/"+layerDgst:
+					// registries usually redirect to storage which expires
+					http.Redirect(w, req, "/storage/"+layerDgst+"?expires=soon", http.StatusTemporaryRedirect)
+				case p == "/storage/"+layerDgst:
+					if !test.Anonymous {
+						w.WriteHeader(http.StatusForbidden)
+						return
+					}
+					_, _ = w.Write(test.Layer)
+				case strings.HasPrefix(p, "/v2/repo/blobs/"):
+					_, _ = w.Write(blobs[digest.Digest(strings.TrimPrefix(p, "/v2/repo/blobs/"))])
+				default:
+					http.NotFound(w, req)
+				}
+			}))
+			defer srv.Close()
+
+			ref := strings.TrimPrefix(srv.URL, "http://") + "/repo:latest"
+			p := &Provider{Storage: &testStorage{}, Client: http.DefaultClient}
+			l, _, err := p.GetContentLayer(context.Background(), ownerID, workspaceID, &csapi.WorkspaceInitializer{
+				Spec: &csapi.WorkspaceInitializer_Oci{Oci: &csapi.OCIInitializer{Ref: ref}},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// the last layer is either the ready or the content descriptor layer
+			var expectedLayer []Layer
+			if test.ExpectedLayer {
+				expectedLayer = []Layer{
+					{
+						DiffID:    test.DiffID.String(),
+						Digest:    layerDgst,
+						MediaType: ociv1.MediaTypeImageLayerGzip,
+						URL:       srv.URL + "/v2/repo/blobs/" + layerDgst,
+						Size:      mf.Layers[0].Size,
+					},
+				}
+			}
+			if len(l) != len(expectedLayer)+1 {
+				t.Fatalf("expected %d layer, got %d", len(expectedLayer)+1, len(l))
+			}
+			if diff := cmp.Diff(expectedLayer, l[:len(l)-1], cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("unexpected layer (-want +got):\n%s", diff)
+			}
+
+			var (
+				last   = l[len(l)-1]
+				marker = "/workspace/.gitpod/ready"
+			)
+			if !test.ExpectedLayer {
+				marker = "/workspace/.gitpod/content.json"
+			}
+			if !bytes.Contains(last.Content, []byte(marker)) {
+				t.Errorf("last layer does not contain %s", marker)
+			}
+		})
+	}
+}
@@ -99,15 +99,17 @@ func (cs *WorkspaceService) DeleteWorkspace(ctx context.Context, req *api.Delete
 		return nil, status.Error(codes.Unknown, err.Error())
 	}
 
-	trailPrefix := cs.s.BackupObject(req.WorkspaceId, "trail-")
-	err = cs.s.DeleteObject(ctx, cs.s.Bucket(req.OwnerId), &storage.DeleteObjectQuery{Prefix: trailPrefix})
-	if err != nil {
+	for _, trail := range []string{"", storage.PeriodicBackupTrail} {
+		trailPrefix := cs.s.BackupObject(req.WorkspaceId, storage.BackupTrailPrefix(trail))
+		err = cs.s.DeleteObject(ctx, cs.s.Bucket(req.OwnerId), &storage.DeleteObjectQuery{Prefix: trailPrefix})
 		if errors.Is(err, storage.ErrNotFound) {
 			log.WithError(err).Debug("deleting workspace backup: NotFound, ", trailPrefix)
-			return &api.DeleteWorkspaceResponse{}, nil
+			continue
+		}
+		if err != nil {
+			log.WithError(err).Error("error deleting workspace backup: ", trailPrefix)
+			return nil, status.Error(codes.Unknown, err.Error())
 		}
-		log.WithError(err).Error("error deleting workspace backup: ", trailPrefix)
-		return nil, status.Error(codes.Unknown, err.Error())
 	}
 
 	return &api.DeleteWorkspaceResponse{}, nil
@@ -325,6 +325,20 @@ func (rs *DirectGCPStorage) UploadInstance(ctx context.Context, source string, n
 
 // Upload takes all files from a local location and uploads it to the remote storage
 func (rs *DirectGCPStorage) Upload(ctx context.Context, source string, name string, opts ...UploadOption) (bucket, object string, err error) {
+	return rs.upload(ctx, source, rs.objectName(name), name != DefaultBackup, opts...)
+}
+
+// UploadVolume takes all files from a local location and uploads it as content of the owner's persistent volume
+func (rs *DirectGCPStorage) UploadVolume(ctx context.Context, source string, name string, opts ...UploadOption) (bucket, object string, err error) {
+	return rs.upload(ctx, source, VolumeObjectName(name), false, opts...)
+}
+
+// DownloadVolume downloads the content of the owner's persistent volume to a local path
+func (rs *DirectGCPStorage) DownloadVolume(ctx context.Context, destination string, name string, mappings []archive.IDMapping) (bool, error) {
+	return rs.download(ctx, destination, rs.bucketName(), VolumeObjectName(name), mappings)
+}
+
+func (rs *DirectGCPStorage) upload(ctx context.Context, source string, objectName string, ensureBackupSlot bool, opts ...UploadOption) (bucket, object string, err error) {
 	//nolint:ineffassign
 	span, ctx := opentracing.StartSpanFromContext(ctx, "GCloudBucketRemotegcpStorage.Upload")
 	defer tracing.FinishSpan(span, &err)
@@ -342,7 +356,7 @@ func (rs *DirectGCPStorage) Upload(ctx context.Context, source string, name stri
 	}
 
 	// check if we have not yet exceeded the max number of backups
-	if name != DefaultBackup {
+	if ensureBackupSlot {
 		if err = rs.ensureBackupSlotAvailable(); err != nil {
 			return
 		}
@@ -366,7 +380,7 @@ func (rs *DirectGCPStorage) Upload(ctx context.Context, source string, name stri
 
 	uploadSpan := opentracing.StartSpan("remote-upload", opentracing.ChildOf(span.Context()))
 	uploadSpan.SetTag("bucket", rs.bucketName())
-	uploadSpan.SetTag("obj", rs.objectName(name))
+	uploadSpan.SetTag("obj", objectName)
 	/* Read back from the file in chunks. We don't wand a complicated composition operation,
 	 * so we'll have 32 chunks max. See https://cloud.google.com/storage/docs/composite-objects
 	 * for more details.
@@ -379,7 +393,7 @@ func (rs *DirectGCPStorage) Upload(ctx context.Context, source string, name stri
 	defer func() {
 		err := rs.deleteChunks(opentracing.ContextWithSpan(ctx, uploadSpan), chunks)
 		if err != nil {
-			log.WithError(err).WithField("name", name).Warn("cannot clean up upload chunks")
+			log.WithError(err).WithField("object", objectName).Warn("cannot clean up upload chunks")
 		}
 	}()
 
@@ -392,7 +406,7 @@ func (rs *DirectGCPStorage) Upload(ctx context.Context, source string, name stri
 	for i := 0; i < len(chunks); i++ {
 		src[i] = bkt.Object(chunks[i])
 	}
-	object = rs.objectName(name)
+	object = objectName
 	obj := bkt.Object(object)
 
 	var firstBackup bool
@@ -402,7 +416,7 @@ func (rs *DirectGCPStorage) Upload(ctx context.Context, source string, name stri
 	// maintain backup trail if we're asked to - we do this prior to overwriting the regular backup file
 	// to make sure we're trailign the previous backup.
 	if options.BackupTrail.Enabled && !firstBackup {
-		err := rs.trailBackup(ctx, bkt, obj, options.BackupTrail.ThisBackupID, options.BackupTrail.TrailLength)
+		err := rs.trailBackup(ctx, bkt, obj, options.BackupTrail.Name, options.BackupTrail.ThisBackupID, options.BackupTrail.TrailLength)
 		if err != nil {
 			log.WithError(err).Error("cannot maintain backup trail")
 		}
@@ -616,13 +630,13 @@ func (rs *DirectGCPStorage) deleteChunks(ctx context.Context, chunks []string) (
 	return nil
 }
 
-func (rs *DirectGCPStorage) trailBackup(ctx context.Context, bkt *gcpstorage.BucketHandle, obj *gcpstorage.ObjectHandle, backupID string, trailLength int) (err error) {
+func (rs *DirectGCPStorage) trailBackup(ctx context.Context, bkt *gcpstorage.BucketHandle, obj *gcpstorage.ObjectHandle, trail string, backupID string, trailLength int) (err error) {
 	//nolint:ineffassign
 	span, ctx := opentracing.StartSpanFromContext(ctx, "uploadChunk")
 	defer tracing.FinishSpan(span, &err)
 
-	trailIter := bkt.Objects(ctx, &gcpstorage.Query{Prefix: rs.trailPrefix()})
-	trailingObj := bkt.Object(rs.trailingObjectName(backupID, time.Now()))
+	trailIter := bkt.Objects(ctx, &gcpstorage.Query{Prefix: rs.trailPrefix(trail)})
+	trailingObj := bkt.Object(rs.trailingObjectName(trail, backupID, time.Now()))
 	_, err = trailingObj.CopierFrom(obj).Run(ctx)
 	if err != nil {
 		return
@@ -632,21 +646,21 @@ func (rs *DirectGCPStorage) trailBackup(ctx context.Context, bkt *gcpstorage.Buc
 
 	var (
 		oldTrailObj *gcpstorage.ObjectAttrs
-		trail       []string
+		trailObjs   []string
 	)
 	for oldTrailObj, err = trailIter.Next(); oldTrailObj != nil; oldTrailObj, err = trailIter.Next() {
-		trail = append(trail, oldTrailObj.Name)
+		trailObjs = append(trailObjs, oldTrailObj.Name)
 	}
 	if err != iterator.Done && err != nil {
 		return
 	}
-	log.WithField("trailLength", len(trail)).Debug("listed backup trail")
-	span.LogKV("trailLength", len(trail), "event", "listed backup trail")
+	log.WithField("trailLength", len(trailObjs)).Debug("listed backup trail")
+	span.LogKV("trailLength", len(trailObjs), "event", "listed backup trail")
 
-	sort.Slice(trail, func(i, j int) bool { return trail[i] < trail[j] })
+	sort.Slice(trailObjs, func(i, j int) bool { return trailObjs[i] < trailObjs[j] })
 
-	for i, oldTrailObj := range trail {
-		if i >= len(trail)-trailLength {
+	for i, oldTrailObj := range trailObjs {
+		if i >= len(trailObjs)-trailLength {
 			break
 		}
 
@@ -657,7 +671,7 @@ func (rs *DirectGCPStorage) trailBackup(ctx context.Context, bkt *gcpstorage.Buc
 			continue
 		}
 		span.LogKV("event", "old trailing object deleted", "bkt", rs.bucketName(), "obj", oldTrailObj)
-		log.WithField("obj", oldTrailObj).WithField("originalTrailLength", len(trail)).Debug("old trailing object deleted")
+		log.WithField("obj", oldTrailObj).WithField("originalTrailLength", len(trailObjs)).Debug("old trailing object deleted")
 	}
 	return nil
 }
@@ -702,12 +716,12 @@ func (rs *DirectGCPStorage) objectName(name string) string {
 	return gcpWorkspaceBackupObjectName(rs.workspacePrefix(), name)
 }
 
-func (rs *DirectGCPStorage) trailPrefix() string {
-	return fmt.Sprintf("%s/trail-", rs.workspacePrefix())
+func (rs *DirectGCPStorage) trailPrefix(trail string) string {
+	return fmt.Sprintf("%s/%s", rs.workspacePrefix(), BackupTrailPrefix(trail))
 }
 
-func (rs *DirectGCPStorage) trailingObjectName(id string, t time.Time) string {
-	return fmt.Sprintf("%s%d-%s", rs.trailPrefix(), t.Unix(), id)
+func (rs *DirectGCPStorage) trailingObjectName(trail string, id string, t time.Time) string {
+	return fmt.Sprintf("%s%d-%s", rs.trailPrefix(trail), t.Unix(), id)
 }
 
 func newGCPClient(ctx context.Context, cfg GCPConfig) (*gcpstorage.Client, error) {
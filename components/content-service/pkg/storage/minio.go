@@ -254,6 +254,20 @@ func (rs *DirectMinIOStorage) UploadInstance(ctx context.Context, source string,
 
 // Upload takes all files from a local location and uploads it to the remote storage
 func (rs *DirectMinIOStorage) Upload(ctx context.Context, source string, name string, opts ...UploadOption) (bucket, obj string, err error) {
+	return rs.upload(ctx, source, rs.objectName(name), opts...)
+}
+
+// UploadVolume takes all files from a local location and uploads it as content of the owner's persistent volume
+func (rs *DirectMinIOStorage) UploadVolume(ctx context.Context, source string, name string, opts ...UploadOption) (bucket, obj string, err error) {
+	return rs.upload(ctx, source, VolumeObjectName(name), opts...)
+}
+
+// DownloadVolume downloads the content of the owner's persistent volume to a local path
+func (rs *DirectMinIOStorage) DownloadVolume(ctx context.Context, destination string, name string, mappings []archive.IDMapping) (bool, error) {
+	return rs.download(ctx, destination, rs.bucketName(), VolumeObjectName(name), mappings)
+}
+
+func (rs *DirectMinIOStorage) upload(ctx context.Context, source string, objectName string, opts ...UploadOption) (bucket, obj string, err error) {
 	//nolint:ineffassign
 	span, ctx := opentracing.StartSpanFromContext(ctx, "DirectUpload")
 	defer tracing.FinishSpan(span, &err)
@@ -271,7 +285,7 @@ func (rs *DirectMinIOStorage) Upload(ctx context.Context, source string, name st
 
 	// upload the thing
 	bucket = rs.bucketName()
-	obj = rs.objectName(name)
+	obj = objectName
 	_, err = rs.client.FPutObject(ctx, bucket, obj, source, minio.PutObjectOptions{
 		NumThreads:   rs.MinIOConfig.ParallelUpload,
 		UserMetadata: options.Annotations,
@@ -280,6 +280,21 @@ func (mr *MockDirectAccessMockRecorder) DownloadSnapshot(arg0, arg1, arg2, arg3
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DownloadSnapshot", reflect.TypeOf((*MockDirectAccess)(nil).DownloadSnapshot), arg0, arg1, arg2, arg3)
 }
 
+// DownloadVolume mocks base method.
+func (m *MockDirectAccess) DownloadVolume(arg0 context.Context, arg1, arg2 string, arg3 []archive.IDMapping) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DownloadVolume", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DownloadVolume indicates an expected call of DownloadVolume.
+func (mr *MockDirectAccessMockRecorder) DownloadVolume(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DownloadVolume", reflect.TypeOf((*MockDirectAccess)(nil).DownloadVolume), arg0, arg1, arg2, arg3)
+}
+
 // EnsureExists mocks base method.
 func (m *MockDirectAccess) EnsureExists(arg0 context.Context) error {
 	m.ctrl.T.Helper()
@@ -378,3 +393,24 @@ func (mr *MockDirectAccessMockRecorder) UploadInstance(arg0, arg1, arg2 interfac
 	varargs := append([]interface{}{arg0, arg1, arg2}, arg3...)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UploadInstance", reflect.TypeOf((*MockDirectAccess)(nil).UploadInstance), varargs...)
 }
+
+// UploadVolume mocks base method.
+func (m *MockDirectAccess) UploadVolume(arg0 context.Context, arg1, arg2 string, arg3 ...storage.UploadOption) (string, string, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1, arg2}
+	for _, a := range arg3 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "UploadVolume", varargs...)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// UploadVolume indicates an expected call of UploadVolume.
+func (mr *MockDirectAccessMockRecorder) UploadVolume(arg0, arg1, arg2 interface{}, arg3 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1, arg2}, arg3...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UploadVolume", reflect.TypeOf((*MockDirectAccess)(nil).UploadVolume), varargs...)
+}
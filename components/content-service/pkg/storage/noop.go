@@ -55,6 +55,16 @@ func (rs *DirectNoopStorage) Upload(ctx context.Context, source string, name str
 	return "", "", nil
 }
 
+// UploadVolume does nothing
+func (rs *DirectNoopStorage) UploadVolume(ctx context.Context, source string, name string, opts ...UploadOption) (bucket, object string, err error) {
+	return "", "", nil
+}
+
+// DownloadVolume always returns false and does nothing
+func (rs *DirectNoopStorage) DownloadVolume(ctx context.Context, destination string, name string, mappings []archive.IDMapping) (bool, error) {
+	return false, nil
+}
+
 // Bucket returns an empty string
 func (rs *DirectNoopStorage) Bucket(string) string {
 	return ""
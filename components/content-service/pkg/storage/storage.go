@@ -28,6 +28,10 @@ const (
 
 	// FmtFullWorkspaceBackup is the format for names of full workspace backups
 	FmtFullWorkspaceBackup = "wsfull-%d.tar"
+
+	// PeriodicBackupTrail is the name of the backup trail of periodic backups. Keeping them apart from the default
+	// trail ensures that periodic backups don't push the backups of previous workspace instances out of it.
+	PeriodicBackupTrail = "periodic"
 )
 
 var (
@@ -158,12 +162,20 @@ type DirectAccess interface {
 
 	// UploadInstance takes all files from a local location and uploads it to the remote storage
 	UploadInstance(ctx context.Context, source string, name string, options ...UploadOption) (bucket, obj string, err error)
+
+	// UploadVolume takes all files from a local location and uploads it as content of the owner's persistent volume.
+	// Unlike backups, volumes are not bound to a workspace and are shared between all workspaces of the owner.
+	UploadVolume(ctx context.Context, source string, name string, options ...UploadOption) (bucket, obj string, err error)
+
+	// DownloadVolume downloads the content of the owner's persistent volume to a local path
+	DownloadVolume(ctx context.Context, destination string, name string, mappings []archive.IDMapping) (found bool, err error)
 }
 
 // UploadOptions configure remote storage upload
 type UploadOptions struct {
 	BackupTrail struct {
 		Enabled      bool
+		Name         string
 		ThisBackupID string
 		TrailLength  int
 	}
@@ -195,6 +207,32 @@ func WithBackupTrail(thisBackupID string, trailLength int) UploadOption {
 	}
 }
 
+// WithNamedBackupTrail enables backup trailing for this upload, using a trail other than the default one.
+// Each trail keeps up to trailLength backups.
+func WithNamedBackupTrail(name string, thisBackupID string, trailLength int) UploadOption {
+	return func(opts *UploadOptions) error {
+		if name == "" {
+			return xerrors.Errorf("backup trail name is missing")
+		}
+		err := WithBackupTrail(thisBackupID, trailLength)(opts)
+		if err != nil {
+			return err
+		}
+
+		opts.BackupTrail.Name = name
+		return nil
+	}
+}
+
+// BackupTrailPrefix returns the prefix of the names of the objects in a backup trail. The default trail has no name.
+func BackupTrailPrefix(name string) string {
+	if name == "" {
+		return "trail-"
+	}
+	// we must not share the prefix of the default trail, otherwise pruning it would remove our objects
+	return name + "-trail-"
+}
+
 // WithAnnotations adds arbitrary metadata to a storage object
 func WithAnnotations(md map[string]string) UploadOption {
 	return func(opts *UploadOptions) error {
@@ -369,3 +407,8 @@ func blobObjectName(name string) (string, error) {
 func InstanceObjectName(instanceID, name string) string {
 	return fmt.Sprintf("instances/%s/%s", instanceID, name)
 }
+
+// VolumeObjectName returns the object name of a persistent volume's content
+func VolumeObjectName(name string) string {
+	return fmt.Sprintf("volumes/%s.tar", name)
+}
@@ -37,6 +37,9 @@ type GitpodConfig struct {
 	// Configures Gitpod's GitHub app
 	Github *Github `yaml:"github,omitempty"`
 
+	// List of host aliases to add to the workspace's /etc/hosts file, e.g. to serve local development on virtual hostnames.
+	HostAliases []*HostAliasesItems `yaml:"hostAliases,omitempty"`
+
 	// Controls what ide should be used for a workspace.
 	Ide interface{} `yaml:"ide,omitempty"`
 
@@ -46,9 +49,15 @@ type GitpodConfig struct {
 	// List of exposed ports.
 	Ports []*PortsItems `yaml:"ports,omitempty"`
 
+	// List of service containers (e.g. databases or message brokers) to run next to the workspace. Services share the workspace's network, so their ports are reachable on localhost.
+	Services []*ServicesItems `yaml:"services,omitempty"`
+
 	// List of tasks to run on start. Each task will open a terminal in the IDE.
 	Tasks []*TasksItems `yaml:"tasks,omitempty"`
 
+	// List of persistent volumes. Their content outlives the workspace and is shared by all workspaces of the same user which use a volume of the same name, e.g. for a persistent home directory or build caches.
+	Volumes []*VolumesItems `yaml:"volumes,omitempty"`
+
 	// Configure VS Code integration
 	Vscode *Vscode `yaml:"vscode,omitempty"`
 
@@ -56,6 +65,16 @@ type GitpodConfig struct {
 	WorkspaceLocation string `yaml:"workspaceLocation,omitempty"`
 }
 
+// HostAliasesItems
+type HostAliasesItems struct {
+
+	// The hostnames which resolve to the IP address, e.g. api.local.
+	Hostnames []string `yaml:"hostnames"`
+
+	// The IP address the hostnames resolve to, e.g. 127.0.0.1.
+	Ip string `yaml:"ip"`
+}
+
 // Image_object The Docker image to run your workspace in.
 type Image_object struct {
 
@@ -78,7 +97,7 @@ type PortsItems struct {
 	// The port number (e.g. 1337) or range (e.g. 3000-3999) to expose.
 	Port interface{} `yaml:"port"`
 
-	// The protocol to be used. (deprecated)
+	// The application protocol the service on this port speaks. 'http' (default) supports HTTP/1.1 and WebSockets. 'http2' forwards requests as HTTP/2 without TLS (h2c), e.g. to a gRPC server. 'TCP' and 'UDP' are deprecated and treated as 'http'.
 	Protocol string `yaml:"protocol,omitempty"`
 
 	// Whether the port visibility should be private or public. 'public' (default) will allow everyone with the port URL to access the port. 'private' will only allow users with workspace access to access the port.
@@ -107,6 +126,38 @@ type Prebuilds_object struct {
 	PullRequestsFromForks bool `yaml:"pullRequestsFromForks,omitempty"`
 }
 
+// Resources Resources requested for the service container.
+type Resources struct {
+
+	// CPU request, e.g. 500m.
+	Cpu string `yaml:"cpu,omitempty"`
+
+	// Memory request, e.g. 512Mi.
+	Memory string `yaml:"memory,omitempty"`
+}
+
+// ServicesItems
+type ServicesItems struct {
+
+	// Environment variables to set in the service container.
+	Env map[string]string `yaml:"env,omitempty"`
+
+	// The Docker image to run the service from. Services run as the unprivileged gitpod user (UID/GID 33333) irrespective of the image's user, hence images which need root are not supported.
+	Image string `yaml:"image"`
+
+	// Name of the service. Must be a lowercase DNS label of at most 32 characters.
+	Name string `yaml:"name"`
+
+	// Ports the service listens on.
+	Ports []int `yaml:"ports,omitempty"`
+
+	// Command executed inside the service container to determine whether the service is ready. The service is considered ready once the command exits with 0.
+	ReadinessCommand []string `yaml:"readinessCommand,omitempty"`
+
+	// Resources requested for the service container.
+	Resources *Resources `yaml:"resources,omitempty"`
+}
+
 // TasksItems
 type TasksItems struct {
 
@@ -135,6 +186,16 @@ type TasksItems struct {
 	Prebuild string `yaml:"prebuild,omitempty" json:"prebuild,omitempty"`
 }
 
+// VolumesItems
+type VolumesItems struct {
+
+	// Absolute path in the workspace at which the volume is mounted, e.g. /home/gitpod/.m2.
+	MountPath string `yaml:"mountPath"`
+
+	// Name of the volume. Must be a lowercase DNS label of at most 32 characters.
+	Name string `yaml:"name"`
+}
+
 // Vscode Configure VS Code integration
 type Vscode struct {
 
@@ -219,6 +280,17 @@ func (strct *GitpodConfig) MarshalJSON() ([]byte, error) {
 		buf.Write(tmp)
 	}
 	comma = true
+	// Marshal the "hostAliases" field
+	if comma {
+		buf.WriteString(",")
+	}
+	buf.WriteString("\"hostAliases\": ")
+	if tmp, err := json.Marshal(strct.HostAliases); err != nil {
+		return nil, err
+	} else {
+		buf.Write(tmp)
+	}
+	comma = true
 	// Marshal the "ide" field
 	if comma {
 		buf.WriteString(",")
@@ -252,6 +324,17 @@ func (strct *GitpodConfig) MarshalJSON() ([]byte, error) {
 		buf.Write(tmp)
 	}
 	comma = true
+	// Marshal the "services" field
+	if comma {
+		buf.WriteString(",")
+	}
+	buf.WriteString("\"services\": ")
+	if tmp, err := json.Marshal(strct.Services); err != nil {
+		return nil, err
+	} else {
+		buf.Write(tmp)
+	}
+	comma = true
 	// Marshal the "tasks" field
 	if comma {
 		buf.WriteString(",")
@@ -263,6 +346,17 @@ func (strct *GitpodConfig) MarshalJSON() ([]byte, error) {
 		buf.Write(tmp)
 	}
 	comma = true
+	// Marshal the "volumes" field
+	if comma {
+		buf.WriteString(",")
+	}
+	buf.WriteString("\"volumes\": ")
+	if tmp, err := json.Marshal(strct.Volumes); err != nil {
+		return nil, err
+	} else {
+		buf.Write(tmp)
+	}
+	comma = true
 	// Marshal the "vscode" field
 	if comma {
 		buf.WriteString(",")
@@ -311,6 +405,10 @@ func (strct *GitpodConfig) UnmarshalJSON(b []byte) error {
 			if err := json.Unmarshal([]byte(v), &strct.Github); err != nil {
 				return err
 			}
+		case "hostAliases":
+			if err := json.Unmarshal([]byte(v), &strct.HostAliases); err != nil {
+				return err
+			}
 		case "ide":
 			if err := json.Unmarshal([]byte(v), &strct.Ide); err != nil {
 				return err
@@ -323,10 +421,18 @@ func (strct *GitpodConfig) UnmarshalJSON(b []byte) error {
 			if err := json.Unmarshal([]byte(v), &strct.Ports); err != nil {
 				return err
 			}
+		case "services":
+			if err := json.Unmarshal([]byte(v), &strct.Services); err != nil {
+				return err
+			}
 		case "tasks":
 			if err := json.Unmarshal([]byte(v), &strct.Tasks); err != nil {
 				return err
 			}
+		case "volumes":
+			if err := json.Unmarshal([]byte(v), &strct.Volumes); err != nil {
+				return err
+			}
 		case "vscode":
 			if err := json.Unmarshal([]byte(v), &strct.Vscode); err != nil {
 				return err
@@ -342,6 +448,77 @@ func (strct *GitpodConfig) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+func (strct *HostAliasesItems) MarshalJSON() ([]byte, error) {
+	buf := bytes.NewBuffer(make([]byte, 0))
+	buf.WriteString("{")
+	comma := false
+	// "Hostnames" field is required
+	// only required object types supported for marshal checking (for now)
+	// Marshal the "hostnames" field
+	if comma {
+		buf.WriteString(",")
+	}
+	buf.WriteString("\"hostnames\": ")
+	if tmp, err := json.Marshal(strct.Hostnames); err != nil {
+		return nil, err
+	} else {
+		buf.Write(tmp)
+	}
+	comma = true
+	// "Ip" field is required
+	// only required object types supported for marshal checking (for now)
+	// Marshal the "ip" field
+	if comma {
+		buf.WriteString(",")
+	}
+	buf.WriteString("\"ip\": ")
+	if tmp, err := json.Marshal(strct.Ip); err != nil {
+		return nil, err
+	} else {
+		buf.Write(tmp)
+	}
+	comma = true
+
+	buf.WriteString("}")
+	rv := buf.Bytes()
+	return rv, nil
+}
+
+func (strct *HostAliasesItems) UnmarshalJSON(b []byte) error {
+	hostnamesReceived := false
+	ipReceived := false
+	var jsonMap map[string]json.RawMessage
+	if err := json.Unmarshal(b, &jsonMap); err != nil {
+		return err
+	}
+	// parse all the defined properties
+	for k, v := range jsonMap {
+		switch k {
+		case "hostnames":
+			if err := json.Unmarshal([]byte(v), &strct.Hostnames); err != nil {
+				return err
+			}
+			hostnamesReceived = true
+		case "ip":
+			if err := json.Unmarshal([]byte(v), &strct.Ip); err != nil {
+				return err
+			}
+			ipReceived = true
+		default:
+			return xerrors.Errorf("additional property not allowed: \"" + k + "\"")
+		}
+	}
+	// check if hostnames (a required property) was received
+	if !hostnamesReceived {
+		return errors.New("\"hostnames\" is required but was not present")
+	}
+	// check if ip (a required property) was received
+	if !ipReceived {
+		return errors.New("\"ip\" is required but was not present")
+	}
+	return nil
+}
+
 func (strct *Image_object) MarshalJSON() ([]byte, error) {
 	buf := bytes.NewBuffer(make([]byte, 0))
 	buf.WriteString("{")
@@ -513,6 +690,192 @@ func (strct *PortsItems) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+func (strct *Resources) MarshalJSON() ([]byte, error) {
+	buf := bytes.NewBuffer(make([]byte, 0))
+	buf.WriteString("{")
+	comma := false
+	// Marshal the "cpu" field
+	if comma {
+		buf.WriteString(",")
+	}
+	buf.WriteString("\"cpu\": ")
+	if tmp, err := json.Marshal(strct.Cpu); err != nil {
+		return nil, err
+	} else {
+		buf.Write(tmp)
+	}
+	comma = true
+	// Marshal the "memory" field
+	if comma {
+		buf.WriteString(",")
+	}
+	buf.WriteString("\"memory\": ")
+	if tmp, err := json.Marshal(strct.Memory); err != nil {
+		return nil, err
+	} else {
+		buf.Write(tmp)
+	}
+	comma = true
+
+	buf.WriteString("}")
+	rv := buf.Bytes()
+	return rv, nil
+}
+
+func (strct *Resources) UnmarshalJSON(b []byte) error {
+	var jsonMap map[string]json.RawMessage
+	if err := json.Unmarshal(b, &jsonMap); err != nil {
+		return err
+	}
+	// parse all the defined properties
+	for k, v := range jsonMap {
+		switch k {
+		case "cpu":
+			if err := json.Unmarshal([]byte(v), &strct.Cpu); err != nil {
+				return err
+			}
+		case "memory":
+			if err := json.Unmarshal([]byte(v), &strct.Memory); err != nil {
+				return err
+			}
+		default:
+			return xerrors.Errorf("additional property not allowed: \"" + k + "\"")
+		}
+	}
+	return nil
+}
+
+func (strct *ServicesItems) MarshalJSON() ([]byte, error) {
+	buf := bytes.NewBuffer(make([]byte, 0))
+	buf.WriteString("{")
+	comma := false
+	// Marshal the "env" field
+	if comma {
+		buf.WriteString(",")
+	}
+	buf.WriteString("\"env\": ")
+	if tmp, err := json.Marshal(strct.Env); err != nil {
+		return nil, err
+	} else {
+		buf.Write(tmp)
+	}
+	comma = true
+	// "Image" field is required
+	// only required object types supported for marshal checking (for now)
+	// Marshal the "image" field
+	if comma {
+		buf.WriteString(",")
+	}
+	buf.WriteString("\"image\": ")
+	if tmp, err := json.Marshal(strct.Image); err != nil {
+		return nil, err
+	} else {
+		buf.Write(tmp)
+	}
+	comma = true
+	// "Name" field is required
+	// only required object types supported for marshal checking (for now)
+	// Marshal the "name" field
+	if comma {
+		buf.WriteString(",")
+	}
+	buf.WriteString("\"name\": ")
+	if tmp, err := json.Marshal(strct.Name); err != nil {
+		return nil, err
+	} else {
+		buf.Write(tmp)
+	}
+	comma = true
+	// Marshal the "ports" field
+	if comma {
+		buf.WriteString(",")
+	}
+	buf.WriteString("\"ports\": ")
+	if tmp, err := json.Marshal(strct.Ports); err != nil {
+		return nil, err
+	} else {
+		buf.Write(tmp)
+	}
+	comma = true
+	// Marshal the "readinessCommand" field
+	if comma {
+		buf.WriteString(",")
+	}
+	buf.WriteString("\"readinessCommand\": ")
+	if tmp, err := json.Marshal(strct.ReadinessCommand); err != nil {
+		return nil, err
+	} else {
+		buf.Write(tmp)
+	}
+	comma = true
+	// Marshal the "resources" field
+	if comma {
+		buf.WriteString(",")
+	}
+	buf.WriteString("\"resources\": ")
+	if tmp, err := json.Marshal(strct.Resources); err != nil {
+		return nil, err
+	} else {
+		buf.Write(tmp)
+	}
+	comma = true
+
+	buf.WriteString("}")
+	rv := buf.Bytes()
+	return rv, nil
+}
+
+func (strct *ServicesItems) UnmarshalJSON(b []byte) error {
+	imageReceived := false
+	nameReceived := false
+	var jsonMap map[string]json.RawMessage
+	if err := json.Unmarshal(b, &jsonMap); err != nil {
+		return err
+	}
+	// parse all the defined properties
+	for k, v := range jsonMap {
+		switch k {
+		case "env":
+			if err := json.Unmarshal([]byte(v), &strct.Env); err != nil {
+				return err
+			}
+		case "image":
+			if err := json.Unmarshal([]byte(v), &strct.Image); err != nil {
+				return err
+			}
+			imageReceived = true
+		case "name":
+			if err := json.Unmarshal([]byte(v), &strct.Name); err != nil {
+				return err
+			}
+			nameReceived = true
+		case "ports":
+			if err := json.Unmarshal([]byte(v), &strct.Ports); err != nil {
+				return err
+			}
+		case "readinessCommand":
+			if err := json.Unmarshal([]byte(v), &strct.ReadinessCommand); err != nil {
+				return err
+			}
+		case "resources":
+			if err := json.Unmarshal([]byte(v), &strct.Resources); err != nil {
+				return err
+			}
+		default:
+			return xerrors.Errorf("additional property not allowed: \"" + k + "\"")
+		}
+	}
+	// check if image (a required property) was received
+	if !imageReceived {
+		return errors.New("\"image\" is required but was not present")
+	}
+	// check if name (a required property) was received
+	if !nameReceived {
+		return errors.New("\"name\" is required but was not present")
+	}
+	return nil
+}
+
 func (strct *TasksItems) MarshalJSON() ([]byte, error) {
 	buf := bytes.NewBuffer(make([]byte, 0))
 	buf.WriteString("{")
@@ -658,6 +1021,77 @@ func (strct *TasksItems) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+func (strct *VolumesItems) MarshalJSON() ([]byte, error) {
+	buf := bytes.NewBuffer(make([]byte, 0))
+	buf.WriteString("{")
+	comma := false
+	// "MountPath" field is required
+	// only required object types supported for marshal checking (for now)
+	// Marshal the "mountPath" field
+	if comma {
+		buf.WriteString(",")
+	}
+	buf.WriteString("\"mountPath\": ")
+	if tmp, err := json.Marshal(strct.MountPath); err != nil {
+		return nil, err
+	} else {
+		buf.Write(tmp)
+	}
+	comma = true
+	// "Name" field is required
+	// only required object types supported for marshal checking (for now)
+	// Marshal the "name" field
+	if comma {
+		buf.WriteString(",")
+	}
+	buf.WriteString("\"name\": ")
+	if tmp, err := json.Marshal(strct.Name); err != nil {
+		return nil, err
+	} else {
+		buf.Write(tmp)
+	}
+	comma = true
+
+	buf.WriteString("}")
+	rv := buf.Bytes()
+	return rv, nil
+}
+
+func (strct *VolumesItems) UnmarshalJSON(b []byte) error {
+	mountPathReceived := false
+	nameReceived := false
+	var jsonMap map[string]json.RawMessage
+	if err := json.Unmarshal(b, &jsonMap); err != nil {
+		return err
+	}
+	// parse all the defined properties
+	for k, v := range jsonMap {
+		switch k {
+		case "mountPath":
+			if err := json.Unmarshal([]byte(v), &strct.MountPath); err != nil {
+				return err
+			}
+			mountPathReceived = true
+		case "name":
+			if err := json.Unmarshal([]byte(v), &strct.Name); err != nil {
+				return err
+			}
+			nameReceived = true
+		default:
+			return xerrors.Errorf("additional property not allowed: \"" + k + "\"")
+		}
+	}
+	// check if mountPath (a required property) was received
+	if !mountPathReceived {
+		return errors.New("\"mountPath\" is required but was not present")
+	}
+	// check if name (a required property) was received
+	if !nameReceived {
+		return errors.New("\"name\" is required but was not present")
+	}
+	return nil
+}
+
 func (strct *Vscode) MarshalJSON() ([]byte, error) {
 	buf := bytes.NewBuffer(make([]byte, 0))
 	buf.WriteString("{")
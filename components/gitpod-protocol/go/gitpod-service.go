@@ -1782,6 +1782,7 @@ type WorkspaceInstancePort struct {
 	TargetPort float64 `json:"targetPort,omitempty"`
 	URL        string  `json:"url,omitempty"`
 	Visibility string  `json:"visibility,omitempty"`
+	Protocol   string  `json:"protocol,omitempty"`
 }
 
 // GithubAppConfig is the GithubAppConfig message type
@@ -1812,6 +1813,7 @@ type PortConfig struct {
 	OnOpen     string  `json:"onOpen,omitempty"`
 	Port       float64 `json:"port,omitempty"`
 	Visibility string  `json:"visibility,omitempty"`
+	Protocol   string  `json:"protocol,omitempty"`
 }
 
 // ResolvedPlugins is the ResolvedPlugins message type
@@ -4,7 +4,7 @@
  * See License-AGPL.txt in the project root for license information.
  */
 
-import { WorkspaceInstance, PortVisibility } from "./workspace-instance";
+import { WorkspaceInstance, PortVisibility, PortProtocol } from "./workspace-instance";
 import { RoleOrPermission } from "./permission";
 import { Project } from "./teams-projects-protocol";
 
@@ -553,6 +553,9 @@ export interface WorkspaceConfig {
     image?: ImageConfig;
     ports?: PortConfig[];
     tasks?: TaskConfig[];
+    services?: ServiceConfig[];
+    volumes?: VolumeConfig[];
+    hostAliases?: HostAliasConfig[];
     checkoutLocation?: string;
     workspaceLocation?: string;
     gitConfig?: { [config: string]: string };
@@ -682,6 +685,7 @@ export interface PortConfig {
     port: number;
     onOpen?: PortOnOpen;
     visibility?: PortVisibility;
+    protocol?: PortProtocol;
 }
 export namespace PortConfig {
     export function is(config: any): config is PortConfig {
@@ -699,6 +703,28 @@ export namespace PortRangeConfig {
     }
 }
 
+export interface ServiceConfig {
+    name: string;
+    image: string;
+    env?: { [env: string]: string };
+    ports?: number[];
+    resources?: {
+        cpu?: string;
+        memory?: string;
+    };
+    readinessCommand?: string[];
+}
+
+export interface VolumeConfig {
+    name: string;
+    mountPath: string;
+}
+
+export interface HostAliasConfig {
+    ip: string;
+    hostnames: string[];
+}
+
 export interface TaskConfig {
     name?: string;
     before?: string;
@@ -153,6 +153,9 @@ export type AdmissionLevel = 'owner_only' | 'everyone';
 // PortVisibility describes how a port can be accessed
 export type PortVisibility = 'public' | 'private';
 
+// PortProtocol describes the application protocol the service behind a port speaks
+export type PortProtocol = 'http' | 'http2';
+
 // WorkspaceInstancePort describes a port exposed on a workspace instance
 export interface WorkspaceInstancePort {
     // The outward-facing port number
@@ -164,6 +167,9 @@ export interface WorkspaceInstancePort {
     // The visiblity of this port. Optional for backwards compatibility.
     visibility?: PortVisibility;
 
+    // The application protocol of this port. Optional, defaults to http.
+    protocol?: PortProtocol;
+
     // Public, outward-facing URL where the port can be accessed on.
     url?: string;
 }
@@ -8,6 +8,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"time"
@@ -20,6 +21,7 @@ import (
 	"github.com/opencontainers/go-digest"
 	ociv1 "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/opentracing/opentracing-go"
+	"golang.org/x/xerrors"
 
 	"github.com/gitpod-io/gitpod/common-go/log"
 	"github.com/gitpod-io/gitpod/common-go/tracing"
@@ -60,6 +62,11 @@ func (reg *Registry) handleBlob(ctx context.Context, r *http.Request) http.Handl
 			reg.LayerSource,
 		},
 		ConfigModifier: reg.ConfigModifier,
+		BlobCache:      reg.blobCache,
+		BlobPeers:      reg.blobPeers,
+		EStargz:        reg.estargz,
+		Cache:          reg.manifestCache,
+		Revision:       reg.revision(),
 
 		Metrics: reg.metrics,
 	}
@@ -86,6 +93,11 @@ type blobHandler struct {
 	Store             content.Store
 	AdditionalSources []BlobSource
 	ConfigModifier    ConfigModifier
+	BlobCache         *BlobCache
+	BlobPeers         *blobPeers
+	EStargz           *estargzConverter
+	Cache             *manifestCache
+	Revision          int
 
 	Metrics *metrics
 }
@@ -102,16 +114,33 @@ func (bh *blobHandler) getBlob(w http.ResponseWriter, r *http.Request) {
 		// TODO: rather than download the same manifest over and over again,
 		//       we should add it to the store and try and fetch it from there.
 		//		 Only if the store fetch fails should we attetmpt to download it.
-		manifest, fetcher, err := bh.downloadManifest(ctx, bh.Spec.BaseRef)
+		manifests, descs, fetcher, err := bh.downloadManifests(ctx, bh.Spec.BaseRef)
 		if err != nil {
 			return err
 		}
 
+		// We serve the layers of all platforms of the base image, as clients pick their platform from the image index.
+		var layers []ociv1.Descriptor
+		for _, m := range manifests {
+			layers = append(layers, m.Layers...)
+		}
+
 		var srcs []BlobSource
 		srcs = append(srcs, storeBlobSource{Store: bh.Store})
-		srcs = append(srcs, proxyingBlobSource{Fetcher: fetcher, Blobs: manifest.Layers})
-		srcs = append(srcs, &configBlobSource{Fetcher: fetcher, Spec: bh.Spec, Manifest: manifest, ConfigModifier: bh.ConfigModifier})
+		upstream := newUpstreamBlobSource(fetcher, layers, bh.BlobCache, bh.BlobPeers, bh.Metrics)
+		srcs = append(srcs, upstream)
+		for i, m := range manifests {
+			key, err := computedManifestKey(bh.Spec, descs[i].Digest, bh.Revision)
+			if err != nil {
+				return err
+			}
+			srcs = append(srcs, &configBlobSource{Fetcher: fetcher, Spec: bh.Spec, Manifest: m, ConfigModifier: bh.ConfigModifier, EStargz: bh.EStargz, Cache: bh.Cache, CacheKey: key})
+		}
 		srcs = append(srcs, bh.AdditionalSources...)
+		if bh.EStargz != nil {
+			layerSrcs := append([]BlobSource{upstream}, bh.AdditionalSources...)
+			srcs = append(srcs, estargzBlobSource{Converter: bh.EStargz, Sources: layerSrcs})
+		}
 
 		var src BlobSource
 		for _, s := range srcs {
@@ -137,9 +166,9 @@ func (bh *blobHandler) getBlob(w http.ResponseWriter, r *http.Request) {
 		}
 
 		w.Header().Set("Content-Type", mediaType)
-		w.Header().Set("Etag", bh.Digest.String())
+		w.Header().Set("Etag", fmt.Sprintf(`"%s"`, bh.Digest))
 		t0 := time.Now()
-		n, err := io.Copy(w, rc)
+		n, err := serveBlob(w, r, rc)
 		dt := time.Since(t0)
 		if err != nil {
 			return err
@@ -156,20 +185,69 @@ func (bh *blobHandler) getBlob(w http.ResponseWriter, r *http.Request) {
 	tracing.FinishSpan(span, &err)
 }
 
-func (bh *blobHandler) downloadManifest(ctx context.Context, ref string) (res *ociv1.Manifest, fetcher remotes.Fetcher, err error) {
-	_, desc, err := bh.Resolver.Resolve(ctx, ref)
+// serveBlob writes a blob to the response. Blobs we can seek in support range requests, which lets lazy-pulling
+// snapshotters fetch individual files of a layer. All other blobs we serve in their entirety, which HTTP permits.
+func serveBlob(w http.ResponseWriter, r *http.Request, blob io.Reader) (n int64, err error) {
+	rs, ok := blob.(io.ReadSeeker)
+	if !ok {
+		return io.Copy(w, blob)
+	}
+
+	cw := &countingResponseWriter{ResponseWriter: w}
+	http.ServeContent(cw, r, "", time.Time{}, rs)
+	return cw.n, nil
+}
+
+// countingResponseWriter counts the bytes written to the response body
+type countingResponseWriter struct {
+	http.ResponseWriter
+	n int64
+}
+
+func (w *countingResponseWriter) Write(b []byte) (n int, err error) {
+	n, err = w.ResponseWriter.Write(b)
+	w.n += int64(n)
+	return
+}
+
+// downloadManifests downloads the manifests of all platforms of an image
+func (bh *blobHandler) downloadManifests(ctx context.Context, ref string) (res []*ociv1.Manifest, descs []ociv1.Descriptor, fetcher remotes.Fetcher, err error) {
+	desc, err := resolveBase(ctx, bh.Resolver, bh.Cache, bh.Name, ref)
 	if err != nil {
 		// ErrInvalidAuthorization
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 
 	fetcher, err = bh.Resolver.Fetcher(ctx, ref)
 	if err != nil {
 		log.WithError(err).WithField("ref", ref).WithField("instanceId", bh.Name).Error("cannot get fetcher")
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
-	res, _, err = DownloadManifest(ctx, fetcher, desc, WithStore(bh.Store))
-	return
+
+	index, err := DownloadIndex(ctx, fetcher, desc, WithStore(bh.Store))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if index == nil {
+		manifest, ndesc, err := DownloadManifest(ctx, fetcher, desc, WithStore(bh.Store))
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		return []*ociv1.Manifest{manifest}, []ociv1.Descriptor{*ndesc}, fetcher, nil
+	}
+
+	for _, md := range index.Manifests {
+		if !isManifestMediaType(md.MediaType) {
+			continue
+		}
+		manifest, _, err := DownloadManifest(ctx, fetcher, md, WithStore(bh.Store))
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		res = append(res, manifest)
+		descs = append(descs, md)
+	}
+	return res, descs, fetcher, nil
 }
 
 type reader struct {
@@ -183,6 +261,23 @@ func (r *reader) Read(b []byte) (n int, err error) {
 	return
 }
 
+func (r *reader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += r.off
+	case io.SeekEnd:
+		offset += r.Size()
+	default:
+		return 0, xerrors.Errorf("invalid whence: %d", whence)
+	}
+	if offset < 0 {
+		return 0, xerrors.Errorf("negative position: %d", offset)
+	}
+	r.off = offset
+	return offset, nil
+}
+
 // BlobSource can provide blobs for download
 type BlobSource interface {
 	// HasBlob checks if a digest can be served by this blob source
@@ -216,6 +311,16 @@ func (sbs storeBlobSource) GetBlob(ctx context.Context, spec *api.ImageSpec, dgs
 	return info.Labels["Content-Type"], "", &reader{ReaderAt: r}, nil
 }
 
+// newUpstreamBlobSource produces a blob source for the layers of the base image. If there's a blob cache
+// we serve the layers from there.
+func newUpstreamBlobSource(fetcher remotes.Fetcher, layers []ociv1.Descriptor, cache *BlobCache, peers *blobPeers, metrics *metrics) BlobSource {
+	upstream := proxyingBlobSource{Fetcher: fetcher, Blobs: layers}
+	if cache == nil {
+		return upstream
+	}
+	return cachingBlobSource{Cache: cache, Peers: peers, Upstream: upstream, Metrics: metrics}
+}
+
 type proxyingBlobSource struct {
 	Fetcher remotes.Fetcher
 	Blobs   []ociv1.Descriptor
@@ -231,30 +336,37 @@ func (pbs proxyingBlobSource) HasBlob(ctx context.Context, spec *api.ImageSpec,
 }
 
 func (pbs proxyingBlobSource) GetBlob(ctx context.Context, spec *api.ImageSpec, dgst digest.Digest) (mediaType string, url string, data io.ReadCloser, err error) {
-	var src ociv1.Descriptor
-	for _, b := range pbs.Blobs {
-		if b.Digest == dgst {
-			src = b
-			break
-		}
-	}
-	if src.Digest == "" {
+	src := pbs.descriptor(dgst)
+	if src == nil {
 		err = errdefs.ErrNotFound
 		return
 	}
 
-	r, err := pbs.Fetcher.Fetch(ctx, src)
+	r, err := pbs.Fetcher.Fetch(ctx, *src)
 	if err != nil {
 		return
 	}
 	return src.MediaType, "", r, nil
 }
 
+// descriptor returns the descriptor of a blob or nil if the blob is not one of ours
+func (pbs proxyingBlobSource) descriptor(dgst digest.Digest) *ociv1.Descriptor {
+	for i := range pbs.Blobs {
+		if pbs.Blobs[i].Digest == dgst {
+			return &pbs.Blobs[i]
+		}
+	}
+	return nil
+}
+
 type configBlobSource struct {
 	Fetcher        remotes.Fetcher
 	Spec           *api.ImageSpec
 	Manifest       *ociv1.Manifest
 	ConfigModifier ConfigModifier
+	EStargz        *estargzConverter
+	Cache          *manifestCache
+	CacheKey       digest.Digest
 }
 
 func (pbs *configBlobSource) HasBlob(ctx context.Context, spec *api.ImageSpec, dgst digest.Digest) bool {
@@ -279,22 +391,41 @@ func (pbs *configBlobSource) GetBlob(ctx context.Context, spec *api.ImageSpec, d
 		return
 	}
 	mediaType = pbs.Manifest.Config.MediaType
-	data = io.NopCloser(bytes.NewReader(cfg))
+	data = nopSeekCloser{bytes.NewReader(cfg)}
 	return
 }
 
 func (pbs *configBlobSource) getConfig(ctx context.Context) (rawCfg []byte, err error) {
+	if pbs.Cache != nil {
+		// the manifest handler computed this config already
+		if m, ok := pbs.Cache.Computed(pbs.CacheKey); ok && m.Config != nil {
+			return m.Config, nil
+		}
+	}
+
 	manifest := *pbs.Manifest
 	cfg, err := DownloadConfig(ctx, pbs.Fetcher, manifest.Config)
 	if err != nil {
 		return
 	}
 
-	_, err = pbs.ConfigModifier(ctx, pbs.Spec, cfg)
+	addonLayer, err := pbs.ConfigModifier(ctx, pbs.Spec, cfg)
 	if err != nil {
 		return
 	}
+	if pbs.EStargz != nil {
+		// the manifest handler serves the config of the eStargz image once all layers are converted
+		layers := append(append([]ociv1.Descriptor{}, manifest.Layers...), addonLayer...)
+		_, _, _ = pbs.EStargz.Rewrite(layers, cfg)
+	}
 
 	rawCfg, err = json.Marshal(cfg)
 	return
 }
+
+// nopSeekCloser is like io.NopCloser but retains the ability to seek
+type nopSeekCloser struct {
+	io.ReadSeeker
+}
+
+func (nopSeekCloser) Close() error { return nil }
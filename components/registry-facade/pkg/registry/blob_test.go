@@ -0,0 +1,90 @@
+// Copyright (c) 2021 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package registry
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestServeBlob(t *testing.T) {
+	tests := []struct {
+		Name          string
+		Blob          func() io.Reader
+		Range         string
+		ExpectedCode  int
+		ExpectedBody  string
+		ExpectedBytes int64
+	}{
+		{
+			Name:          "seekable blob",
+			Blob:          func() io.Reader { return strings.NewReader("0123456789") },
+			ExpectedCode:  http.StatusOK,
+			ExpectedBody:  "0123456789",
+			ExpectedBytes: 10,
+		},
+		{
+			Name:          "range request",
+			Blob:          func() io.Reader { return strings.NewReader("0123456789") },
+			Range:         "bytes=2-5",
+			ExpectedCode:  http.StatusPartialContent,
+			ExpectedBody:  "2345",
+			ExpectedBytes: 4,
+		},
+		{
+			Name:          "range request for the end of a blob",
+			Blob:          func() io.Reader { return strings.NewReader("0123456789") },
+			Range:         "bytes=-3",
+			ExpectedCode:  http.StatusPartialContent,
+			ExpectedBody:  "789",
+			ExpectedBytes: 3,
+		},
+		{
+			Name:         "unsatisfiable range",
+			Blob:         func() io.Reader { return strings.NewReader("0123456789") },
+			Range:        "bytes=20-30",
+			ExpectedCode: http.StatusRequestedRangeNotSatisfiable,
+		},
+		{
+			Name:          "range request for a stream",
+			Blob:          func() io.Reader { return io.MultiReader(strings.NewReader("0123456789")) },
+			Range:         "bytes=2-5",
+			ExpectedCode:  http.StatusOK,
+			ExpectedBody:  "0123456789",
+			ExpectedBytes: 10,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/v2/foo/blobs/sha256:abc", nil)
+			if test.Range != "" {
+				req.Header.Set("Range", test.Range)
+			}
+			rec := httptest.NewRecorder()
+
+			n, err := serveBlob(rec, req, test.Blob())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if rec.Code != test.ExpectedCode {
+				t.Errorf("unexpected status code: %d", rec.Code)
+			}
+			if test.ExpectedCode >= 300 {
+				return
+			}
+			if act := rec.Body.String(); act != test.ExpectedBody {
+				t.Errorf("unexpected body: %q", act)
+			}
+			if n != test.ExpectedBytes {
+				t.Errorf("unexpected number of bytes served: %d", n)
+			}
+		})
+	}
+}
@@ -0,0 +1,454 @@
+// Copyright (c) 2021 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package registry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/containerd/containerd/errdefs"
+	"github.com/hashicorp/golang-lru/simplelru"
+	"github.com/opencontainers/go-digest"
+	"golang.org/x/xerrors"
+
+	"github.com/gitpod-io/gitpod/common-go/log"
+	"github.com/gitpod-io/gitpod/registry-facade/api"
+)
+
+// BlobCacheConfig configures the on-disk cache of blobs we pull from upstream registries
+type BlobCacheConfig struct {
+	// Location is the directory the cache lives in
+	Location string `json:"location"`
+	// MaxSizeBytes is the total size of all cached blobs beyond which we evict the least recently used ones
+	MaxSizeBytes int64 `json:"maxSizeBytes"`
+	// Peers enables fetching blobs from other registry-facade instances before going upstream
+	Peers *BlobCachePeerConfig `json:"peers,omitempty"`
+}
+
+const (
+	// blobCacheTmpDir is where blobs are written to until their digest is verified
+	blobCacheTmpDir = "tmp"
+	// blobCacheMaxEntries limits the number of entries of the LRU list. The cache is bounded by the size of its blobs,
+	// hence we just need a number which we never reach.
+	blobCacheMaxEntries = 1 << 20
+)
+
+// BlobCache is an on-disk LRU cache of blobs, keyed by their digest. Blobs enter the cache only
+// once their content was verified against their digest.
+type BlobCache struct {
+	location string
+	maxSize  int64
+	metrics  *metrics
+
+	mu       sync.Mutex
+	entries  *simplelru.LRU
+	size     int64
+	inflight map[digest.Digest]struct{}
+}
+
+// NewBlobCache creates a new blob cache and picks up the blobs cached by a previous instance
+func NewBlobCache(cfg BlobCacheConfig, metrics *metrics) (*BlobCache, error) {
+	if cfg.Location == "" {
+		return nil, xerrors.Errorf("blob cache location is required")
+	}
+	if cfg.MaxSizeBytes <= 0 {
+		return nil, xerrors.Errorf("blob cache max size must be positive")
+	}
+
+	c := &BlobCache{
+		location: cfg.Location,
+		maxSize:  cfg.MaxSizeBytes,
+		metrics:  metrics,
+		inflight: make(map[digest.Digest]struct{}),
+	}
+	entries, err := simplelru.NewLRU(blobCacheMaxEntries, c.onEvict)
+	if err != nil {
+		return nil, err
+	}
+	c.entries = entries
+
+	// blobs which were still being downloaded when we last stopped are useless
+	err = os.RemoveAll(filepath.Join(c.location, blobCacheTmpDir))
+	if err != nil {
+		return nil, xerrors.Errorf("cannot clean up blob cache: %w", err)
+	}
+	err = os.MkdirAll(filepath.Join(c.location, blobCacheTmpDir), 0755)
+	if err != nil {
+		return nil, xerrors.Errorf("cannot create blob cache: %w", err)
+	}
+
+	err = c.load()
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+type cachedBlob struct {
+	Digest  digest.Digest
+	Size    int64
+	ModTime time.Time
+}
+
+// load adds all blobs present on disk to the cache. We use their modification time to restore the LRU order.
+func (c *BlobCache) load() error {
+	var blobs []cachedBlob
+	err := filepath.WalkDir(c.location, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != c.location && d.Name() == blobCacheTmpDir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		rel, err := filepath.Rel(c.location, path)
+		if err != nil {
+			return err
+		}
+		dgst := digest.Digest(filepath.Dir(rel) + ":" + filepath.Base(rel))
+		if dgst.Validate() != nil {
+			log.WithField("path", path).Warn("removing unknown file from blob cache")
+			return os.Remove(path)
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		blobs = append(blobs, cachedBlob{Digest: dgst, Size: info.Size(), ModTime: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return xerrors.Errorf("cannot load blob cache: %w", err)
+	}
+
+	sort.Slice(blobs, func(i, j int) bool { return blobs[i].ModTime.Before(blobs[j].ModTime) })
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, b := range blobs {
+		c.add(b.Digest, b.Size)
+	}
+	log.WithField("blobs", c.entries.Len()).WithField("size", c.size).Info("loaded blob cache")
+	return nil
+}
+
+func (c *BlobCache) path(dgst digest.Digest) string {
+	return filepath.Join(c.location, dgst.Algorithm().String(), dgst.Encoded())
+}
+
+// add adds a blob which is present on disk to the cache and evicts blobs until the cache fits its size again.
+// Callers must hold c.mu.
+func (c *BlobCache) add(dgst digest.Digest, size int64) {
+	c.entries.Add(dgst, size)
+	c.size += size
+	for c.size > c.maxSize {
+		_, _, ok := c.entries.RemoveOldest()
+		if !ok {
+			break
+		}
+	}
+	c.metrics.BlobCacheSize.Set(float64(c.size))
+}
+
+// onEvict removes an evicted blob from disk. It's called by the LRU list while we hold c.mu.
+func (c *BlobCache) onEvict(key, value interface{}) {
+	dgst, size := key.(digest.Digest), value.(int64)
+	c.size -= size
+
+	// Requests which are serving this blob right now keep their file descriptor, hence can finish.
+	err := os.Remove(c.path(dgst))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		log.WithError(err).WithField("digest", dgst).Warn("cannot remove evicted blob")
+	}
+}
+
+// Has returns true if a blob is in the cache
+func (c *BlobCache) Has(dgst digest.Digest) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.entries.Contains(dgst)
+}
+
+// Get provides the content of a cached blob. If the blob is not in the cache, Get returns errdefs.ErrNotFound.
+func (c *BlobCache) Get(dgst digest.Digest) (io.ReadCloser, int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	size, ok := c.entries.Get(dgst)
+	if !ok {
+		return nil, 0, errdefs.ErrNotFound
+	}
+
+	fn := c.path(dgst)
+	f, err := os.Open(fn)
+	if errors.Is(err, fs.ErrNotExist) {
+		// someone removed the blob behind our back
+		c.entries.Remove(dgst)
+		return nil, 0, errdefs.ErrNotFound
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// the modification time restores the LRU order when we restart
+	now := time.Now()
+	_ = os.Chtimes(fn, now, now)
+
+	return f, size.(int64), nil
+}
+
+// Fill produces a reader which adds a blob to the cache while r is read. The blob enters the cache once r is read completely
+// and its content matches the digest. If the blob is too large for the cache or is being added already, Fill returns r as is.
+func (c *BlobCache) Fill(dgst digest.Digest, size int64, r io.ReadCloser) io.ReadCloser {
+	if size > c.maxSize || dgst.Validate() != nil {
+		return r
+	}
+
+	c.mu.Lock()
+	_, inflight := c.inflight[dgst]
+	if inflight || c.entries.Contains(dgst) {
+		c.mu.Unlock()
+		return r
+	}
+	c.inflight[dgst] = struct{}{}
+	c.mu.Unlock()
+
+	tmp, err := os.CreateTemp(filepath.Join(c.location, blobCacheTmpDir), "blob-*")
+	if err != nil {
+		log.WithError(err).WithField("digest", dgst).Warn("cannot cache blob")
+		c.doneFilling(dgst)
+		return r
+	}
+
+	return &blobCacheFiller{
+		cache:    c,
+		digest:   dgst,
+		size:     size,
+		src:      r,
+		tmp:      tmp,
+		verifier: dgst.Verifier(),
+	}
+}
+
+// Add reads a blob completely and adds it to the cache. Add fails if the blob's content does not match the digest,
+// or if the blob cannot enter the cache, e.g. because it's too large or being added already.
+func (c *BlobCache) Add(dgst digest.Digest, size int64, r io.Reader) error {
+	filler, ok := c.Fill(dgst, size, io.NopCloser(r)).(*blobCacheFiller)
+	if !ok {
+		return xerrors.Errorf("cannot add blob %s to the cache", dgst)
+	}
+	defer filler.Close()
+
+	_, err := io.Copy(io.Discard, filler)
+	if err != nil {
+		return err
+	}
+	if !filler.cached {
+		return xerrors.Errorf("blob %s does not match its digest", dgst)
+	}
+	return nil
+}
+
+func (c *BlobCache) doneFilling(dgst digest.Digest) {
+	c.mu.Lock()
+	delete(c.inflight, dgst)
+	c.mu.Unlock()
+}
+
+// commit moves a verified blob into place and adds it to the cache
+func (c *BlobCache) commit(dgst digest.Digest, size int64, tmpfn string) error {
+	fn := c.path(dgst)
+	err := os.MkdirAll(filepath.Dir(fn), 0755)
+	if err != nil {
+		return err
+	}
+	err = os.Rename(tmpfn, fn)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.add(dgst, size)
+	return nil
+}
+
+// blobCacheFiller writes everything it reads to a temporary file which becomes the cached blob
+// once we've read the blob completely and verified its digest
+type blobCacheFiller struct {
+	cache    *BlobCache
+	digest   digest.Digest
+	size     int64
+	src      io.ReadCloser
+	tmp      *os.File
+	verifier digest.Verifier
+	n        int64
+	done     bool
+	// cached is true once the blob has entered the cache
+	cached bool
+}
+
+func (f *blobCacheFiller) Read(b []byte) (n int, err error) {
+	n, err = f.src.Read(b)
+	if n > 0 && f.tmp != nil {
+		f.n += int64(n)
+		_, _ = f.verifier.Write(b[:n])
+		_, werr := f.tmp.Write(b[:n])
+		if werr != nil {
+			log.WithError(werr).WithField("digest", f.digest).Warn("cannot cache blob")
+			f.abandon()
+		}
+	}
+	if err == io.EOF && f.tmp != nil {
+		f.finish()
+	}
+	return n, err
+}
+
+// finish adds the blob to the cache if it's complete and its content matches the digest
+func (f *blobCacheFiller) finish() {
+	if (f.size >= 0 && f.n != f.size) || !f.verifier.Verified() {
+		log.WithField("digest", f.digest).WithField("size", f.n).Warn("blob does not match its digest - not caching it")
+		f.abandon()
+		return
+	}
+
+	tmpfn := f.tmp.Name()
+	err := f.tmp.Close()
+	f.tmp = nil
+	if err == nil {
+		err = f.cache.commit(f.digest, f.n, tmpfn)
+	}
+	if err != nil {
+		log.WithError(err).WithField("digest", f.digest).Warn("cannot cache blob")
+		_ = os.Remove(tmpfn)
+	}
+	f.cached = err == nil
+	f.cache.doneFilling(f.digest)
+	f.done = true
+}
+
+// abandon gives up on caching the blob, but continues to read it
+func (f *blobCacheFiller) abandon() {
+	if f.tmp == nil {
+		return
+	}
+	_ = f.tmp.Close()
+	_ = os.Remove(f.tmp.Name())
+	f.tmp = nil
+	f.cache.doneFilling(f.digest)
+	f.done = true
+}
+
+func (f *blobCacheFiller) Close() error {
+	if !f.done {
+		// the reader stopped before the blob was complete
+		f.abandon()
+	}
+	return f.src.Close()
+}
+
+// cachingBlobSource serves the layers of an image from the blob cache. Blobs which are not cached yet are fetched
+// from peers or the upstream registry and added to the cache while we serve them.
+type cachingBlobSource struct {
+	Cache    *BlobCache
+	Peers    *blobPeers
+	Upstream proxyingBlobSource
+	Metrics  *metrics
+}
+
+func (cbs cachingBlobSource) HasBlob(ctx context.Context, spec *api.ImageSpec, dgst digest.Digest) bool {
+	// We only serve blobs of the image that's being pulled, no matter what else we have in the cache.
+	return cbs.Upstream.HasBlob(ctx, spec, dgst)
+}
+
+func (cbs cachingBlobSource) GetBlob(ctx context.Context, spec *api.ImageSpec, dgst digest.Digest) (mediaType string, url string, data io.ReadCloser, err error) {
+	var desc = cbs.Upstream.descriptor(dgst)
+	if desc == nil {
+		err = errdefs.ErrNotFound
+		return
+	}
+	mediaType = desc.MediaType
+
+	rc, _, err := cbs.Cache.Get(dgst)
+	if err == nil {
+		cbs.Metrics.BlobCacheRequests.WithLabelValues("hit").Inc()
+		cr := &countingReader{ReadCloser: rc, Counter: cbs.Metrics.BlobBytesServed.WithLabelValues("cache")}
+		if s, ok := rc.(io.Seeker); ok {
+			return mediaType, "", &countingReadSeeker{countingReader: cr, Seeker: s}, nil
+		}
+		return mediaType, "", cr, nil
+	}
+	if !errdefs.IsNotFound(err) {
+		log.WithError(err).WithField("digest", dgst).Warn("cannot read blob from cache")
+	}
+
+	if cbs.Peers != nil {
+		rc, err = cbs.fetchFromPeers(ctx, dgst, desc.Size)
+		if err == nil {
+			cbs.Metrics.BlobCacheRequests.WithLabelValues("peer").Inc()
+			return mediaType, "", &countingReader{ReadCloser: rc, Counter: cbs.Metrics.BlobBytesServed.WithLabelValues("peer")}, nil
+		}
+		if !errdefs.IsNotFound(err) {
+			log.WithError(err).WithField("digest", dgst).Debug("cannot fetch blob from peers")
+		}
+	}
+
+	cbs.Metrics.BlobCacheRequests.WithLabelValues("miss").Inc()
+	rc, err = cbs.Upstream.Fetcher.Fetch(ctx, *desc)
+	if err != nil {
+		return "", "", nil, err
+	}
+	rc = cbs.Cache.Fill(dgst, desc.Size, rc)
+	return mediaType, "", &countingReader{ReadCloser: rc, Counter: cbs.Metrics.BlobBytesServed.WithLabelValues("upstream")}, nil
+}
+
+// fetchFromPeers adds a blob from a peer to the cache and serves it from there. We don't stream the peer's response
+// directly, so that we never serve content which doesn't match the digest, and can go upstream if the peer fails halfway.
+func (cbs cachingBlobSource) fetchFromPeers(ctx context.Context, dgst digest.Digest, size int64) (io.ReadCloser, error) {
+	rc, err := cbs.Peers.Fetch(ctx, dgst)
+	if err != nil {
+		return nil, err
+	}
+	err = cbs.Cache.Add(dgst, size, rc)
+	rc.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	rc, _, err = cbs.Cache.Get(dgst)
+	return rc, err
+}
+
+// countingReader adds the number of bytes read to a counter
+type countingReader struct {
+	io.ReadCloser
+	Counter interface{ Add(float64) }
+}
+
+func (r *countingReader) Read(b []byte) (n int, err error) {
+	n, err = r.ReadCloser.Read(b)
+	if n > 0 {
+		r.Counter.Add(float64(n))
+	}
+	return
+}
+
+// countingReadSeeker is a countingReader which retains the ability to seek, so that we can serve range requests
+type countingReadSeeker struct {
+	*countingReader
+	io.Seeker
+}
@@ -0,0 +1,198 @@
+// Copyright (c) 2021 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package registry
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/containerd/containerd/errdefs"
+	"github.com/opencontainers/go-digest"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func newTestBlobCache(t *testing.T, loc string, maxSize int64) *BlobCache {
+	metrics, err := newMetrics(prometheus.NewRegistry(), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := NewBlobCache(BlobCacheConfig{Location: loc, MaxSizeBytes: maxSize}, metrics)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func fillBlobCache(t *testing.T, c *BlobCache, dgst digest.Digest, content string) {
+	rc := c.Fill(dgst, int64(len(content)), io.NopCloser(strings.NewReader(content)))
+	act, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(act) != content {
+		t.Errorf("filling the cache altered the content: %q", act)
+	}
+	err = rc.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readBlobCache(t *testing.T, c *BlobCache, dgst digest.Digest) (string, error) {
+	rc, _, err := c.Get(dgst)
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+	b, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b), nil
+}
+
+func TestBlobCache(t *testing.T) {
+	var (
+		loc = t.TempDir()
+		c   = newTestBlobCache(t, loc, 10)
+		foo = digest.FromString("foo")
+		bar = digest.FromString("bar")
+		baz = digest.FromString("baz")
+	)
+
+	fillBlobCache(t, c, foo, "foo")
+	if act, err := readBlobCache(t, c, foo); err != nil || act != "foo" {
+		t.Fatalf("cannot read cached blob: %q, %v", act, err)
+	}
+
+	// blobs whose content does not match their digest must not enter the cache
+	fillBlobCache(t, c, bar, "not bar")
+	if c.Has(bar) {
+		t.Errorf("cached blob with mismatching digest")
+	}
+
+	// blobs which weren't read completely must not enter the cache
+	rc := c.Fill(bar, 3, io.NopCloser(strings.NewReader("bar")))
+	_, _ = rc.Read(make([]byte, 1))
+	_ = rc.Close()
+	if c.Has(bar) {
+		t.Errorf("cached incomplete blob")
+	}
+
+	// exceeding the size limit evicts the least recently used blob, which is bar because we just read foo
+	fillBlobCache(t, c, bar, "bar")
+	_, _ = readBlobCache(t, c, foo)
+	fillBlobCache(t, c, baz, "baz")
+	fillBlobCache(t, c, digest.FromString("1234"), "1234")
+	if _, err := readBlobCache(t, c, bar); !errdefs.IsNotFound(err) {
+		t.Errorf("expected bar to be evicted, got %v", err)
+	}
+	for _, d := range []digest.Digest{foo, baz} {
+		if !c.Has(d) {
+			t.Errorf("expected %s to be cached", d)
+		}
+	}
+
+	// a new cache picks up what's on disk
+	c = newTestBlobCache(t, loc, 10)
+	if act, err := readBlobCache(t, c, baz); err != nil || act != "baz" {
+		t.Errorf("cannot read cached blob after restart: %q, %v", act, err)
+	}
+	if c.Has(bar) {
+		t.Errorf("evicted blob came back after restart")
+	}
+}
+
+func TestBlobCacheAdd(t *testing.T) {
+	var (
+		c   = newTestBlobCache(t, t.TempDir(), 1024)
+		foo = digest.FromString("foo")
+		bar = digest.FromString("bar")
+	)
+
+	err := c.Add(foo, 3, strings.NewReader("foo"))
+	if err != nil {
+		t.Errorf("cannot add blob: %v", err)
+	}
+	if act, err := readBlobCache(t, c, foo); err != nil || act != "foo" {
+		t.Errorf("cannot read added blob: %q, %v", act, err)
+	}
+
+	err = c.Add(bar, 3, strings.NewReader("baz"))
+	if err == nil || c.Has(bar) {
+		t.Errorf("added blob with mismatching digest: %v", err)
+	}
+
+	// e.g. a peer which fails halfway
+	err = c.Add(bar, 3, io.MultiReader(strings.NewReader("b"), iotest.ErrReader(io.ErrUnexpectedEOF)))
+	if err == nil || c.Has(bar) {
+		t.Errorf("added incomplete blob: %v", err)
+	}
+}
+
+func TestBlobPeers(t *testing.T) {
+	var (
+		peer = newTestBlobCache(t, t.TempDir(), 1024)
+		foo  = digest.FromString("foo")
+	)
+	fillBlobCache(t, peer, foo, "foo")
+	srv := httptest.NewServer(peer)
+	defer srv.Close()
+
+	host, port, err := net.SplitHostPort(strings.TrimPrefix(srv.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	portNum, err := strconv.Atoi(port)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	peers, err := newBlobPeers(BlobCachePeerConfig{Service: "registry-facade-peers", Port: portNum})
+	if err != nil {
+		t.Fatal(err)
+	}
+	peers.lookup = func(ctx context.Context, h string) ([]string, error) { return []string{host}, nil }
+	peers.local = func() ([]net.Addr, error) { return nil, nil }
+	err = peers.refresh(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rc, err := peers.Fetch(context.Background(), foo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	act, _ := io.ReadAll(rc)
+	rc.Close()
+	if !bytes.Equal(act, []byte("foo")) {
+		t.Errorf("unexpected blob content from peer: %q", act)
+	}
+
+	_, err = peers.Fetch(context.Background(), digest.FromString("bar"))
+	if !errdefs.IsNotFound(err) {
+		t.Errorf("expected not found for blob the peer does not have, got %v", err)
+	}
+
+	// we never ask ourselves
+	peers.local = func() ([]net.Addr, error) {
+		return []net.Addr{&net.IPNet{IP: net.ParseIP(host), Mask: net.CIDRMask(32, 32)}}, nil
+	}
+	err = peers.refresh(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = peers.Fetch(context.Background(), foo)
+	if !errdefs.IsNotFound(err) {
+		t.Errorf("expected not to fetch from ourselves, got %v", err)
+	}
+}
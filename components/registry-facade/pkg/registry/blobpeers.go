@@ -0,0 +1,202 @@
+// Copyright (c) 2021 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package registry
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/containerd/containerd/errdefs"
+	"github.com/opencontainers/go-digest"
+	"golang.org/x/xerrors"
+
+	"github.com/gitpod-io/gitpod/common-go/log"
+)
+
+// BlobCachePeerConfig configures how registry-facade instances share their cached blobs
+type BlobCachePeerConfig struct {
+	// Port is the port on which we serve cached blobs to peers
+	Port int `json:"port"`
+	// Service is a DNS name which resolves to the addresses of all registry-facade instances, e.g. a headless Kubernetes service
+	Service string `json:"service"`
+}
+
+const (
+	// blobPeerRefreshInterval is how often we look for new peers
+	blobPeerRefreshInterval = 30 * time.Second
+	// blobPeerTimeout is how long we wait for a peer to start sending a blob.
+	// Peers serve from their local disk, hence a slow peer is worse than going upstream.
+	blobPeerTimeout = 2 * time.Second
+	// blobPeerMaxAttempts is the number of peers we ask for a blob before we go upstream
+	blobPeerMaxAttempts = 3
+	// blobPeerPathPrefix is the path under which peers serve their cached blobs
+	blobPeerPathPrefix = "/blobs/"
+)
+
+// blobPeers finds other registry-facade instances and fetches blobs from their cache
+type blobPeers struct {
+	Config BlobCachePeerConfig
+
+	client *http.Client
+	lookup func(ctx context.Context, host string) ([]string, error)
+	local  func() ([]net.Addr, error)
+
+	mu    sync.RWMutex
+	addrs []string
+}
+
+func newBlobPeers(cfg BlobCachePeerConfig) (*blobPeers, error) {
+	if cfg.Service == "" {
+		return nil, xerrors.Errorf("blob cache peer service is required")
+	}
+	if cfg.Port <= 0 {
+		return nil, xerrors.Errorf("blob cache peer port is required")
+	}
+
+	return &blobPeers{
+		Config: cfg,
+		client: &http.Client{
+			Transport: &http.Transport{
+				DialContext:           (&net.Dialer{Timeout: blobPeerTimeout}).DialContext,
+				ResponseHeaderTimeout: blobPeerTimeout,
+				MaxIdleConnsPerHost:   4,
+				IdleConnTimeout:       30 * time.Second,
+			},
+		},
+		lookup: net.DefaultResolver.LookupHost,
+		local:  net.InterfaceAddrs,
+	}, nil
+}
+
+// Run regularly looks for peers until the context is canceled
+func (p *blobPeers) Run(ctx context.Context) {
+	t := time.NewTicker(blobPeerRefreshInterval)
+	defer t.Stop()
+
+	for {
+		err := p.refresh(ctx)
+		if err != nil {
+			log.WithError(err).Warn("cannot find blob cache peers")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// refresh looks up the addresses of all peers, except our own
+func (p *blobPeers) refresh(ctx context.Context) error {
+	addrs, err := p.lookup(ctx, p.Config.Service)
+	if err != nil {
+		return err
+	}
+
+	own := make(map[string]struct{})
+	if local, err := p.local(); err == nil {
+		for _, a := range local {
+			if ipnet, ok := a.(*net.IPNet); ok {
+				own[ipnet.IP.String()] = struct{}{}
+			}
+		}
+	}
+
+	peers := make([]string, 0, len(addrs))
+	for _, a := range addrs {
+		if ip := net.ParseIP(a); ip != nil {
+			if _, self := own[ip.String()]; self {
+				continue
+			}
+		}
+		peers = append(peers, net.JoinHostPort(a, strconv.Itoa(p.Config.Port)))
+	}
+
+	p.mu.Lock()
+	p.addrs = peers
+	p.mu.Unlock()
+	return nil
+}
+
+// Fetch asks a few random peers for a blob. If none of them has it, Fetch returns errdefs.ErrNotFound.
+// The caller is expected to verify the content, e.g. by adding it to the blob cache.
+func (p *blobPeers) Fetch(ctx context.Context, dgst digest.Digest) (io.ReadCloser, error) {
+	p.mu.RLock()
+	addrs := append([]string(nil), p.addrs...)
+	p.mu.RUnlock()
+
+	rand.Shuffle(len(addrs), func(i, j int) { addrs[i], addrs[j] = addrs[j], addrs[i] })
+	if len(addrs) > blobPeerMaxAttempts {
+		addrs = addrs[:blobPeerMaxAttempts]
+	}
+
+	for _, addr := range addrs {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("http://%s%s%s", addr, blobPeerPathPrefix, dgst), nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := p.client.Do(req)
+		if err != nil {
+			log.WithError(err).WithField("peer", addr).Debug("cannot reach blob cache peer")
+			continue
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			continue
+		}
+		return resp.Body, nil
+	}
+	return nil, errdefs.ErrNotFound
+}
+
+// ServeHTTP serves blobs from the cache to peers. It never goes upstream, hence peers cannot use it
+// to make us pull arbitrary blobs.
+func (c *BlobCache) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	if !strings.HasPrefix(r.URL.Path, blobPeerPathPrefix) {
+		http.NotFound(w, r)
+		return
+	}
+	dgst, err := digest.Parse(strings.TrimPrefix(r.URL.Path, blobPeerPathPrefix))
+	if err != nil {
+		http.Error(w, "invalid digest", http.StatusBadRequest)
+		return
+	}
+
+	rc, size, err := c.Get(dgst)
+	if errdefs.IsNotFound(err) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		log.WithError(err).WithField("digest", dgst).Warn("cannot serve blob to peer")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	defer rc.Close()
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Length", strconv.FormatInt(size, 10))
+	w.Header().Set("Docker-Content-Digest", dgst.String())
+	if r.Method == http.MethodHead {
+		return
+	}
+	_, err = io.Copy(w, rc)
+	if err != nil {
+		log.WithError(err).WithField("digest", dgst).Debug("cannot serve blob to peer")
+	}
+}
@@ -0,0 +1,580 @@
+// Copyright (c) 2021 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package registry
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/containerd/containerd/errdefs"
+	"github.com/containerd/containerd/images"
+	"github.com/containerd/stargz-snapshotter/estargz"
+	"github.com/hashicorp/golang-lru/simplelru"
+	"github.com/opencontainers/go-digest"
+	ociv1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"golang.org/x/xerrors"
+
+	"github.com/gitpod-io/gitpod/common-go/log"
+	"github.com/gitpod-io/gitpod/registry-facade/api"
+)
+
+// EStargzConfig configures the conversion of image layers to eStargz. eStargz layers let lazy-pulling snapshotters
+// start a container before its layers are downloaded completely.
+type EStargzConfig struct {
+	// Location is the directory in which we keep the converted layers
+	Location string `json:"location"`
+	// MaxConcurrentConversions limits the number of layers we convert at the same time
+	MaxConcurrentConversions int `json:"maxConcurrentConversions"`
+	// MaxSizeBytes is the total size of all converted layers beyond which we evict the least recently used ones
+	MaxSizeBytes int64 `json:"maxSizeBytes"`
+}
+
+const (
+	// estargzBlobDir contains the converted layers, keyed by their digest
+	estargzBlobDir = "blobs"
+	// estargzLayerDir contains an estargzLayer for each converted layer, keyed by the digest of the original layer
+	estargzLayerDir = "layers"
+	// estargzTmpDir is where layers are written to while we convert them
+	estargzTmpDir = "tmp"
+	// estargzConversionTimeout is how long we try to convert a single layer
+	estargzConversionTimeout = 30 * time.Minute
+	// defaultMaxConcurrentConversions is used if the config does not limit the number of concurrent conversions
+	defaultMaxConcurrentConversions = 2
+	// estargzFailureTTL is how long we serve the original version of a layer we could not convert before we try again
+	estargzFailureTTL = 1 * time.Hour
+	// estargzMaxEntries limits the number of entries of the LRU list. Like the blob cache, we're bounded by the size
+	// of the converted layers.
+	estargzMaxEntries = 1 << 20
+)
+
+// estargzLayer describes the eStargz version of a layer
+type estargzLayer struct {
+	Digest           digest.Digest `json:"digest"`
+	Size             int64         `json:"size"`
+	DiffID           digest.Digest `json:"diffID"`
+	TOCDigest        digest.Digest `json:"tocDigest"`
+	UncompressedSize int64         `json:"uncompressedSize"`
+}
+
+// layerOpener provides the content of a layer we're about to convert
+type layerOpener func(ctx context.Context, desc ociv1.Descriptor) (io.ReadCloser, error)
+
+// estargzConverter converts image layers to eStargz in the background and keeps the converted layers on disk.
+// Once the converted layers exceed their maximum size we remove the least recently used ones.
+type estargzConverter struct {
+	location string
+	maxSize  int64
+	metrics  *metrics
+	sem      chan struct{}
+
+	mu        sync.Mutex
+	layers    map[digest.Digest]estargzLayer
+	blobs     map[digest.Digest]digest.Digest
+	entries   *simplelru.LRU
+	size      int64
+	evictions int
+	inflight  map[digest.Digest]struct{}
+	failed    map[digest.Digest]time.Time
+}
+
+func newEStargzConverter(cfg EStargzConfig, metrics *metrics) (*estargzConverter, error) {
+	if cfg.Location == "" {
+		return nil, xerrors.Errorf("eStargz location is required")
+	}
+	if cfg.MaxSizeBytes <= 0 {
+		return nil, xerrors.Errorf("eStargz max size must be positive")
+	}
+	concurrency := cfg.MaxConcurrentConversions
+	if concurrency <= 0 {
+		concurrency = defaultMaxConcurrentConversions
+	}
+
+	c := &estargzConverter{
+		location: cfg.Location,
+		maxSize:  cfg.MaxSizeBytes,
+		metrics:  metrics,
+		sem:      make(chan struct{}, concurrency),
+		layers:   make(map[digest.Digest]estargzLayer),
+		blobs:    make(map[digest.Digest]digest.Digest),
+		inflight: make(map[digest.Digest]struct{}),
+		failed:   make(map[digest.Digest]time.Time),
+	}
+	entries, err := simplelru.NewLRU(estargzMaxEntries, c.onEvict)
+	if err != nil {
+		return nil, err
+	}
+	c.entries = entries
+
+	// layers which were still being converted when we last stopped are useless
+	err = os.RemoveAll(filepath.Join(c.location, estargzTmpDir))
+	if err != nil {
+		return nil, xerrors.Errorf("cannot clean up eStargz layers: %w", err)
+	}
+	for _, d := range []string{estargzTmpDir, estargzBlobDir, estargzLayerDir} {
+		err = os.MkdirAll(filepath.Join(c.location, d), 0755)
+		if err != nil {
+			return nil, xerrors.Errorf("cannot create eStargz location: %w", err)
+		}
+	}
+
+	err = c.load()
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+type loadedEStargzLayer struct {
+	Original digest.Digest
+	Layer    estargzLayer
+	ModTime  time.Time
+}
+
+// load picks up the layers converted by a previous instance. We use the modification time of their layer files
+// to restore the LRU order.
+func (c *estargzConverter) load() error {
+	var (
+		root   = filepath.Join(c.location, estargzLayerDir)
+		loaded []loadedEStargzLayer
+	)
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		orig := digest.Digest(filepath.Dir(rel) + ":" + strings.TrimSuffix(filepath.Base(rel), ".json"))
+		if orig.Validate() != nil {
+			log.WithField("path", path).Warn("removing unknown file from eStargz layers")
+			return os.Remove(path)
+		}
+
+		fc, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var layer estargzLayer
+		err = json.Unmarshal(fc, &layer)
+		if err == nil {
+			_, err = os.Stat(c.blobPath(layer.Digest))
+		}
+		if err != nil {
+			log.WithError(err).WithField("path", path).Warn("removing broken eStargz layer")
+			return os.Remove(path)
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		loaded = append(loaded, loadedEStargzLayer{Original: orig, Layer: layer, ModTime: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return xerrors.Errorf("cannot load eStargz layers: %w", err)
+	}
+
+	sort.Slice(loaded, func(i, j int) bool { return loaded[i].ModTime.Before(loaded[j].ModTime) })
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, l := range loaded {
+		c.add(l.Original, l.Layer)
+	}
+	log.WithField("layers", len(c.layers)).WithField("size", c.size).Info("loaded eStargz layers")
+	return nil
+}
+
+// add adds a converted layer which is present on disk and evicts layers until we fit our size again.
+// Callers must hold c.mu.
+func (c *estargzConverter) add(orig digest.Digest, layer estargzLayer) {
+	c.layers[orig] = layer
+	c.blobs[layer.Digest] = orig
+	c.entries.Add(orig, layer.Size)
+	c.size += layer.Size
+	for c.size > c.maxSize {
+		_, _, ok := c.entries.RemoveOldest()
+		if !ok {
+			break
+		}
+	}
+}
+
+// onEvict removes an evicted layer from disk. It's called by the LRU list while we hold c.mu.
+func (c *estargzConverter) onEvict(key, value interface{}) {
+	orig, size := key.(digest.Digest), value.(int64)
+	c.size -= size
+
+	layer, ok := c.layers[orig]
+	if !ok {
+		return
+	}
+	delete(c.layers, orig)
+	delete(c.blobs, layer.Digest)
+	// Manifests we rewrote refer to this layer, hence must not be served from the cache anymore
+	c.evictions++
+
+	// the layer file marks the conversion as complete, hence we remove it first
+	for _, fn := range []string{c.layerPath(orig), c.blobPath(layer.Digest)} {
+		err := os.Remove(fn)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			log.WithError(err).WithField("digest", orig).Warn("cannot remove evicted eStargz layer")
+		}
+	}
+}
+
+// Revision returns the number of layers we have evicted. Manifests we rewrote before an eviction may refer to
+// layers we no longer have.
+func (c *estargzConverter) Revision() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.evictions
+}
+
+func (c *estargzConverter) blobPath(dgst digest.Digest) string {
+	return filepath.Join(c.location, estargzBlobDir, dgst.Algorithm().String(), dgst.Encoded())
+}
+
+func (c *estargzConverter) layerPath(orig digest.Digest) string {
+	return filepath.Join(c.location, estargzLayerDir, orig.Algorithm().String(), orig.Encoded()+".json")
+}
+
+// isConvertible returns true if we can convert a layer to eStargz. Layers with URLs are not served by us,
+// hence we cannot replace them.
+func isConvertible(desc ociv1.Descriptor) bool {
+	if len(desc.URLs) > 0 {
+		return false
+	}
+	if _, ok := desc.Annotations[estargz.TOCJSONDigestAnnotation]; ok {
+		// already eStargz
+		return false
+	}
+	switch desc.MediaType {
+	case ociv1.MediaTypeImageLayer, ociv1.MediaTypeImageLayerGzip, images.MediaTypeDockerSchema2Layer, images.MediaTypeDockerSchema2LayerGzip:
+		return true
+	default:
+		return false
+	}
+}
+
+// Rewrite replaces the layers of an image and their diffIDs in cfg with their eStargz counterparts.
+// We rewrite an image only once all of its layers are converted, so that its manifest does not flip back and forth.
+// If a layer has not been converted yet, Rewrite returns false and leaves cfg untouched.
+// Layers we failed to convert keep their original version until we retry them. In that case final is false,
+// because the manifest changes once the retry succeeds.
+func (c *estargzConverter) Rewrite(layers []ociv1.Descriptor, cfg *ociv1.Image) (res []ociv1.Descriptor, ok bool, final bool) {
+	if len(layers) != len(cfg.RootFS.DiffIDs) {
+		return nil, false, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var diffIDs = make([]digest.Digest, len(layers))
+	res, final = make([]ociv1.Descriptor, len(layers)), true
+	for i, l := range layers {
+		res[i], diffIDs[i] = l, cfg.RootFS.DiffIDs[i]
+		if !isConvertible(l) {
+			continue
+		}
+		if _, failed := c.failed[l.Digest]; failed {
+			final = false
+			continue
+		}
+		el, ok := c.layers[l.Digest]
+		if !ok {
+			return nil, false, false
+		}
+		// serving a manifest makes its layers recently used
+		c.entries.Get(l.Digest)
+
+		mediaType := ociv1.MediaTypeImageLayerGzip
+		if strings.HasPrefix(l.MediaType, images.MediaTypeDockerSchema2Layer) {
+			mediaType = images.MediaTypeDockerSchema2LayerGzip
+		}
+		annotations := make(map[string]string, len(l.Annotations)+2)
+		for k, v := range l.Annotations {
+			annotations[k] = v
+		}
+		annotations[estargz.TOCJSONDigestAnnotation] = el.TOCDigest.String()
+		annotations[estargz.StoreUncompressedSizeAnnotation] = strconv.FormatInt(el.UncompressedSize, 10)
+
+		res[i] = ociv1.Descriptor{
+			MediaType:   mediaType,
+			Digest:      el.Digest,
+			Size:        el.Size,
+			Annotations: annotations,
+		}
+		diffIDs[i] = el.DiffID
+	}
+
+	cfg.RootFS.DiffIDs = diffIDs
+	return res, true, final
+}
+
+// Convert starts converting all layers which have not been converted yet in the background.
+// Layers we failed to convert are retried once estargzFailureTTL has passed.
+func (c *estargzConverter) Convert(layers []ociv1.Descriptor, open layerOpener) {
+	for _, l := range layers {
+		if !isConvertible(l) {
+			continue
+		}
+
+		c.mu.Lock()
+		_, done := c.layers[l.Digest]
+		_, running := c.inflight[l.Digest]
+		failedAt, failed := c.failed[l.Digest]
+		if done || running || (failed && time.Since(failedAt) < estargzFailureTTL) {
+			c.mu.Unlock()
+			continue
+		}
+		c.inflight[l.Digest] = struct{}{}
+		c.mu.Unlock()
+
+		go func(desc ociv1.Descriptor) {
+			c.sem <- struct{}{}
+			defer func() { <-c.sem }()
+
+			ctx, cancel := context.WithTimeout(context.Background(), estargzConversionTimeout)
+			defer cancel()
+
+			t0 := time.Now()
+			err := c.convert(ctx, desc, open)
+
+			c.mu.Lock()
+			delete(c.inflight, desc.Digest)
+			if err != nil {
+				// Images which contain this layer still get the other layers converted until we retry it
+				c.failed[desc.Digest] = time.Now()
+			} else {
+				delete(c.failed, desc.Digest)
+			}
+			c.mu.Unlock()
+
+			if err != nil {
+				c.metrics.EStargzConversions.WithLabelValues("failure").Inc()
+				log.WithError(err).WithField("digest", desc.Digest).Warn("cannot convert layer to eStargz")
+				return
+			}
+			c.metrics.EStargzConversions.WithLabelValues("success").Inc()
+			log.WithField("digest", desc.Digest).WithField("duration", time.Since(t0).String()).Debug("converted layer to eStargz")
+		}(l)
+	}
+}
+
+// convert converts a single layer and adds it to the converted layers
+func (c *estargzConverter) convert(ctx context.Context, desc ociv1.Descriptor, open layerOpener) (err error) {
+	defer func() {
+		// a layer we cannot convert must not take registry-facade down with it
+		if r := recover(); r != nil {
+			err = xerrors.Errorf("cannot convert layer: %v", r)
+		}
+	}()
+
+	tmpdir := filepath.Join(c.location, estargzTmpDir)
+
+	rc, err := open(ctx, desc)
+	if err != nil {
+		return xerrors.Errorf("cannot open layer: %w", err)
+	}
+	src, err := os.CreateTemp(tmpdir, "src-*")
+	if err != nil {
+		rc.Close()
+		return err
+	}
+	defer os.Remove(src.Name())
+	defer src.Close()
+
+	verifier := desc.Digest.Verifier()
+	n, err := io.Copy(io.MultiWriter(src, verifier), rc)
+	rc.Close()
+	if err != nil {
+		return xerrors.Errorf("cannot download layer: %w", err)
+	}
+	if !verifier.Verified() {
+		return xerrors.Errorf("layer does not match its digest")
+	}
+
+	blob, err := estargz.Build(io.NewSectionReader(src, 0, n), estargz.WithCompressionLevel(gzip.DefaultCompression))
+	if err != nil {
+		return xerrors.Errorf("cannot build eStargz layer: %w", err)
+	}
+	defer blob.Close()
+
+	dst, err := os.CreateTemp(tmpdir, "estargz-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(dst.Name())
+	defer dst.Close()
+
+	dgst := digest.Canonical.Digester()
+	size, err := io.Copy(io.MultiWriter(dst, dgst.Hash()), blob)
+	if err != nil {
+		return xerrors.Errorf("cannot write eStargz layer: %w", err)
+	}
+	if size > c.maxSize {
+		return xerrors.Errorf("eStargz layer is larger than the maximum size of all layers")
+	}
+
+	// eStargz layers are regular gzip layers, hence we compute their diffID like we would for any other layer
+	_, err = dst.Seek(0, io.SeekStart)
+	if err != nil {
+		return err
+	}
+	zr, err := gzip.NewReader(dst)
+	if err != nil {
+		return xerrors.Errorf("cannot decompress eStargz layer: %w", err)
+	}
+	diffID := digest.Canonical.Digester()
+	usize, err := io.Copy(diffID.Hash(), zr)
+	if err != nil {
+		return xerrors.Errorf("cannot decompress eStargz layer: %w", err)
+	}
+
+	layer := estargzLayer{
+		Digest:           dgst.Digest(),
+		Size:             size,
+		DiffID:           diffID.Digest(),
+		TOCDigest:        blob.TOCDigest(),
+		UncompressedSize: usize,
+	}
+
+	fn := c.blobPath(layer.Digest)
+	err = os.MkdirAll(filepath.Dir(fn), 0755)
+	if err != nil {
+		return err
+	}
+	err = os.Rename(dst.Name(), fn)
+	if err != nil {
+		return err
+	}
+
+	// the layer file marks the conversion as complete, hence we write it last
+	fc, err := json.Marshal(layer)
+	if err != nil {
+		return err
+	}
+	tmpfn := filepath.Join(tmpdir, desc.Digest.Encoded()+".json")
+	err = os.WriteFile(tmpfn, fc, 0644)
+	if err != nil {
+		return err
+	}
+	fn = c.layerPath(desc.Digest)
+	err = os.MkdirAll(filepath.Dir(fn), 0755)
+	if err != nil {
+		return err
+	}
+	err = os.Rename(tmpfn, fn)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.add(desc.Digest, layer)
+	c.mu.Unlock()
+	return nil
+}
+
+// Original returns the digest of the layer an eStargz blob was converted from
+func (c *estargzConverter) Original(dgst digest.Digest) (digest.Digest, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	orig, ok := c.blobs[dgst]
+	return orig, ok
+}
+
+// Open provides the content of a converted layer. If there's no such layer, Open returns errdefs.ErrNotFound.
+func (c *estargzConverter) Open(dgst digest.Digest) (*os.File, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	orig, ok := c.blobs[dgst]
+	if !ok {
+		return nil, errdefs.ErrNotFound
+	}
+	// Lazy-pulling snapshotters fetch layers while workspaces run, hence layers in use must stay recently used
+	c.entries.Get(orig)
+
+	f, err := os.Open(c.blobPath(dgst))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, errdefs.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// the modification time of the layer file restores the LRU order when we restart
+	now := time.Now()
+	_ = os.Chtimes(c.layerPath(orig), now, now)
+
+	return f, nil
+}
+
+// blobSourceOpener opens layers using the first blob source which has them
+func blobSourceOpener(spec *api.ImageSpec, srcs ...BlobSource) layerOpener {
+	return func(ctx context.Context, desc ociv1.Descriptor) (io.ReadCloser, error) {
+		for _, s := range srcs {
+			if !s.HasBlob(ctx, spec, desc.Digest) {
+				continue
+			}
+			_, url, rc, err := s.GetBlob(ctx, spec, desc.Digest)
+			if err != nil {
+				return nil, err
+			}
+			if rc == nil {
+				return nil, xerrors.Errorf("layer is served from %s, not by us", url)
+			}
+			return rc, nil
+		}
+		return nil, errdefs.ErrNotFound
+	}
+}
+
+// estargzBlobSource serves converted layers
+type estargzBlobSource struct {
+	Converter *estargzConverter
+	// Sources serve the layers the eStargz layers were converted from. We only serve an eStargz layer
+	// if one of them has the original layer, i.e. if it's part of the image that's being pulled.
+	Sources []BlobSource
+}
+
+func (ebs estargzBlobSource) HasBlob(ctx context.Context, spec *api.ImageSpec, dgst digest.Digest) bool {
+	orig, ok := ebs.Converter.Original(dgst)
+	if !ok {
+		return false
+	}
+	for _, s := range ebs.Sources {
+		if s.HasBlob(ctx, spec, orig) {
+			return true
+		}
+	}
+	return false
+}
+
+func (ebs estargzBlobSource) GetBlob(ctx context.Context, spec *api.ImageSpec, dgst digest.Digest) (mediaType string, url string, data io.ReadCloser, err error) {
+	f, err := ebs.Converter.Open(dgst)
+	if err != nil {
+		return
+	}
+	return ociv1.MediaTypeImageLayerGzip, "", f, nil
+}
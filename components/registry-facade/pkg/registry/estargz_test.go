@@ -0,0 +1,301 @@
+// Copyright (c) 2021 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package registry
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/containerd/containerd/errdefs"
+	"github.com/containerd/stargz-snapshotter/estargz"
+	"github.com/opencontainers/go-digest"
+	ociv1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func newTestLayer(t *testing.T, files map[string]string) (layer []byte, diffID digest.Digest) {
+	var (
+		tarball bytes.Buffer
+		tw      = tar.NewWriter(&tarball)
+	)
+	for name, content := range files {
+		err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = tw.Write([]byte(content))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := tw.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	_, err = zw.Write(tarball.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = zw.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return gz.Bytes(), digest.FromBytes(tarball.Bytes())
+}
+
+func newTestEStargzConverter(t *testing.T, loc string) *estargzConverter {
+	return newTestEStargzConverterWithSize(t, loc, 1<<30)
+}
+
+func newTestEStargzConverterWithSize(t *testing.T, loc string, maxSize int64) *estargzConverter {
+	metrics, err := newMetrics(prometheus.NewRegistry(), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := newEStargzConverter(EStargzConfig{Location: loc, MaxSizeBytes: maxSize}, metrics)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// skipIfEStargzUnsupported skips a test if the toolchain's compress/gzip produces an eStargz footer of the wrong size
+func skipIfEStargzUnsupported(t *testing.T) {
+	defer func() {
+		if r := recover(); r != nil {
+			t.Skipf("eStargz is not supported by this toolchain: %v", r)
+		}
+	}()
+	_, _ = estargz.NewGzipCompressor().WriteTOCAndFooter(io.Discard, 0, &estargz.JTOC{}, sha256.New())
+}
+
+func TestEStargzConverter(t *testing.T) {
+	skipIfEStargzUnsupported(t)
+
+	var (
+		loc             = t.TempDir()
+		c               = newTestEStargzConverter(t, loc)
+		content, diffID = newTestLayer(t, map[string]string{"foo": "foo", "bar/baz": "baz"})
+		layer           = ociv1.Descriptor{MediaType: ociv1.MediaTypeImageLayerGzip, Digest: digest.FromBytes(content), Size: int64(len(content))}
+		remoteLayer     = ociv1.Descriptor{MediaType: ociv1.MediaTypeImageLayerGzip, Digest: digest.FromString("remote"), URLs: []string{"https://example.com/layer"}}
+		cfg             = &ociv1.Image{RootFS: ociv1.RootFS{Type: "layers", DiffIDs: []digest.Digest{diffID, digest.FromString("remote diffID")}}}
+		open            = func(ctx context.Context, desc ociv1.Descriptor) (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(content)), nil
+		}
+	)
+
+	if _, ok, _ := c.Rewrite([]ociv1.Descriptor{layer, remoteLayer}, cfg); ok {
+		t.Fatal("rewrote image before its layers were converted")
+	}
+	if cfg.RootFS.DiffIDs[0] != diffID {
+		t.Fatal("rewrite altered the config of an image it did not rewrite")
+	}
+
+	c.Convert([]ociv1.Descriptor{layer, remoteLayer}, open)
+	var (
+		layers []ociv1.Descriptor
+		ok     bool
+		final  bool
+	)
+	for i := 0; i < 100 && !ok; i++ {
+		time.Sleep(50 * time.Millisecond)
+		layers, ok, final = c.Rewrite([]ociv1.Descriptor{layer, remoteLayer}, cfg)
+	}
+	if !ok {
+		t.Fatal("layer was not converted")
+	}
+	if !final {
+		t.Error("rewrite of a completely converted image is not final")
+	}
+
+	// layers with URLs are not served by us, hence we leave them alone
+	if layers[1].Digest != remoteLayer.Digest || cfg.RootFS.DiffIDs[1] != digest.FromString("remote diffID") {
+		t.Errorf("rewrote layer with URL: %v", layers[1])
+	}
+
+	converted := layers[0]
+	if converted.Digest == layer.Digest {
+		t.Fatal("layer was not replaced")
+	}
+	tocDigest, err := digest.Parse(converted.Annotations[estargz.TOCJSONDigestAnnotation])
+	if err != nil {
+		t.Fatalf("converted layer has no valid TOC digest annotation: %v", err)
+	}
+	if converted.Annotations[estargz.StoreUncompressedSizeAnnotation] == "" {
+		t.Error("converted layer has no uncompressed size annotation")
+	}
+
+	f, err := c.Open(converted.Digest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	blob, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if act := digest.FromBytes(blob); act != converted.Digest || int64(len(blob)) != converted.Size {
+		t.Fatalf("converted layer does not match its descriptor: %s, %d bytes", act, len(blob))
+	}
+
+	r, err := estargz.Open(io.NewSectionReader(bytes.NewReader(blob), 0, int64(len(blob))))
+	if err != nil {
+		t.Fatalf("converted layer is no eStargz layer: %v", err)
+	}
+	_, err = r.VerifyTOC(tocDigest)
+	if err != nil {
+		t.Errorf("TOC does not match its annotation: %v", err)
+	}
+	if _, ok := r.Lookup("bar/baz"); !ok {
+		t.Error("converted layer lost a file")
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(blob))
+	if err != nil {
+		t.Fatal(err)
+	}
+	uncompressed, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if act := digest.FromBytes(uncompressed); act != cfg.RootFS.DiffIDs[0] {
+		t.Errorf("config diffID %s does not match converted layer %s", cfg.RootFS.DiffIDs[0], act)
+	}
+
+	// a new converter picks up what's on disk
+	c = newTestEStargzConverter(t, loc)
+	if orig, ok := c.Original(converted.Digest); !ok || orig != layer.Digest {
+		t.Errorf("converted layer is gone after restart: %v", orig)
+	}
+}
+
+// addTestEStargzLayer adds a converted layer of the given size without converting anything
+func addTestEStargzLayer(t *testing.T, c *estargzConverter, name string, size int64) (orig digest.Digest, layer estargzLayer) {
+	orig = digest.FromString(name)
+	layer = estargzLayer{Digest: digest.FromString(name + " eStargz"), Size: size}
+	for _, fn := range []string{c.blobPath(layer.Digest), c.layerPath(orig)} {
+		err := os.MkdirAll(filepath.Dir(fn), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(fn, nil, 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c.mu.Lock()
+	c.add(orig, layer)
+	c.mu.Unlock()
+	return
+}
+
+func TestEStargzConverterEviction(t *testing.T) {
+	c := newTestEStargzConverterWithSize(t, t.TempDir(), 10)
+
+	_, first := addTestEStargzLayer(t, c, "first", 4)
+	secondOrig, second := addTestEStargzLayer(t, c, "second", 4)
+
+	// using the first layer makes the second one the least recently used
+	f, err := c.Open(first.Digest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	if c.Revision() != 0 {
+		t.Fatalf("revision changed without eviction: %d", c.Revision())
+	}
+
+	_, third := addTestEStargzLayer(t, c, "third", 4)
+
+	if _, ok := c.Original(second.Digest); ok {
+		t.Error("least recently used layer was not evicted")
+	}
+	if _, err := c.Open(second.Digest); !errdefs.IsNotFound(err) {
+		t.Errorf("evicted layer can still be opened: %v", err)
+	}
+	for _, fn := range []string{c.blobPath(second.Digest), c.layerPath(secondOrig)} {
+		if _, err := os.Stat(fn); !os.IsNotExist(err) {
+			t.Errorf("evicted layer is still on disk: %s", fn)
+		}
+	}
+	for _, l := range []estargzLayer{first, third} {
+		if _, ok := c.Original(l.Digest); !ok {
+			t.Errorf("layer %s was evicted", l.Digest)
+		}
+	}
+	if c.size != 8 {
+		t.Errorf("unexpected size after eviction: %d", c.size)
+	}
+	if c.Revision() != 1 {
+		t.Errorf("eviction did not change the revision: %d", c.Revision())
+	}
+}
+
+func TestEStargzConverterRetry(t *testing.T) {
+	var (
+		c      = newTestEStargzConverter(t, t.TempDir())
+		layer  = ociv1.Descriptor{MediaType: ociv1.MediaTypeImageLayerGzip, Digest: digest.FromString("layer")}
+		cfg    = &ociv1.Image{RootFS: ociv1.RootFS{Type: "layers", DiffIDs: []digest.Digest{digest.FromString("diffID")}}}
+		opened = make(chan struct{}, 10)
+		open   = func(ctx context.Context, desc ociv1.Descriptor) (io.ReadCloser, error) {
+			opened <- struct{}{}
+			return nil, errdefs.ErrNotFound
+		}
+		hasFailed = func() bool {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			_, failed := c.failed[layer.Digest]
+			_, running := c.inflight[layer.Digest]
+			return failed && !running
+		}
+	)
+
+	c.Convert([]ociv1.Descriptor{layer}, open)
+	for i := 0; i < 100 && !hasFailed(); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !hasFailed() {
+		t.Fatal("conversion did not fail")
+	}
+	<-opened
+
+	// images with a failed layer keep its original version, but aren't final until we retried
+	layers, ok, final := c.Rewrite([]ociv1.Descriptor{layer}, cfg)
+	if !ok || final {
+		t.Errorf("unexpected rewrite of image with failed layer: ok=%v final=%v", ok, final)
+	}
+	if len(layers) != 1 || layers[0].Digest != layer.Digest {
+		t.Errorf("failed layer was replaced: %v", layers)
+	}
+
+	c.Convert([]ociv1.Descriptor{layer}, open)
+	select {
+	case <-opened:
+		t.Fatal("retried failed conversion before its TTL passed")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	c.mu.Lock()
+	c.failed[layer.Digest] = time.Now().Add(-estargzFailureTTL)
+	c.mu.Unlock()
+	c.Convert([]ociv1.Descriptor{layer}, open)
+	select {
+	case <-opened:
+	case <-time.After(5 * time.Second):
+		t.Fatal("did not retry failed conversion after its TTL passed")
+	}
+}
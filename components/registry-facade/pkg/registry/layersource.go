@@ -659,9 +659,10 @@ func NewRevisioningLayerSource(active LayerSource) *RevisioningLayerSource {
 }
 
 type RevisioningLayerSource struct {
-	mu     sync.RWMutex
-	active LayerSource
-	past   []LayerSource
+	mu       sync.RWMutex
+	active   LayerSource
+	past     []LayerSource
+	revision int
 }
 
 func (src *RevisioningLayerSource) Update(s LayerSource) {
@@ -670,6 +671,15 @@ func (src *RevisioningLayerSource) Update(s LayerSource) {
 
 	src.past = append(src.past, src.active)
 	src.active = s
+	src.revision++
+}
+
+// Revision returns the number of times this layer source was updated
+func (src *RevisioningLayerSource) Revision() int {
+	src.mu.RLock()
+	defer src.mu.RUnlock()
+
+	return src.revision
 }
 
 func (src *RevisioningLayerSource) GetLayer(ctx context.Context, spec *api.ImageSpec) ([]AddonLayer, error) {
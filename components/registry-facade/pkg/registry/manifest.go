@@ -18,6 +18,7 @@ import (
 	"github.com/containerd/containerd/content"
 	"github.com/containerd/containerd/errdefs"
 	"github.com/containerd/containerd/images"
+	"github.com/containerd/containerd/platforms"
 	"github.com/containerd/containerd/remotes"
 	distv2 "github.com/docker/distribution/registry/api/v2"
 	"github.com/gorilla/handlers"
@@ -43,6 +44,7 @@ func (reg *Registry) handleManifest(ctx context.Context, r *http.Request) http.H
 			respondWithError(w, distv2.ErrorCodeManifestUnknown)
 		})
 	}
+
 	spec, err := sp.GetSpec(ctx, name)
 	if err != nil {
 		log.WithError(err).WithField("specProvName", spname).WithField("name", name).Error("cannot get spec")
@@ -58,6 +60,15 @@ func (reg *Registry) handleManifest(ctx context.Context, r *http.Request) http.H
 		Resolver:       reg.Resolver(),
 		Store:          reg.Store,
 		ConfigModifier: reg.ConfigModifier,
+		LayerSource:    reg.LayerSource,
+		BlobCache:      reg.blobCache,
+		BlobPeers:      reg.blobPeers,
+		EStargz:        reg.estargz,
+		Signatures:     reg.signatures,
+		Metrics:        reg.metrics,
+		Platform:       reg.platform,
+		Cache:          reg.manifestCache,
+		Revision:       reg.revision(),
 	}
 	reference := getReference(ctx)
 	dgst, err := digest.Parse(reference)
@@ -90,6 +101,15 @@ type manifestHandler struct {
 	Resolver       remotes.Resolver
 	Store          content.Store
 	ConfigModifier ConfigModifier
+	LayerSource    LayerSource
+	BlobCache      *BlobCache
+	BlobPeers      *blobPeers
+	EStargz        *estargzConverter
+	Signatures     *signatureVerifier
+	Metrics        *metrics
+	Platform       ociv1.Platform
+	Cache          *manifestCache
+	Revision       int
 
 	Name   string
 	Tag    string
@@ -107,8 +127,9 @@ func (mh *manifestHandler) getManifest(w http.ResponseWriter, r *http.Request) {
 		tracing.LogMessageSafe(span, "spec", mh.Spec)
 
 		var (
-			acceptType string
-			err        error
+			acceptManifest bool
+			acceptIndex    bool
+			err            error
 		)
 		for _, acceptHeader := range r.Header["Accept"] {
 			for _, mediaType := range strings.Split(acceptHeader, ",") {
@@ -116,129 +137,342 @@ func (mh *manifestHandler) getManifest(w http.ResponseWriter, r *http.Request) {
 					continue
 				}
 
-				if mediaType == ociv1.MediaTypeImageManifest ||
-					mediaType == images.MediaTypeDockerSchema2Manifest ||
-					mediaType == "*" {
-
-					acceptType = ociv1.MediaTypeImageManifest
-					break
+				switch mediaType {
+				case ociv1.MediaTypeImageManifest, images.MediaTypeDockerSchema2Manifest, "*":
+					acceptManifest = true
+				case ociv1.MediaTypeImageIndex, images.MediaTypeDockerSchema2ManifestList:
+					acceptIndex = true
 				}
 			}
-			if acceptType != "" {
-				break
-			}
 		}
-		if acceptType == "" {
+		if !acceptManifest && !acceptIndex {
 			return distv2.ErrorCodeManifestUnknown.WithMessage("Accept header does not include OCIv1 or v2 manifests")
 		}
 
-		// Note: we ignore the mh.Digest for now because we always return a manifest, never a manifest index.
 		ref := mh.Spec.BaseRef
 
-		_, desc, err := mh.Resolver.Resolve(ctx, ref)
+		desc, err := resolveBase(ctx, mh.Resolver, mh.Cache, mh.Name, ref)
 		if err != nil {
 			// ErrInvalidAuthorization
 			return err
 		}
 
+		if mh.Signatures != nil {
+			err = mh.Signatures.Verify(ctx, mh.Resolver, ref, desc)
+			if err != nil {
+				return err
+			}
+		}
+
+		// Workspace images are pulled many times, e.g. whenever a workspace is restarted. If we served this manifest
+		// before we don't need to download the base image manifest and config, or add our layers again.
+		var responseKey digest.Digest
+		if mh.Cache != nil {
+			responseKey, err = manifestResponseKey(mh.Spec, desc.Digest, mh.Revision, mh.Digest, acceptManifest, acceptIndex)
+			if err != nil {
+				return err
+			}
+			if resp, ok := mh.Cache.Response(responseKey); ok {
+				writeManifest(w, resp.MediaType, resp.Content)
+				return nil
+			}
+		}
+
 		fetcher, err := mh.Resolver.Fetcher(ctx, ref)
 		if err != nil {
 			log.WithError(err).WithField("ref", ref).WithFields(logFields).Error("cannot get fetcher")
 			return distv2.ErrorCodeManifestUnknown.WithDetail(err)
 		}
-		rc, err := fetcher.Fetch(ctx, desc)
+
+		index, err := DownloadIndex(ctx, fetcher, desc, WithStore(mh.Store))
 		if err != nil {
-			log.WithError(err).WithField("ref", ref).WithFields(logFields).Error("cannot fetch manifest")
 			return distv2.ErrorCodeManifestUnknown.WithDetail(err)
 		}
-		defer rc.Close()
 
-		manifest, ndesc, err := DownloadManifest(ctx, fetcher, desc, WithStore(mh.Store))
+		var res *computedManifest
+		if index != nil {
+			res, err = mh.getIndexManifest(ctx, fetcher, desc, index, acceptIndex)
+		} else if acceptManifest && mh.Digest != "" {
+			res, err = mh.getManifestByDigest(ctx, fetcher, desc)
+		} else if acceptManifest {
+			res, err = mh.modifyManifest(ctx, fetcher, desc)
+		} else {
+			err = distv2.ErrorCodeManifestUnknown.WithMessage("Accept header does not include OCIv1 or v2 manifests")
+		}
 		if err != nil {
-			return distv2.ErrorCodeManifestUnknown.WithDetail(err)
+			return err
 		}
-		desc = *ndesc
 
-		var p []byte
-		switch desc.MediaType {
-		case images.MediaTypeDockerSchema2Manifest, ociv1.MediaTypeImageManifest:
-			// download config
-			cfg, err := DownloadConfig(ctx, fetcher, manifest.Config)
-			if err != nil {
-				return err
-			}
+		if mh.Cache != nil && res.Final {
+			mh.Cache.PutResponse(responseKey, &cachedResponse{Content: res.Manifest, MediaType: res.MediaType})
+		}
+		writeManifest(w, res.MediaType, res.Manifest)
 
-			// modify config
-			addonLayer, err := mh.ConfigModifier(ctx, mh.Spec, cfg)
-			if err != nil {
-				return err
-			}
-			manifest.Layers = append(manifest.Layers, addonLayer...)
+		log.WithFields(logFields).Debug("get manifest (end)")
+		return nil
+	}()
+
+	if err != nil {
+		log.WithError(err).WithField("spec", mh.Spec).Error("cannot get manifest")
+		respondWithError(w, err)
+	}
+	tracing.FinishSpan(span, &err)
+}
+
+func writeManifest(w http.ResponseWriter, mediaType string, p []byte) {
+	dgst := digest.FromBytes(p).String()
+
+	w.Header().Set("Content-Type", mediaType)
+	w.Header().Set("Content-Length", fmt.Sprint(len(p)))
+	w.Header().Set("Etag", fmt.Sprintf(`"%s"`, dgst))
+	w.Header().Set("Docker-Content-Digest", dgst)
+	_, _ = w.Write(p)
+}
+
+// errManifestDigestMismatch is returned when a client requests a manifest by a digest which does not denote the image (anymore)
+var errManifestDigestMismatch = distv2.ErrorCodeManifestUnknown.WithMessage("manifest digest does not match the image")
+
+// getManifestByDigest serves an image whose base image is a single manifest to a client which requests it by digest.
+// Clients get the digest from a manifest we served earlier, which may have referred to the original layers while
+// we converted them to eStargz.
+func (mh *manifestHandler) getManifestByDigest(ctx context.Context, fetcher remotes.Fetcher, desc ociv1.Descriptor) (*computedManifest, error) {
+	res, err := mh.modifyManifest(ctx, fetcher, desc)
+	if err != nil {
+		return nil, err
+	}
+	if digest.FromBytes(res.Manifest) == mh.Digest {
+		return res, nil
+	}
+	if mh.EStargz == nil {
+		return nil, errManifestDigestMismatch
+	}
+
+	res, err = mh.computeManifest(ctx, fetcher, desc, false)
+	if err != nil {
+		return nil, err
+	}
+	if digest.FromBytes(res.Manifest) != mh.Digest {
+		return nil, errManifestDigestMismatch
+	}
+	return res, nil
+}
 
-			// place config in store
-			rawCfg, err := json.Marshal(cfg)
+// getIndexManifest serves an image whose base image is an image index. Clients which accept image indexes get
+// the index with all of its manifests modified, and pick the manifest of their platform from it. All other clients
+// get the modified manifest of our default platform. Clients which request a digest get the index or manifest
+// with that digest, or an error if there is none.
+func (mh *manifestHandler) getIndexManifest(ctx context.Context, fetcher remotes.Fetcher, desc ociv1.Descriptor, index *ociv1.Index, acceptIndex bool) (*computedManifest, error) {
+	if mh.Digest != "" {
+		res, match, err := mh.modifyIndex(ctx, fetcher, desc, index, true)
+		if err != nil {
+			return nil, err
+		}
+		if !match && mh.EStargz != nil {
+			// we may have served the original layers while we converted them to eStargz
+			res, match, err = mh.modifyIndex(ctx, fetcher, desc, index, false)
 			if err != nil {
-				return err
+				return nil, err
 			}
-			cfgDgst := digest.FromBytes(rawCfg)
+		}
+		if !match && !(acceptIndex && digest.FromBytes(res.Manifest) == mh.Digest) {
+			return nil, errManifestDigestMismatch
+		}
+		return res, nil
+	}
+	if acceptIndex {
+		res, _, err := mh.modifyIndex(ctx, fetcher, desc, index, true)
+		return res, err
+	}
 
-			// optimization: we store the config in the store just in case the client attempts to download the config blob
-			// 				 from us. If they download it from a registry facade from which the manifest hasn't been downloaded
-			//               we'll re-create the config on the fly.
-			if w, err := mh.Store.Writer(ctx, content.WithRef(ref), content.WithDescriptor(desc)); err == nil {
-				defer w.Close()
+	md, err := selectManifest(index.Manifests, mh.Platform)
+	if err != nil {
+		return nil, distv2.ErrorCodeManifestUnknown.WithDetail(err)
+	}
+	return mh.modifyManifest(ctx, fetcher, *md)
+}
 
-				_, err = w.Write(rawCfg)
-				if err != nil {
-					log.WithError(err).WithFields(logFields).Warn("cannot write config to store - we'll regenerate it on demand")
-				}
-				err = w.Commit(ctx, 0, cfgDgst)
-				if err != nil {
-					log.WithError(err).WithFields(logFields).Warn("cannot commit config to store - we'll regenerate it on demand")
-				}
+// modifyIndex modifies all manifests of an image index and produces the index which refers to them. If one of the
+// manifests has the digest the client requested, modifyIndex returns that manifest instead and match is true.
+func (mh *manifestHandler) modifyIndex(ctx context.Context, fetcher remotes.Fetcher, desc ociv1.Descriptor, index *ociv1.Index, rewriteEStargz bool) (res *computedManifest, match bool, err error) {
+	var (
+		idx   = *index
+		final = true
+	)
+	idx.Manifests = make([]ociv1.Descriptor, 0, len(index.Manifests))
+	for _, md := range index.Manifests {
+		if !isManifestMediaType(md.MediaType) {
+			// we don't support nested indexes
+			continue
+		}
+
+		m, err := mh.computeManifest(ctx, fetcher, md, rewriteEStargz)
+		if err != nil {
+			return nil, false, err
+		}
+		mdgst := digest.FromBytes(m.Manifest)
+		if mh.Digest != "" && mdgst == mh.Digest {
+			// a client picked this manifest from the index we served earlier
+			return m, true, nil
+		}
+
+		idx.Manifests = append(idx.Manifests, ociv1.Descriptor{
+			MediaType:   m.MediaType,
+			Digest:      mdgst,
+			Size:        int64(len(m.Manifest)),
+			Platform:    md.Platform,
+			Annotations: md.Annotations,
+		})
+		final = final && m.Final
+	}
+
+	p, err := marshalWithMediaType(idx, desc.MediaType)
+	if err != nil {
+		return nil, false, err
+	}
+	return &computedManifest{Manifest: p, MediaType: desc.MediaType, Final: final}, false, nil
+}
+
+// modifyManifest downloads the manifest desc points to and adds our layers to it
+func (mh *manifestHandler) modifyManifest(ctx context.Context, fetcher remotes.Fetcher, desc ociv1.Descriptor) (res *computedManifest, err error) {
+	return mh.computeManifest(ctx, fetcher, desc, true)
+}
+
+// computeManifest downloads the manifest desc points to and adds our layers to it. Unless rewriteEStargz is true,
+// the manifest refers to the original layers, even if their eStargz conversion is done.
+func (mh *manifestHandler) computeManifest(ctx context.Context, fetcher remotes.Fetcher, desc ociv1.Descriptor, rewriteEStargz bool) (res *computedManifest, err error) {
+	logFields := log.OWI("", "", mh.Name)
+	ref := mh.Spec.BaseRef
+
+	key, err := computedManifestKey(mh.Spec, desc.Digest, mh.Revision)
+	if err != nil {
+		return nil, err
+	}
+	// the cache holds the manifests we serve by default only
+	cache := mh.Cache
+	if !rewriteEStargz && mh.EStargz != nil {
+		cache = nil
+	}
+	if cache != nil {
+		if res, ok := cache.Computed(key); ok {
+			return res, nil
+		}
+	}
+
+	manifest, ndesc, err := DownloadManifest(ctx, fetcher, desc, WithStore(mh.Store), WithPlatform(mh.Platform))
+	if err != nil {
+		return nil, distv2.ErrorCodeManifestUnknown.WithDetail(err)
+	}
+	desc = *ndesc
+
+	res = &computedManifest{MediaType: desc.MediaType, Final: true}
+
+	switch desc.MediaType {
+	case images.MediaTypeDockerSchema2Manifest, ociv1.MediaTypeImageManifest:
+		// download config
+		cfg, err := DownloadConfig(ctx, fetcher, manifest.Config)
+		if err != nil {
+			return nil, err
+		}
+
+		// modify config
+		addonLayer, err := mh.ConfigModifier(ctx, mh.Spec, cfg)
+		if err != nil {
+			return nil, err
+		}
+		baseLayers := manifest.Layers
+		manifest.Layers = append(manifest.Layers, addonLayer...)
+
+		// Once all layers are converted we serve their eStargz version, which enables lazy-pulling.
+		// Until then clients get the original layers.
+		if mh.EStargz != nil && !rewriteEStargz {
+			res.Final = false
+		} else if mh.EStargz != nil {
+			layers, ok, final := mh.EStargz.Rewrite(manifest.Layers, cfg)
+			if ok {
+				manifest.Layers = layers
+			}
+			if !final {
+				// the manifest changes once the conversion is done or a failed conversion is retried,
+				// hence we must not cache it
+				res.Final = false
+				upstream := newUpstreamBlobSource(fetcher, baseLayers, mh.BlobCache, mh.BlobPeers, mh.Metrics)
+				mh.EStargz.Convert(manifest.Layers, blobSourceOpener(mh.Spec, upstream, mh.LayerSource))
 			}
+		}
 
-			// update config digest in manifest
-			manifest.Config.Digest = cfgDgst
-			manifest.Config.URLs = nil
-			manifest.Config.Size = int64(len(rawCfg))
-
-			// When serving images.MediaTypeDockerSchema2Manifest we have to set the mediaType in the manifest itself.
-			// Although somewhat compatible with the OCI manifest spec (see https://github.com/opencontainers/image-spec/blob/master/manifest.md),
-			// this field is not part of the OCI Go structs. In this particular case, we'll go ahead and add it ourselves.
-			//
-			// fixes https://github.com/gitpod-io/gitpod/pull/3397
-			if desc.MediaType == images.MediaTypeDockerSchema2Manifest {
-				type ManifestWithMediaType struct {
-					ociv1.Manifest
-					MediaType string `json:"mediaType"`
-				}
-				p, _ = json.Marshal(ManifestWithMediaType{
-					Manifest:  *manifest,
-					MediaType: images.MediaTypeDockerSchema2Manifest,
-				})
-			} else {
-				p, _ = json.Marshal(manifest)
+		// place config in store
+		rawCfg, err := json.Marshal(cfg)
+		if err != nil {
+			return nil, err
+		}
+		cfgDgst := digest.FromBytes(rawCfg)
+
+		// optimization: we store the config in the store just in case the client attempts to download the config blob
+		// 				 from us. If they download it from a registry facade from which the manifest hasn't been downloaded
+		//               we'll re-create the config on the fly.
+		if w, err := mh.Store.Writer(ctx, content.WithRef(ref), content.WithDescriptor(desc)); err == nil {
+			defer w.Close()
+
+			_, err = w.Write(rawCfg)
+			if err != nil {
+				log.WithError(err).WithFields(logFields).Warn("cannot write config to store - we'll regenerate it on demand")
+			}
+			err = w.Commit(ctx, 0, cfgDgst)
+			if err != nil {
+				log.WithError(err).WithFields(logFields).Warn("cannot commit config to store - we'll regenerate it on demand")
 			}
 		}
 
-		dgst := digest.FromBytes(p).String()
+		// update config digest in manifest
+		manifest.Config.Digest = cfgDgst
+		manifest.Config.URLs = nil
+		manifest.Config.Size = int64(len(rawCfg))
 
-		w.Header().Set("Content-Type", desc.MediaType)
-		w.Header().Set("Content-Length", fmt.Sprint(len(p)))
-		w.Header().Set("Etag", fmt.Sprintf(`"%s"`, dgst))
-		w.Header().Set("Docker-Content-Digest", dgst)
-		_, _ = w.Write(p)
+		res.Manifest, err = marshalWithMediaType(manifest, desc.MediaType)
+		if err != nil {
+			return nil, err
+		}
+		res.Config = rawCfg
+	}
 
-		log.WithFields(logFields).Debug("get manifest (end)")
-		return nil
-	}()
+	if cache != nil {
+		cache.PutComputed(key, res)
+	}
+	return res, nil
+}
 
-	if err != nil {
-		log.WithError(err).WithField("spec", mh.Spec).Error("cannot get manifest")
-		respondWithError(w, err)
+// marshalWithMediaType marshals a manifest or image index. When serving Docker manifests or manifest lists we have
+// to set the mediaType in the manifest itself. Although somewhat compatible with the OCI manifest spec
+// (see https://github.com/opencontainers/image-spec/blob/master/manifest.md), this field is not part of the OCI
+// Go structs. In this particular case, we'll go ahead and add it ourselves.
+//
+// fixes https://github.com/gitpod-io/gitpod/pull/3397
+func marshalWithMediaType(v interface{}, mediaType string) ([]byte, error) {
+	switch v := v.(type) {
+	case *ociv1.Manifest:
+		if mediaType == images.MediaTypeDockerSchema2Manifest {
+			type ManifestWithMediaType struct {
+				ociv1.Manifest
+				MediaType string `json:"mediaType"`
+			}
+			return json.Marshal(ManifestWithMediaType{
+				Manifest:  *v,
+				MediaType: mediaType,
+			})
+		}
+	case ociv1.Index:
+		if mediaType == images.MediaTypeDockerSchema2ManifestList {
+			type IndexWithMediaType struct {
+				ociv1.Index
+				MediaType string `json:"mediaType"`
+			}
+			return json.Marshal(IndexWithMediaType{
+				Index:     v,
+				MediaType: mediaType,
+			})
+		}
 	}
-	tracing.FinishSpan(span, &err)
+	return json.Marshal(v)
 }
 
 // DownloadConfig downloads and unmarshales OCIv2 image config, referred to by an OCI descriptor.
@@ -265,7 +499,8 @@ func DownloadConfig(ctx context.Context, fetcher remotes.Fetcher, desc ociv1.Des
 }
 
 type manifestDownloadOptions struct {
-	Store content.Store
+	Store    content.Store
+	Platform *ociv1.Platform
 }
 
 // ManifestDownloadOption alters the default manifest download behaviour
@@ -278,80 +513,83 @@ func WithStore(store content.Store) ManifestDownloadOption {
 	}
 }
 
-// DownloadManifest downloads and unmarshals the manifest of the given desc. If the desc points to manifest list
-// we choose the first manifest in that list.
-func DownloadManifest(ctx context.Context, fetcher remotes.Fetcher, desc ociv1.Descriptor, options ...ManifestDownloadOption) (cfg *ociv1.Manifest, rdesc *ociv1.Descriptor, err error) {
+// WithPlatform chooses the manifest of a platform when downloading an image index
+func WithPlatform(platform ociv1.Platform) ManifestDownloadOption {
+	return func(o *manifestDownloadOptions) {
+		o.Platform = &platform
+	}
+}
+
+func isManifestMediaType(mediaType string) bool {
+	return mediaType == images.MediaTypeDockerSchema2Manifest || mediaType == ociv1.MediaTypeImageManifest
+}
+
+func isIndexMediaType(mediaType string) bool {
+	return mediaType == images.MediaTypeDockerSchema2ManifestList || mediaType == ociv1.MediaTypeImageIndex
+}
+
+// DownloadIndex downloads and unmarshals the image index of the given desc. If desc does not point to an
+// image index, DownloadIndex returns nil.
+func DownloadIndex(ctx context.Context, fetcher remotes.Fetcher, desc ociv1.Descriptor, options ...ManifestDownloadOption) (*ociv1.Index, error) {
+	if !isIndexMediaType(desc.MediaType) {
+		return nil, nil
+	}
+
 	var opts manifestDownloadOptions
 	for _, o := range options {
 		o(&opts)
 	}
 
-	var (
-		rc           io.ReadCloser
-		placeInStore bool
-	)
-	if opts.Store != nil {
-		r, err := opts.Store.ReaderAt(ctx, desc)
-		if errors.Cause(err) == errdefs.ErrNotFound {
-			// not in store yet
-		} else if err != nil {
-			log.WithError(err).WithField("desc", desc).Warn("cannot get manifest from store")
-		} else {
-			rc = &reader{ReaderAt: r}
-		}
+	inpt, err := fetchManifest(ctx, fetcher, desc, opts.Store)
+	if err != nil {
+		return nil, err
 	}
-	if rc == nil {
-		placeInStore = true
-		rc, err = fetcher.Fetch(ctx, desc)
-		if err != nil {
-			err = xerrors.Errorf("cannot download manifest: %w", err)
-			return
-		}
+	var res ociv1.Index
+	err = json.Unmarshal(inpt, &res)
+	if err != nil {
+		return nil, xerrors.Errorf("cannot unmarshal index: %w", err)
 	}
+	return &res, nil
+}
 
-	inpt, err := io.ReadAll(rc)
-	rc.Close()
+// DownloadManifest downloads and unmarshals the manifest of the given desc. If the desc points to an image index
+// we choose the manifest of the platform set using WithPlatform, or the platform we're running on.
+func DownloadManifest(ctx context.Context, fetcher remotes.Fetcher, desc ociv1.Descriptor, options ...ManifestDownloadOption) (cfg *ociv1.Manifest, rdesc *ociv1.Descriptor, err error) {
+	var opts manifestDownloadOptions
+	for _, o := range options {
+		o(&opts)
+	}
+
+	inpt, err := fetchManifest(ctx, fetcher, desc, opts.Store)
 	if err != nil {
-		err = xerrors.Errorf("cannot download manifest: %w", err)
 		return
 	}
 
 	rdesc = &desc
 
-	switch rdesc.MediaType {
-	case images.MediaTypeDockerSchema2ManifestList, ociv1.MediaTypeImageIndex:
-		// we received a manifest list which means we'll pick the default platform
-		// and fetch that manifest
+	if isIndexMediaType(rdesc.MediaType) {
 		var list ociv1.Index
 		err = json.Unmarshal(inpt, &list)
 		if err != nil {
 			err = xerrors.Errorf("cannot unmarshal index: %w", err)
 			return
 		}
-		if len(list.Manifests) == 0 {
-			err = xerrors.Errorf("empty manifest")
-			return
-		}
 
-		// TODO: choose by platform, not just the first manifest
-		md := list.Manifests[0]
-		rc, err = fetcher.Fetch(ctx, md)
+		platform := platforms.DefaultSpec()
+		if opts.Platform != nil {
+			platform = *opts.Platform
+		}
+		rdesc, err = selectManifest(list.Manifests, platform)
 		if err != nil {
-			err = xerrors.Errorf("cannot download config: %w", err)
 			return
 		}
-		rdesc = &md
-		inpt, err = io.ReadAll(rc)
-		rc.Close()
+		inpt, err = fetchManifest(ctx, fetcher, *rdesc, opts.Store)
 		if err != nil {
-			err = xerrors.Errorf("cannot download manifest: %w", err)
 			return
 		}
 	}
 
-	switch rdesc.MediaType {
-	case images.MediaTypeDockerSchema2Manifest, ociv1.MediaTypeImageManifest:
-	default:
+	if !isManifestMediaType(rdesc.MediaType) {
 		err = xerrors.Errorf("unsupported media type")
 		return
 	}
@@ -363,29 +601,89 @@ func DownloadManifest(ctx context.Context, fetcher remotes.Fetcher, desc ociv1.D
 		return
 	}
 
-	if opts.Store != nil && placeInStore {
-		// We're cheating here and store the actual image manifest under the desc of what's
-		// possibly an image index. This way we don't have to resolve the image index the next
-		// time one wishes to resolve desc.
-		w, err := opts.Store.Writer(ctx, content.WithDescriptor(desc), content.WithRef(desc.Digest.String()))
+	cfg = &res
+	return
+}
+
+// selectManifest chooses the manifest which best matches a platform from the manifests of an image index.
+// Manifests which don't state their platform match any platform, but we prefer those that do.
+func selectManifest(manifests []ociv1.Descriptor, platform ociv1.Platform) (*ociv1.Descriptor, error) {
+	var (
+		matcher = platforms.Only(platform)
+		res     *ociv1.Descriptor
+	)
+	for i := range manifests {
+		m := manifests[i]
+		if !isManifestMediaType(m.MediaType) {
+			continue
+		}
+		if m.Platform == nil {
+			if res == nil {
+				res = &m
+			}
+			continue
+		}
+		if !matcher.Match(*m.Platform) {
+			continue
+		}
+		if res == nil || res.Platform == nil || matcher.Less(*m.Platform, *res.Platform) {
+			res = &m
+		}
+	}
+	if res == nil {
+		return nil, xerrors.Errorf("image has no manifest for platform %s", platforms.Format(platform))
+	}
+	return res, nil
+}
+
+// fetchManifest reads a manifest or image index from the store. If it's not in the store yet, we download it
+// and place it in the store.
+func fetchManifest(ctx context.Context, fetcher remotes.Fetcher, desc ociv1.Descriptor, store content.Store) ([]byte, error) {
+	if store != nil {
+		r, err := store.ReaderAt(ctx, desc)
+		if errors.Cause(err) == errdefs.ErrNotFound {
+			// not in store yet
+		} else if err != nil {
+			log.WithError(err).WithField("desc", desc).Warn("cannot get manifest from store")
+		} else {
+			defer r.Close()
+			inpt, err := io.ReadAll(&reader{ReaderAt: r})
+			if err != nil {
+				return nil, xerrors.Errorf("cannot read manifest from store: %w", err)
+			}
+			return inpt, nil
+		}
+	}
+
+	rc, err := fetcher.Fetch(ctx, desc)
+	if err != nil {
+		return nil, xerrors.Errorf("cannot download manifest: %w", err)
+	}
+	inpt, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		return nil, xerrors.Errorf("cannot download manifest: %w", err)
+	}
+
+	if store != nil {
+		w, err := store.Writer(ctx, content.WithDescriptor(desc), content.WithRef(desc.Digest.String()))
 		if err != nil {
-			log.WithError(err).WithField("desc", *rdesc).Warn("cannot store manifest")
+			log.WithError(err).WithField("desc", desc).Warn("cannot store manifest")
 		} else {
 			_, err = io.Copy(w, bytes.NewReader(inpt))
 			if err != nil {
-				log.WithError(err).WithField("desc", *rdesc).Warn("cannot store manifest")
+				log.WithError(err).WithField("desc", desc).Warn("cannot store manifest")
 			}
 
 			err = w.Commit(ctx, 0, digest.FromBytes(inpt))
 			if err != nil {
-				log.WithError(err).WithField("desc", *rdesc).Warn("cannot store manifest")
+				log.WithError(err).WithField("desc", desc).Warn("cannot store manifest")
 			}
 			w.Close()
 		}
 	}
 
-	cfg = &res
-	return
+	return inpt, nil
 }
 
 func (mh *manifestHandler) putManifest(w http.ResponseWriter, r *http.Request) {
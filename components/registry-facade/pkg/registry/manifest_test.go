@@ -0,0 +1,218 @@
+// Copyright (c) 2021 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package registry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/containerd/containerd/content/local"
+	"github.com/containerd/containerd/errdefs"
+	"github.com/containerd/containerd/remotes"
+	"github.com/opencontainers/go-digest"
+	ociv1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"golang.org/x/xerrors"
+
+	"github.com/gitpod-io/gitpod/registry-facade/api"
+)
+
+// fakeRegistry serves images from memory
+type fakeRegistry struct {
+	Refs  map[string]ociv1.Descriptor
+	Blobs map[digest.Digest][]byte
+}
+
+func (r *fakeRegistry) add(t *testing.T, mediaType string, v interface{}) ociv1.Descriptor {
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dgst := digest.FromBytes(b)
+	r.Blobs[dgst] = b
+	return ociv1.Descriptor{MediaType: mediaType, Digest: dgst, Size: int64(len(b))}
+}
+
+func (r *fakeRegistry) Resolve(ctx context.Context, ref string) (name string, desc ociv1.Descriptor, err error) {
+	desc, ok := r.Refs[ref]
+	if !ok {
+		return "", desc, errdefs.ErrNotFound
+	}
+	return ref, desc, nil
+}
+
+func (r *fakeRegistry) Fetcher(ctx context.Context, ref string) (remotes.Fetcher, error) {
+	return r, nil
+}
+
+func (r *fakeRegistry) Pusher(ctx context.Context, ref string) (remotes.Pusher, error) {
+	return nil, xerrors.Errorf("not supported")
+}
+
+func (r *fakeRegistry) Fetch(ctx context.Context, desc ociv1.Descriptor) (io.ReadCloser, error) {
+	b, ok := r.Blobs[desc.Digest]
+	if !ok {
+		return nil, errdefs.ErrNotFound
+	}
+	return io.NopCloser(bytes.NewReader(b)), nil
+}
+
+func TestGetManifestOfIndex(t *testing.T) {
+	var (
+		reg   = &fakeRegistry{Refs: make(map[string]ociv1.Descriptor), Blobs: make(map[digest.Digest][]byte)}
+		addon = ociv1.Descriptor{MediaType: ociv1.MediaTypeImageLayer, Digest: digest.FromString("addon"), Size: 5}
+		index ociv1.Index
+	)
+	for _, arch := range []string{"amd64", "arm64"} {
+		layer := ociv1.Descriptor{MediaType: ociv1.MediaTypeImageLayerGzip, Digest: digest.FromString(arch + " layer"), Size: 10}
+		cfg := reg.add(t, ociv1.MediaTypeImageConfig, ociv1.Image{
+			Architecture: arch,
+			OS:           "linux",
+			RootFS:       ociv1.RootFS{Type: "layers", DiffIDs: []digest.Digest{digest.FromString(arch + " diffID")}},
+		})
+		mf := reg.add(t, ociv1.MediaTypeImageManifest, ociv1.Manifest{Config: cfg, Layers: []ociv1.Descriptor{layer}})
+		mf.Platform = &ociv1.Platform{OS: "linux", Architecture: arch}
+		index.Manifests = append(index.Manifests, mf)
+	}
+	reg.Refs["base:latest"] = reg.add(t, ociv1.MediaTypeImageIndex, index)
+
+	store, err := local.NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	newHandler := func(dgst digest.Digest) *manifestHandler {
+		return &manifestHandler{
+			Spec:     &api.ImageSpec{BaseRef: "base:latest"},
+			Resolver: reg,
+			Store:    store,
+			ConfigModifier: func(ctx context.Context, spec *api.ImageSpec, cfg *ociv1.Image) ([]ociv1.Descriptor, error) {
+				cfg.RootFS.DiffIDs = append(cfg.RootFS.DiffIDs, addon.Digest)
+				return []ociv1.Descriptor{addon}, nil
+			},
+			Platform: ociv1.Platform{OS: "linux", Architecture: "arm64"},
+			Digest:   dgst,
+		}
+	}
+	serve := func(dgst digest.Digest, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/v2/foo/manifests/latest", nil)
+		req.Header.Set("Accept", accept)
+		rec := httptest.NewRecorder()
+		newHandler(dgst).getManifest(rec, req)
+		return rec
+	}
+	get := func(dgst digest.Digest, accept string) (mediaType string, body []byte) {
+		rec := serve(dgst, accept)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("unexpected status %d: %s", rec.Code, rec.Body.String())
+		}
+		if act := digest.FromBytes(rec.Body.Bytes()).String(); act != rec.Header().Get("Docker-Content-Digest") {
+			t.Errorf("manifest digest %s does not match header %s", act, rec.Header().Get("Docker-Content-Digest"))
+		}
+		return rec.Header().Get("Content-Type"), rec.Body.Bytes()
+	}
+	checkManifest := func(body []byte, arch string) {
+		var mf ociv1.Manifest
+		err := json.Unmarshal(body, &mf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(mf.Layers) != 2 || mf.Layers[0].Digest != digest.FromString(arch+" layer") || mf.Layers[1].Digest != addon.Digest {
+			t.Errorf("expected %s manifest with addon layer, got %v", arch, mf.Layers)
+		}
+	}
+
+	// clients which don't accept an index get the manifest of the default platform
+	mediaType, body := get("", ociv1.MediaTypeImageManifest)
+	if mediaType != ociv1.MediaTypeImageManifest {
+		t.Errorf("unexpected media type %s", mediaType)
+	}
+	checkManifest(body, "arm64")
+
+	// clients which accept an index pick their manifest from it
+	mediaType, body = get("", ociv1.MediaTypeImageIndex+", "+ociv1.MediaTypeImageManifest)
+	if mediaType != ociv1.MediaTypeImageIndex {
+		t.Fatalf("unexpected media type %s", mediaType)
+	}
+	indexDigest := digest.FromBytes(body)
+	var idx ociv1.Index
+	err = json.Unmarshal(body, &idx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(idx.Manifests) != 2 {
+		t.Fatalf("expected two manifests in index, got %d", len(idx.Manifests))
+	}
+	for _, md := range idx.Manifests {
+		if md.Platform == nil {
+			t.Fatalf("manifest %s lost its platform", md.Digest)
+		}
+		_, body = get(md.Digest, ociv1.MediaTypeImageManifest)
+		if act := digest.FromBytes(body); act != md.Digest {
+			t.Errorf("requested manifest %s, got %s", md.Digest, act)
+		}
+		checkManifest(body, md.Platform.Architecture)
+	}
+
+	// the index itself can be requested by digest, too
+	_, body = get(indexDigest, ociv1.MediaTypeImageIndex)
+	if act := digest.FromBytes(body); act != indexDigest {
+		t.Errorf("requested index %s, got %s", indexDigest, act)
+	}
+
+	// digests which denote neither the index nor one of its manifests are unknown, instead of falling back to the default platform
+	for _, accept := range []string{ociv1.MediaTypeImageManifest, ociv1.MediaTypeImageIndex} {
+		if rec := serve(digest.FromString("unknown"), accept); rec.Code != http.StatusNotFound {
+			t.Errorf("expected unknown manifest digest to be not found with Accept %s, got status %d", accept, rec.Code)
+		}
+	}
+}
+
+func TestSelectManifest(t *testing.T) {
+	var (
+		amd64      = ociv1.Descriptor{MediaType: ociv1.MediaTypeImageManifest, Digest: digest.FromString("amd64"), Platform: &ociv1.Platform{OS: "linux", Architecture: "amd64"}}
+		arm64      = ociv1.Descriptor{MediaType: ociv1.MediaTypeImageManifest, Digest: digest.FromString("arm64"), Platform: &ociv1.Platform{OS: "linux", Architecture: "arm64", Variant: "v8"}}
+		armv7      = ociv1.Descriptor{MediaType: ociv1.MediaTypeImageManifest, Digest: digest.FromString("armv7"), Platform: &ociv1.Platform{OS: "linux", Architecture: "arm", Variant: "v7"}}
+		noPlatform = ociv1.Descriptor{MediaType: ociv1.MediaTypeImageManifest, Digest: digest.FromString("any")}
+		nested     = ociv1.Descriptor{MediaType: ociv1.MediaTypeImageIndex, Digest: digest.FromString("nested"), Platform: &ociv1.Platform{OS: "linux", Architecture: "amd64"}}
+		windows    = ociv1.Descriptor{MediaType: ociv1.MediaTypeImageManifest, Digest: digest.FromString("windows"), Platform: &ociv1.Platform{OS: "windows", Architecture: "amd64"}}
+	)
+
+	tests := []struct {
+		Name        string
+		Manifests   []ociv1.Descriptor
+		Platform    ociv1.Platform
+		Expectation digest.Digest
+	}{
+		{Name: "exact match", Manifests: []ociv1.Descriptor{amd64, arm64}, Platform: ociv1.Platform{OS: "linux", Architecture: "amd64"}, Expectation: amd64.Digest},
+		{Name: "variant", Manifests: []ociv1.Descriptor{amd64, armv7, arm64}, Platform: ociv1.Platform{OS: "linux", Architecture: "arm64"}, Expectation: arm64.Digest},
+		{Name: "prefers platform over none", Manifests: []ociv1.Descriptor{noPlatform, amd64}, Platform: ociv1.Platform{OS: "linux", Architecture: "amd64"}, Expectation: amd64.Digest},
+		{Name: "falls back to none", Manifests: []ociv1.Descriptor{arm64, noPlatform}, Platform: ociv1.Platform{OS: "linux", Architecture: "amd64"}, Expectation: noPlatform.Digest},
+		{Name: "ignores nested indexes", Manifests: []ociv1.Descriptor{nested, amd64}, Platform: ociv1.Platform{OS: "linux", Architecture: "amd64"}, Expectation: amd64.Digest},
+		{Name: "no match", Manifests: []ociv1.Descriptor{windows, arm64}, Platform: ociv1.Platform{OS: "linux", Architecture: "amd64"}},
+		{Name: "empty index", Platform: ociv1.Platform{OS: "linux", Architecture: "amd64"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			act, err := selectManifest(test.Manifests, test.Platform)
+			if test.Expectation == "" {
+				if err == nil {
+					t.Fatalf("expected an error, got %s", act.Digest)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if act.Digest != test.Expectation {
+				t.Errorf("expected %s, got %s", test.Expectation, act.Digest)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright (c) 2021 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package registry
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/containerd/containerd/remotes"
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/opencontainers/go-digest"
+	ociv1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"golang.org/x/xerrors"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/gitpod-io/gitpod/registry-facade/api"
+)
+
+const (
+	// manifestCacheSize is the number of entries we keep in each of the manifest caches
+	manifestCacheSize = 1024
+)
+
+// manifestCache caches what we compute for workspace images so that we don't have to ask for their spec,
+// download their base image manifest and config, and add our layers whenever they're pulled.
+type manifestCache struct {
+	// computed contains the manifests we've added our layers to, keyed by everything that goes into computing them
+	computed *lru.Cache
+	// responses contains the manifests and indexes we served, keyed by what goes into computing them and the request variant
+	responses *lru.Cache
+	// bases pins the base image of a workspace image to the descriptor it resolved to first
+	bases *lru.Cache
+}
+
+// computedManifest is a manifest we've added our layers to, together with its config
+type computedManifest struct {
+	Manifest  []byte
+	MediaType string
+	Config    []byte
+
+	// Final is false if the manifest is going to change, e.g. because its layers are still being converted.
+	// We don't cache manifests which aren't final.
+	Final bool
+}
+
+// cachedResponse is a manifest or image index we served for a workspace image
+type cachedResponse struct {
+	Content   []byte
+	MediaType string
+}
+
+func newManifestCache() (*manifestCache, error) {
+	computed, err := lru.New(manifestCacheSize)
+	if err != nil {
+		return nil, err
+	}
+	responses, err := lru.New(manifestCacheSize)
+	if err != nil {
+		return nil, err
+	}
+	bases, err := lru.New(manifestCacheSize)
+	if err != nil {
+		return nil, err
+	}
+	return &manifestCache{
+		computed:  computed,
+		responses: responses,
+		bases:     bases,
+	}, nil
+}
+
+// computedManifestKey identifies a computed manifest by everything that goes into computing it: the image spec,
+// the digest of the base image manifest and the registry revision, which covers the static layer and eStargz evictions.
+func computedManifestKey(spec *api.ImageSpec, base digest.Digest, revision int) (digest.Digest, error) {
+	rspec, err := proto.MarshalOptions{Deterministic: true}.Marshal(spec)
+	if err != nil {
+		return "", xerrors.Errorf("cannot marshal image spec: %w", err)
+	}
+
+	h := digest.Canonical.Digester()
+	_, _ = h.Hash().Write(rspec)
+	_, _ = fmt.Fprintf(h.Hash(), "\n%s\n%d", base, revision)
+	return h.Digest(), nil
+}
+
+// manifestResponseKey identifies a manifest or index we served by the computed manifest key (spec hash, base digest
+// and static layer revision), and the variant of the request: the digest the client asked for and the media types it accepts.
+// Once the static layer changes, the revision and hence the key changes and outdated responses age out of the cache.
+func manifestResponseKey(spec *api.ImageSpec, base digest.Digest, revision int, reqDigest digest.Digest, acceptManifest, acceptIndex bool) (digest.Digest, error) {
+	key, err := computedManifestKey(spec, base, revision)
+	if err != nil {
+		return "", err
+	}
+	return digest.FromString(fmt.Sprintf("%s\n%s\n%v\n%v", key, reqDigest, acceptManifest, acceptIndex)), nil
+}
+
+// Computed returns a computed manifest if it's in the cache
+func (c *manifestCache) Computed(key digest.Digest) (*computedManifest, bool) {
+	res, ok := c.computed.Get(key)
+	if !ok {
+		return nil, false
+	}
+	return res.(*computedManifest), true
+}
+
+// PutComputed adds a computed manifest to the cache unless it's going to change
+func (c *manifestCache) PutComputed(key digest.Digest, m *computedManifest) {
+	if !m.Final {
+		return
+	}
+	c.computed.Add(key, m)
+}
+
+// Response returns a manifest or index we served before if it's in the cache
+func (c *manifestCache) Response(key digest.Digest) (*cachedResponse, bool) {
+	res, ok := c.responses.Get(key)
+	if !ok {
+		return nil, false
+	}
+	return res.(*cachedResponse), true
+}
+
+// PutResponse adds a manifest we served to the cache
+func (c *manifestCache) PutResponse(key digest.Digest, resp *cachedResponse) {
+	c.responses.Add(key, resp)
+}
+
+// resolveBase resolves the base image of a workspace image. We pin the base image to the descriptor it resolved to
+// first, so that a workspace image does not change while it's being pulled, even if the base image tag moves.
+func resolveBase(ctx context.Context, resolver remotes.Resolver, cache *manifestCache, name, ref string) (ociv1.Descriptor, error) {
+	key := name + "@" + ref
+	if cache != nil {
+		if desc, ok := cache.bases.Get(key); ok {
+			return desc.(ociv1.Descriptor), nil
+		}
+	}
+
+	_, desc, err := resolver.Resolve(ctx, ref)
+	if err != nil {
+		return desc, err
+	}
+	if cache != nil {
+		cache.bases.Add(key, desc)
+	}
+	return desc, nil
+}
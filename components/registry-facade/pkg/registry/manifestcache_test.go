@@ -0,0 +1,162 @@
+// Copyright (c) 2021 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package registry
+
+import (
+	"context"
+	"testing"
+
+	"github.com/containerd/containerd/content/local"
+	"github.com/opencontainers/go-digest"
+	ociv1 "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/gitpod-io/gitpod/registry-facade/api"
+)
+
+func newTestManifestCache(t *testing.T) *manifestCache {
+	c, err := newManifestCache()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestComputedManifestKey(t *testing.T) {
+	var (
+		spec  = &api.ImageSpec{BaseRef: "base:latest", ContentLayer: []*api.ContentLayer{{Spec: &api.ContentLayer_Remote{Remote: &api.RemoteContentLayer{Url: "https://example.com/layer", Digest: "sha256:abc"}}}}}
+		other = &api.ImageSpec{BaseRef: "base:other"}
+		base  = digest.FromString("base")
+	)
+	key := func(spec *api.ImageSpec, base digest.Digest, revision int) digest.Digest {
+		k, err := computedManifestKey(spec, base, revision)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return k
+	}
+
+	ref := key(spec, base, 1)
+	if act := key(spec, base, 1); act != ref {
+		t.Errorf("key is not stable: %s != %s", act, ref)
+	}
+	if key(other, base, 1) == ref {
+		t.Error("key does not depend on the spec")
+	}
+	if key(spec, digest.FromString("other"), 1) == ref {
+		t.Error("key does not depend on the base digest")
+	}
+	if key(spec, base, 2) == ref {
+		t.Error("key does not depend on the static layer revision")
+	}
+}
+
+func TestManifestResponseKey(t *testing.T) {
+	var (
+		spec = &api.ImageSpec{BaseRef: "base:latest"}
+		base = digest.FromString("base")
+	)
+	key := func(spec *api.ImageSpec, base digest.Digest, revision int, reqDigest digest.Digest, acceptManifest, acceptIndex bool) digest.Digest {
+		k, err := manifestResponseKey(spec, base, revision, reqDigest, acceptManifest, acceptIndex)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return k
+	}
+
+	ref := key(spec, base, 1, "", true, false)
+	if act := key(&api.ImageSpec{BaseRef: "base:latest"}, base, 1, "", true, false); act != ref {
+		t.Errorf("key is not stable: %s != %s", act, ref)
+	}
+	for desc, act := range map[string]digest.Digest{
+		"spec":                    key(&api.ImageSpec{BaseRef: "base:other"}, base, 1, "", true, false),
+		"base digest":             key(spec, digest.FromString("other"), 1, "", true, false),
+		"static layer revision":   key(spec, base, 2, "", true, false),
+		"requested digest":        key(spec, base, 1, digest.FromString("manifest"), true, false),
+		"accepted manifest types": key(spec, base, 1, "", true, true),
+	} {
+		if act == ref {
+			t.Errorf("key does not depend on the %s", desc)
+		}
+	}
+
+	c := newTestManifestCache(t)
+	c.PutResponse(ref, &cachedResponse{Content: []byte("{}"), MediaType: ociv1.MediaTypeImageManifest})
+	if _, ok := c.Response(ref); !ok {
+		t.Fatal("response is not cached")
+	}
+	if _, ok := c.Response(key(spec, base, 2, "", true, false)); ok {
+		t.Error("served response of an outdated static layer revision")
+	}
+
+	c.PutComputed("pending", &computedManifest{Manifest: []byte("{}")})
+	if _, ok := c.Computed("pending"); ok {
+		t.Error("cached a manifest which isn't final")
+	}
+}
+
+func TestModifyManifestFromCache(t *testing.T) {
+	var (
+		reg   = &fakeRegistry{Refs: make(map[string]ociv1.Descriptor), Blobs: make(map[digest.Digest][]byte)}
+		cfg   = reg.add(t, ociv1.MediaTypeImageConfig, ociv1.Image{OS: "linux", Architecture: "amd64"})
+		mf    = reg.add(t, ociv1.MediaTypeImageManifest, ociv1.Manifest{Config: cfg})
+		calls int
+	)
+	reg.Refs["base:latest"] = mf
+
+	store, err := local.NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	mh := &manifestHandler{
+		Spec:     &api.ImageSpec{BaseRef: "base:latest"},
+		Resolver: reg,
+		Store:    store,
+		ConfigModifier: func(ctx context.Context, spec *api.ImageSpec, cfg *ociv1.Image) ([]ociv1.Descriptor, error) {
+			calls++
+			return nil, nil
+		},
+		Cache: newTestManifestCache(t),
+	}
+
+	ctx := context.Background()
+	desc, err := resolveBase(ctx, reg, mh.Cache, "foo", "base:latest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := mh.modifyManifest(ctx, reg, desc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the base image tag moves, but the workspace image stays pinned to the base image it was computed from
+	reg.Refs["base:latest"] = reg.add(t, ociv1.MediaTypeImageManifest, ociv1.Manifest{Config: cfg, Annotations: map[string]string{"moved": "true"}})
+	desc, err = resolveBase(ctx, reg, mh.Cache, "foo", "base:latest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if desc.Digest != mf.Digest {
+		t.Errorf("base image was not pinned: %s", desc.Digest)
+	}
+	second, err := mh.modifyManifest(ctx, reg, desc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 1 {
+		t.Errorf("recomputed the manifest %d times", calls)
+	}
+	if string(first.Manifest) != string(second.Manifest) || string(first.Config) != string(second.Config) {
+		t.Error("cached manifest differs from the computed one")
+	}
+
+	// the static layer changed
+	mh.Revision++
+	_, err = mh.modifyManifest(ctx, reg, desc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 {
+		t.Error("did not recompute the manifest after the static layer changed")
+	}
+}
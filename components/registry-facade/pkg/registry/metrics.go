@@ -45,9 +45,14 @@ func (m *measuringRegistryRoundTripper) RoundTrip(req *http.Request) (*http.Resp
 
 // Metrics combine custom metrics exported by registry facade
 type metrics struct {
-	ManifestHist          prometheus.Histogram
-	BlobCounter           prometheus.Counter
-	BlobDownloadSpeedHist prometheus.Histogram
+	ManifestHist           prometheus.Histogram
+	BlobCounter            prometheus.Counter
+	BlobDownloadSpeedHist  prometheus.Histogram
+	BlobCacheRequests      *prometheus.CounterVec
+	BlobBytesServed        *prometheus.CounterVec
+	BlobCacheSize          prometheus.Gauge
+	EStargzConversions     *prometheus.CounterVec
+	SignatureVerifications *prometheus.CounterVec
 }
 
 func newMetrics(reg prometheus.Registerer, upstream bool) (*metrics, error) {
@@ -75,16 +80,43 @@ func newMetrics(reg prometheus.Registerer, upstream bool) (*metrics, error) {
 		Help:    "blob download speed in bytes per second",
 		Buckets: prometheus.ExponentialBuckets(1024*1024, 2, 10),
 	})
+	blobCacheRequests := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "blob_cache_requests_total",
+		Help: "number of layer blob requests by where we found the blob: in the blob cache (hit), with a peer (peer) or upstream (miss)",
+	}, []string{"result"})
+	blobBytesServed := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "blob_bytes_served_total",
+		Help: "number of layer blob bytes served by where they came from: cache, peer or upstream",
+	}, []string{"source"})
+	blobCacheSize := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "blob_cache_size_bytes",
+		Help: "total size of all blobs in the blob cache",
+	})
+	estargzConversions := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "estargz_conversions_total",
+		Help: "number of layers converted to eStargz by result: success or failure",
+	}, []string{"result"})
+	signatureVerifications := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "signature_verifications_total",
+		Help: "number of base image signature verifications by result: verified or failed",
+	}, []string{"result"})
 	if upstream {
-		err = reg.Register(blobDownloadSpeedHist)
-		if err != nil {
-			return nil, err
+		for _, c := range []prometheus.Collector{blobDownloadSpeedHist, blobCacheRequests, blobBytesServed, blobCacheSize, estargzConversions, signatureVerifications} {
+			err = reg.Register(c)
+			if err != nil {
+				return nil, err
+			}
 		}
 	}
 
 	return &metrics{
-		ManifestHist:          manifestHist,
-		BlobCounter:           blobCounter,
-		BlobDownloadSpeedHist: blobDownloadSpeedHist,
+		ManifestHist:           manifestHist,
+		BlobCounter:            blobCounter,
+		BlobDownloadSpeedHist:  blobDownloadSpeedHist,
+		BlobCacheRequests:      blobCacheRequests,
+		BlobBytesServed:        blobBytesServed,
+		BlobCacheSize:          blobCacheSize,
+		EStargzConversions:     estargzConversions,
+		SignatureVerifications: signatureVerifications,
 	}, nil
 }
@@ -21,12 +21,14 @@ import (
 
 	"github.com/containerd/containerd/content"
 	"github.com/containerd/containerd/content/local"
+	"github.com/containerd/containerd/platforms"
 	"github.com/containerd/containerd/remotes"
 	"github.com/docker/distribution"
 	"github.com/docker/distribution/reference"
 	"github.com/docker/distribution/registry/api/errcode"
 	distv2 "github.com/docker/distribution/registry/api/v2"
 	"github.com/gorilla/mux"
+	ociv1 "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/prometheus/client_golang/prometheus"
 	"golang.org/x/xerrors"
 	"google.golang.org/grpc"
@@ -46,12 +48,19 @@ type Config struct {
 			PrivateKey  string `json:"key"`
 		} `json:"tls,omitempty"`
 	} `json:"remoteSpecProvider,omitempty"`
-	Store       string `json:"store"`
-	RequireAuth bool   `json:"requireAuth"`
+	Store       string           `json:"store"`
+	BlobCache   *BlobCacheConfig `json:"blobCache,omitempty"`
+	EStargz     *EStargzConfig   `json:"estargz,omitempty"`
+	Signatures  *SignatureConfig `json:"signatures,omitempty"`
+	RequireAuth bool             `json:"requireAuth"`
 	TLS         *struct {
 		Certificate string `json:"crt"`
 		PrivateKey  string `json:"key"`
 	} `json:"tls"`
+
+	// DefaultPlatform is the platform whose manifest we serve if the base image is an image index, but the client
+	// does not accept image indexes. Defaults to the platform registry-facade runs on, i.e. the node it serves.
+	DefaultPlatform string `json:"defaultPlatform,omitempty"`
 }
 
 // StaticLayerCfg configure statically added layer
@@ -97,6 +106,12 @@ type Registry struct {
 	SpecProvider   map[string]ImageSpecProvider
 
 	staticLayerSource *RevisioningLayerSource
+	blobCache         *BlobCache
+	blobPeers         *blobPeers
+	estargz           *estargzConverter
+	signatures        *signatureVerifier
+	platform          ociv1.Platform
+	manifestCache     *manifestCache
 	metrics           *metrics
 	srv               *http.Server
 }
@@ -200,6 +215,52 @@ func NewRegistry(cfg Config, newResolver ResolverProvider, reg prometheus.Regist
 		specProvider[api.ProviderPrefixRemote] = specprov
 	}
 
+	var (
+		blobCache *BlobCache
+		peers     *blobPeers
+	)
+	if cfg.BlobCache != nil {
+		blobCache, err = NewBlobCache(*cfg.BlobCache, metrics)
+		if err != nil {
+			return nil, xerrors.Errorf("cannot create blob cache: %w", err)
+		}
+		if cfg.BlobCache.Peers != nil {
+			peers, err = newBlobPeers(*cfg.BlobCache.Peers)
+			if err != nil {
+				return nil, xerrors.Errorf("cannot create blob cache peers: %w", err)
+			}
+		}
+	}
+
+	var converter *estargzConverter
+	if cfg.EStargz != nil {
+		converter, err = newEStargzConverter(*cfg.EStargz, metrics)
+		if err != nil {
+			return nil, xerrors.Errorf("cannot create eStargz converter: %w", err)
+		}
+	}
+
+	var verifier *signatureVerifier
+	if cfg.Signatures != nil {
+		verifier, err = newSignatureVerifier(*cfg.Signatures, metrics)
+		if err != nil {
+			return nil, xerrors.Errorf("cannot create signature verifier: %w", err)
+		}
+	}
+
+	platform := platforms.DefaultSpec()
+	if cfg.DefaultPlatform != "" {
+		platform, err = platforms.Parse(cfg.DefaultPlatform)
+		if err != nil {
+			return nil, xerrors.Errorf("cannot parse default platform: %w", err)
+		}
+	}
+
+	manifestCache, err := newManifestCache()
+	if err != nil {
+		return nil, xerrors.Errorf("cannot create manifest cache: %w", err)
+	}
+
 	layerSource := CompositeLayerSource(layerSources)
 	return &Registry{
 		Config:            cfg,
@@ -208,6 +269,12 @@ func NewRegistry(cfg Config, newResolver ResolverProvider, reg prometheus.Regist
 		SpecProvider:      specProvider,
 		LayerSource:       layerSource,
 		staticLayerSource: staticLayer,
+		blobCache:         blobCache,
+		blobPeers:         peers,
+		estargz:           converter,
+		signatures:        verifier,
+		platform:          platform,
+		manifestCache:     manifestCache,
 		ConfigModifier:    NewConfigModifierFromLayerSource(layerSource),
 		metrics:           metrics,
 	}, nil
@@ -223,6 +290,16 @@ func (reg *Registry) UpdateStaticLayer(ctx context.Context, cfg []StaticLayerCfg
 	return nil
 }
 
+// revision changes whenever the manifests we compute for a spec may change, i.e. when the static layer is updated
+// or when we evict eStargz layers which rewritten manifests refer to
+func (reg *Registry) revision() int {
+	rev := reg.staticLayerSource.Revision()
+	if reg.estargz != nil {
+		rev += reg.estargz.Revision()
+	}
+	return rev
+}
+
 // Serve serves the registry on the given port
 func (reg *Registry) Serve() error {
 	routes := distv2.RouterWithPrefix(reg.Config.Prefix)
@@ -250,6 +327,18 @@ func (reg *Registry) Serve() error {
 		}()
 	}
 
+	if reg.blobPeers != nil {
+		go reg.blobPeers.Run(context.Background())
+		go func() {
+			peerAddr := fmt.Sprintf(":%d", reg.blobPeers.Config.Port)
+			log.WithField("addr", peerAddr).Info("blob cache peer server listening")
+			err := http.ListenAndServe(peerAddr, reg.blobCache)
+			if err != nil {
+				log.WithError(err).Error("blob cache peer server failed")
+			}
+		}()
+	}
+
 	addr := fmt.Sprintf(":%d", reg.Config.Port)
 	l, err := net.Listen("tcp", addr)
 	if err != nil {
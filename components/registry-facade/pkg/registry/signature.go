@@ -0,0 +1,343 @@
+// Copyright (c) 2021 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package registry
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/containerd/containerd/errdefs"
+	"github.com/containerd/containerd/remotes"
+	"github.com/docker/distribution/reference"
+	"github.com/docker/distribution/registry/api/errcode"
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/opencontainers/go-digest"
+	ociv1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"golang.org/x/xerrors"
+
+	"github.com/gitpod-io/gitpod/common-go/log"
+)
+
+const (
+	// cosignSignatureAnnotation is the annotation of a signature layer which contains the base64 encoded signature
+	cosignSignatureAnnotation = "dev.cosignproject.cosign/signature"
+	// cosignSignatureType is the critical.type of cosign's simple signing payload
+	cosignSignatureType = "cosign container image signature"
+
+	// maxSignaturePayloadSize is the size limit of the payloads we download to verify a signature
+	maxSignaturePayloadSize = 1 << 20
+	// verifiedImagesCacheSize is the number of verified images we remember
+	verifiedImagesCacheSize = 1024
+)
+
+// ErrorCodeImageNotVerified is returned when the base image of a workspace image is not signed by any of the trusted keys
+var ErrorCodeImageNotVerified = errcode.Register("registry-facade", errcode.ErrorDescriptor{
+	Value:          "IMAGE_NOT_VERIFIED",
+	Message:        "base image is not signed by a trusted key",
+	Description:    "The base image of the workspace image has no valid signature, but the signature policy requires one.",
+	HTTPStatusCode: http.StatusForbidden,
+})
+
+// SignaturePolicy determines what happens if a base image is not signed by a trusted key
+type SignaturePolicy string
+
+const (
+	// SignaturePolicyEnforce refuses to serve workspace images whose base image is not signed
+	SignaturePolicyEnforce SignaturePolicy = "enforce"
+	// SignaturePolicyWarn serves workspace images whose base image is not signed, but logs a warning
+	SignaturePolicyWarn SignaturePolicy = "warn"
+	// SignaturePolicyOff does not verify signatures
+	SignaturePolicyOff SignaturePolicy = "off"
+)
+
+// SignatureConfig configures the verification of cosign signatures of base images
+type SignatureConfig struct {
+	// PublicKeys are paths to PEM encoded public keys. Base images must be signed by one of them.
+	PublicKeys []string `json:"publicKeys"`
+	// DefaultPolicy applies to base images from repositories none of the Repositories match. Defaults to off.
+	DefaultPolicy SignaturePolicy `json:"defaultPolicy,omitempty"`
+	// Repositories configures the policy per repository. The first matching entry applies.
+	Repositories []RepositorySignaturePolicy `json:"repositories,omitempty"`
+}
+
+// RepositorySignaturePolicy configures the signature policy of a repository
+type RepositorySignaturePolicy struct {
+	// Repository is a fully qualified repository name, e.g. docker.io/library/ubuntu. If it ends with *
+	// it matches all repositories with that prefix.
+	Repository string          `json:"repository"`
+	Policy     SignaturePolicy `json:"policy"`
+}
+
+func (p SignaturePolicy) validate() error {
+	switch p {
+	case SignaturePolicyEnforce, SignaturePolicyWarn, SignaturePolicyOff:
+		return nil
+	default:
+		return xerrors.Errorf("unknown signature policy: %s", p)
+	}
+}
+
+// signatureVerifier verifies cosign signatures of base images
+type signatureVerifier struct {
+	Config SignatureConfig
+
+	keys     []crypto.PublicKey
+	verified *lru.Cache
+	metrics  *metrics
+}
+
+func newSignatureVerifier(cfg SignatureConfig, metrics *metrics) (*signatureVerifier, error) {
+	if cfg.DefaultPolicy == "" {
+		cfg.DefaultPolicy = SignaturePolicyOff
+	}
+	err := cfg.DefaultPolicy.validate()
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range cfg.Repositories {
+		err := r.Policy.validate()
+		if err != nil {
+			return nil, xerrors.Errorf("repository %s: %w", r.Repository, err)
+		}
+	}
+
+	var keys []crypto.PublicKey
+	for _, fn := range cfg.PublicKeys {
+		key, err := loadPublicKey(fn)
+		if err != nil {
+			return nil, xerrors.Errorf("cannot load public key %s: %w", fn, err)
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return nil, xerrors.Errorf("no public keys configured")
+	}
+
+	verified, err := lru.New(verifiedImagesCacheSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &signatureVerifier{
+		Config:   cfg,
+		keys:     keys,
+		verified: verified,
+		metrics:  metrics,
+	}, nil
+}
+
+func loadPublicKey(fn string) (crypto.PublicKey, error) {
+	raw, err := os.ReadFile(fn)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, xerrors.Errorf("no PEM data found")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	switch key.(type) {
+	case *ecdsa.PublicKey, *rsa.PublicKey, ed25519.PublicKey:
+		return key, nil
+	default:
+		return nil, xerrors.Errorf("unsupported key type %T", key)
+	}
+}
+
+// Policy returns the signature policy of a repository
+func (v *signatureVerifier) Policy(repo string) SignaturePolicy {
+	for _, r := range v.Config.Repositories {
+		if pattern := strings.TrimSuffix(r.Repository, "*"); pattern != r.Repository {
+			if strings.HasPrefix(repo, pattern) {
+				return r.Policy
+			}
+			continue
+		}
+		if r.Repository == repo {
+			return r.Policy
+		}
+	}
+	return v.Config.DefaultPolicy
+}
+
+// Verify checks if the image ref resolved to desc is signed by one of our keys. Depending on the policy of the
+// image's repository it returns an ErrorCodeImageNotVerified error if it isn't.
+func (v *signatureVerifier) Verify(ctx context.Context, resolver remotes.Resolver, ref string, desc ociv1.Descriptor) error {
+	named, err := reference.ParseNormalizedNamed(ref)
+	if err != nil {
+		return xerrors.Errorf("cannot parse base image ref %s: %w", ref, err)
+	}
+	repo := reference.TrimNamed(named).String()
+
+	policy := v.Policy(repo)
+	if policy == SignaturePolicyOff {
+		return nil
+	}
+	// signatures are bound to a repository, hence so is the verification
+	verifiedKey := repo + "@" + desc.Digest.String()
+	if _, ok := v.verified.Get(verifiedKey); ok {
+		return nil
+	}
+
+	err = v.verify(ctx, resolver, repo, desc.Digest)
+	if err == nil {
+		v.metrics.SignatureVerifications.WithLabelValues("verified").Inc()
+		v.verified.Add(verifiedKey, struct{}{})
+		return nil
+	}
+	v.metrics.SignatureVerifications.WithLabelValues("failed").Inc()
+
+	if policy == SignaturePolicyWarn {
+		log.WithError(err).WithField("ref", ref).WithField("digest", desc.Digest).Warn("base image is not signed by a trusted key")
+		return nil
+	}
+	return ErrorCodeImageNotVerified.WithMessage(fmt.Sprintf("base image %s is not signed by a trusted key: %v", ref, err))
+}
+
+func (v *signatureVerifier) verify(ctx context.Context, resolver remotes.Resolver, repo string, dgst digest.Digest) error {
+	// cosign stores the signatures of an image in the same repository, tagged with the image digest
+	sigRef := fmt.Sprintf("%s:%s-%s.sig", repo, dgst.Algorithm(), dgst.Encoded())
+	_, sigDesc, err := resolver.Resolve(ctx, sigRef)
+	if errdefs.IsNotFound(err) {
+		return xerrors.Errorf("image has no signature")
+	}
+	if err != nil {
+		return xerrors.Errorf("cannot resolve signature: %w", err)
+	}
+	fetcher, err := resolver.Fetcher(ctx, sigRef)
+	if err != nil {
+		return xerrors.Errorf("cannot get signature fetcher: %w", err)
+	}
+	manifest, _, err := DownloadManifest(ctx, fetcher, sigDesc)
+	if err != nil {
+		return xerrors.Errorf("cannot download signature manifest: %w", err)
+	}
+
+	err = xerrors.Errorf("image has no signature")
+	for _, l := range manifest.Layers {
+		sig, ok := l.Annotations[cosignSignatureAnnotation]
+		if !ok {
+			continue
+		}
+
+		payload, ferr := fetchSignaturePayload(ctx, fetcher, l)
+		if ferr != nil {
+			err = ferr
+			continue
+		}
+		verr := v.verifyPayload(payload, sig, repo, dgst)
+		if verr != nil {
+			err = verr
+			continue
+		}
+		return nil
+	}
+	return err
+}
+
+func fetchSignaturePayload(ctx context.Context, fetcher remotes.Fetcher, desc ociv1.Descriptor) ([]byte, error) {
+	if desc.Size > maxSignaturePayloadSize {
+		return nil, xerrors.Errorf("signature payload is too large: %d bytes", desc.Size)
+	}
+	rc, err := fetcher.Fetch(ctx, desc)
+	if err != nil {
+		return nil, xerrors.Errorf("cannot download signature payload: %w", err)
+	}
+	defer rc.Close()
+
+	payload, err := io.ReadAll(io.LimitReader(rc, maxSignaturePayloadSize))
+	if err != nil {
+		return nil, xerrors.Errorf("cannot download signature payload: %w", err)
+	}
+	if act := digest.FromBytes(payload); act != desc.Digest {
+		return nil, xerrors.Errorf("signature payload digest %s does not match %s", act, desc.Digest)
+	}
+	return payload, nil
+}
+
+// simpleSigningPayload is the payload cosign signs
+type simpleSigningPayload struct {
+	Critical struct {
+		Identity struct {
+			DockerReference string `json:"docker-reference"`
+		} `json:"identity"`
+		Image struct {
+			DockerManifestDigest digest.Digest `json:"docker-manifest-digest"`
+		} `json:"image"`
+		Type string `json:"type"`
+	} `json:"critical"`
+}
+
+func (v *signatureVerifier) verifyPayload(payload []byte, signature string, repo string, dgst digest.Digest) error {
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return xerrors.Errorf("cannot decode signature: %w", err)
+	}
+
+	var trusted bool
+	for _, key := range v.keys {
+		if verifySignature(key, payload, sig) {
+			trusted = true
+			break
+		}
+	}
+	if !trusted {
+		return xerrors.Errorf("signature does not match any trusted key")
+	}
+
+	// the signature is valid, hence we can trust the payload
+	var p simpleSigningPayload
+	err = json.Unmarshal(payload, &p)
+	if err != nil {
+		return xerrors.Errorf("cannot unmarshal signature payload: %w", err)
+	}
+	if p.Critical.Type != cosignSignatureType {
+		return xerrors.Errorf("unknown signature type: %s", p.Critical.Type)
+	}
+	if p.Critical.Image.DockerManifestDigest != dgst {
+		return xerrors.Errorf("signature is for %s", p.Critical.Image.DockerManifestDigest)
+	}
+
+	// A signature only vouches for the image in the repository it was made for. Without this check a signed image
+	// copied into a repository with a more lenient policy, or a less trustworthy owner, would pass as well.
+	identity, err := reference.ParseNormalizedNamed(p.Critical.Identity.DockerReference)
+	if err != nil {
+		return xerrors.Errorf("cannot parse signature identity %s: %w", p.Critical.Identity.DockerReference, err)
+	}
+	if act := reference.TrimNamed(identity).String(); act != repo {
+		return xerrors.Errorf("signature is for repository %s", act)
+	}
+	return nil
+}
+
+func verifySignature(key crypto.PublicKey, payload, sig []byte) bool {
+	h := sha256.Sum256(payload)
+	switch k := key.(type) {
+	case *ecdsa.PublicKey:
+		return ecdsa.VerifyASN1(k, h[:], sig)
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(k, crypto.SHA256, h[:], sig) == nil
+	case ed25519.PublicKey:
+		return ed25519.Verify(k, payload, sig)
+	default:
+		return false
+	}
+}
@@ -0,0 +1,183 @@
+// Copyright (c) 2021 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package registry
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/distribution/registry/api/errcode"
+	"github.com/opencontainers/go-digest"
+	ociv1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/xerrors"
+)
+
+func newTestSigningKey(t *testing.T) (key *ecdsa.PrivateKey, fn string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fn = filepath.Join(t.TempDir(), "cosign.pub")
+	err = os.WriteFile(fn, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: raw}), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key, fn
+}
+
+// sign adds a cosign signature of dgst to the repository repo
+func sign(t *testing.T, reg *fakeRegistry, key *ecdsa.PrivateKey, repo string, dgst digest.Digest) {
+	signAs(t, reg, key, repo, repo, dgst)
+}
+
+// signAs adds a cosign signature of dgst to the repository repo, which claims the image stems from identity
+func signAs(t *testing.T, reg *fakeRegistry, key *ecdsa.PrivateKey, repo, identity string, dgst digest.Digest) {
+	payload := []byte(fmt.Sprintf(`{"critical":{"identity":{"docker-reference":"%s"},"image":{"docker-manifest-digest":"%s"},"type":"cosign container image signature"},"optional":null}`, identity, dgst))
+	h := sha256.Sum256(payload)
+	sig, err := ecdsa.SignASN1(rand.Reader, key, h[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	layer := ociv1.Descriptor{
+		MediaType:   "application/vnd.dev.cosign.simplesigning.v1+json",
+		Digest:      digest.FromBytes(payload),
+		Size:        int64(len(payload)),
+		Annotations: map[string]string{cosignSignatureAnnotation: base64.StdEncoding.EncodeToString(sig)},
+	}
+	reg.Blobs[layer.Digest] = payload
+	cfg := reg.add(t, ociv1.MediaTypeImageConfig, ociv1.Image{})
+	reg.Refs[fmt.Sprintf("%s:%s-%s.sig", repo, dgst.Algorithm(), dgst.Encoded())] = reg.add(t, ociv1.MediaTypeImageManifest, ociv1.Manifest{Config: cfg, Layers: []ociv1.Descriptor{layer}})
+}
+
+func TestSignatureVerifier(t *testing.T) {
+	var (
+		trusted, fn = newTestSigningKey(t)
+		untrusted   = func() *ecdsa.PrivateKey { k, _ := newTestSigningKey(t); return k }()
+		repo        = "docker.io/library/base"
+	)
+	newImage := func(reg *fakeRegistry, name string) ociv1.Descriptor {
+		cfg := reg.add(t, ociv1.MediaTypeImageConfig, ociv1.Image{Author: name})
+		return reg.add(t, ociv1.MediaTypeImageManifest, ociv1.Manifest{Config: cfg})
+	}
+
+	tests := []struct {
+		Name        string
+		Policy      SignaturePolicy
+		Sign        func(reg *fakeRegistry, img ociv1.Descriptor)
+		Expectation bool
+	}{
+		{Name: "signed", Policy: SignaturePolicyEnforce, Sign: func(reg *fakeRegistry, img ociv1.Descriptor) { sign(t, reg, trusted, repo, img.Digest) }, Expectation: true},
+		{Name: "unsigned", Policy: SignaturePolicyEnforce},
+		{Name: "untrusted key", Policy: SignaturePolicyEnforce, Sign: func(reg *fakeRegistry, img ociv1.Descriptor) { sign(t, reg, untrusted, repo, img.Digest) }},
+		{
+			Name:   "signature of another image",
+			Policy: SignaturePolicyEnforce,
+			Sign: func(reg *fakeRegistry, img ociv1.Descriptor) {
+				other := newImage(reg, "other")
+				sign(t, reg, trusted, repo, other.Digest)
+				// someone copied the signature of another image
+				reg.Refs[fmt.Sprintf("%s:%s-%s.sig", repo, img.Digest.Algorithm(), img.Digest.Encoded())] = reg.Refs[fmt.Sprintf("%s:%s-%s.sig", repo, other.Digest.Algorithm(), other.Digest.Encoded())]
+			},
+		},
+		{
+			Name:   "legacy registry name",
+			Policy: SignaturePolicyEnforce,
+			Sign: func(reg *fakeRegistry, img ociv1.Descriptor) {
+				signAs(t, reg, trusted, repo, "index.docker.io/library/base", img.Digest)
+			},
+			Expectation: true,
+		},
+		{
+			Name:   "signature for another repository",
+			Policy: SignaturePolicyEnforce,
+			Sign: func(reg *fakeRegistry, img ociv1.Descriptor) {
+				// someone copied a signed image, along with its signature, from another repository
+				signAs(t, reg, trusted, repo, "docker.io/someone/else", img.Digest)
+			},
+		},
+		{Name: "warn", Policy: SignaturePolicyWarn, Expectation: true},
+		{Name: "off", Policy: SignaturePolicyOff, Expectation: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			metrics, err := newMetrics(prometheus.NewRegistry(), true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			v, err := newSignatureVerifier(SignatureConfig{
+				PublicKeys:   []string{fn},
+				Repositories: []RepositorySignaturePolicy{{Repository: "docker.io/library/*", Policy: test.Policy}},
+			}, metrics)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			reg := &fakeRegistry{Refs: make(map[string]ociv1.Descriptor), Blobs: make(map[digest.Digest][]byte)}
+			img := newImage(reg, "base")
+			if test.Sign != nil {
+				test.Sign(reg, img)
+			}
+
+			err = v.Verify(context.Background(), reg, "base:latest", img)
+			if test.Expectation {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			var ec errcode.Error
+			if !xerrors.As(err, &ec) || ec.Code != ErrorCodeImageNotVerified {
+				t.Errorf("expected %v, got %v", ErrorCodeImageNotVerified, err)
+			}
+		})
+	}
+}
+
+func TestSignaturePolicy(t *testing.T) {
+	v := &signatureVerifier{Config: SignatureConfig{
+		DefaultPolicy: SignaturePolicyWarn,
+		Repositories: []RepositorySignaturePolicy{
+			{Repository: "eu.gcr.io/gitpod-core-dev/build/*", Policy: SignaturePolicyEnforce},
+			{Repository: "docker.io/gitpod/workspace-full", Policy: SignaturePolicyEnforce},
+			{Repository: "docker.io/gitpod/*", Policy: SignaturePolicyOff},
+		},
+	}}
+
+	tests := []struct {
+		Repo        string
+		Expectation SignaturePolicy
+	}{
+		{Repo: "eu.gcr.io/gitpod-core-dev/build/supervisor", Expectation: SignaturePolicyEnforce},
+		{Repo: "docker.io/gitpod/workspace-full", Expectation: SignaturePolicyEnforce},
+		{Repo: "docker.io/gitpod/workspace-base", Expectation: SignaturePolicyOff},
+		{Repo: "docker.io/gitpod/workspace-full-vnc", Expectation: SignaturePolicyOff},
+		{Repo: "docker.io/library/ubuntu", Expectation: SignaturePolicyWarn},
+	}
+	for _, test := range tests {
+		t.Run(test.Repo, func(t *testing.T) {
+			if act := v.Policy(test.Repo); act != test.Expectation {
+				t.Errorf("expected %s, got %s", test.Expectation, act)
+			}
+		})
+	}
+}
@@ -56,6 +56,12 @@ export interface ConfigSerialized {
     devBranch?: string;
     insecureNoDomain: boolean;
 
+    /**
+     * How ws-proxy routes requests to workspaces. When routing by path all workspaces share one host,
+     * hence owner cookies are scoped to the workspace's path prefix.
+     */
+    workspaceRouting?: "host" | "path";
+
     license?: string;
 
     workspaceHeartbeat: {
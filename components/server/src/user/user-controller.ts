@@ -213,7 +213,8 @@ export class UserController {
 
             const name = `_${cookiePrefix}_ws_${instanceID}_owner_`;
             res.cookie(name, token, {
-                path: "/",
+                // when routing by path all workspaces share one host: only send the cookie to this workspace
+                path: this.config.workspaceRouting === "path" ? `/ws/${workspace.id}/` : "/",
                 httpOnly: true,
                 secure: true,
                 maxAge: 1000 * 60 * 60 * 24 * 1,    // 1 day
@@ -7,7 +7,7 @@
 import { BlobServiceClient } from "@gitpod/content-service/lib/blobs_grpc_pb";
 import { DownloadUrlRequest, DownloadUrlResponse, UploadUrlRequest, UploadUrlResponse } from '@gitpod/content-service/lib/blobs_pb';
 import { AppInstallationDB, UserDB, UserMessageViewsDB, WorkspaceDB, DBWithTracing, TracedWorkspaceDB, DBGitpodToken, DBUser, UserStorageResourcesDB, TeamDB } from '@gitpod/gitpod-db/lib';
-import { AuthProviderEntry, AuthProviderInfo, Branding, CommitContext, Configuration, CreateWorkspaceMode, DisposableCollection, GetWorkspaceTimeoutResult, GitpodClient, GitpodServer, GitpodToken, GitpodTokenType, InstallPluginsParams, PermissionName, PortVisibility, PrebuiltWorkspace, PrebuiltWorkspaceContext, PreparePluginUploadParams, ResolvedPlugins, ResolvePluginsParams, SetWorkspaceTimeoutResult, StartPrebuildContext, StartWorkspaceResult, Terms, Token, UninstallPluginParams, User, UserEnvVar, UserEnvVarValue, UserInfo, WhitelistedRepository, Workspace, WorkspaceContext, WorkspaceCreationResult, WorkspaceImageBuild, WorkspaceInfo, WorkspaceInstance, WorkspaceInstancePort, WorkspaceInstanceUser, WorkspaceTimeoutDuration, GuessGitTokenScopesParams, GuessedGitTokenScopes, Team, TeamMemberInfo, TeamMembershipInvite, CreateProjectParams, Project, ProviderRepository, TeamMemberRole, WithDefaultConfig, FindPrebuildsParams, PrebuildWithStatus, StartPrebuildResult } from '@gitpod/gitpod-protocol';
+import { AuthProviderEntry, AuthProviderInfo, Branding, CommitContext, Configuration, CreateWorkspaceMode, DisposableCollection, GetWorkspaceTimeoutResult, GitpodClient, GitpodServer, GitpodToken, GitpodTokenType, InstallPluginsParams, PermissionName, PortProtocol, PortVisibility, PrebuiltWorkspace, PrebuiltWorkspaceContext, PreparePluginUploadParams, ResolvedPlugins, ResolvePluginsParams, SetWorkspaceTimeoutResult, StartPrebuildContext, StartWorkspaceResult, Terms, Token, UninstallPluginParams, User, UserEnvVar, UserEnvVarValue, UserInfo, WhitelistedRepository, Workspace, WorkspaceContext, WorkspaceCreationResult, WorkspaceImageBuild, WorkspaceInfo, WorkspaceInstance, WorkspaceInstancePort, WorkspaceInstanceUser, WorkspaceTimeoutDuration, GuessGitTokenScopesParams, GuessedGitTokenScopes, Team, TeamMemberInfo, TeamMembershipInvite, CreateProjectParams, Project, ProviderRepository, TeamMemberRole, WithDefaultConfig, FindPrebuildsParams, PrebuildWithStatus, StartPrebuildResult } from '@gitpod/gitpod-protocol';
 import { AccountStatement } from "@gitpod/gitpod-protocol/lib/accounting-protocol";
 import { AdminBlockUserRequest, AdminGetListRequest, AdminGetListResult, AdminGetWorkspacesRequest, AdminModifyPermanentWorkspaceFeatureFlagRequest, AdminModifyRoleOrPermissionRequest, WorkspaceAndInstance } from '@gitpod/gitpod-protocol/lib/admin-protocol';
 import { GetLicenseInfoResult, LicenseFeature, LicenseValidationResult } from '@gitpod/gitpod-protocol/lib/license-protocol';
@@ -21,7 +21,7 @@ import { TraceContext } from '@gitpod/gitpod-protocol/lib/util/tracing';
 import { RemoteTrackMessage, TrackMessage } from '@gitpod/gitpod-protocol/lib/analytics';
 import { ImageBuilderClientProvider, LogsRequest } from '@gitpod/image-builder/lib';
 import { WorkspaceManagerClientProvider } from '@gitpod/ws-manager/lib/client-provider';
-import { ControlPortRequest, DescribeWorkspaceRequest, MarkActiveRequest, PortSpec, PortVisibility as ProtoPortVisibility, StopWorkspacePolicy, StopWorkspaceRequest } from '@gitpod/ws-manager/lib/core_pb';
+import { ControlPortRequest, DescribeWorkspaceRequest, MarkActiveRequest, PortProtocol as ProtoPortProtocol, PortSpec, PortVisibility as ProtoPortVisibility, StopWorkspacePolicy, StopWorkspaceRequest } from '@gitpod/ws-manager/lib/core_pb';
 import * as crypto from 'crypto';
 import { inject, injectable } from 'inversify';
 import * as opentracing from 'opentracing';
@@ -1029,7 +1029,8 @@ export class GitpodServerImpl<Client extends GitpodClient, Server extends Gitpod
                 port: p.getPort(),
                 targetPort: p.getTarget(),
                 url: p.getUrl(),
-                visibility: this.portVisibilityFromProto(p.getVisibility())
+                visibility: this.portVisibilityFromProto(p.getVisibility()),
+                protocol: this.portProtocolFromProto(p.getProtocol())
             });
 
             return ports;
@@ -1067,6 +1068,7 @@ export class GitpodServerImpl<Client extends GitpodClient, Server extends Gitpod
                 spec.setTarget(port.port);
             }
             spec.setVisibility(this.portVisibilityToProto(port.visibility))
+            spec.setProtocol(this.portProtocolToProto(port.protocol))
             req.setSpec(spec);
             req.setExpose(true);
 
@@ -1100,6 +1102,26 @@ export class GitpodServerImpl<Client extends GitpodClient, Server extends Gitpod
         }
     }
 
+    protected portProtocolFromProto(protocol: ProtoPortProtocol): PortProtocol {
+        switch (protocol) {
+            default:    // the default in the protobuf def is: http
+            case ProtoPortProtocol.PORT_PROTOCOL_HTTP:
+                return 'http';
+            case ProtoPortProtocol.PORT_PROTOCOL_HTTP2:
+                return 'http2';
+        }
+    }
+
+    protected portProtocolToProto(protocol: PortProtocol | undefined): ProtoPortProtocol {
+        switch (protocol) {
+            default:    // the default for requests is: http
+            case 'http':
+                return ProtoPortProtocol.PORT_PROTOCOL_HTTP;
+            case 'http2':
+                return ProtoPortProtocol.PORT_PROTOCOL_HTTP2;
+        }
+    }
+
     public async closePort(workspaceId: string, port: number) {
         const user = this.checkAndBlockUser("closePort");
         const span = opentracing.globalTracer().startSpan("closePort");
@@ -14,7 +14,7 @@ import { TraceContext } from "@gitpod/gitpod-protocol/lib/util/tracing";
 import { BuildRegistryAuth, BuildRegistryAuthSelective, BuildRegistryAuthTotal, BuildRequest, BuildResponse, BuildSource, BuildSourceDockerfile, BuildSourceReference, BuildStatus, ImageBuilderClientProvider, ResolveBaseImageRequest, ResolveWorkspaceImageRequest } from "@gitpod/image-builder/lib";
 import { StartWorkspaceSpec, WorkspaceFeatureFlag } from "@gitpod/ws-manager/lib";
 import { WorkspaceManagerClientProvider } from "@gitpod/ws-manager/lib/client-provider";
-import { AdmissionLevel, EnvironmentVariable, GitSpec, PortSpec, PortVisibility, StartWorkspaceRequest, WorkspaceMetadata, WorkspaceType } from "@gitpod/ws-manager/lib/core_pb";
+import { AdmissionLevel, EnvironmentVariable, GitSpec, PersistentVolumeSpec, PortProtocol, PortSpec, PortVisibility, ServiceContainerResources, ServiceContainerSpec, StartWorkspaceRequest, WorkspaceMetadata, WorkspaceType } from "@gitpod/ws-manager/lib/core_pb";
 import * as crypto from 'crypto';
 import { inject, injectable } from "inversify";
 import * as uuidv4 from 'uuid/v4';
@@ -653,9 +653,38 @@ export class WorkspaceStarter {
             spec.setPort(p.port);
             spec.setTarget(target);
             spec.setVisibility(p.visibility == 'public' ? PortVisibility.PORT_VISIBILITY_PUBLIC : PortVisibility.PORT_VISIBILITY_PRIVATE);
+            spec.setProtocol(p.protocol == 'http2' ? PortProtocol.PORT_PROTOCOL_HTTP2 : PortProtocol.PORT_PROTOCOL_HTTP);
             return spec;
         }).filter(spec => !!spec) as PortSpec[];
 
+        const volumes = (workspace.config.volumes || []).map(v => {
+            const spec = new PersistentVolumeSpec();
+            spec.setName(v.name);
+            spec.setMountPath(v.mountPath);
+            return spec;
+        });
+
+        const services = (workspace.config.services || []).map(s => {
+            const spec = new ServiceContainerSpec();
+            spec.setName(s.name);
+            spec.setImage(s.image);
+            spec.setEnvList(Object.entries(s.env || {}).map(([name, value]) => {
+                const ev = new EnvironmentVariable();
+                ev.setName(name);
+                ev.setValue(value);
+                return ev;
+            }));
+            spec.setPortsList(s.ports || []);
+            if (s.resources) {
+                const resources = new ServiceContainerResources();
+                resources.setCpu(s.resources.cpu || "");
+                resources.setMemory(s.resources.memory || "");
+                spec.setResources(resources);
+            }
+            spec.setReadinessCommandList(s.readinessCommand || []);
+            return spec;
+        });
+
         let admissionLevel: AdmissionLevel;
         if (workspace.shareable) {
             admissionLevel = AdmissionLevel.ADMIT_EVERYONE;
@@ -690,6 +719,8 @@ export class WorkspaceStarter {
         spec.setEnvvarsList(envvars);
         spec.setGit(this.createGitSpec(workspace, user));
         spec.setPortsList(ports);
+        spec.setVolumesList(volumes);
+        spec.setServicesList(services);
         spec.setInitializer((await initializerPromise).initializer);
         spec.setIdeImage(ideImage);
         spec.setWorkspaceImage(instance.workspaceImage);
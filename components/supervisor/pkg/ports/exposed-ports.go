@@ -18,6 +18,7 @@ type ExposedPort struct {
 	GlobalPort uint32
 	URL        string
 	Public     bool
+	Protocol   string
 }
 
 // ExposedPortsInterface provides access to port exposure
@@ -33,7 +34,7 @@ type ExposedPortsInterface interface {
 	Run(ctx context.Context)
 
 	// Expose exposes a port to the internet. Upon successful execution any Observer will be updated.
-	Expose(ctx context.Context, local, global uint32, public bool) <-chan error
+	Expose(ctx context.Context, local, global uint32, public bool, protocol string) <-chan error
 }
 
 // NoopExposedPorts implements ExposedPortsInterface but does nothing
@@ -48,7 +49,7 @@ func (*NoopExposedPorts) Observe(ctx context.Context) (<-chan []ExposedPort, <-c
 func (*NoopExposedPorts) Run(ctx context.Context) {}
 
 // Expose exposes a port to the internet. Upon successful execution any Observer will be updated.
-func (*NoopExposedPorts) Expose(ctx context.Context, local, global uint32, public bool) <-chan error {
+func (*NoopExposedPorts) Expose(ctx context.Context, local, global uint32, public bool, protocol string) <-chan error {
 	done := make(chan error)
 	close(done)
 	return done
@@ -127,6 +128,7 @@ func (g *GitpodExposedPorts) Observe(ctx context.Context) (<-chan []ExposedPort,
 						GlobalPort: uint32(globalport),
 						Public:     p.Visibility == "public",
 						URL:        p.URL,
+						Protocol:   p.Protocol,
 					}
 				}
 
@@ -182,7 +184,7 @@ func (g *GitpodExposedPorts) doExpose(req *exposePortRequest) {
 }
 
 // Expose exposes a port to the internet. Upon successful execution any Observer will be updated.
-func (g *GitpodExposedPorts) Expose(ctx context.Context, local, global uint32, public bool) <-chan error {
+func (g *GitpodExposedPorts) Expose(ctx context.Context, local, global uint32, public bool, protocol string) <-chan error {
 	var v string
 	if public {
 		v = "public"
@@ -194,6 +196,7 @@ func (g *GitpodExposedPorts) Expose(ctx context.Context, local, global uint32, p
 			Port:       float64(local),
 			TargetPort: float64(global),
 			Visibility: v,
+			Protocol:   protocol,
 		},
 		ctx:  ctx,
 		done: make(chan error),
@@ -27,6 +27,7 @@ type Configs struct {
 	workspaceConfigs     map[uint32]*gitpod.PortConfig
 	instancePortConfigs  map[uint32]*gitpod.PortConfig
 	instanceRangeConfigs []*RangeConfig
+	serviceConfigs       map[uint32]*gitpod.PortConfig
 }
 
 // ForEach iterates over all configured ports
@@ -35,7 +36,7 @@ func (configs *Configs) ForEach(callback func(port uint32, config *gitpod.PortCo
 		return
 	}
 	visited := make(map[uint32]struct{})
-	for _, configs := range []map[uint32]*gitpod.PortConfig{configs.instancePortConfigs, configs.workspaceConfigs} {
+	for _, configs := range []map[uint32]*gitpod.PortConfig{configs.instancePortConfigs, configs.workspaceConfigs, configs.serviceConfigs} {
 		for port, config := range configs {
 			_, exists := visited[port]
 			if exists {
@@ -76,9 +77,14 @@ func (configs *Configs) Get(port uint32) (*gitpod.PortConfig, ConfigKind, bool)
 				Port:       float64(port),
 				OnOpen:     rangeConfig.OnOpen,
 				Visibility: rangeConfig.Visibility,
+				Protocol:   rangeConfig.Protocol,
 			}, RangeConfigKind, true
 		}
 	}
+	config, exists = configs.serviceConfigs[port]
+	if exists {
+		return config, PortConfigKind, true
+	}
 	return nil, PortConfigKind, false
 }
 
@@ -93,14 +99,18 @@ type ConfigService struct {
 	workspaceID   string
 	configService gitpod.ConfigInterface
 	gitpodAPI     gitpod.APIInterface
+	servicePorts  []uint32
 }
 
-// NewConfigService creates a new instance of ConfigService
-func NewConfigService(workspaceID string, configService gitpod.ConfigInterface, gitpodAPI gitpod.APIInterface) *ConfigService {
+// NewConfigService creates a new instance of ConfigService.
+// Service ports are the ports of the workspace's service containers. They are
+// configured as private and ignored on open unless the user configures them otherwise.
+func NewConfigService(workspaceID string, configService gitpod.ConfigInterface, gitpodAPI gitpod.APIInterface, servicePorts ...uint32) *ConfigService {
 	return &ConfigService{
 		workspaceID:   workspaceID,
 		configService: configService,
 		gitpodAPI:     gitpodAPI,
+		servicePorts:  servicePorts,
 	}
 }
 
@@ -115,14 +125,19 @@ func (service *ConfigService) Observe(ctx context.Context) (<-chan *Configs, <-c
 
 		configs, errs := service.configService.Observe(ctx)
 
-		current := &Configs{}
+		current := &Configs{
+			serviceConfigs: parseServiceConfigs(service.servicePorts),
+		}
 		if service.gitpodAPI != nil {
 			info, err := service.gitpodAPI.GetWorkspace(ctx, service.workspaceID)
 			if err != nil {
 				errorsChan <- err
 			} else {
 				current.workspaceConfigs = parseWorkspaceConfigs(info.Workspace.Config.Ports)
-				updatesChan <- &Configs{workspaceConfigs: current.workspaceConfigs}
+				updatesChan <- &Configs{
+					workspaceConfigs: current.workspaceConfigs,
+					serviceConfigs:   current.serviceConfigs,
+				}
 			}
 		} else {
 			errorsChan <- errors.New("could not connect to Gitpod API to fetch workspace port configs")
@@ -143,6 +158,7 @@ func (service *ConfigService) Observe(ctx context.Context) (<-chan *Configs, <-c
 					workspaceConfigs:     current.workspaceConfigs,
 					instancePortConfigs:  current.instancePortConfigs,
 					instanceRangeConfigs: current.instanceRangeConfigs,
+					serviceConfigs:       current.serviceConfigs,
 				}
 			}
 		}
@@ -179,6 +195,21 @@ func parseWorkspaceConfigs(ports []*gitpod.PortConfig) (portConfigs map[uint32]*
 	return portConfigs
 }
 
+func parseServiceConfigs(ports []uint32) (portConfigs map[uint32]*gitpod.PortConfig) {
+	if len(ports) == 0 {
+		return nil
+	}
+	portConfigs = make(map[uint32]*gitpod.PortConfig)
+	for _, port := range ports {
+		portConfigs[port] = &gitpod.PortConfig{
+			Port:       float64(port),
+			OnOpen:     "ignore",
+			Visibility: "private",
+		}
+	}
+	return portConfigs
+}
+
 func parseInstanceConfigs(ports []*gitpod.PortsItems) (portConfigs map[uint32]*gitpod.PortConfig, rangeConfigs []*RangeConfig) {
 	for _, config := range ports {
 		if config == nil {
@@ -198,6 +229,7 @@ func parseInstanceConfigs(ports []*gitpod.PortsItems) (portConfigs map[uint32]*g
 					OnOpen:     config.OnOpen,
 					Port:       float64(Port),
 					Visibility: config.Visibility,
+					Protocol:   config.Protocol,
 				}
 			}
 			continue
@@ -19,6 +19,7 @@ func TestPortsConfig(t *testing.T) {
 		Desc           string
 		WorkspacePorts []*gitpod.PortConfig
 		GitpodConfig   *gitpod.GitpodConfig
+		ServicePorts   []uint32
 		Expectation    *PortConfigTestExpectations
 	}{
 		{
@@ -90,6 +91,19 @@ func TestPortsConfig(t *testing.T) {
 				},
 			},
 		},
+		{
+			Desc:         "service port config",
+			ServicePorts: []uint32{5432},
+			Expectation: &PortConfigTestExpectations{
+				ServiceConfigs: []*gitpod.PortConfig{
+					{
+						Port:       5432,
+						OnOpen:     "ignore",
+						Visibility: "private",
+					},
+				},
+			},
+		},
 	}
 	for _, test := range tests {
 		t.Run(test.Desc, func(t *testing.T) {
@@ -117,7 +131,7 @@ func TestPortsConfig(t *testing.T) {
 				},
 			}, nil)
 
-			service := NewConfigService(workspaceID, configService, gitpodAPI)
+			service := NewConfigService(workspaceID, configService, gitpodAPI, test.ServicePorts...)
 			updates, errors := service.Observe(context)
 
 			actual := &PortConfigTestExpectations{}
@@ -128,6 +142,9 @@ func TestPortsConfig(t *testing.T) {
 				for _, config := range change.workspaceConfigs {
 					actual.WorkspaceConfigs = append(actual.WorkspaceConfigs, config)
 				}
+				for _, config := range change.serviceConfigs {
+					actual.ServiceConfigs = append(actual.ServiceConfigs, config)
+				}
 			}
 
 			if test.GitpodConfig != nil {
@@ -156,6 +173,7 @@ type PortConfigTestExpectations struct {
 	WorkspaceConfigs     []*gitpod.PortConfig
 	InstancePortConfigs  []*gitpod.PortConfig
 	InstanceRangeConfigs []*RangeConfig
+	ServiceConfigs       []*gitpod.PortConfig
 }
 
 type testGitpodConfigService struct {
@@ -70,6 +70,7 @@ type autoExposure struct {
 	ctx        context.Context
 	globalPort uint32
 	public     bool
+	protocol   string
 }
 
 // Manager brings together served and exposed ports. It keeps track of which port is exposed, which one is served,
@@ -428,12 +429,14 @@ func (pm *Manager) nextState(ctx context.Context) map[uint32]*managedPort {
 
 // clients should guard a call with check whether such port is already exposed or auto exposed
 func (pm *Manager) autoExpose(ctx context.Context, localPort uint32, globalPort uint32, public bool) *autoExposure {
-	exposing := pm.E.Expose(ctx, localPort, globalPort, public)
+	protocol := pm.portProtocol(localPort)
+	exposing := pm.E.Expose(ctx, localPort, globalPort, public, protocol)
 	autoExpose := &autoExposure{
 		state:      api.PortAutoExposure_trying,
 		ctx:        ctx,
 		globalPort: globalPort,
 		public:     public,
+		protocol:   protocol,
 	}
 	go func() {
 		err := <-exposing
@@ -464,6 +467,15 @@ func (pm *Manager) RetryAutoExpose(ctx context.Context, localPort uint32) {
 	pm.forceUpdate()
 }
 
+// portProtocol returns the application protocol configured for a port, or an empty string if there is none
+func (pm *Manager) portProtocol(port uint32) string {
+	config, _, exists := pm.configs.Get(port)
+	if !exists {
+		return ""
+	}
+	return config.Protocol
+}
+
 func (pm *Manager) forceUpdate() {
 	if len(pm.forceUpdates) == 0 {
 		pm.forceUpdates <- struct{}{}
@@ -638,7 +650,7 @@ func (pm *Manager) Expose(ctx context.Context, port uint32, targetPort uint32) e
 		global = port
 	}
 	public := exists && config.Visibility != "private"
-	err := <-pm.E.Expose(ctx, port, global, public)
+	err := <-pm.E.Expose(ctx, port, global, public, pm.portProtocol(port))
 	if err != nil && err != context.Canceled {
 		log.WithError(err).WithField("port", port).WithField("targetPort", targetPort).Error("cannot expose port")
 	}
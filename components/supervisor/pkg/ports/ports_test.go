@@ -596,7 +596,7 @@ func (tep *testExposedPorts) Observe(ctx context.Context) (<-chan []ExposedPort,
 func (tep *testExposedPorts) Run(ctx context.Context) {
 }
 
-func (tep *testExposedPorts) Expose(ctx context.Context, local, global uint32, public bool) <-chan error {
+func (tep *testExposedPorts) Expose(ctx context.Context, local, global uint32, public bool, protocol string) <-chan error {
 	tep.mu.Lock()
 	defer tep.mu.Unlock()
 
@@ -604,6 +604,7 @@ func (tep *testExposedPorts) Expose(ctx context.Context, local, global uint32, p
 		GlobalPort: global,
 		LocalPort:  local,
 		Public:     public,
+		Protocol:   protocol,
 	})
 	return nil
 }
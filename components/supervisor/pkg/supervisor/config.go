@@ -13,6 +13,8 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
 	env "github.com/Netflix/go-env"
@@ -214,6 +216,10 @@ type WorkspaceConfig struct {
 
 	// WorkspaceClusterHost is a host under which this workspace is served, e.g. ws-eu11.gitpod.io
 	WorkspaceClusterHost string `env:"GITPOD_WORKSPACE_CLUSTER_HOST"`
+
+	// ServicePorts lists the ports of the workspace's service containers as comma separated
+	// name:port pairs, e.g. postgres:5432,redis:6379
+	ServicePorts string `env:"GITPOD_SERVICE_PORTS"`
 }
 
 // WorkspaceGitpodToken is a list of tokens that should be added to supervisor's token service
@@ -256,9 +262,35 @@ func (c WorkspaceConfig) Validate() error {
 		return err
 	}
 
+	if _, err := c.GetServicePorts(); err != nil {
+		return err
+	}
+
 	return nil
 }
 
+// GetServicePorts parses the service container ports from GITPOD_SERVICE_PORTS.
+// The result maps each port to the name of the service exposing it.
+func (c WorkspaceConfig) GetServicePorts() (map[uint32]string, error) {
+	if c.ServicePorts == "" {
+		return nil, nil
+	}
+
+	res := make(map[uint32]string)
+	for _, segment := range strings.Split(c.ServicePorts, ",") {
+		segs := strings.Split(segment, ":")
+		if len(segs) != 2 || segs[0] == "" {
+			return nil, xerrors.Errorf("invalid GITPOD_SERVICE_PORTS entry: %s", segment)
+		}
+		port, err := strconv.ParseUint(segs[1], 10, 16)
+		if err != nil || port == 0 {
+			return nil, xerrors.Errorf("invalid GITPOD_SERVICE_PORTS port for %s: %s", segs[0], segs[1])
+		}
+		res[uint32(port)] = segs[0]
+	}
+	return res, nil
+}
+
 // GetTokens parses tokens from GITPOD_TOKENS and possibly downloads OTS.
 func (c WorkspaceConfig) GetTokens(downloadOTS bool) ([]WorkspaceGitpodToken, error) {
 	if c.Tokens == "" {
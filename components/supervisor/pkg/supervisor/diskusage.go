@@ -0,0 +1,123 @@
+// Copyright (c) 2021 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package supervisor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/gitpod-io/gitpod/common-go/log"
+	"github.com/gitpod-io/gitpod/supervisor/api"
+	daemonapi "github.com/gitpod-io/gitpod/ws-daemon/api"
+)
+
+const (
+	// daemonSocket is where ws-daemon offers the in-workspace service
+	daemonSocket = "/.workspace/daemon.sock"
+
+	diskUsageCheckInterval = 1 * time.Minute
+
+	// diskUsageWarningThreshold is the share of the disk quota above which we warn the user
+	diskUsageWarningThreshold = 0.9
+)
+
+type diskUsageClient interface {
+	DiskUsage(ctx context.Context, in *daemonapi.DiskUsageRequest, opts ...grpc.CallOption) (*daemonapi.DiskUsageResponse, error)
+}
+
+// watchDiskUsage connects to ws-daemon and warns the user once the workspace is about to exceed its disk quota,
+// or when ws-daemon has a notice for the user, e.g. because the node is running out of disk space.
+func watchDiskUsage(ctx context.Context, notificationService *NotificationService) {
+	if _, err := os.Stat(daemonSocket); err != nil {
+		log.WithError(err).Debug("ws-daemon socket is not available - not watching disk usage")
+		return
+	}
+	conn, err := grpc.DialContext(ctx, "unix://"+daemonSocket, grpc.WithInsecure())
+	if err != nil {
+		log.WithError(err).Warn("cannot connect to ws-daemon - not watching disk usage")
+		return
+	}
+	defer conn.Close()
+
+	t := time.NewTicker(diskUsageCheckInterval)
+	defer t.Stop()
+
+	w := &diskUsageWatcher{
+		Client: daemonapi.NewInWorkspaceServiceClient(conn),
+		Notify: func(msg string) {
+			_, err := notificationService.Notify(ctx, &api.NotifyRequest{
+				Level:   api.NotifyRequest_WARNING,
+				Message: msg,
+			})
+			if err != nil && ctx.Err() == nil {
+				log.WithError(err).Warn("cannot notify user about disk usage")
+			}
+		},
+	}
+	for {
+		if !w.check(ctx) {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+type diskUsageWatcher struct {
+	Client diskUsageClient
+	Notify func(msg string)
+
+	warned     bool
+	lastNotice string
+}
+
+// check checks the disk usage once and returns false if there's no point in checking again
+func (w *diskUsageWatcher) check(ctx context.Context) (keepWatching bool) {
+	resp, err := w.Client.DiskUsage(ctx, &daemonapi.DiskUsageRequest{})
+	if status.Code(err) == codes.FailedPrecondition || status.Code(err) == codes.Unimplemented {
+		log.WithError(err).Debug("neither disk quota nor notices are available - not watching disk usage")
+		return false
+	}
+	if err != nil {
+		log.WithError(err).Debug("cannot get disk usage")
+		return true
+	}
+
+	if resp.Notice != w.lastNotice {
+		w.lastNotice = resp.Notice
+		if resp.Notice != "" {
+			go w.Notify(resp.Notice)
+		}
+	}
+	if resp.QuotaBytes <= 0 {
+		return true
+	}
+
+	if float64(resp.UsedBytes) < diskUsageWarningThreshold*float64(resp.QuotaBytes) {
+		// warn again should the usage grow beyond the threshold once more
+		w.warned = false
+		return true
+	}
+	if w.warned {
+		return true
+	}
+	w.warned = true
+
+	msg := fmt.Sprintf("Your workspace uses %s of its %s disk space. Once the limit is reached, writing files will fail.", humanize.IBytes(uint64(resp.UsedBytes)), humanize.IBytes(uint64(resp.QuotaBytes)))
+	// Notify blocks until the user responds, hence we must not wait for it
+	go w.Notify(msg)
+	return true
+}
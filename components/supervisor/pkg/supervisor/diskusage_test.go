@@ -0,0 +1,127 @@
+// Copyright (c) 2021 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package supervisor
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	daemonapi "github.com/gitpod-io/gitpod/ws-daemon/api"
+)
+
+type fakeDiskUsageClient struct {
+	Resp *daemonapi.DiskUsageResponse
+	Err  error
+}
+
+func (c *fakeDiskUsageClient) DiskUsage(ctx context.Context, in *daemonapi.DiskUsageRequest, opts ...grpc.CallOption) (*daemonapi.DiskUsageResponse, error) {
+	return c.Resp, c.Err
+}
+
+func TestDiskUsageWatcher(t *testing.T) {
+	type check struct {
+		Resp         *daemonapi.DiskUsageResponse
+		Err          error
+		KeepWatching bool
+	}
+	tests := []struct {
+		Name          string
+		Checks        []check
+		Notifications int
+	}{
+		{
+			Name:          "no quota",
+			Checks:        []check{{Err: status.Error(codes.FailedPrecondition, "no quota")}},
+			Notifications: 0,
+		},
+		{
+			Name: "below threshold",
+			Checks: []check{
+				{Resp: &daemonapi.DiskUsageResponse{UsedBytes: 10, QuotaBytes: 100}, KeepWatching: true},
+			},
+			Notifications: 0,
+		},
+		{
+			Name: "warns once",
+			Checks: []check{
+				{Resp: &daemonapi.DiskUsageResponse{UsedBytes: 95, QuotaBytes: 100}, KeepWatching: true},
+				{Resp: &daemonapi.DiskUsageResponse{UsedBytes: 97, QuotaBytes: 100}, KeepWatching: true},
+			},
+			Notifications: 1,
+		},
+		{
+			Name: "warns again after recovery",
+			Checks: []check{
+				{Resp: &daemonapi.DiskUsageResponse{UsedBytes: 95, QuotaBytes: 100}, KeepWatching: true},
+				{Resp: &daemonapi.DiskUsageResponse{UsedBytes: 50, QuotaBytes: 100}, KeepWatching: true},
+				{Resp: &daemonapi.DiskUsageResponse{UsedBytes: 95, QuotaBytes: 100}, KeepWatching: true},
+			},
+			Notifications: 2,
+		},
+		{
+			Name: "notice without quota",
+			Checks: []check{
+				{Resp: &daemonapi.DiskUsageResponse{}, KeepWatching: true},
+				{Resp: &daemonapi.DiskUsageResponse{Notice: "running out of space"}, KeepWatching: true},
+				{Resp: &daemonapi.DiskUsageResponse{Notice: "running out of space"}, KeepWatching: true},
+			},
+			Notifications: 1,
+		},
+		{
+			Name: "changed notice",
+			Checks: []check{
+				{Resp: &daemonapi.DiskUsageResponse{Notice: "running out of space"}, KeepWatching: true},
+				{Resp: &daemonapi.DiskUsageResponse{Notice: "still running out of space"}, KeepWatching: true},
+				{Resp: &daemonapi.DiskUsageResponse{}, KeepWatching: true},
+			},
+			Notifications: 2,
+		},
+		{
+			Name: "transient error",
+			Checks: []check{
+				{Err: status.Error(codes.ResourceExhausted, "rate limited"), KeepWatching: true},
+			},
+			Notifications: 0,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			var (
+				client = &fakeDiskUsageClient{}
+				wg     sync.WaitGroup
+				mu     sync.Mutex
+				msgs   []string
+			)
+			w := &diskUsageWatcher{
+				Client: client,
+				Notify: func(msg string) {
+					defer wg.Done()
+					mu.Lock()
+					msgs = append(msgs, msg)
+					mu.Unlock()
+				},
+			}
+			wg.Add(test.Notifications)
+
+			for i, c := range test.Checks {
+				client.Resp, client.Err = c.Resp, c.Err
+				if act := w.check(context.Background()); act != c.KeepWatching {
+					t.Errorf("check %d: unexpected keepWatching: expected %v, got %v", i, c.KeepWatching, act)
+				}
+			}
+			wg.Wait()
+
+			if len(msgs) != test.Notifications {
+				t.Errorf("unexpected notifications: expected %d, got %d: %v", test.Notifications, len(msgs), msgs)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright (c) 2021 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package supervisor
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/gitpod-io/gitpod/common-go/log"
+	gitpod "github.com/gitpod-io/gitpod/gitpod-protocol"
+	daemonapi "github.com/gitpod-io/gitpod/ws-daemon/api"
+)
+
+type hostAliasesClient interface {
+	SetHostAliases(ctx context.Context, in *daemonapi.SetHostAliasesRequest, opts ...grpc.CallOption) (*daemonapi.SetHostAliasesResponse, error)
+}
+
+// watchHostAliases applies the host aliases declared in the .gitpod.yml to the workspace's /etc/hosts
+// whenever they change.
+func watchHostAliases(ctx context.Context, cfgobs gitpod.ConfigInterface) {
+	if _, err := os.Stat(daemonSocket); err != nil {
+		log.WithError(err).Debug("ws-daemon socket is not available - not watching host aliases")
+		return
+	}
+	conn, err := grpc.DialContext(ctx, "unix://"+daemonSocket, grpc.WithInsecure())
+	if err != nil {
+		log.WithError(err).Warn("cannot connect to ws-daemon - not watching host aliases")
+		return
+	}
+	defer conn.Close()
+
+	w := &hostAliasesWatcher{Client: daemonapi.NewInWorkspaceServiceClient(conn)}
+	cfgs, errs := cfgobs.Observe(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case err, ok := <-errs:
+			if !ok {
+				return
+			}
+			log.WithError(err).Debug("cannot read .gitpod.yml - keeping host aliases")
+		case cfg, ok := <-cfgs:
+			if !ok {
+				return
+			}
+			if !w.update(ctx, cfg) {
+				return
+			}
+		}
+	}
+}
+
+type hostAliasesWatcher struct {
+	Client hostAliasesClient
+
+	applied []*gitpod.HostAliasesItems
+}
+
+// update applies the host aliases of cfg if they differ from what we applied last and returns false
+// if there's no point in updating again.
+func (w *hostAliasesWatcher) update(ctx context.Context, cfg *gitpod.GitpodConfig) (keepWatching bool) {
+	var aliases []*gitpod.HostAliasesItems
+	if cfg != nil {
+		aliases = cfg.HostAliases
+	}
+	if len(aliases) == 0 && len(w.applied) == 0 {
+		return true
+	}
+	if reflect.DeepEqual(aliases, w.applied) {
+		return true
+	}
+
+	req := &daemonapi.SetHostAliasesRequest{}
+	for _, a := range aliases {
+		if a == nil {
+			continue
+		}
+		req.Aliases = append(req.Aliases, &daemonapi.HostAlias{Ip: a.Ip, Hostnames: a.Hostnames})
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	_, err := w.Client.SetHostAliases(ctx, req)
+	switch status.Code(err) {
+	case codes.OK:
+		w.applied = aliases
+		log.WithField("aliases", len(req.Aliases)).Info("updated host aliases")
+	case codes.Unimplemented, codes.FailedPrecondition:
+		log.WithError(err).Warn("host aliases are not supported - not watching host aliases")
+		return false
+	case codes.InvalidArgument:
+		log.WithError(err).Warn("invalid host aliases in .gitpod.yml")
+	default:
+		log.WithError(err).Warn("cannot update host aliases")
+	}
+	return true
+}
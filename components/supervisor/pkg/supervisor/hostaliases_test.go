@@ -0,0 +1,116 @@
+// Copyright (c) 2021 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package supervisor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/testing/protocmp"
+
+	gitpod "github.com/gitpod-io/gitpod/gitpod-protocol"
+	daemonapi "github.com/gitpod-io/gitpod/ws-daemon/api"
+)
+
+type fakeHostAliasesClient struct {
+	Err  error
+	Reqs []*daemonapi.SetHostAliasesRequest
+}
+
+func (c *fakeHostAliasesClient) SetHostAliases(ctx context.Context, in *daemonapi.SetHostAliasesRequest, opts ...grpc.CallOption) (*daemonapi.SetHostAliasesResponse, error) {
+	c.Reqs = append(c.Reqs, in)
+	if c.Err != nil {
+		return nil, c.Err
+	}
+	return &daemonapi.SetHostAliasesResponse{}, nil
+}
+
+func TestHostAliasesWatcher(t *testing.T) {
+	var (
+		apiLocal = &gitpod.GitpodConfig{HostAliases: []*gitpod.HostAliasesItems{{Ip: "127.0.0.1", Hostnames: []string{"api.local"}}}}
+		dbLocal  = &gitpod.GitpodConfig{HostAliases: []*gitpod.HostAliasesItems{{Ip: "127.0.0.1", Hostnames: []string{"api.local", "db.local"}}}}
+	)
+
+	type update struct {
+		Config       *gitpod.GitpodConfig
+		Err          error
+		KeepWatching bool
+	}
+	tests := []struct {
+		Name        string
+		Updates     []update
+		Expectation []*daemonapi.SetHostAliasesRequest
+	}{
+		{
+			Name:    "no config",
+			Updates: []update{{Config: nil, KeepWatching: true}},
+		},
+		{
+			Name:    "no aliases",
+			Updates: []update{{Config: &gitpod.GitpodConfig{}, KeepWatching: true}},
+		},
+		{
+			Name: "aliases change",
+			Updates: []update{
+				{Config: apiLocal, KeepWatching: true},
+				{Config: apiLocal, KeepWatching: true},
+				{Config: dbLocal, KeepWatching: true},
+			},
+			Expectation: []*daemonapi.SetHostAliasesRequest{
+				{Aliases: []*daemonapi.HostAlias{{Ip: "127.0.0.1", Hostnames: []string{"api.local"}}}},
+				{Aliases: []*daemonapi.HostAlias{{Ip: "127.0.0.1", Hostnames: []string{"api.local", "db.local"}}}},
+			},
+		},
+		{
+			Name: "aliases removed",
+			Updates: []update{
+				{Config: apiLocal, KeepWatching: true},
+				{Config: &gitpod.GitpodConfig{}, KeepWatching: true},
+			},
+			Expectation: []*daemonapi.SetHostAliasesRequest{
+				{Aliases: []*daemonapi.HostAlias{{Ip: "127.0.0.1", Hostnames: []string{"api.local"}}}},
+				{},
+			},
+		},
+		{
+			Name: "retries after failure",
+			Updates: []update{
+				{Config: apiLocal, Err: status.Error(codes.InvalidArgument, "invalid"), KeepWatching: true},
+				{Config: apiLocal, KeepWatching: true},
+			},
+			Expectation: []*daemonapi.SetHostAliasesRequest{
+				{Aliases: []*daemonapi.HostAlias{{Ip: "127.0.0.1", Hostnames: []string{"api.local"}}}},
+				{Aliases: []*daemonapi.HostAlias{{Ip: "127.0.0.1", Hostnames: []string{"api.local"}}}},
+			},
+		},
+		{
+			Name:    "unsupported",
+			Updates: []update{{Config: apiLocal, Err: status.Error(codes.Unimplemented, "unimplemented"), KeepWatching: false}},
+			Expectation: []*daemonapi.SetHostAliasesRequest{
+				{Aliases: []*daemonapi.HostAlias{{Ip: "127.0.0.1", Hostnames: []string{"api.local"}}}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			client := &fakeHostAliasesClient{}
+			w := &hostAliasesWatcher{Client: client}
+			for i, u := range test.Updates {
+				client.Err = u.Err
+				if keepWatching := w.update(context.Background(), u.Config); keepWatching != u.KeepWatching {
+					t.Errorf("update %d: unexpected keepWatching: expected %v, got %v", i, u.KeepWatching, keepWatching)
+				}
+			}
+			if diff := cmp.Diff(test.Expectation, client.Reqs, protocmp.Transform()); diff != "" {
+				t.Errorf("unexpected requests (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
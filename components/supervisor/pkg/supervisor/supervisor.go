@@ -178,7 +178,7 @@ func Run(options ...RunOption) {
 			&ports.PollingServedPortsObserver{
 				RefreshInterval: 2 * time.Second,
 			},
-			ports.NewConfigService(cfg.WorkspaceID, gitpodConfigService, gitpodService),
+			ports.NewConfigService(cfg.WorkspaceID, gitpodConfigService, gitpodService, servicePorts(cfg)...),
 			tunneledPortsService,
 			uint32(cfg.IDEPort),
 			uint32(cfg.APIEndpointPort),
@@ -194,6 +194,7 @@ func Run(options ...RunOption) {
 
 	defer analytics.Close()
 	go analyseConfigChanges(ctx, cfg, analytics, gitpodConfigService)
+	go watchHostAliases(ctx, gitpodConfigService)
 
 	termMuxSrv.DefaultWorkdir = cfg.RepoRoot
 	if cfg.WorkspaceRoot != "" {
@@ -263,6 +264,7 @@ func Run(options ...RunOption) {
 	} else {
 		wg.Add(1)
 		go portMgmt.Run(ctx, &wg)
+		go watchDiskUsage(ctx, notificationService)
 	}
 
 	if cfg.PreventMetadataAccess {
@@ -299,6 +301,19 @@ func Run(options ...RunOption) {
 	wg.Wait()
 }
 
+func servicePorts(cfg *Config) []uint32 {
+	services, err := cfg.GetServicePorts()
+	if err != nil {
+		log.WithError(err).Warn("cannot parse service ports")
+		return nil
+	}
+	res := make([]uint32, 0, len(services))
+	for port := range services {
+		res = append(res, port)
+	}
+	return res
+}
+
 func createGitpodService(cfg *Config, tknsrv api.TokenServiceServer) *gitpod.APIoverJSONRPC {
 	endpoint, host, err := cfg.GitpodAPIEndpoint()
 	if err != nil {
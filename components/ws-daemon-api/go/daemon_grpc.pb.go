@@ -224,3 +224,92 @@ var WorkspaceContentService_ServiceDesc = grpc.ServiceDesc{
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "daemon.proto",
 }
+
+// WorkspaceIntrospectionServiceClient is the client API for WorkspaceIntrospectionService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type WorkspaceIntrospectionServiceClient interface {
+	// ListWorkspaces lists the workspaces on this node together with everything the daemon knows about them
+	ListWorkspaces(ctx context.Context, in *ListWorkspacesRequest, opts ...grpc.CallOption) (*ListWorkspacesResponse, error)
+}
+
+type workspaceIntrospectionServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewWorkspaceIntrospectionServiceClient(cc grpc.ClientConnInterface) WorkspaceIntrospectionServiceClient {
+	return &workspaceIntrospectionServiceClient{cc}
+}
+
+func (c *workspaceIntrospectionServiceClient) ListWorkspaces(ctx context.Context, in *ListWorkspacesRequest, opts ...grpc.CallOption) (*ListWorkspacesResponse, error) {
+	out := new(ListWorkspacesResponse)
+	err := c.cc.Invoke(ctx, "/wsdaemon.WorkspaceIntrospectionService/ListWorkspaces", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// WorkspaceIntrospectionServiceServer is the server API for WorkspaceIntrospectionService service.
+// All implementations must embed UnimplementedWorkspaceIntrospectionServiceServer
+// for forward compatibility
+type WorkspaceIntrospectionServiceServer interface {
+	// ListWorkspaces lists the workspaces on this node together with everything the daemon knows about them
+	ListWorkspaces(context.Context, *ListWorkspacesRequest) (*ListWorkspacesResponse, error)
+	mustEmbedUnimplementedWorkspaceIntrospectionServiceServer()
+}
+
+// UnimplementedWorkspaceIntrospectionServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedWorkspaceIntrospectionServiceServer struct {
+}
+
+func (UnimplementedWorkspaceIntrospectionServiceServer) ListWorkspaces(context.Context, *ListWorkspacesRequest) (*ListWorkspacesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListWorkspaces not implemented")
+}
+func (UnimplementedWorkspaceIntrospectionServiceServer) mustEmbedUnimplementedWorkspaceIntrospectionServiceServer() {
+}
+
+// UnsafeWorkspaceIntrospectionServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to WorkspaceIntrospectionServiceServer will
+// result in compilation errors.
+type UnsafeWorkspaceIntrospectionServiceServer interface {
+	mustEmbedUnimplementedWorkspaceIntrospectionServiceServer()
+}
+
+func RegisterWorkspaceIntrospectionServiceServer(s grpc.ServiceRegistrar, srv WorkspaceIntrospectionServiceServer) {
+	s.RegisterService(&WorkspaceIntrospectionService_ServiceDesc, srv)
+}
+
+func _WorkspaceIntrospectionService_ListWorkspaces_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListWorkspacesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WorkspaceIntrospectionServiceServer).ListWorkspaces(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/wsdaemon.WorkspaceIntrospectionService/ListWorkspaces",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WorkspaceIntrospectionServiceServer).ListWorkspaces(ctx, req.(*ListWorkspacesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// WorkspaceIntrospectionService_ServiceDesc is the grpc.ServiceDesc for WorkspaceIntrospectionService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var WorkspaceIntrospectionService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "wsdaemon.WorkspaceIntrospectionService",
+	HandlerType: (*WorkspaceIntrospectionServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListWorkspaces",
+			Handler:    _WorkspaceIntrospectionService_ListWorkspaces_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "daemon.proto",
+}
@@ -239,6 +239,26 @@ func (m *MockInWorkspaceServiceClient) EXPECT() *MockInWorkspaceServiceClientMoc
 	return m.recorder
 }
 
+// DiskUsage mocks base method.
+func (m *MockInWorkspaceServiceClient) DiskUsage(arg0 context.Context, arg1 *api.DiskUsageRequest, arg2 ...grpc.CallOption) (*api.DiskUsageResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "DiskUsage", varargs...)
+	ret0, _ := ret[0].(*api.DiskUsageResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DiskUsage indicates an expected call of DiskUsage.
+func (mr *MockInWorkspaceServiceClientMockRecorder) DiskUsage(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DiskUsage", reflect.TypeOf((*MockInWorkspaceServiceClient)(nil).DiskUsage), varargs...)
+}
+
 // MountProc mocks base method.
 func (m *MockInWorkspaceServiceClient) MountProc(arg0 context.Context, arg1 *api.MountProcRequest, arg2 ...grpc.CallOption) (*api.MountProcResponse, error) {
 	m.ctrl.T.Helper()
@@ -299,6 +319,26 @@ func (mr *MockInWorkspaceServiceClientMockRecorder) PrepareForUserNS(arg0, arg1
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PrepareForUserNS", reflect.TypeOf((*MockInWorkspaceServiceClient)(nil).PrepareForUserNS), varargs...)
 }
 
+// SetHostAliases mocks base method.
+func (m *MockInWorkspaceServiceClient) SetHostAliases(arg0 context.Context, arg1 *api.SetHostAliasesRequest, arg2 ...grpc.CallOption) (*api.SetHostAliasesResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "SetHostAliases", varargs...)
+	ret0, _ := ret[0].(*api.SetHostAliasesResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetHostAliases indicates an expected call of SetHostAliases.
+func (mr *MockInWorkspaceServiceClientMockRecorder) SetHostAliases(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetHostAliases", reflect.TypeOf((*MockInWorkspaceServiceClient)(nil).SetHostAliases), varargs...)
+}
+
 // Teardown mocks base method.
 func (m *MockInWorkspaceServiceClient) Teardown(arg0 context.Context, arg1 *api.TeardownRequest, arg2 ...grpc.CallOption) (*api.TeardownResponse, error) {
 	m.ctrl.T.Helper()
@@ -51,6 +51,12 @@ type InWorkspaceServiceClient interface {
 	// Teardown prepares workspace content backups and unmounts shiftfs mounts. The canary is supposed to be triggered
 	// when the workspace is about to shut down, e.g. using the PreStop hook of a Kubernetes container.
 	Teardown(ctx context.Context, in *TeardownRequest, opts ...grpc.CallOption) (*TeardownResponse, error)
+	// DiskUsage reports the disk space used by the workspace content and the quota enforced on it.
+	// Supervisor uses this to warn users who are about to run out of disk space.
+	DiskUsage(ctx context.Context, in *DiskUsageRequest, opts ...grpc.CallOption) (*DiskUsageResponse, error)
+	// SetHostAliases replaces the host aliases in the workspace's /etc/hosts file with the ones in the request.
+	// Other entries of the hosts file remain untouched. Pass no aliases to remove all previously set ones.
+	SetHostAliases(ctx context.Context, in *SetHostAliasesRequest, opts ...grpc.CallOption) (*SetHostAliasesResponse, error)
 }
 
 type inWorkspaceServiceClient struct {
@@ -124,6 +130,24 @@ func (c *inWorkspaceServiceClient) Teardown(ctx context.Context, in *TeardownReq
 	return out, nil
 }
 
+func (c *inWorkspaceServiceClient) DiskUsage(ctx context.Context, in *DiskUsageRequest, opts ...grpc.CallOption) (*DiskUsageResponse, error) {
+	out := new(DiskUsageResponse)
+	err := c.cc.Invoke(ctx, "/iws.InWorkspaceService/DiskUsage", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *inWorkspaceServiceClient) SetHostAliases(ctx context.Context, in *SetHostAliasesRequest, opts ...grpc.CallOption) (*SetHostAliasesResponse, error) {
+	out := new(SetHostAliasesResponse)
+	err := c.cc.Invoke(ctx, "/iws.InWorkspaceService/SetHostAliases", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // InWorkspaceServiceServer is the server API for InWorkspaceService service.
 // All implementations must embed UnimplementedInWorkspaceServiceServer
 // for forward compatibility
@@ -157,6 +181,12 @@ type InWorkspaceServiceServer interface {
 	// Teardown prepares workspace content backups and unmounts shiftfs mounts. The canary is supposed to be triggered
 	// when the workspace is about to shut down, e.g. using the PreStop hook of a Kubernetes container.
 	Teardown(context.Context, *TeardownRequest) (*TeardownResponse, error)
+	// DiskUsage reports the disk space used by the workspace content and the quota enforced on it.
+	// Supervisor uses this to warn users who are about to run out of disk space.
+	DiskUsage(context.Context, *DiskUsageRequest) (*DiskUsageResponse, error)
+	// SetHostAliases replaces the host aliases in the workspace's /etc/hosts file with the ones in the request.
+	// Other entries of the hosts file remain untouched. Pass no aliases to remove all previously set ones.
+	SetHostAliases(context.Context, *SetHostAliasesRequest) (*SetHostAliasesResponse, error)
 	mustEmbedUnimplementedInWorkspaceServiceServer()
 }
 
@@ -185,6 +215,12 @@ func (UnimplementedInWorkspaceServiceServer) UmountSysfs(context.Context, *Umoun
 func (UnimplementedInWorkspaceServiceServer) Teardown(context.Context, *TeardownRequest) (*TeardownResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Teardown not implemented")
 }
+func (UnimplementedInWorkspaceServiceServer) DiskUsage(context.Context, *DiskUsageRequest) (*DiskUsageResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DiskUsage not implemented")
+}
+func (UnimplementedInWorkspaceServiceServer) SetHostAliases(context.Context, *SetHostAliasesRequest) (*SetHostAliasesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetHostAliases not implemented")
+}
 func (UnimplementedInWorkspaceServiceServer) mustEmbedUnimplementedInWorkspaceServiceServer() {}
 
 // UnsafeInWorkspaceServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -324,6 +360,42 @@ func _InWorkspaceService_Teardown_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _InWorkspaceService_DiskUsage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DiskUsageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InWorkspaceServiceServer).DiskUsage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/iws.InWorkspaceService/DiskUsage",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InWorkspaceServiceServer).DiskUsage(ctx, req.(*DiskUsageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _InWorkspaceService_SetHostAliases_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetHostAliasesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InWorkspaceServiceServer).SetHostAliases(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/iws.InWorkspaceService/SetHostAliases",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InWorkspaceServiceServer).SetHostAliases(ctx, req.(*SetHostAliasesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // InWorkspaceService_ServiceDesc is the grpc.ServiceDesc for InWorkspaceService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -359,6 +431,14 @@ var InWorkspaceService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Teardown",
 			Handler:    _InWorkspaceService_Teardown_Handler,
 		},
+		{
+			MethodName: "DiskUsage",
+			Handler:    _InWorkspaceService_DiskUsage_Handler,
+		},
+		{
+			MethodName: "SetHostAliases",
+			Handler:    _InWorkspaceService_SetHostAliases_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "workspace_daemon.proto",
@@ -0,0 +1,94 @@
+// Copyright (c) 2021 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/gitpod-io/gitpod/common-go/log"
+	"github.com/gitpod-io/gitpod/ws-daemon/api"
+)
+
+var clientWorkspacesOpts struct {
+	JSON bool
+}
+
+// clientWorkspacesCmd lists the workspaces a ws-daemon manages
+var clientWorkspacesCmd = &cobra.Command{
+	Use:   "workspaces [id]",
+	Short: "lists the workspaces on a node and what ws-daemon knows about them",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var req api.ListWorkspacesRequest
+		if len(args) > 0 {
+			req.Id = args[0]
+		}
+
+		conn, err := getGRPCConnection()
+		if err != nil {
+			log.WithError(err).Fatal("cannot connect")
+		}
+		defer conn.Close()
+
+		client := api.NewWorkspaceIntrospectionServiceClient(conn)
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		resp, err := client.ListWorkspaces(ctx, &req)
+		if err != nil {
+			log.WithError(err).Fatal("error during RPC call")
+		}
+
+		if clientWorkspacesOpts.JSON {
+			out, err := protojson.MarshalOptions{Multiline: true, Indent: "  "}.Marshal(resp)
+			if err != nil {
+				log.WithError(err).Fatal("cannot marshal response")
+			}
+			fmt.Println(string(out))
+			return
+		}
+
+		tw := tabwriter.NewWriter(os.Stdout, 2, 4, 1, ' ', 0)
+		defer tw.Flush()
+		fmt.Fprintln(tw, "ID\tSTATE\tSOURCE\tCONTAINER\tCPU LIMIT\tDISK\tBACKUP\tAGE")
+		for _, ws := range resp.Workspaces {
+			cid := ws.ContainerId
+			if len(cid) > 12 {
+				cid = cid[:12]
+			}
+
+			cpu := "-"
+			if ws.CpuLimit > 0 {
+				// the limit is expressed in jiffies/sec, i.e. 100 is one CPU
+				cpu = fmt.Sprintf("%.2f", float64(ws.CpuLimit)/100)
+			}
+
+			disk := humanize.IBytes(uint64(ws.DiskUsedBytes))
+			if ws.DiskQuotaBytes > 0 {
+				disk += "/" + humanize.IBytes(uint64(ws.DiskQuotaBytes))
+			}
+
+			backup := "-"
+			if ws.Backup != nil {
+				backup = fmt.Sprintf("%s (%s)", ws.Backup.Phase, time.Since(ws.Backup.StartedAt.AsTime()).Round(time.Second))
+			}
+
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", ws.Id, ws.State, ws.InitSource, cid, cpu, disk, backup, time.Since(ws.CreatedAt.AsTime()).Round(time.Second))
+		}
+	},
+}
+
+func init() {
+	clientWorkspacesCmd.Flags().BoolVar(&clientWorkspacesOpts.JSON, "json", false, "print the complete introspection as JSON")
+	clientCmd.AddCommand(clientWorkspacesCmd)
+}
@@ -0,0 +1,200 @@
+// Copyright (c) 2021 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package main
+
+import (
+	"encoding/json"
+	"net"
+
+	"github.com/google/nftables"
+	"github.com/google/nftables/binaryutil"
+	"github.com/google/nftables/expr"
+	"golang.org/x/sys/unix"
+	"golang.org/x/xerrors"
+)
+
+// egressTableName is the nftables table which holds the egress policy of a workspace
+const egressTableName = "gitpod_egress"
+
+// egressPolicy is the egress policy ws-daemon passes to nsinsider. All hostnames are resolved by then.
+type egressPolicy struct {
+	DefaultDeny bool         `json:"defaultDeny"`
+	Required    []egressRule `json:"required,omitempty"`
+	Allow       []egressRule `json:"allow,omitempty"`
+	Deny        []egressRule `json:"deny,omitempty"`
+}
+
+type egressRule struct {
+	CIDRs []string `json:"cidrs"`
+	Ports []uint16 `json:"ports,omitempty"`
+}
+
+// setEgressPolicy replaces the egress policy of the current network namespace. The workspace user's
+// capabilities stem from a user namespace which does not own the network namespace, hence they cannot
+// modify the table we create here.
+func setEgressPolicy(rawPolicy string) error {
+	var policy egressPolicy
+	err := json.Unmarshal([]byte(rawPolicy), &policy)
+	if err != nil {
+		return xerrors.Errorf("cannot unmarshal egress policy: %w", err)
+	}
+
+	rules, err := egressPolicyRules(&policy)
+	if err != nil {
+		return err
+	}
+
+	conn, err := nftables.New()
+	if err != nil {
+		return xerrors.Errorf("cannot connect to nftables: %w", err)
+	}
+
+	// Adding a table which exists already is a no-op, hence adding, deleting and re-adding the
+	// table replaces a previous policy within a single, atomic transaction.
+	table := &nftables.Table{Name: egressTableName, Family: nftables.TableFamilyINet}
+	conn.AddTable(table)
+	conn.DelTable(table)
+	conn.AddTable(table)
+
+	for _, hook := range []nftables.ChainHook{nftables.ChainHookOutput, nftables.ChainHookForward} {
+		name := "output"
+		if hook == nftables.ChainHookForward {
+			name = "forward"
+		}
+		chain := conn.AddChain(&nftables.Chain{
+			Name:     name,
+			Table:    table,
+			Type:     nftables.ChainTypeFilter,
+			Hooknum:  hook,
+			Priority: nftables.ChainPriorityFilter,
+		})
+		for _, e := range rules {
+			conn.AddRule(&nftables.Rule{Table: table, Chain: chain, Exprs: e})
+		}
+	}
+
+	err = conn.Flush()
+	if err != nil {
+		return xerrors.Errorf("cannot apply egress policy: %w", err)
+	}
+	return nil
+}
+
+// egressPolicyRules produces the rules of an egress chain. Loopback traffic, replies to established
+// connections and the required destinations are always accepted. Deny rules come before allow rules
+// so that they take precedence.
+func egressPolicyRules(policy *egressPolicy) ([][]expr.Any, error) {
+	var (
+		accept = &expr.Verdict{Kind: expr.VerdictAccept}
+		// rejecting rather than dropping makes connection attempts fail right away instead of timing out
+		reject = &expr.Reject{Type: unix.NFT_REJECT_ICMPX_UNREACH, Code: unix.NFT_REJECT_ICMPX_ADMIN_PROHIBITED}
+	)
+
+	res := [][]expr.Any{
+		{
+			&expr.Meta{Key: expr.MetaKeyOIFNAME, Register: 1},
+			&expr.Cmp{Op: expr.CmpOpEq, Register: 1, Data: ifname("lo")},
+			accept,
+		},
+		{
+			&expr.Ct{Register: 1, Key: expr.CtKeySTATE},
+			&expr.Bitwise{
+				SourceRegister: 1,
+				DestRegister:   1,
+				Len:            4,
+				Mask:           binaryutil.NativeEndian.PutUint32(expr.CtStateBitESTABLISHED | expr.CtStateBitRELATED),
+				Xor:            binaryutil.NativeEndian.PutUint32(0),
+			},
+			&expr.Cmp{Op: expr.CmpOpNeq, Register: 1, Data: binaryutil.NativeEndian.PutUint32(0)},
+			accept,
+		},
+	}
+
+	for _, r := range []struct {
+		Rules   []egressRule
+		Verdict expr.Any
+	}{
+		{policy.Required, accept},
+		{policy.Deny, reject},
+		{policy.Allow, accept},
+	} {
+		for _, rule := range r.Rules {
+			for _, c := range rule.CIDRs {
+				dst, err := matchDestination(c)
+				if err != nil {
+					return nil, err
+				}
+				if len(rule.Ports) == 0 {
+					res = append(res, append(dst, r.Verdict))
+					continue
+				}
+				for _, proto := range []byte{unix.IPPROTO_TCP, unix.IPPROTO_UDP} {
+					for _, port := range rule.Ports {
+						e := append([]expr.Any{}, dst...)
+						e = append(e,
+							&expr.Meta{Key: expr.MetaKeyL4PROTO, Register: 1},
+							&expr.Cmp{Op: expr.CmpOpEq, Register: 1, Data: []byte{proto}},
+							&expr.Payload{DestRegister: 1, Base: expr.PayloadBaseTransportHeader, Offset: 2, Len: 2},
+							&expr.Cmp{Op: expr.CmpOpEq, Register: 1, Data: binaryutil.BigEndian.PutUint16(port)},
+							r.Verdict,
+						)
+						res = append(res, e)
+					}
+				}
+			}
+		}
+	}
+
+	if policy.DefaultDeny {
+		res = append(res, []expr.Any{reject})
+	}
+	return res, nil
+}
+
+// matchDestination produces the expressions which match packets whose destination address lies within cidr
+func matchDestination(cidr string) ([]expr.Any, error) {
+	_, ipnet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return nil, xerrors.Errorf("invalid CIDR %s: %w", cidr, err)
+	}
+
+	var (
+		nfproto byte
+		offset  uint32
+		addr    = ipnet.IP.To4()
+		mask    = ipnet.Mask
+	)
+	if addr != nil {
+		// the destination address is at offset 16 of the IPv4 header
+		nfproto, offset = unix.NFPROTO_IPV4, 16
+		if len(mask) == net.IPv6len {
+			mask = mask[12:]
+		}
+	} else {
+		// the destination address is at offset 24 of the IPv6 header
+		nfproto, offset, addr = unix.NFPROTO_IPV6, 24, ipnet.IP.To16()
+	}
+
+	return []expr.Any{
+		&expr.Meta{Key: expr.MetaKeyNFPROTO, Register: 1},
+		&expr.Cmp{Op: expr.CmpOpEq, Register: 1, Data: []byte{nfproto}},
+		&expr.Payload{DestRegister: 1, Base: expr.PayloadBaseNetworkHeader, Offset: offset, Len: uint32(len(addr))},
+		&expr.Bitwise{
+			SourceRegister: 1,
+			DestRegister:   1,
+			Len:            uint32(len(addr)),
+			Mask:           mask,
+			Xor:            make([]byte, len(addr)),
+		},
+		&expr.Cmp{Op: expr.CmpOpEq, Register: 1, Data: addr},
+	}, nil
+}
+
+// ifname pads an interface name the way the kernel stores it
+func ifname(n string) []byte {
+	b := make([]byte, unix.IFNAMSIZ)
+	copy(b, n)
+	return b
+}
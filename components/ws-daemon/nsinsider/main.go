@@ -6,6 +6,7 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -259,6 +260,36 @@ func main() {
 					return nil
 				},
 			},
+			{
+				Name:  "set-hosts",
+				Usage: "replaces all /etc/hosts entries carrying a marker",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:     "marker",
+						Required: true,
+					},
+					&cli.StringSliceFlag{
+						Name: "entry",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					return setHosts("/etc/hosts", c.String("marker"), c.StringSlice("entry"))
+				},
+			},
+			{
+				Name:  "set-egress-policy",
+				Usage: "replaces the egress policy of the network namespace",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:     "policy",
+						Usage:    "the egress policy in JSON",
+						Required: true,
+					},
+				},
+				Action: func(c *cli.Context) error {
+					return setEgressPolicy(c.String("policy"))
+				},
+			},
 		},
 	}
 
@@ -311,3 +342,55 @@ const (
 	// FlagAtRecursive: Apply to the entire subtree: https://elixir.bootlin.com/linux/latest/source/include/uapi/linux/fcntl.h#L112
 	flagAtRecursive = 0x8000
 )
+
+// setHosts removes all lines containing marker from the hosts file and appends the entries instead.
+// The hosts file is typically bind-mounted into the container, hence we must modify it in place rather
+// than replace it. It lives in a filesystem the workspace user controls, so we refuse to follow symlinks.
+func setHosts(fn, marker string, entries []string) error {
+	fd, err := unix.Openat2(unix.AT_FDCWD, fn, &unix.OpenHow{
+		Flags:   unix.O_RDWR | unix.O_CLOEXEC,
+		Resolve: unix.RESOLVE_NO_SYMLINKS | unix.RESOLVE_NO_MAGICLINKS,
+	})
+	if err != nil {
+		return xerrors.Errorf("cannot open %s: %w", fn, err)
+	}
+	f := os.NewFile(uintptr(fd), fn)
+	defer f.Close()
+
+	stat, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if !stat.Mode().IsRegular() {
+		return xerrors.Errorf("%s is not a regular file", fn)
+	}
+
+	content, err := io.ReadAll(f)
+	if err != nil {
+		return err
+	}
+
+	var lines []string
+	if len(content) > 0 {
+		for _, l := range strings.Split(strings.TrimSuffix(string(content), "\n"), "\n") {
+			if strings.Contains(l, marker) {
+				continue
+			}
+			lines = append(lines, l)
+		}
+	}
+	for _, e := range entries {
+		lines = append(lines, e+marker)
+	}
+
+	err = f.Truncate(0)
+	if err != nil {
+		return err
+	}
+	_, err = f.WriteAt([]byte(strings.Join(lines, "\n")+"\n"), 0)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,29 @@
+// Copyright (c) 2021 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package cgroup
+
+import (
+	"golang.org/x/sys/unix"
+	"golang.org/x/xerrors"
+)
+
+// DefaultBasePath is where the cgroup hierarchy is usually mounted
+const DefaultBasePath = "/sys/fs/cgroup"
+
+// IsUnifiedCgroupSetup returns true if the cgroup hierarchy mounted at basePath
+// is the unified (cgroup v2) hierarchy.
+func IsUnifiedCgroupSetup(basePath string) (bool, error) {
+	if basePath == "" {
+		basePath = DefaultBasePath
+	}
+
+	var st unix.Statfs_t
+	err := unix.Statfs(basePath, &st)
+	if err != nil {
+		return false, xerrors.Errorf("cannot statfs cgroup base path %s: %w", basePath, err)
+	}
+
+	return st.Type == unix.CGROUP2_SUPER_MAGIC, nil
+}
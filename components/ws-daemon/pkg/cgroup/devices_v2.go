@@ -0,0 +1,185 @@
+// Copyright (c) 2021 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package cgroup
+
+import (
+	"runtime"
+	"unsafe"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/link"
+	cgroupsv2 "github.com/containerd/cgroups/v2"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"golang.org/x/sys/unix"
+	"golang.org/x/xerrors"
+)
+
+var (
+	wildcard int64 = -1
+
+	// DefaultDevices are the devices a container can access by default. This mirrors the list runc adds to the devices
+	// of the OCI spec (see libcontainer/specconv) because on the unified hierarchy we replace runc's device filter program.
+	DefaultDevices = []specs.LinuxDeviceCgroup{
+		// allow mknod for any device
+		{Type: "c", Major: &wildcard, Minor: &wildcard, Access: "m", Allow: true},
+		{Type: "b", Major: &wildcard, Minor: &wildcard, Access: "m", Allow: true},
+		// /dev/null
+		deviceRule(1, 3),
+		// /dev/random
+		deviceRule(1, 8),
+		// /dev/full
+		deviceRule(1, 7),
+		// /dev/tty
+		deviceRule(5, 0),
+		// /dev/zero
+		deviceRule(1, 5),
+		// /dev/urandom
+		deviceRule(1, 9),
+		// /dev/console
+		deviceRule(5, 1),
+		// /dev/pts/*
+		{Type: "c", Major: int64Ptr(136), Minor: &wildcard, Access: "rwm", Allow: true},
+		// /dev/ptmx
+		deviceRule(5, 2),
+		// /dev/net/tun
+		deviceRule(10, 200),
+	}
+)
+
+func deviceRule(major, minor int64) specs.LinuxDeviceCgroup {
+	return specs.LinuxDeviceCgroup{Type: "c", Major: &major, Minor: &minor, Access: "rwm", Allow: true}
+}
+
+func int64Ptr(v int64) *int64 {
+	return &v
+}
+
+// ReplaceDeviceFilter installs an eBPF device filter program which allows access to the devices the container
+// runtime granted, the default devices and the ones listed, and removes all device filter programs that were
+// attached to the cgroup before.
+//
+// On the unified hierarchy device access is controlled by eBPF programs attached to the cgroup. When multiple
+// programs are attached, all of them must allow access to a device. Hence, we cannot just add another program,
+// but have to replace the one the container runtime installed - with one that keeps granting what it granted.
+func ReplaceDeviceFilter(cgroupPath string, granted, devices []specs.LinuxDeviceCgroup) error {
+	insts, license, err := cgroupsv2.DeviceFilter(deviceFilterRules(granted, devices))
+	if err != nil {
+		return xerrors.Errorf("cannot produce device filter: %w", err)
+	}
+
+	dirFD, err := unix.Open(cgroupPath, unix.O_DIRECTORY|unix.O_RDONLY, 0600)
+	if err != nil {
+		return xerrors.Errorf("cannot open cgroup %s: %w", cgroupPath, err)
+	}
+	defer unix.Close(dirFD)
+
+	existing, err := queryDeviceFilterPrograms(dirFD)
+	if err != nil {
+		return xerrors.Errorf("cannot list device filter programs: %w", err)
+	}
+
+	prog, err := ebpf.NewProgram(&ebpf.ProgramSpec{
+		Type:         ebpf.CGroupDevice,
+		Instructions: insts,
+		License:      license,
+	})
+	if err != nil {
+		return xerrors.Errorf("cannot load device filter: %w", err)
+	}
+	// once attached the kernel keeps the program alive
+	defer prog.Close()
+
+	err = link.RawAttachProgram(link.RawAttachProgramOptions{
+		Target:  dirFD,
+		Program: prog,
+		Attach:  ebpf.AttachCGroupDevice,
+		Flags:   unix.BPF_F_ALLOW_MULTI,
+	})
+	if err != nil {
+		return xerrors.Errorf("cannot attach device filter: %w", err)
+	}
+
+	for _, id := range existing {
+		old, err := ebpf.NewProgramFromID(id)
+		if err != nil {
+			return xerrors.Errorf("cannot load device filter program %d: %w", id, err)
+		}
+		err = link.RawDetachProgram(link.RawDetachProgramOptions{
+			Target:  dirFD,
+			Program: old,
+			Attach:  ebpf.AttachCGroupDevice,
+		})
+		old.Close()
+		if err != nil {
+			return xerrors.Errorf("cannot detach device filter program %d: %w", id, err)
+		}
+	}
+
+	return nil
+}
+
+// deviceFilterRules produces the rules of a device filter which grants what the container runtime granted, plus
+// the default devices and the ones listed. Like runc, we add the default devices after the rules of the OCI spec.
+// Later rules take precedence over earlier ones, hence the spec's initial deny-all rule does not affect them.
+func deviceFilterRules(granted, devices []specs.LinuxDeviceCgroup) []specs.LinuxDeviceCgroup {
+	rules := make([]specs.LinuxDeviceCgroup, 0, len(granted)+len(DefaultDevices)+len(devices))
+	for _, r := range granted {
+		rules = append(rules, normalizeDeviceRule(r))
+	}
+	rules = append(rules, DefaultDevices...)
+	rules = append(rules, devices...)
+	return rules
+}
+
+// normalizeDeviceRule fills in what the OCI runtime spec implies for omitted fields:
+// an omitted type, major or minor matches all devices, an omitted access means rwm.
+func normalizeDeviceRule(r specs.LinuxDeviceCgroup) specs.LinuxDeviceCgroup {
+	if r.Type == "" {
+		r.Type = "a"
+	}
+	if r.Major == nil {
+		r.Major = &wildcard
+	}
+	if r.Minor == nil {
+		r.Minor = &wildcard
+	}
+	if r.Access == "" {
+		r.Access = "rwm"
+	}
+	return r
+}
+
+// bpfProgQueryAttr is the BPF_PROG_QUERY part of union bpf_attr
+type bpfProgQueryAttr struct {
+	TargetFD    uint32
+	AttachType  uint32
+	QueryFlags  uint32
+	AttachFlags uint32
+	ProgIDs     uint64
+	ProgCnt     uint32
+}
+
+// queryDeviceFilterPrograms lists the IDs of the device filter programs attached to a cgroup
+func queryDeviceFilterPrograms(dirFD int) ([]ebpf.ProgramID, error) {
+	// a cgroup can have at most 64 programs attached per attach type (BPF_CGROUP_MAX_PROGS)
+	ids := make([]uint32, 64)
+	attr := bpfProgQueryAttr{
+		TargetFD:   uint32(dirFD),
+		AttachType: uint32(ebpf.AttachCGroupDevice),
+		ProgIDs:    uint64(uintptr(unsafe.Pointer(&ids[0]))),
+		ProgCnt:    uint32(len(ids)),
+	}
+	_, _, errno := unix.Syscall(unix.SYS_BPF, unix.BPF_PROG_QUERY, uintptr(unsafe.Pointer(&attr)), unsafe.Sizeof(attr))
+	runtime.KeepAlive(ids)
+	if errno != 0 {
+		return nil, errno
+	}
+
+	res := make([]ebpf.ProgramID, attr.ProgCnt)
+	for i := range res {
+		res[i] = ebpf.ProgramID(ids[i])
+	}
+	return res, nil
+}
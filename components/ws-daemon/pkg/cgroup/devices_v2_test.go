@@ -0,0 +1,42 @@
+// Copyright (c) 2021 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package cgroup
+
+import (
+	"testing"
+
+	cgroupsv2 "github.com/containerd/cgroups/v2"
+	"github.com/google/go-cmp/cmp"
+	"github.com/opencontainers/runtime-spec/specs-go"
+)
+
+func TestDeviceFilterRules(t *testing.T) {
+	var (
+		nvidiaMajor int64 = 195
+		nvidiaMinor int64 = 0
+		fuse              = deviceRule(10, 229)
+	)
+	// this is what containerd puts in the OCI spec of a container which got a GPU from a device plugin
+	granted := []specs.LinuxDeviceCgroup{
+		{Allow: false, Access: "rwm"},
+		{Allow: true, Type: "c", Major: &nvidiaMajor, Minor: &nvidiaMinor, Access: "rw"},
+	}
+
+	rules := deviceFilterRules(granted, []specs.LinuxDeviceCgroup{fuse})
+
+	expectation := append([]specs.LinuxDeviceCgroup{
+		{Allow: false, Type: "a", Major: &wildcard, Minor: &wildcard, Access: "rwm"},
+		{Allow: true, Type: "c", Major: &nvidiaMajor, Minor: &nvidiaMinor, Access: "rw"},
+	}, DefaultDevices...)
+	expectation = append(expectation, fuse)
+	if diff := cmp.Diff(expectation, rules); diff != "" {
+		t.Errorf("unexpected rules (-want +got):\n%s", diff)
+	}
+
+	_, _, err := cgroupsv2.DeviceFilter(rules)
+	if err != nil {
+		t.Errorf("cannot produce device filter: %v", err)
+	}
+}
@@ -10,6 +10,8 @@ import (
 	"strings"
 
 	"golang.org/x/xerrors"
+
+	"github.com/gitpod-io/gitpod/common-go/util"
 )
 
 // NodeMountsLookupConfig confiugures the node mount/fs access
@@ -33,6 +35,9 @@ type Config struct {
 
 	// Containerd contains the containerd CRI config if runtime == RuntimeContainerd
 	Containerd *ContainerdConfig `json:"containerd,omitempty"`
+
+	// CRI contains the CRI config if runtime == RuntimeCRI
+	CRI *CRIConfig `json:"cri,omitempty"`
 }
 
 // RuntimeType lists the supported container runtimes
@@ -41,6 +46,9 @@ type RuntimeType string
 const (
 	// RuntimeContainerd connects to containerd
 	RuntimeContainerd RuntimeType = "containerd"
+
+	// RuntimeCRI connects to any runtime implementing the Kubernetes container runtime interface, e.g. CRI-O
+	RuntimeCRI RuntimeType = "cri"
 )
 
 // ContainerdConfig configures access to containerd
@@ -50,6 +58,15 @@ type ContainerdConfig struct {
 	SocketPath string `json:"socket"`
 }
 
+// CRIConfig configures access to a runtime through the Kubernetes container runtime interface
+type CRIConfig struct {
+	// SocketPath is the path in the local file system pointing to the CRI socket, e.g. /var/run/crio/crio.sock
+	SocketPath string `json:"socket"`
+
+	// PollInterval is the interval in which we list the runtime's containers. Defaults to one second.
+	PollInterval util.Duration `json:"pollInterval,omitempty"`
+}
+
 // FromConfig produces a container runtime interface instance from the configuration
 func FromConfig(cfg *Config) (rt Runtime, err error) {
 	if cfg == nil {
@@ -67,6 +84,11 @@ func FromConfig(cfg *Config) (rt Runtime, err error) {
 			return nil, xerrors.Errorf("runtime is set to containerd, but not containerd config is provided")
 		}
 		return NewContainerd(cfg.Containerd, mounts, cfg.Mapping)
+	case RuntimeCRI:
+		if cfg.CRI == nil {
+			return nil, xerrors.Errorf("runtime is set to cri, but no cri config is provided")
+		}
+		return NewCRI(cfg.CRI, mounts, cfg.Mapping)
 	default:
 		return nil, xerrors.Errorf("unknown runtime type: %s", cfg.Runtime)
 	}
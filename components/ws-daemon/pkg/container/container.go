@@ -7,6 +7,7 @@ package container
 import (
 	"context"
 
+	ocispecs "github.com/opencontainers/runtime-spec/specs-go"
 	"golang.org/x/xerrors"
 )
 
@@ -45,6 +46,12 @@ type Runtime interface {
 	// ContainerPID returns the PID of the container's namespace root process, e.g. the container shim.
 	ContainerPID(ctx context.Context, id ID) (pid uint64, err error)
 
+	// ContainerDevices returns the device cgroup rules of the container's OCI spec, i.e. the devices the container runtime
+	// grants the container. The list is empty if the runtime does not tell.
+	//
+	// If the container is not found ErrNotFound is returned.
+	ContainerDevices(ctx context.Context, id ID) (devices []ocispecs.LinuxDeviceCgroup, err error)
+
 	// IsContainerdReady returns is the status of containerd.
 	IsContainerdReady(ctx context.Context) (bool, error)
 }
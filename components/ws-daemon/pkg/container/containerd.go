@@ -86,6 +86,7 @@ type containerInfo struct {
 	Rootfs      string
 	UpperDir    string
 	CGroupPath  string
+	Devices     []ocispecs.LinuxDeviceCgroup
 	PID         uint32
 }
 
@@ -235,6 +236,10 @@ func (s *Containerd) handleNewContainer(c containers.Container) {
 		if err != nil {
 			log.WithError(err).WithFields(log.OWI(info.OwnerID, info.WorkspaceID, info.InstanceID)).Warn("cannot extract cgroup path")
 		}
+		info.Devices, err = ExtractDevicesFromContainer(c)
+		if err != nil {
+			log.WithError(err).WithFields(log.OWI(info.OwnerID, info.WorkspaceID, info.InstanceID)).Warn("cannot extract devices")
+		}
 
 		info.ID = c.ID
 		info.SnapshotKey = c.SnapshotKey
@@ -450,6 +455,16 @@ func (s *Containerd) ContainerPID(ctx context.Context, id ID) (pid uint64, err e
 	return uint64(info.PID), nil
 }
 
+// ContainerDevices returns the devices the container runtime grants the workspace container
+func (s *Containerd) ContainerDevices(ctx context.Context, id ID) (devices []ocispecs.LinuxDeviceCgroup, err error) {
+	info, ok := s.cntIdx[string(id)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return info.Devices, nil
+}
+
 // ContainerPID returns the PID of the container's namespace root process, e.g. the container shim.
 func (s *Containerd) IsContainerdReady(ctx context.Context) (bool, error) {
 	return s.Client.IsServing(ctx)
@@ -468,3 +483,17 @@ func ExtractCGroupPathFromContainer(container containers.Container) (cgroupPath
 	}
 	return spec.Linux.CgroupsPath, nil
 }
+
+// ExtractDevicesFromContainer retrieves the device cgroup rules from the resources
+// in a container's OCI spec.
+func ExtractDevicesFromContainer(container containers.Container) (devices []ocispecs.LinuxDeviceCgroup, err error) {
+	var spec ocispecs.Spec
+	err = json.Unmarshal(container.Spec.Value, &spec)
+	if err != nil {
+		return
+	}
+	if spec.Linux == nil || spec.Linux.Resources == nil {
+		return nil, nil
+	}
+	return spec.Linux.Resources.Devices, nil
+}
@@ -0,0 +1,422 @@
+// Copyright (c) 2021 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package container
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	ocispecs "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/opentracing/opentracing-go"
+	"golang.org/x/xerrors"
+	"google.golang.org/grpc"
+	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1alpha2"
+
+	wsk8s "github.com/gitpod-io/gitpod/common-go/kubernetes"
+	"github.com/gitpod-io/gitpod/common-go/log"
+	"github.com/gitpod-io/gitpod/common-go/tracing"
+)
+
+const (
+	defaultCRIPollInterval = 1 * time.Second
+)
+
+// NewCRI creates a new adapter for a runtime implementing the Kubernetes container runtime interface
+func NewCRI(cfg *CRIConfig, mounts *NodeMountsLookup, pathMapping PathMapping) (*CRI, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn, err := grpc.DialContext(ctx, "unix://"+cfg.SocketPath, grpc.WithInsecure(), grpc.WithBlock())
+	if err != nil {
+		return nil, xerrors.Errorf("cannot connect to CRI runtime at %s: %w", cfg.SocketPath, err)
+	}
+	client := runtimeapi.NewRuntimeServiceClient(conn)
+	version, err := client.Version(ctx, &runtimeapi.VersionRequest{})
+	if err != nil {
+		conn.Close()
+		return nil, xerrors.Errorf("cannot connect to CRI runtime: %w", err)
+	}
+	log.WithField("runtime", version.RuntimeName).WithField("version", version.RuntimeVersion).Info("connected to CRI runtime")
+
+	pollInterval := time.Duration(cfg.PollInterval)
+	if pollInterval == 0 {
+		pollInterval = defaultCRIPollInterval
+	}
+
+	res := newCRI(client, mounts, pathMapping)
+	res.PollInterval = pollInterval
+	go res.start()
+
+	return res, nil
+}
+
+func newCRI(client runtimeapi.RuntimeServiceClient, mounts *NodeMountsLookup, pathMapping PathMapping) *CRI {
+	return &CRI{
+		Client:       client,
+		Mounts:       mounts,
+		Mapping:      pathMapping,
+		PollInterval: defaultCRIPollInterval,
+
+		cond:   sync.NewCond(&sync.Mutex{}),
+		cntIdx: make(map[string]*containerInfo),
+		wsiIdx: make(map[string]*containerInfo),
+	}
+}
+
+// CRI implements the ws-daemon container runtime interface on top of the Kubernetes container runtime interface (CRI),
+// e.g. for CRI-O. CRI offers no way to subscribe to container events, hence we poll the runtime for changes.
+type CRI struct {
+	Client       runtimeapi.RuntimeServiceClient
+	Mounts       *NodeMountsLookup
+	Mapping      PathMapping
+	PollInterval time.Duration
+
+	cond   *sync.Cond
+	wsiIdx map[string]*containerInfo
+	cntIdx map[string]*containerInfo
+}
+
+// start polling the runtime
+func (s *CRI) start() {
+	t := time.NewTicker(s.PollInterval)
+	defer t.Stop()
+
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		err := s.sync(ctx)
+		cancel()
+		if err != nil {
+			log.WithError(err).Error("cannot list containers of CRI runtime")
+		}
+
+		<-t.C
+	}
+}
+
+// sync updates the container index with the workspace containers the runtime currently knows about
+func (s *CRI) sync(ctx context.Context) error {
+	sandboxes, err := s.Client.ListPodSandbox(ctx, &runtimeapi.ListPodSandboxRequest{})
+	if err != nil {
+		return xerrors.Errorf("cannot list pod sandboxes: %w", err)
+	}
+	wsSandboxes := make(map[string]*runtimeapi.PodSandbox)
+	for _, sb := range sandboxes.Items {
+		// sandboxes carry the labels of their pod
+		if sb.Labels[wsk8s.WorkspaceIDLabel] == "" {
+			continue
+		}
+		wsSandboxes[sb.Id] = sb
+	}
+
+	cnts, err := s.Client.ListContainers(ctx, &runtimeapi.ListContainersRequest{
+		Filter: &runtimeapi.ContainerFilter{
+			LabelSelector: map[string]string{containerLabelK8sContainerName: "workspace"},
+		},
+	})
+	if err != nil {
+		return xerrors.Errorf("cannot list containers: %w", err)
+	}
+
+	present := make(map[string]struct{}, len(cnts.Containers))
+	for _, c := range cnts.Containers {
+		sb, ok := wsSandboxes[c.PodSandboxId]
+		if !ok {
+			continue
+		}
+		present[c.Id] = struct{}{}
+
+		s.cond.L.Lock()
+		_, known := s.cntIdx[c.Id]
+		s.cond.L.Unlock()
+		if known || c.State != runtimeapi.ContainerState_CONTAINER_RUNNING {
+			continue
+		}
+
+		info, err := s.inspect(ctx, c.Id, sb)
+		if err != nil {
+			log.WithError(err).WithField("ID", c.Id).Warn("cannot inspect workspace container")
+			continue
+		}
+
+		s.cond.L.Lock()
+		s.cntIdx[c.Id] = info
+		s.wsiIdx[info.InstanceID] = info
+		s.cond.Broadcast()
+		s.cond.L.Unlock()
+		log.WithField("podname", info.PodName).WithFields(log.OWI(info.OwnerID, info.WorkspaceID, info.InstanceID)).WithField("ID", c.Id).WithField("rootfs", info.Rootfs).Debug("found workspace container")
+	}
+
+	s.cond.L.Lock()
+	defer s.cond.L.Unlock()
+	var changed bool
+	for id, info := range s.cntIdx {
+		if _, ok := present[id]; ok {
+			continue
+		}
+		delete(s.cntIdx, id)
+		if s.wsiIdx[info.InstanceID] == info {
+			delete(s.wsiIdx, info.InstanceID)
+		}
+		changed = true
+	}
+	if changed {
+		s.cond.Broadcast()
+	}
+
+	return nil
+}
+
+// criContainerInfo is the verbose container info both CRI-O and containerd produce
+type criContainerInfo struct {
+	PID         uint32         `json:"pid"`
+	RuntimeSpec *ocispecs.Spec `json:"runtimeSpec"`
+}
+
+// inspect gathers the container's PID, cgroup, devices and rootfs from its verbose status
+func (s *CRI) inspect(ctx context.Context, id string, sb *runtimeapi.PodSandbox) (*containerInfo, error) {
+	status, err := s.Client.ContainerStatus(ctx, &runtimeapi.ContainerStatusRequest{ContainerId: id, Verbose: true})
+	if err != nil {
+		return nil, err
+	}
+	rawInfo, ok := status.Info["info"]
+	if !ok {
+		return nil, xerrors.Errorf("runtime did not provide verbose container info")
+	}
+	var cinfo criContainerInfo
+	err = json.Unmarshal([]byte(rawInfo), &cinfo)
+	if err != nil {
+		return nil, xerrors.Errorf("cannot unmarshal verbose container info: %w", err)
+	}
+
+	res := &containerInfo{
+		ID:          id,
+		InstanceID:  sb.Labels[wsk8s.WorkspaceIDLabel],
+		OwnerID:     sb.Labels[wsk8s.OwnerLabel],
+		WorkspaceID: sb.Labels[wsk8s.MetaIDLabel],
+		PID:         cinfo.PID,
+		SeenTask:    true,
+	}
+	if sb.Metadata != nil {
+		res.PodName = sb.Metadata.Name
+	}
+	if spec := cinfo.RuntimeSpec; spec != nil {
+		// CRI-O reports the mountpoint of the container's storage as root path. containerd on the other hand
+		// uses a path relative to the bundle, in which case we look at the mount table instead.
+		if spec.Root != nil && filepath.IsAbs(spec.Root.Path) {
+			res.Rootfs = spec.Root.Path
+		}
+		if spec.Linux != nil {
+			res.CGroupPath = criCGroupPath(spec.Linux.CgroupsPath)
+			if spec.Linux.Resources != nil {
+				res.Devices = spec.Linux.Resources.Devices
+			}
+		}
+	}
+
+	return res, nil
+}
+
+// criCGroupPath turns the systemd notation of a cgroup (slice:prefix:name) into a cgroupfs path.
+// Paths not in systemd notation are returned as is.
+func criCGroupPath(cgroupsPath string) string {
+	segs := strings.Split(cgroupsPath, ":")
+	if len(segs) != 3 || !strings.HasSuffix(segs[0], ".slice") {
+		return cgroupsPath
+	}
+	slice, prefix, name := segs[0], segs[1], segs[2]
+
+	// a slice named a-b-c.slice lives in /a.slice/a-b.slice/a-b-c.slice
+	var (
+		res    = "/"
+		parent string
+	)
+	for _, comp := range strings.Split(strings.TrimSuffix(slice, ".slice"), "-") {
+		if parent != "" {
+			parent += "-"
+		}
+		parent += comp
+		res = filepath.Join(res, parent+".slice")
+	}
+
+	if prefix != "" {
+		name = prefix + "-" + name
+	}
+	return filepath.Join(res, name+".scope")
+}
+
+// WaitForContainer waits for workspace container to come into existence.
+func (s *CRI) WaitForContainer(ctx context.Context, workspaceInstanceID string) (cid ID, err error) {
+	//nolint:ineffassign
+	span, ctx := opentracing.StartSpanFromContext(ctx, "WaitForContainer")
+	defer tracing.FinishSpan(span, &err)
+
+	rchan := make(chan ID, 1)
+	go func() {
+		s.cond.L.Lock()
+		defer s.cond.L.Unlock()
+
+		for {
+			info, ok := s.wsiIdx[workspaceInstanceID]
+			if ok {
+				rchan <- ID(info.ID)
+				break
+			}
+
+			if ctx.Err() != nil {
+				break
+			}
+
+			s.cond.Wait()
+		}
+	}()
+
+	select {
+	case cid = <-rchan:
+		return
+	case <-ctx.Done():
+		err = ctx.Err()
+		return
+	}
+}
+
+// WaitForContainerStop waits for workspace container to be deleted.
+func (s *CRI) WaitForContainerStop(ctx context.Context, workspaceInstanceID string) (err error) {
+	//nolint:ineffassign
+	span, ctx := opentracing.StartSpanFromContext(ctx, "WaitForContainerStop")
+	defer tracing.FinishSpan(span, &err)
+
+	rchan := make(chan struct{}, 1)
+	go func() {
+		s.cond.L.Lock()
+		defer s.cond.L.Unlock()
+
+		// the container might not exist yet, in which case we wait for it to come and go
+		var seen bool
+		for {
+			_, ok := s.wsiIdx[workspaceInstanceID]
+			if ok {
+				seen = true
+			} else if seen {
+				rchan <- struct{}{}
+				break
+			}
+
+			if ctx.Err() != nil {
+				break
+			}
+
+			s.cond.Wait()
+		}
+	}()
+
+	select {
+	case <-rchan:
+		return
+	case <-ctx.Done():
+		err = ctx.Err()
+		return
+	}
+}
+
+// ContainerExists finds out if a container with the given ID exists.
+func (s *CRI) ContainerExists(ctx context.Context, id ID) (exists bool, err error) {
+	cnts, err := s.Client.ListContainers(ctx, &runtimeapi.ListContainersRequest{
+		Filter: &runtimeapi.ContainerFilter{Id: string(id)},
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return len(cnts.Containers) > 0, nil
+}
+
+// ContainerRootfs finds the workspace container's rootfs.
+func (s *CRI) ContainerRootfs(ctx context.Context, id ID, opts OptsContainerRootfs) (loc string, err error) {
+	s.cond.L.Lock()
+	info, ok := s.cntIdx[string(id)]
+	s.cond.L.Unlock()
+	if !ok {
+		return "", ErrNotFound
+	}
+
+	mnt := info.Rootfs
+	if mnt == "" {
+		// The runtime did not tell us where the rootfs is. Runtimes commonly mount the rootfs in a directory
+		// named after the container, e.g. containerd's /run/containerd/io.containerd.runtime.v2.task/k8s.io/<id>/rootfs
+		mnt, err = s.Mounts.GetMountpoint(func(mountPoint string) bool {
+			return strings.Contains(mountPoint, info.ID)
+		})
+		if err != nil {
+			return
+		}
+	}
+
+	if opts.Unmapped {
+		return mnt, nil
+	}
+
+	return s.Mapping.Translate(mnt)
+}
+
+// ContainerCGroupPath finds the container's cgroup path suffix
+func (s *CRI) ContainerCGroupPath(ctx context.Context, id ID) (loc string, err error) {
+	s.cond.L.Lock()
+	info, ok := s.cntIdx[string(id)]
+	s.cond.L.Unlock()
+	if !ok {
+		return "", ErrNotFound
+	}
+
+	if info.CGroupPath == "" {
+		return "", ErrNoCGroup
+	}
+
+	return info.CGroupPath, nil
+}
+
+// ContainerPID finds the workspace container's PID
+func (s *CRI) ContainerPID(ctx context.Context, id ID) (pid uint64, err error) {
+	s.cond.L.Lock()
+	info, ok := s.cntIdx[string(id)]
+	s.cond.L.Unlock()
+	if !ok {
+		return 0, ErrNotFound
+	}
+
+	return uint64(info.PID), nil
+}
+
+// ContainerDevices returns the devices the container runtime grants the workspace container
+func (s *CRI) ContainerDevices(ctx context.Context, id ID) (devices []ocispecs.LinuxDeviceCgroup, err error) {
+	s.cond.L.Lock()
+	info, ok := s.cntIdx[string(id)]
+	s.cond.L.Unlock()
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return info.Devices, nil
+}
+
+// IsContainerdReady returns true if the runtime reports to be ready
+func (s *CRI) IsContainerdReady(ctx context.Context) (bool, error) {
+	resp, err := s.Client.Status(ctx, &runtimeapi.StatusRequest{})
+	if err != nil {
+		return false, err
+	}
+	if resp.Status == nil {
+		return false, nil
+	}
+	for _, c := range resp.Status.Conditions {
+		if c.Type == runtimeapi.RuntimeReady {
+			return c.Status, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,181 @@
+// Copyright (c) 2021 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package container
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1alpha2"
+
+	wsk8s "github.com/gitpod-io/gitpod/common-go/kubernetes"
+)
+
+// fakeRuntimeService serves the CRI calls the CRI adapter makes from a fixed set of sandboxes and containers
+type fakeRuntimeService struct {
+	runtimeapi.RuntimeServiceClient
+
+	Sandboxes  []*runtimeapi.PodSandbox
+	Containers []*runtimeapi.Container
+	Info       map[string]string
+}
+
+func (f *fakeRuntimeService) ListPodSandbox(ctx context.Context, in *runtimeapi.ListPodSandboxRequest, opts ...grpc.CallOption) (*runtimeapi.ListPodSandboxResponse, error) {
+	return &runtimeapi.ListPodSandboxResponse{Items: f.Sandboxes}, nil
+}
+
+func (f *fakeRuntimeService) ListContainers(ctx context.Context, in *runtimeapi.ListContainersRequest, opts ...grpc.CallOption) (*runtimeapi.ListContainersResponse, error) {
+	var res []*runtimeapi.Container
+	for _, c := range f.Containers {
+		if in.Filter != nil && in.Filter.Id != "" && in.Filter.Id != c.Id {
+			continue
+		}
+		var mismatch bool
+		for k, v := range in.GetFilter().GetLabelSelector() {
+			if c.Labels[k] != v {
+				mismatch = true
+			}
+		}
+		if mismatch {
+			continue
+		}
+		res = append(res, c)
+	}
+	return &runtimeapi.ListContainersResponse{Containers: res}, nil
+}
+
+func (f *fakeRuntimeService) ContainerStatus(ctx context.Context, in *runtimeapi.ContainerStatusRequest, opts ...grpc.CallOption) (*runtimeapi.ContainerStatusResponse, error) {
+	return &runtimeapi.ContainerStatusResponse{
+		Status: &runtimeapi.ContainerStatus{Id: in.ContainerId},
+		Info:   map[string]string{"info": f.Info[in.ContainerId]},
+	}, nil
+}
+
+func TestCRISync(t *testing.T) {
+	fake := &fakeRuntimeService{
+		Sandboxes: []*runtimeapi.PodSandbox{
+			{
+				Id:       "sandbox-ws",
+				Metadata: &runtimeapi.PodSandboxMetadata{Name: "ws-foobar"},
+				Labels: map[string]string{
+					wsk8s.WorkspaceIDLabel: "foobar",
+					wsk8s.MetaIDLabel:      "meta",
+					wsk8s.OwnerLabel:       "owner",
+				},
+			},
+			{Id: "sandbox-other", Metadata: &runtimeapi.PodSandboxMetadata{Name: "other"}},
+		},
+		Containers: []*runtimeapi.Container{
+			{
+				Id:           "cnt-ws",
+				PodSandboxId: "sandbox-ws",
+				State:        runtimeapi.ContainerState_CONTAINER_RUNNING,
+				Labels:       map[string]string{containerLabelK8sContainerName: "workspace"},
+			},
+			{
+				Id:           "cnt-other",
+				PodSandboxId: "sandbox-other",
+				State:        runtimeapi.ContainerState_CONTAINER_RUNNING,
+				Labels:       map[string]string{containerLabelK8sContainerName: "workspace"},
+			},
+		},
+		Info: map[string]string{
+			"cnt-ws": `{"pid": 42, "runtimeSpec": {"root": {"path": "/var/lib/containers/storage/overlay/abc/merged"}, "linux": {"cgroupsPath": "kubepods-burstable-pod123.slice:crio:cnt-ws", "resources": {"devices": [{"allow": false, "access": "rwm"}]}}}}`,
+		},
+	}
+	cri := newCRI(fake, nil, nil)
+
+	err := cri.sync(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	id, err := cri.WaitForContainer(ctx, "foobar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != "cnt-ws" {
+		t.Errorf("unexpected container ID: %s", id)
+	}
+	if _, ok := cri.cntIdx["cnt-other"]; ok {
+		t.Errorf("indexed a container which does not belong to a workspace")
+	}
+
+	pid, err := cri.ContainerPID(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pid != 42 {
+		t.Errorf("unexpected PID: %d", pid)
+	}
+	rootfs, err := cri.ContainerRootfs(ctx, id, OptsContainerRootfs{Unmapped: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rootfs != "/var/lib/containers/storage/overlay/abc/merged" {
+		t.Errorf("unexpected rootfs: %s", rootfs)
+	}
+	cgroup, err := cri.ContainerCGroupPath(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := "/kubepods.slice/kubepods-burstable.slice/kubepods-burstable-pod123.slice/crio-cnt-ws.scope"; cgroup != exp {
+		t.Errorf("unexpected cgroup path: expected %s, got %s", exp, cgroup)
+	}
+	devices, err := cri.ContainerDevices(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(devices) != 1 || devices[0].Allow || devices[0].Access != "rwm" {
+		t.Errorf("unexpected devices: %v", devices)
+	}
+	exists, err := cri.ContainerExists(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !exists {
+		t.Errorf("container does not exist")
+	}
+
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- cri.WaitForContainerStop(ctx, "foobar")
+	}()
+	// give WaitForContainerStop a chance to see the container before it's removed
+	time.Sleep(50 * time.Millisecond)
+
+	fake.Containers = fake.Containers[1:]
+	err = cri.sync(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = <-stopped
+	if err != nil {
+		t.Fatalf("container stop was not observed: %v", err)
+	}
+	if _, err := cri.ContainerPID(ctx, id); err != ErrNotFound {
+		t.Errorf("expected removed container to be gone, got %v", err)
+	}
+}
+
+func TestCRICGroupPath(t *testing.T) {
+	tests := []struct {
+		Input       string
+		Expectation string
+	}{
+		{"/kubepods/burstable/pod123/abc", "/kubepods/burstable/pod123/abc"},
+		{"kubepods-pod123.slice:cri-containerd:abc", "/kubepods.slice/kubepods-pod123.slice/cri-containerd-abc.scope"},
+		{"system.slice::abc", "/system.slice/abc.scope"},
+	}
+	for _, test := range tests {
+		if act := criCGroupPath(test.Input); act != test.Expectation {
+			t.Errorf("criCGroupPath(%q): expected %q, got %q", test.Input, test.Expectation, act)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+// Copyright (c) 2021 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package container
+
+import (
+	"context"
+	"sync"
+
+	ocispecs "github.com/opencontainers/runtime-spec/specs-go"
+)
+
+// FakeContainer describes a container of the fake runtime
+type FakeContainer struct {
+	ID         ID
+	Rootfs     string
+	CGroupPath string
+	Devices    []ocispecs.LinuxDeviceCgroup
+	PID        uint64
+}
+
+// NewFake creates a new fake runtime without any containers
+func NewFake() *Fake {
+	return &Fake{
+		Ready:      true,
+		cond:       sync.NewCond(&sync.Mutex{}),
+		containers: make(map[string]*FakeContainer),
+	}
+}
+
+// Fake is an in-memory container runtime for tests. Workspace containers come into existence
+// using AddContainer and are deleted using RemoveContainer.
+type Fake struct {
+	// Ready is what IsContainerdReady returns
+	Ready bool
+
+	cond       *sync.Cond
+	containers map[string]*FakeContainer
+}
+
+// AddContainer adds a workspace container and wakes up everyone waiting for it
+func (f *Fake) AddContainer(workspaceInstanceID string, c FakeContainer) {
+	f.cond.L.Lock()
+	defer f.cond.L.Unlock()
+
+	f.containers[workspaceInstanceID] = &c
+	f.cond.Broadcast()
+}
+
+// RemoveContainer deletes a workspace container and wakes up everyone waiting for it to stop
+func (f *Fake) RemoveContainer(workspaceInstanceID string) {
+	f.cond.L.Lock()
+	defer f.cond.L.Unlock()
+
+	delete(f.containers, workspaceInstanceID)
+	f.cond.Broadcast()
+}
+
+// WaitForContainer waits for workspace container to come into existence.
+func (f *Fake) WaitForContainer(ctx context.Context, workspaceInstanceID string) (id ID, err error) {
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		// wake up the waiting loop below once the context is canceled
+		select {
+		case <-ctx.Done():
+			f.cond.L.Lock()
+			f.cond.Broadcast()
+			f.cond.L.Unlock()
+		case <-done:
+		}
+	}()
+
+	f.cond.L.Lock()
+	defer f.cond.L.Unlock()
+	for {
+		if c, ok := f.containers[workspaceInstanceID]; ok {
+			return c.ID, nil
+		}
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		f.cond.Wait()
+	}
+}
+
+// WaitForContainerStop waits for a workspace container to be deleted.
+func (f *Fake) WaitForContainerStop(ctx context.Context, workspaceInstanceID string) error {
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			f.cond.L.Lock()
+			f.cond.Broadcast()
+			f.cond.L.Unlock()
+		case <-done:
+		}
+	}()
+
+	f.cond.L.Lock()
+	defer f.cond.L.Unlock()
+	// like the real runtimes we wait for containers which do not exist yet to come and go
+	var seen bool
+	for {
+		if _, ok := f.containers[workspaceInstanceID]; ok {
+			seen = true
+		} else if seen {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		f.cond.Wait()
+	}
+}
+
+// ContainerExists finds out if a container with the given ID exists.
+func (f *Fake) ContainerExists(ctx context.Context, id ID) (exists bool, err error) {
+	_, err = f.find(id)
+	if err == ErrNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// ContainerRootfs returns the rootfs of the container. The fake runtime makes no difference between mapped and unmapped locations.
+func (f *Fake) ContainerRootfs(ctx context.Context, id ID, opts OptsContainerRootfs) (loc string, err error) {
+	c, err := f.find(id)
+	if err != nil {
+		return "", err
+	}
+	return c.Rootfs, nil
+}
+
+// ContainerCGroupPath returns the container's cgroup path suffix
+func (f *Fake) ContainerCGroupPath(ctx context.Context, id ID) (loc string, err error) {
+	c, err := f.find(id)
+	if err != nil {
+		return "", err
+	}
+	if c.CGroupPath == "" {
+		return "", ErrNoCGroup
+	}
+	return c.CGroupPath, nil
+}
+
+// ContainerPID returns the container's PID
+func (f *Fake) ContainerPID(ctx context.Context, id ID) (pid uint64, err error) {
+	c, err := f.find(id)
+	if err != nil {
+		return 0, err
+	}
+	return c.PID, nil
+}
+
+// ContainerDevices returns the container's devices
+func (f *Fake) ContainerDevices(ctx context.Context, id ID) (devices []ocispecs.LinuxDeviceCgroup, err error) {
+	c, err := f.find(id)
+	if err != nil {
+		return nil, err
+	}
+	return c.Devices, nil
+}
+
+// IsContainerdReady returns the Ready field
+func (f *Fake) IsContainerdReady(ctx context.Context) (bool, error) {
+	return f.Ready, nil
+}
+
+func (f *Fake) find(id ID) (*FakeContainer, error) {
+	f.cond.L.Lock()
+	defer f.cond.L.Unlock()
+
+	for _, c := range f.containers {
+		if c.ID == id {
+			return c, nil
+		}
+	}
+	return nil, ErrNotFound
+}
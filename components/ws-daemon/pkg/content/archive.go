@@ -60,15 +60,17 @@ func BuildTarbal(ctx context.Context, src string, dst string, fullWorkspaceBacku
 	var tarout io.ReadCloser
 	if fullWorkspaceBackup {
 		tarout, err = archive.TarWithOptions(src, &archive.TarOptions{
-			UIDMaps:        uidMaps,
-			GIDMaps:        gidMaps,
-			InUserNS:       true,
-			WhiteoutFormat: archive.OverlayWhiteoutFormat,
+			UIDMaps:         uidMaps,
+			GIDMaps:         gidMaps,
+			InUserNS:        true,
+			WhiteoutFormat:  archive.OverlayWhiteoutFormat,
+			ExcludePatterns: cfg.ExcludedFiles,
 		})
 	} else {
 		tarout, err = TarWithOptions(src, &TarOptions{
-			UIDMaps: uidMaps,
-			GIDMaps: gidMaps,
+			UIDMaps:       uidMaps,
+			GIDMaps:       gidMaps,
+			ExcludedFiles: cfg.ExcludedFiles,
 		})
 	}
 
@@ -5,12 +5,16 @@
 package content
 
 import (
+	"archive/tar"
 	"context"
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
+
 	carchive "github.com/gitpod-io/gitpod/content-service/pkg/archive"
 )
 
@@ -103,3 +107,73 @@ func TestBuildTarbalMaxSize(t *testing.T) {
 		os.RemoveAll(c)
 	}
 }
+
+func TestBuildTarbalExcludedFiles(t *testing.T) {
+	tests := []struct {
+		Name                string
+		FullWorkspaceBackup bool
+		Root                string
+		Excluded            string
+		Expectation         []string
+	}{
+		{
+			Name:        "workspace content",
+			Excluded:    ".gitpod/ready",
+			Expectation: []string{"./.gitpod/", "./.gitpod/content.json", "./README.md"},
+		},
+		{
+			Name:                "full workspace backup",
+			FullWorkspaceBackup: true,
+			Root:                "workspace",
+			Excluded:            "workspace/.gitpod/ready",
+			Expectation:         []string{"workspace/", "workspace/.gitpod/", "workspace/.gitpod/content.json", "workspace/README.md"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			wd := t.TempDir()
+			for _, fn := range []string{"README.md", ".gitpod/ready", ".gitpod/content.json"} {
+				fn = filepath.Join(wd, test.Root, fn)
+				err := os.MkdirAll(filepath.Dir(fn), 0755)
+				if err != nil {
+					t.Fatalf("cannot prepare test: %v", err)
+				}
+				err = os.WriteFile(fn, []byte(fn), 0600)
+				if err != nil {
+					t.Fatalf("cannot prepare test: %v", err)
+				}
+			}
+
+			tgt := filepath.Join(t.TempDir(), "backup.tar")
+			err := BuildTarbal(context.Background(), wd, tgt, test.FullWorkspaceBackup, carchive.WithExcludedFiles(test.Excluded))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			f, err := os.Open(tgt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			var names []string
+			tr := tar.NewReader(f)
+			for {
+				hdr, err := tr.Next()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				names = append(names, hdr.Name)
+			}
+			sort.Strings(names)
+
+			if diff := cmp.Diff(test.Expectation, names); diff != "" {
+				t.Errorf("unexpected archive content (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
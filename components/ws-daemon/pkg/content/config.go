@@ -10,6 +10,7 @@ import (
 	"github.com/gitpod-io/gitpod/common-go/util"
 	"github.com/gitpod-io/gitpod/content-service/pkg/storage"
 	"github.com/gitpod-io/gitpod/ws-daemon/api"
+	"github.com/gitpod-io/gitpod/ws-daemon/pkg/iws"
 	"github.com/gitpod-io/gitpod/ws-daemon/pkg/quota"
 	"golang.org/x/xerrors"
 )
@@ -31,6 +32,14 @@ type Config struct {
 	// Limit limits the size of a sandbox
 	WorkspaceSizeLimit quota.Size `json:"workspaceSizeLimit"`
 
+	// Quota configures how WorkspaceSizeLimit is enforced while a workspace is running
+	Quota struct {
+		// Mode is the means by which we enforce the quota: "xfs" uses XFS project quotas on the working area,
+		// "loopback" mounts a size-limited image on each workspace and is meant for testing.
+		// If empty, WorkspaceSizeLimit only limits the size of backups.
+		Mode quota.Mode `json:"mode,omitempty"`
+	} `json:"quota,omitempty"`
+
 	// Storage is some form of permanent file store to which we back up workspaces
 	Storage storage.Config `json:"storage"`
 
@@ -44,8 +53,13 @@ type Config struct {
 		// Detaults to 3
 		Attempts int `json:"attempts"`
 
-		// Period is the time between regular workspace backups
+		// Period is the minimum time between periodic backups of a running workspace. Workspaces asking
+		// for a shorter interval are backed up at this period.
 		Period util.Duration `json:"period"`
+
+		// PeriodicConcurrency limits the number of periodic backups running at the same time on this node,
+		// so that they do not saturate its bandwidth. Defaults to 1.
+		PeriodicConcurrency int `json:"periodicConcurrency,omitempty"`
 	} `json:"backup,omitempty"`
 
 	// UserNamespaces configures the behaviour of the user-namespace support
@@ -53,6 +67,9 @@ type Config struct {
 		FSShift FSShiftMethod `json:"fsShift"`
 	} `json:"userNamespaces,omitempty"`
 
+	// EgressPolicy configures the enforcement of workspace egress policies
+	EgressPolicy iws.EgressConfig `json:"egressPolicy,omitempty"`
+
 	// Initializer configures the isolated content initializer runtime
 	Initializer struct {
 		// Command is the path to content-initializer executable
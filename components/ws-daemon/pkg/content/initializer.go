@@ -368,6 +368,16 @@ func (rs *remoteContentStorage) UploadInstance(ctx context.Context, source strin
 	return "", "", xerrors.Errorf("not implemented")
 }
 
+// UploadVolume does nothing
+func (rs *remoteContentStorage) UploadVolume(ctx context.Context, source string, name string, options ...storage.UploadOption) (bucket, obj string, err error) {
+	return "", "", xerrors.Errorf("not implemented")
+}
+
+// DownloadVolume always returns false and does nothing
+func (rs *remoteContentStorage) DownloadVolume(ctx context.Context, destination string, name string, mappings []archive.IDMapping) (bool, error) {
+	return false, nil
+}
+
 // Bucket returns an empty string
 func (rs *remoteContentStorage) Bucket(string) string {
 	return ""
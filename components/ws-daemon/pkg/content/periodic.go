@@ -0,0 +1,284 @@
+// Copyright (c) 2021 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package content
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"golang.org/x/xerrors"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/gitpod-io/gitpod/common-go/log"
+	csapi "github.com/gitpod-io/gitpod/content-service/api"
+	"github.com/gitpod-io/gitpod/content-service/pkg/storage"
+	"github.com/gitpod-io/gitpod/ws-daemon/pkg/internal/session"
+)
+
+// periodicBackupCheckInterval is how often we look for workspaces which are due for a periodic backup
+const periodicBackupCheckInterval = 1 * time.Minute
+
+// periodicBackups keeps track of the periodic backups of running workspaces
+type periodicBackups struct {
+	// slots limits the number of concurrent periodic backups
+	slots chan struct{}
+
+	mu      sync.Mutex
+	running map[string]*periodicBackup
+	// nextCheck is when we look at a workspace again after backing it up or finding its content unchanged
+	nextCheck map[string]time.Time
+	// gitStatus is the Git status of a workspace at the time of its last periodic backup
+	gitStatus map[string]*csapi.GitStatus
+}
+
+type periodicBackup struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func newPeriodicBackups(concurrency int) *periodicBackups {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	return &periodicBackups{
+		slots:     make(chan struct{}, concurrency),
+		running:   make(map[string]*periodicBackup),
+		nextCheck: make(map[string]time.Time),
+		gitStatus: make(map[string]*csapi.GitStatus),
+	}
+}
+
+// runPeriodicBackups regularly backs up running workspaces whose content changed.
+// This function returns when the context is canceled.
+func (s *WorkspaceService) runPeriodicBackups(ctx context.Context) {
+	t := time.NewTicker(periodicBackupCheckInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			s.schedulePeriodicBackups(ctx, time.Now())
+		}
+	}
+}
+
+// schedulePeriodicBackups starts the periodic backups which are due and for which there's a free slot.
+// Workspaces we cannot back up right now because all slots are taken are retried during the next check.
+// Finding out if the content of a workspace changed is part of its backup, hence we look at the content of
+// a workspace at most once per interval and never without a slot.
+func (s *WorkspaceService) schedulePeriodicBackups(ctx context.Context, now time.Time) {
+	var (
+		p    = s.periodic
+		wss  = s.store.List()
+		seen = make(map[string]struct{}, len(wss))
+	)
+	for _, sess := range wss {
+		seen[sess.InstanceID] = struct{}{}
+
+		interval := s.periodicBackupInterval(sess)
+		if interval == 0 || !sess.IsReady() {
+			continue
+		}
+
+		p.mu.Lock()
+		_, running := p.running[sess.InstanceID]
+		next, ok := p.nextCheck[sess.InstanceID]
+		lastStatus := p.gitStatus[sess.InstanceID]
+		p.mu.Unlock()
+		if !ok {
+			last := sess.LastPeriodicBackup
+			if last.IsZero() {
+				last = sess.CreatedAt
+			}
+			next = last.Add(interval)
+		}
+		if running || now.Before(next) {
+			continue
+		}
+
+		select {
+		case p.slots <- struct{}{}:
+		default:
+			log.WithFields(sess.OWI()).Debug("too many periodic backups in flight - postponing backup")
+			continue
+		}
+		if !s.startPeriodicBackup(sess, lastStatus, interval) {
+			<-p.slots
+		}
+	}
+
+	// forget about workspaces which no longer exist
+	p.mu.Lock()
+	for id := range p.nextCheck {
+		if _, ok := seen[id]; !ok {
+			delete(p.nextCheck, id)
+		}
+	}
+	for id := range p.gitStatus {
+		if _, ok := seen[id]; !ok {
+			delete(p.gitStatus, id)
+		}
+	}
+	p.mu.Unlock()
+}
+
+// periodicBackupInterval returns the time between periodic backups of a workspace, or zero if it gets none
+func (s *WorkspaceService) periodicBackupInterval(sess *session.Workspace) time.Duration {
+	if sess.PeriodicBackupInterval <= 0 || sess.FullWorkspaceBackup || sess.RemoteStorageDisabled {
+		return 0
+	}
+	if min := time.Duration(s.config.Backup.Period); sess.PeriodicBackupInterval < min {
+		return min
+	}
+	return sess.PeriodicBackupInterval
+}
+
+// startPeriodicBackup backs up a workspace in the background if its content changed since lastStatus was recorded,
+// holding one of the periodic backup slots. It returns false if the workspace is no longer running, e.g. because
+// its disposal has begun.
+func (s *WorkspaceService) startPeriodicBackup(sess *session.Workspace, lastStatus *csapi.GitStatus, interval time.Duration) bool {
+	p := s.periodic
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// DisposeWorkspace marks the workspace as disposing before it stops the periodic backup. Checking the state
+	// while holding the lock ensures we never start a backup that disposal does not know about.
+	if !sess.IsReady() {
+		return false
+	}
+
+	ctx, cancel := context.WithCancel(s.ctx)
+	b := &periodicBackup{cancel: cancel, done: make(chan struct{})}
+	p.running[sess.InstanceID] = b
+
+	go func() {
+		defer close(b.done)
+		defer cancel()
+
+		start := time.Now()
+		changed, status, err := contentChanged(ctx, sess, lastStatus)
+		if err != nil && ctx.Err() == nil {
+			log.WithError(err).WithFields(sess.OWI()).Warn("cannot determine if the workspace content changed - backing it up anyways")
+			changed = true
+		}
+		ok := changed && ctx.Err() == nil && s.takePeriodicBackup(ctx, sess)
+		<-p.slots
+
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		delete(p.running, sess.InstanceID)
+		// We don't retry failed backups right away so that a broken remote storage does not keep us busy.
+		// Unchanged workspaces aren't looked at again before the next interval either.
+		p.nextCheck[sess.InstanceID] = start.Add(interval)
+		if ok {
+			p.gitStatus[sess.InstanceID] = status
+		}
+	}()
+	return true
+}
+
+// takePeriodicBackup uploads the workspace content as its regular backup, s.t. restoring the workspace starts from there.
+// Persistent volumes are backed up alongside the content. The backups we replace go to a trail of their own, otherwise
+// periodic backups would push the backups of previous workspace instances out of the regular trail.
+func (s *WorkspaceService) takePeriodicBackup(ctx context.Context, sess *session.Workspace) (ok bool) {
+	log := log.WithFields(sess.OWI())
+
+	start := time.Now()
+	err := s.uploadWorkspaceContent(ctx, sess, storage.DefaultBackup, storage.DefaultBackupManifest, storage.PeriodicBackupTrail)
+	if err == nil {
+		err = s.uploadPersistentVolumes(ctx, sess)
+	}
+	if ctx.Err() != nil {
+		log.Debug("periodic backup was canceled")
+		return false
+	}
+	if err != nil {
+		log.WithError(err).Warn("periodic backup failed")
+		return false
+	}
+
+	err = sess.SetLastPeriodicBackup(start)
+	if err != nil {
+		log.WithError(err).Warn("cannot persist time of periodic backup")
+	}
+	log.WithField("duration", time.Since(start).String()).Info("periodic backup done")
+	return true
+}
+
+// stopPeriodicBackup cancels the periodic backup of a workspace, if there is one, and waits for it to stop
+func (s *WorkspaceService) stopPeriodicBackup(instanceID string) {
+	s.periodic.mu.Lock()
+	b, ok := s.periodic.running[instanceID]
+	s.periodic.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	b.cancel()
+	<-b.done
+}
+
+// contentChanged determines if the workspace content changed since its last periodic backup. A different Git status,
+// e.g. because of a new commit, is a change. Otherwise we look for files which were modified since the last backup.
+func contentChanged(ctx context.Context, sess *session.Workspace, lastStatus *csapi.GitStatus) (changed bool, status *csapi.GitStatus, err error) {
+	status, err = sess.UpdateGitStatus(ctx)
+	if err != nil {
+		log.WithError(err).WithFields(sess.OWI()).Debug("cannot get Git status - relying on modification times only")
+	}
+	if status != nil && lastStatus != nil && !proto.Equal(status, lastStatus) {
+		return true, status, nil
+	}
+
+	since := sess.LastPeriodicBackup
+	if since.IsZero() {
+		since = sess.CreatedAt
+	}
+	changed, err = contentModifiedSince(ctx, sess.Location, since)
+	return changed, status, err
+}
+
+var errContentModified = errors.New("content modified")
+
+// contentModifiedSince finds out if anything below loc was modified after t. Creating, deleting or renaming a file
+// modifies its parent directory, hence modification times reveal most changes. We stop at the first modification.
+func contentModifiedSince(ctx context.Context, loc string, t time.Time) (bool, error) {
+	err := filepath.WalkDir(loc, func(path string, d fs.DirEntry, err error) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if errors.Is(err, fs.ErrNotExist) {
+			// the workspace is in use - files come and go while we walk
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		info, err := d.Info()
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if info.ModTime().After(t) {
+			return errContentModified
+		}
+		return nil
+	})
+	if err == errContentModified {
+		return true, nil
+	}
+	if err != nil {
+		return false, xerrors.Errorf("cannot walk workspace content: %w", err)
+	}
+	return false, nil
+}
@@ -0,0 +1,94 @@
+// Copyright (c) 2021 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package content
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gitpod-io/gitpod/common-go/util"
+	"github.com/gitpod-io/gitpod/ws-daemon/pkg/internal/session"
+)
+
+func TestContentModifiedSince(t *testing.T) {
+	var (
+		lastBackup = time.Now().Add(-1 * time.Hour)
+		before     = lastBackup.Add(-1 * time.Hour)
+		after      = lastBackup.Add(30 * time.Minute)
+	)
+
+	tests := []struct {
+		Name        string
+		Modified    []string
+		Expectation bool
+	}{
+		{Name: "unchanged"},
+		{Name: "modified file", Modified: []string{"src/main.go"}, Expectation: true},
+		{Name: "modified directory", Modified: []string{"src"}, Expectation: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			loc := t.TempDir()
+			err := os.MkdirAll(filepath.Join(loc, "src"), 0755)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = os.WriteFile(filepath.Join(loc, "src", "main.go"), []byte("package main"), 0644)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, p := range []string{"src/main.go", "src", "."} {
+				err = os.Chtimes(filepath.Join(loc, p), before, before)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			for _, p := range test.Modified {
+				err = os.Chtimes(filepath.Join(loc, p), after, after)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			act, err := contentModifiedSince(context.Background(), loc, lastBackup)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if act != test.Expectation {
+				t.Errorf("unexpected result: expected %v, got %v", test.Expectation, act)
+			}
+		})
+	}
+}
+
+func TestPeriodicBackupInterval(t *testing.T) {
+	tests := []struct {
+		Name        string
+		Workspace   *session.Workspace
+		Expectation time.Duration
+	}{
+		{Name: "disabled", Workspace: &session.Workspace{}, Expectation: 0},
+		{Name: "regular", Workspace: &session.Workspace{PeriodicBackupInterval: 30 * time.Minute}, Expectation: 30 * time.Minute},
+		{Name: "below minimum", Workspace: &session.Workspace{PeriodicBackupInterval: 1 * time.Minute}, Expectation: 10 * time.Minute},
+		{Name: "full workspace backup", Workspace: &session.Workspace{PeriodicBackupInterval: 30 * time.Minute, FullWorkspaceBackup: true}, Expectation: 0},
+		{Name: "no remote storage", Workspace: &session.Workspace{PeriodicBackupInterval: 30 * time.Minute, RemoteStorageDisabled: true}, Expectation: 0},
+	}
+
+	var cfg Config
+	cfg.Backup.Period = util.Duration(10 * time.Minute)
+	s := &WorkspaceService{config: cfg, periodic: newPeriodicBackups(0)}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			act := s.periodicBackupInterval(test.Workspace)
+			if act != test.Expectation {
+				t.Errorf("unexpected interval: expected %v, got %v", test.Expectation, act)
+			}
+		})
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io/fs"
 	"io/ioutil"
 	"math"
 	"os"
@@ -42,6 +43,7 @@ import (
 	"github.com/gitpod-io/gitpod/ws-daemon/pkg/container"
 	"github.com/gitpod-io/gitpod/ws-daemon/pkg/internal/session"
 	"github.com/gitpod-io/gitpod/ws-daemon/pkg/iws"
+	"github.com/gitpod-io/gitpod/ws-daemon/pkg/quota"
 )
 
 // WorkspaceService implements the InitService and WorkspaceService
@@ -52,6 +54,8 @@ type WorkspaceService struct {
 	ctx         context.Context
 	stopService context.CancelFunc
 	runtime     container.Runtime
+	quota       quota.Enforcer
+	periodic    *periodicBackups
 
 	api.UnimplementedInWorkspaceServiceServer
 	api.UnimplementedWorkspaceContentServiceServer
@@ -61,7 +65,7 @@ type WorkspaceService struct {
 type WorkspaceExistenceCheck func(instanceID string) bool
 
 // NewWorkspaceService creates a new workspce initialization service, starts housekeeping and the Prometheus integration
-func NewWorkspaceService(ctx context.Context, cfg Config, kubernetesNamespace string, runtime container.Runtime, wec WorkspaceExistenceCheck, uidmapper *iws.Uidmapper, reg prometheus.Registerer) (res *WorkspaceService, err error) {
+func NewWorkspaceService(ctx context.Context, cfg Config, kubernetesNamespace string, runtime container.Runtime, wec WorkspaceExistenceCheck, uidmapper *iws.Uidmapper, notices iws.NoticeProvider, reg prometheus.Registerer) (res *WorkspaceService, err error) {
 	//nolint:ineffassign
 	span, ctx := opentracing.StartSpanFromContext(ctx, "NewWorkspaceService")
 	defer tracing.FinishSpan(span, &err)
@@ -72,8 +76,18 @@ func NewWorkspaceService(ctx context.Context, cfg Config, kubernetesNamespace st
 		return nil, xerrors.Errorf("cannot create working area: %w", err)
 	}
 
+	var quotaEnforcer quota.Enforcer
+	if cfg.WorkspaceSizeLimit > 0 {
+		quotaEnforcer, err = quota.NewEnforcer(cfg.Quota.Mode, cfg.WorkingArea)
+		if err != nil {
+			return nil, xerrors.Errorf("cannot enforce workspace quota: %w", err)
+		}
+	} else if cfg.Quota.Mode != quota.ModeNone {
+		log.WithField("mode", cfg.Quota.Mode).Warn("quota mode is configured, but there's no workspace size limit - not enforcing quotas")
+	}
+
 	// read all session json files
-	store, err := session.NewStore(ctx, cfg.WorkingArea, workspaceLifecycleHooks(cfg, kubernetesNamespace, wec, uidmapper))
+	store, err := session.NewStore(ctx, cfg.WorkingArea, workspaceLifecycleHooks(cfg, kubernetesNamespace, wec, uidmapper, quotaEnforcer, notices))
 	if err != nil {
 		return nil, xerrors.Errorf("cannot create session store: %w", err)
 	}
@@ -89,6 +103,8 @@ func NewWorkspaceService(ctx context.Context, cfg Config, kubernetesNamespace st
 		ctx:         ctx,
 		stopService: stopService,
 		runtime:     runtime,
+		quota:       quotaEnforcer,
+		periodic:    newPeriodicBackups(cfg.Backup.PeriodicConcurrency),
 	}, nil
 }
 
@@ -116,6 +132,7 @@ func registerWorkingAreaDiskspaceGauge(workingArea string, reg prometheus.Regist
 // Start starts this workspace service and returns when the service gets stopped.
 // This function is intended to run as Go routine.
 func (s *WorkspaceService) Start() {
+	go s.runPeriodicBackups(s.ctx)
 	s.store.StartHousekeeping(s.ctx, 5*time.Minute)
 }
 
@@ -152,7 +169,8 @@ func (s *WorkspaceService) InitWorkspace(ctx context.Context, req *api.InitWorks
 	log.Info("InitWorkspace called")
 
 	var (
-		wsloc string
+		wsloc       string
+		contentSize uint64
 	)
 	if req.FullWorkspaceBackup {
 		if s.runtime == nil {
@@ -178,6 +196,20 @@ func (s *WorkspaceService) InitWorkspace(ctx context.Context, req *api.InitWorks
 		return nil, err
 	}
 
+	if !req.RemoteStorageDisabled && len(workspace.PersistentVolumes) > 0 {
+		rs, ok := workspace.NonPersistentAttrs[session.AttrRemoteStorage].(storage.DirectAccess)
+		if rs == nil || !ok {
+			log.Error("workspace has no remote storage")
+			return nil, status.Error(codes.FailedPrecondition, "workspace has no remote storage")
+		}
+
+		err = s.restorePersistentVolumes(ctx, workspace, rs)
+		if err != nil {
+			log.WithError(err).Error("cannot restore persistent volumes")
+			return nil, status.Error(codes.Internal, fmt.Sprintf("cannot restore persistent volumes: %s", err.Error()))
+		}
+	}
+
 	if !req.FullWorkspaceBackup {
 		var remoteContent map[string]storage.DownloadInfo
 
@@ -204,6 +236,13 @@ func (s *WorkspaceService) InitWorkspace(ctx context.Context, req *api.InitWorks
 				log.WithError(err).Error("cannot collect remote content")
 				return nil, status.Error(codes.Internal, "remote content error")
 			}
+			if _, ok := remoteContent[storage.DefaultBackup]; ok {
+				// the initializer prefers the backup over anything else
+				err = workspace.SetInitSource(initSourceBackup)
+				if err != nil {
+					log.WithError(err).Warn("cannot persist the init source")
+				}
+			}
 		}
 
 		// This task/call cannot be canceled. Once it's started it's brought to a conclusion, independent of the caller disconnecting
@@ -233,6 +272,17 @@ func (s *WorkspaceService) InitWorkspace(ctx context.Context, req *api.InitWorks
 			log.WithError(err).WithField("workspaceId", req.Id).Error("cannot initialize workspace")
 			return nil, status.Error(codes.Internal, fmt.Sprintf("cannot initialize workspace: %s", err.Error()))
 		}
+
+		// The content size is informational only (it ends up in the workspace start timings), hence failing to determine
+		// it must not fail the initialization. We only report it if the disk quota tells us, as walking the content would
+		// delay the workspace start.
+		if s.quota != nil {
+			used, _, err := s.quota.GetUsage(workspace.Location, workspace.QuotaID)
+			if err != nil {
+				log.WithError(err).Warn("cannot determine workspace content size")
+			}
+			contentSize = uint64(used)
+		}
 	}
 
 	// Tell the world we're done
@@ -242,7 +292,31 @@ func (s *WorkspaceService) InitWorkspace(ctx context.Context, req *api.InitWorks
 		return nil, status.Error(codes.Internal, fmt.Sprintf("cannot finish workspace init: %v", err))
 	}
 
-	return &api.InitWorkspaceResponse{}, nil
+	return &api.InitWorkspaceResponse{
+		ContentSize: contentSize,
+	}, nil
+}
+
+// getContentSize sums up the size of all regular files below location
+func getContentSize(location string) (size uint64, err error) {
+	err = filepath.WalkDir(location, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		size += uint64(info.Size())
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return size, nil
 }
 
 func (s *WorkspaceService) creator(req *api.InitWorkspaceRequest) session.WorkspaceFactory {
@@ -257,6 +331,11 @@ func (s *WorkspaceService) creator(req *api.InitWorkspaceRequest) session.Worksp
 			FullWorkspaceBackup:   req.FullWorkspaceBackup,
 			ContentManifest:       req.ContentManifest,
 			RemoteStorageDisabled: req.RemoteStorageDisabled,
+			PersistentVolumes:     req.PersistentVolumes,
+			InitSource:            getInitSource(req),
+
+			PeriodicBackupInterval: req.PeriodicBackupInterval.AsDuration(),
+			EgressPolicy:           req.EgressPolicy,
 
 			ServiceLocDaemon: filepath.Join(s.config.WorkingArea, req.Id+"-daemon"),
 			ServiceLocNode:   filepath.Join(s.config.WorkingAreaNode, req.Id+"-daemon"),
@@ -264,6 +343,40 @@ func (s *WorkspaceService) creator(req *api.InitWorkspaceRequest) session.Worksp
 	}
 }
 
+const (
+	initSourceBackup              = "backup"
+	initSourceFullWorkspaceBackup = "full workspace backup"
+)
+
+// getInitSource describes where the content of a workspace will come from based on its initializer.
+// Should a backup exist, the backup takes precedence over the initializer.
+func getInitSource(req *api.InitWorkspaceRequest) string {
+	if req.FullWorkspaceBackup {
+		return initSourceFullWorkspaceBackup
+	}
+
+	switch req.Initializer.GetSpec().(type) {
+	case *csapi.WorkspaceInitializer_Empty:
+		return "empty"
+	case *csapi.WorkspaceInitializer_Git:
+		return "git"
+	case *csapi.WorkspaceInitializer_Snapshot:
+		return "snapshot"
+	case *csapi.WorkspaceInitializer_Prebuild:
+		return "prebuild"
+	case *csapi.WorkspaceInitializer_Composite:
+		return "composite"
+	case *csapi.WorkspaceInitializer_Download:
+		return "download"
+	case *csapi.WorkspaceInitializer_Backup:
+		return initSourceBackup
+	case *csapi.WorkspaceInitializer_Oci:
+		return "oci"
+	default:
+		return ""
+	}
+}
+
 // getCheckoutLocation returns the first checkout location found of any Git initializer configured by this request
 func getCheckoutLocation(req *api.InitWorkspaceRequest) string {
 	spec := req.Initializer.Spec
@@ -318,6 +431,9 @@ func (s *WorkspaceService) DisposeWorkspace(ctx context.Context, req *api.Dispos
 		return resp, nil
 	}
 
+	// A periodic backup finishing after the final one would overwrite it with older content
+	s.stopPeriodicBackup(sess.InstanceID)
+
 	if req.BackupLogs {
 		// Ok, we have to do all the work
 		err = s.uploadWorkspaceLogs(ctx, sess)
@@ -340,11 +456,18 @@ func (s *WorkspaceService) DisposeWorkspace(ctx context.Context, req *api.Dispos
 			backupName = fmt.Sprintf(storage.FmtFullWorkspaceBackup, time.Now().UnixNano())
 		}
 
-		err = s.uploadWorkspaceContent(ctx, sess, backupName, mfName)
+		err = s.uploadWorkspaceContent(ctx, sess, backupName, mfName, "")
 		if err != nil {
 			log.WithError(err).WithFields(sess.OWI()).Error("final backup failed")
 			return nil, status.Error(codes.DataLoss, "final backup failed")
 		}
+
+		err = s.uploadPersistentVolumes(ctx, sess)
+		if err != nil {
+			// we keep the volumes on the node, hence a retried disposal can back them up
+			log.WithError(err).WithFields(sess.OWI()).Error("persistent volume backup failed")
+			return nil, status.Error(codes.DataLoss, "persistent volume backup failed")
+		}
 	}
 
 	// Update the git status prior to deleting the workspace
@@ -380,10 +503,18 @@ func (s *WorkspaceService) DisposeWorkspace(ctx context.Context, req *api.Dispos
 		log.WithError(err).WithField("workspaceId", req.Id).Error("cannot delete workspace daemon directory")
 	}
 
+	// remove the persistent volumes in the node - their content lives in remote storage
+	s.removePersistentVolumes(sess)
+
 	return resp, nil
 }
 
-func (s *WorkspaceService) uploadWorkspaceContent(ctx context.Context, sess *session.Workspace, backupName, mfName string) (err error) {
+// fullWorkspaceBackupWorkspaceDir is where the workspace content lives in a full workspace backup, relative to its root
+const fullWorkspaceBackupWorkspaceDir = "workspace"
+
+// uploadWorkspaceContent uploads the content of a workspace to remote storage. If backup trailing is enabled, the backup
+// it replaces is kept in the backup trail, or in the named trail if trail is not empty.
+func (s *WorkspaceService) uploadWorkspaceContent(ctx context.Context, sess *session.Workspace, backupName, mfName, trail string) (err error) {
 	//nolint:ineffassign
 	span, ctx := opentracing.StartSpanFromContext(ctx, "uploadWorkspaceContent")
 	defer tracing.FinishSpan(span, &err)
@@ -404,15 +535,10 @@ func (s *WorkspaceService) uploadWorkspaceContent(ctx context.Context, sess *ses
 		}
 	}
 
-	err = os.Remove(filepath.Join(sess.Location, wsinit.WorkspaceReadyFile))
-	if err != nil && !os.IsNotExist(err) {
-		// We'll still upload the backup, well aware that the UX during restart will be broken.
-		// But it's better to have a backup with all files (albeit one too many), than having no backup at all.
-		log.WithError(err).WithFields(sess.OWI()).Warn("cannot remove workspace ready file")
-	}
-
-	if s.config.Storage.BackupTrail.Enabled && !sess.FullWorkspaceBackup {
+	if s.config.Storage.BackupTrail.Enabled && !sess.FullWorkspaceBackup && trail == "" {
 		opts = append(opts, storage.WithBackupTrail("trail", s.config.Storage.BackupTrail.MaxLength))
+	} else if s.config.Storage.BackupTrail.Enabled && !sess.FullWorkspaceBackup {
+		opts = append(opts, storage.WithNamedBackupTrail(trail, "trail", s.config.Storage.BackupTrail.MaxLength))
 	}
 
 	rs, ok := sess.NonPersistentAttrs[session.AttrRemoteStorage].(storage.DirectAccess)
@@ -420,6 +546,10 @@ func (s *WorkspaceService) uploadWorkspaceContent(ctx context.Context, sess *ses
 		return xerrors.Errorf("no remote storage configured")
 	}
 
+	progress := session.BackupProgress{Phase: session.BackupArchiving, StartedAt: time.Now()}
+	sess.SetBackupProgress(&progress)
+	defer sess.SetBackupProgress(nil)
+
 	var (
 		tmpf       *os.File
 		tmpfSize   int64
@@ -432,8 +562,19 @@ func (s *WorkspaceService) uploadWorkspaceContent(ctx context.Context, sess *ses
 		}
 		defer tmpf.Close()
 
+		// Were the ready file part of the backup, the workspace would appear ready while the backup is still being
+		// restored. We leave it out rather than remove it, because we also back up running workspaces.
+		readyFile := wsinit.WorkspaceReadyFile
+		if sess.FullWorkspaceBackup {
+			// the upper directory holds the changes to the whole root filesystem of the workspace
+			readyFile = filepath.Join(fullWorkspaceBackupWorkspaceDir, wsinit.WorkspaceReadyFile)
+		}
+
 		var opts []archive.TarOption
-		opts = append(opts, archive.TarbalMaxSize(int64(s.config.WorkspaceSizeLimit)))
+		opts = append(opts,
+			archive.TarbalMaxSize(int64(s.config.WorkspaceSizeLimit)),
+			archive.WithExcludedFiles(readyFile),
+		)
 		if !sess.FullWorkspaceBackup {
 			mappings := []archive.IDMapping{
 				{ContainerID: 0, HostID: wsinit.GitpodUID, Size: 1},
@@ -481,6 +622,10 @@ func (s *WorkspaceService) uploadWorkspaceContent(ctx context.Context, sess *ses
 		}
 	}()
 
+	progress.Phase = session.BackupUploading
+	progress.ArchiveSize = tmpfSize
+	sess.SetBackupProgress(&progress)
+
 	var (
 		layerBucket string
 		layerObject string
@@ -509,6 +654,10 @@ func (s *WorkspaceService) uploadWorkspaceContent(ctx context.Context, sess *ses
 		return xerrors.Errorf("cannot upload workspace content: %w", err)
 	}
 
+	if sess.FullWorkspaceBackup {
+		progress.Phase = session.BackupUploadingManifest
+		sess.SetBackupProgress(&progress)
+	}
 	err = retryIfErr(ctx, s.config.Backup.Attempts, log.WithFields(sess.OWI()).WithField("op", "upload manifest"), func(ctx context.Context) (err error) {
 		if !sess.FullWorkspaceBackup {
 			return
@@ -698,17 +847,71 @@ func (s *WorkspaceService) TakeSnapshot(ctx context.Context, req *api.TakeSnapsh
 		snapshotName = rs.Qualify(backupName)
 	}
 
-	err = s.uploadWorkspaceContent(ctx, sess, backupName, mfName)
+	err = s.uploadWorkspaceContent(ctx, sess, backupName, mfName, "")
 	if err != nil {
 		log.WithError(err).WithField("workspaceId", req.Id).Error("snapshot upload failed")
 		return nil, status.Error(codes.Internal, "cannot upload snapshot")
 	}
+	err = s.uploadPersistentVolumes(ctx, sess)
+	if err != nil {
+		log.WithError(err).WithField("workspaceId", req.Id).Error("persistent volume upload failed")
+		return nil, status.Error(codes.Internal, "cannot upload persistent volumes")
+	}
 
 	return &api.TakeSnapshotResponse{
 		Url: snapshotName,
 	}, nil
 }
 
+// Workspaces lists all workspaces this service manages
+func (s *WorkspaceService) Workspaces() []*session.Workspace {
+	return s.store.List()
+}
+
+// DiskUsage reports the disk space used by the workspace content. If a quota is enforced on the workspace,
+// the usage is taken from the quota and limit is the quota. Otherwise we sum up the size of the content and limit is zero.
+func (s *WorkspaceService) DiskUsage(ws *session.Workspace) (used, limit int64, err error) {
+	if s.quota != nil && !ws.FullWorkspaceBackup {
+		u, l, err := s.quota.GetUsage(ws.Location, ws.QuotaID)
+		if err != nil {
+			return 0, 0, err
+		}
+		return int64(u), int64(l), nil
+	}
+	if ws.Location == "" {
+		// full workspace backup workspaces have no content on the node we could measure
+		return 0, 0, nil
+	}
+
+	size, err := getContentSize(ws.Location)
+	if err != nil {
+		return 0, 0, err
+	}
+	return int64(size), 0, nil
+}
+
+// QuotaUsage reports the disk space used by each workspace whose content is subject to a quota, keyed by instance ID.
+// Workspaces without a quota are not part of the result. If no quota is enforced at all this function returns nil.
+func (s *WorkspaceService) QuotaUsage() map[string]uint64 {
+	if s.quota == nil {
+		return nil
+	}
+
+	res := make(map[string]uint64)
+	for _, ws := range s.store.List() {
+		if ws.FullWorkspaceBackup || ws.Location == "" {
+			continue
+		}
+		used, _, err := s.quota.GetUsage(ws.Location, ws.QuotaID)
+		if err != nil {
+			log.WithError(err).WithFields(ws.OWI()).Debug("cannot get quota usage")
+			continue
+		}
+		res[ws.InstanceID] = uint64(used)
+	}
+	return res
+}
+
 // Close ends this service and its housekeeping
 func (s *WorkspaceService) Close() error {
 	s.stopService()
@@ -748,7 +951,7 @@ func (c *cannotCancelContext) Value(key interface{}) interface{} {
 	return c.Delegate.Value(key)
 }
 
-func workspaceLifecycleHooks(cfg Config, kubernetesNamespace string, workspaceExistenceCheck WorkspaceExistenceCheck, uidmapper *iws.Uidmapper) map[session.WorkspaceState][]session.WorkspaceLivecycleHook {
+func workspaceLifecycleHooks(cfg Config, kubernetesNamespace string, workspaceExistenceCheck WorkspaceExistenceCheck, uidmapper *iws.Uidmapper, quotaEnforcer quota.Enforcer, notices iws.NoticeProvider) map[session.WorkspaceState][]session.WorkspaceLivecycleHook {
 	var setupWorkspace session.WorkspaceLivecycleHook = func(ctx context.Context, ws *session.Workspace) error {
 		if _, ok := ws.NonPersistentAttrs[session.AttrRemoteStorage]; !ws.RemoteStorageDisabled && !ok {
 			remoteStorage, err := storage.NewDirectAccess(&cfg.Storage)
@@ -772,14 +975,42 @@ func workspaceLifecycleHooks(cfg Config, kubernetesNamespace string, workspaceEx
 		return nil
 	}
 
+	// setupQuota limits the disk space the workspace content can use. This has to happen before the content
+	// is initialized, because quotas only account for files written after they were set up.
+	var setupQuota session.WorkspaceLivecycleHook = func(ctx context.Context, ws *session.Workspace) error {
+		if quotaEnforcer == nil || ws.FullWorkspaceBackup {
+			return nil
+		}
+
+		id, err := quotaEnforcer.SetQuota(ws.Location, cfg.WorkspaceSizeLimit)
+		if err != nil {
+			return xerrors.Errorf("cannot enforce workspace quota: %w", err)
+		}
+		ws.QuotaID = id
+		log.WithFields(ws.OWI()).WithField("quota", cfg.WorkspaceSizeLimit.String()).WithField("quotaID", id).Info("enforcing workspace quota")
+
+		return nil
+	}
+	var removeQuota session.WorkspaceLivecycleHook = func(ctx context.Context, ws *session.Workspace) error {
+		if quotaEnforcer == nil || ws.FullWorkspaceBackup {
+			return nil
+		}
+
+		err := quotaEnforcer.RemoveQuota(ws.Location, ws.QuotaID)
+		if err != nil {
+			return xerrors.Errorf("cannot remove workspace quota: %w", err)
+		}
+		return nil
+	}
+
 	// startIWS starts the in-workspace service for a workspace. This lifecycle hook is idempotent, hence can - and must -
 	// be called on initialization and ready. The on-ready hook exists only to support ws-daemon restarts.
-	startIWS := iws.ServeWorkspace(uidmapper, api.FSShiftMethod(cfg.UserNamespaces.FSShift))
+	startIWS := iws.ServeWorkspace(uidmapper, api.FSShiftMethod(cfg.UserNamespaces.FSShift), quotaEnforcer, notices, cfg.EgressPolicy)
 
 	return map[session.WorkspaceState][]session.WorkspaceLivecycleHook{
-		session.WorkspaceInitializing: {setupWorkspace, startIWS},
+		session.WorkspaceInitializing: {setupQuota, setupWorkspace, startIWS},
 		session.WorkspaceReady:        {setupWorkspace, startIWS},
-		session.WorkspaceDisposed:     {iws.StopServingWorkspace},
+		session.WorkspaceDisposed:     {iws.StopServingWorkspace, removeQuota},
 	}
 }
 
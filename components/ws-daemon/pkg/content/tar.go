@@ -39,6 +39,9 @@ import (
 type TarOptions struct {
 	UIDMaps []idtools.IDMap
 	GIDMaps []idtools.IDMap
+
+	// ExcludedFiles are left out of the archive. The paths are relative to srcPath.
+	ExcludedFiles []string
 }
 
 // tarWithOptions creates an archive from the directory at `path`
@@ -72,6 +75,10 @@ func TarWithOptions(srcPath string, options *TarOptions) (io.ReadCloser, error)
 		defer pools.BufioWriter32KPool.Put(ta.Buffer)
 
 		seen := make(map[string]bool)
+		// we skip excluded files as if we had added them already
+		for _, p := range options.ExcludedFiles {
+			seen["./"+filepath.Clean(p)] = true
+		}
 
 		_ = filepath.Walk(srcPath, func(filePath string, f os.FileInfo, err error) error {
 			if err != nil {
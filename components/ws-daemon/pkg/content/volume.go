@@ -0,0 +1,129 @@
+// Copyright (c) 2021 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package content
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/opentracing/opentracing-go"
+	"golang.org/x/xerrors"
+
+	"github.com/gitpod-io/gitpod/common-go/log"
+	"github.com/gitpod-io/gitpod/common-go/tracing"
+	"github.com/gitpod-io/gitpod/content-service/pkg/archive"
+	wsinit "github.com/gitpod-io/gitpod/content-service/pkg/initializer"
+	"github.com/gitpod-io/gitpod/content-service/pkg/storage"
+	"github.com/gitpod-io/gitpod/ws-daemon/pkg/internal/session"
+)
+
+// persistentVolumeLocation returns the location of a persistent volume in the working area.
+// ws-manager mounts the same location (as seen from the node) into the workspace pod.
+func (s *WorkspaceService) persistentVolumeLocation(sess *session.Workspace, name string) string {
+	return filepath.Join(s.config.WorkingArea, fmt.Sprintf("%s-vol-%s", sess.InstanceID, name))
+}
+
+// restorePersistentVolumes downloads the content of all persistent volumes of a workspace.
+// Volumes which have never been backed up before start out empty.
+func (s *WorkspaceService) restorePersistentVolumes(ctx context.Context, sess *session.Workspace, rs storage.DirectAccess) (err error) {
+	//nolint:ineffassign
+	span, ctx := opentracing.StartSpanFromContext(ctx, "restorePersistentVolumes")
+	defer tracing.FinishSpan(span, &err)
+
+	mappings := []archive.IDMapping{
+		{ContainerID: 0, HostID: wsinit.GitpodUID, Size: 1},
+		{ContainerID: 1, HostID: 100000, Size: 65534},
+	}
+	for _, name := range sess.PersistentVolumes {
+		loc := s.persistentVolumeLocation(sess, name)
+		err = os.MkdirAll(loc, 0755)
+		if err != nil {
+			return xerrors.Errorf("cannot create persistent volume %s: %w", name, err)
+		}
+
+		found, err := rs.DownloadVolume(ctx, loc, name, mappings)
+		if err != nil {
+			return xerrors.Errorf("cannot restore persistent volume %s: %w", name, err)
+		}
+		if !found {
+			log.WithFields(sess.OWI()).WithField("volume", name).Debug("persistent volume has no content yet")
+		}
+
+		// The volume root must belong to the gitpod user in the workspace, otherwise it cannot write to it.
+		// This is a bit of a hack as it makes the same assumptions about the UID mapping as the initializer does.
+		err = os.Chown(loc, wsinit.GitpodUID+100000-1, wsinit.GitpodGID+100000-1)
+		if err != nil {
+			return xerrors.Errorf("cannot chown persistent volume %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// uploadPersistentVolumes backs up the content of all persistent volumes of a workspace
+func (s *WorkspaceService) uploadPersistentVolumes(ctx context.Context, sess *session.Workspace) (err error) {
+	if len(sess.PersistentVolumes) == 0 {
+		return nil
+	}
+
+	//nolint:ineffassign
+	span, ctx := opentracing.StartSpanFromContext(ctx, "uploadPersistentVolumes")
+	defer tracing.FinishSpan(span, &err)
+
+	rs, ok := sess.NonPersistentAttrs[session.AttrRemoteStorage].(storage.DirectAccess)
+	if rs == nil || !ok {
+		return xerrors.Errorf("no remote storage configured")
+	}
+
+	mappings := []archive.IDMapping{
+		{ContainerID: 0, HostID: wsinit.GitpodUID, Size: 1},
+		{ContainerID: 1, HostID: 100000, Size: 65534},
+	}
+	for _, name := range sess.PersistentVolumes {
+		loc := s.persistentVolumeLocation(sess, name)
+		if _, err := os.Stat(loc); os.IsNotExist(err) {
+			log.WithFields(sess.OWI()).WithField("volume", name).Warn("persistent volume does not exist - not backing it up")
+			continue
+		}
+
+		err = retryIfErr(ctx, s.config.Backup.Attempts, log.WithFields(sess.OWI()).WithField("op", "upload volume").WithField("volume", name), func(ctx context.Context) (err error) {
+			tmpf, err := os.CreateTemp(s.config.TmpDir, fmt.Sprintf("wsvol-%s-%s-*.tar", sess.InstanceID, name))
+			if err != nil {
+				return
+			}
+			tmpf.Close()
+			defer os.Remove(tmpf.Name())
+
+			err = BuildTarbal(ctx, loc, tmpf.Name(), false,
+				archive.TarbalMaxSize(int64(s.config.WorkspaceSizeLimit)),
+				archive.WithUIDMapping(mappings),
+				archive.WithGIDMapping(mappings),
+			)
+			if err != nil {
+				return
+			}
+
+			_, _, err = rs.UploadVolume(ctx, tmpf.Name(), name)
+			return
+		})
+		if err != nil {
+			return xerrors.Errorf("cannot upload persistent volume %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// removePersistentVolumes removes the persistent volumes of a workspace from the working area
+func (s *WorkspaceService) removePersistentVolumes(sess *session.Workspace) {
+	for _, name := range sess.PersistentVolumes {
+		err := os.RemoveAll(s.persistentVolumeLocation(sess, name))
+		if err != nil {
+			log.WithError(err).WithFields(sess.OWI()).WithField("volume", name).Error("cannot remove persistent volume")
+		}
+	}
+}
@@ -6,8 +6,10 @@ package daemon
 
 import (
 	"context"
+	"path/filepath"
 
 	"github.com/containerd/cgroups"
+	"github.com/gitpod-io/gitpod/ws-daemon/pkg/cgroup"
 	"github.com/gitpod-io/gitpod/ws-daemon/pkg/dispatch"
 	"github.com/opencontainers/runtime-spec/specs-go"
 	"golang.org/x/xerrors"
@@ -20,12 +22,18 @@ var (
 
 type CgroupCustomizer struct {
 	cgroupBasePath string
+	unified        bool
 }
 
 func (c *CgroupCustomizer) WithCgroupBasePath(basePath string) {
 	c.cgroupBasePath = basePath
 }
 
+// WithUnifiedCgroups makes the customizer use the unified (cgroup v2) hierarchy
+func (c *CgroupCustomizer) WithUnifiedCgroups(unified bool) {
+	c.unified = unified
+}
+
 // WorkspaceAdded will customize the cgroups for every workspace that is started
 func (c *CgroupCustomizer) WorkspaceAdded(ctx context.Context, ws *dispatch.Workspace) error {
 	disp := dispatch.GetFromContext(ctx)
@@ -38,6 +46,28 @@ func (c *CgroupCustomizer) WorkspaceAdded(ctx context.Context, ws *dispatch.Work
 		return xerrors.Errorf("cannot start governer: %w", err)
 	}
 
+	// /dev/fuse
+	fuse := specs.LinuxDeviceCgroup{
+		Type:   "c",
+		Minor:  &fuseDeviceMinor,
+		Major:  &fuseDeviceMajor,
+		Access: "rwm",
+		Allow:  true,
+	}
+
+	if c.unified {
+		// The unified hierarchy has no devices controller. Device access is governed by eBPF programs instead.
+		granted, err := disp.Runtime.ContainerDevices(context.Background(), ws.ContainerID)
+		if err != nil {
+			return xerrors.Errorf("cannot find devices of container %s: %w", ws.ContainerID, err)
+		}
+		err = cgroup.ReplaceDeviceFilter(filepath.Join(c.cgroupBasePath, cgroupPath), granted, []specs.LinuxDeviceCgroup{fuse})
+		if err != nil {
+			return xerrors.Errorf("cannot update device filter of cgroup %s: %w", cgroupPath, err)
+		}
+		return nil
+	}
+
 	control, err := cgroups.Load(c.customV1, cgroups.StaticPath(cgroupPath))
 
 	if err != nil {
@@ -45,16 +75,7 @@ func (c *CgroupCustomizer) WorkspaceAdded(ctx context.Context, ws *dispatch.Work
 	}
 
 	res := &specs.LinuxResources{
-		Devices: []specs.LinuxDeviceCgroup{
-			// /dev/fuse
-			{
-				Type:   "c",
-				Minor:  &fuseDeviceMinor,
-				Major:  &fuseDeviceMajor,
-				Access: "rwm",
-				Allow:  true,
-			},
-		},
+		Devices: []specs.LinuxDeviceCgroup{fuse},
 	}
 
 	if err := control.Update(res); err != nil {
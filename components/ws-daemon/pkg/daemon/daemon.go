@@ -19,11 +19,13 @@ import (
 
 	"github.com/gitpod-io/gitpod/common-go/log"
 	"github.com/gitpod-io/gitpod/ws-daemon/api"
+	"github.com/gitpod-io/gitpod/ws-daemon/pkg/cgroup"
 	"github.com/gitpod-io/gitpod/ws-daemon/pkg/container"
 	"github.com/gitpod-io/gitpod/ws-daemon/pkg/content"
 	"github.com/gitpod-io/gitpod/ws-daemon/pkg/diskguard"
 	"github.com/gitpod-io/gitpod/ws-daemon/pkg/dispatch"
 	"github.com/gitpod-io/gitpod/ws-daemon/pkg/hosts"
+	"github.com/gitpod-io/gitpod/ws-daemon/pkg/introspection"
 	"github.com/gitpod-io/gitpod/ws-daemon/pkg/iws"
 	"github.com/gitpod-io/gitpod/ws-daemon/pkg/resources"
 )
@@ -46,16 +48,29 @@ func NewDaemon(config Config, reg prometheus.Registerer) (*Daemon, error) {
 	if nodename == "" {
 		return nil, xerrors.Errorf("NODENAME env var isn't set")
 	}
+	unifiedCgroups, err := cgroup.IsUnifiedCgroupSetup(config.Resources.CGroupsBasePath)
+	if err != nil {
+		return nil, xerrors.Errorf("cannot determine cgroup setup: %w", err)
+	}
+	log.WithField("unified", unifiedCgroups).Info("detected cgroup hierarchy")
+
 	cgCustomizer := &CgroupCustomizer{}
 	cgCustomizer.WithCgroupBasePath(config.Resources.CGroupsBasePath)
+	cgCustomizer.WithUnifiedCgroups(unifiedCgroups)
+	resourceGoverner := resources.NewDispatchListener(&config.Resources, unifiedCgroups, reg)
 	dsptch, err := dispatch.NewDispatch(containerRuntime, clientset, config.Runtime.KubernetesNamespace, nodename,
-		resources.NewDispatchListener(&config.Resources, reg),
+		resourceGoverner,
 		cgCustomizer,
 	)
 	if err != nil {
 		return nil, err
 	}
 
+	notices := diskguard.NewNotices()
+	var noticeProvider iws.NoticeProvider
+	if config.DiskSpaceGuard.EvictionEnabled() {
+		noticeProvider = notices
+	}
 	contentService, err := content.NewWorkspaceService(
 		context.Background(),
 		config.Content,
@@ -63,13 +78,14 @@ func NewDaemon(config Config, reg prometheus.Registerer) (*Daemon, error) {
 		containerRuntime,
 		dsptch.WorkspaceExistsOnNode,
 		&iws.Uidmapper{Config: config.Uidmapper, Runtime: containerRuntime},
+		noticeProvider,
 		reg,
 	)
 	if err != nil {
 		return nil, xerrors.Errorf("cannot create content service: %w", err)
 	}
 
-	dsk := diskguard.FromConfig(config.DiskSpaceGuard, clientset, nodename)
+	dsk := diskguard.FromConfig(config.DiskSpaceGuard, clientset, nodename, config.Runtime.KubernetesNamespace, notices, contentService)
 
 	hsts, err := hosts.FromConfig(config.Hosts, clientset, config.Runtime.KubernetesNamespace)
 	if err != nil {
@@ -83,6 +99,12 @@ func NewDaemon(config Config, reg prometheus.Registerer) (*Daemon, error) {
 		content:    contentService,
 		diskGuards: dsk,
 		hosts:      hsts,
+		introspection: &introspection.Server{
+			Workspaces: contentService,
+			Containers: dsptch,
+			Runtime:    containerRuntime,
+			CPULimits:  resourceGoverner,
+		},
 	}, nil
 }
 
@@ -112,10 +134,11 @@ func newClientSet(kubeconfig string) (res *kubernetes.Clientset, err error) {
 type Daemon struct {
 	Config Config
 
-	dispatch   *dispatch.Dispatch
-	content    *content.WorkspaceService
-	diskGuards []*diskguard.Guard
-	hosts      hosts.Controller
+	dispatch      *dispatch.Dispatch
+	content       *content.WorkspaceService
+	diskGuards    []*diskguard.Guard
+	hosts         hosts.Controller
+	introspection *introspection.Server
 }
 
 // Start runs all parts of the daemon until stop is called
@@ -143,6 +166,7 @@ func (d *Daemon) Start() error {
 // Register registers all gRPC services provided by this daemon
 func (d *Daemon) Register(srv *grpc.Server) {
 	api.RegisterWorkspaceContentServiceServer(srv, d.content)
+	api.RegisterWorkspaceIntrospectionServiceServer(srv, d.introspection)
 }
 
 func (d *Daemon) startReadinessSignal() {
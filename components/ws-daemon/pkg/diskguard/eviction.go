@@ -0,0 +1,255 @@
+// Copyright (c) 2021 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package diskguard
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"golang.org/x/xerrors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	wsk8s "github.com/gitpod-io/gitpod/common-go/kubernetes"
+	"github.com/gitpod-io/gitpod/common-go/log"
+)
+
+// Notices holds the messages we want to show to the users of workspaces which consume a lot of disk space.
+// Notices are shared between all guards and served to the workspaces through the in-workspace API.
+type Notices struct {
+	mu      sync.RWMutex
+	notices map[string]map[string]string
+}
+
+// NewNotices creates an empty set of notices
+func NewNotices() *Notices {
+	return &Notices{notices: make(map[string]map[string]string)}
+}
+
+// WorkspaceNotice returns the notice for a workspace instance, or an empty string if there is none
+func (n *Notices) WorkspaceNotice(instanceID string) string {
+	if n == nil {
+		return ""
+	}
+
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	for _, notices := range n.notices {
+		if msg, ok := notices[instanceID]; ok {
+			return msg
+		}
+	}
+	return ""
+}
+
+// set replaces all notices previously published by source
+func (n *Notices) set(source string, notices map[string]string) {
+	if n == nil {
+		return
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if len(notices) == 0 {
+		delete(n.notices, source)
+		return
+	}
+	n.notices[source] = notices
+}
+
+// UsageProvider reports the disk space used by workspaces without walking their content, e.g. from a disk quota
+type UsageProvider interface {
+	// QuotaUsage returns the space used by each workspace whose content is subject to a quota, keyed by instance ID.
+	QuotaUsage() map[string]uint64
+}
+
+type workspaceUsage struct {
+	InstanceID string
+	Bytes      uint64
+}
+
+// respondToPressure notifies or stops the workspaces which use the most space in the guarded path
+// depending on how much space is left.
+func (g *Guard) respondToPressure(ctx context.Context, bvail uint64) error {
+	cfg := g.Eviction
+	if bvail > cfg.NotifyBytesAvail && bvail > cfg.StopBytesAvail {
+		g.Notices.set(g.Path, nil)
+		g.stopRequested = nil
+		return nil
+	}
+
+	var quotaUsage map[string]uint64
+	if g.Usage != nil {
+		quotaUsage = g.Usage.QuotaUsage()
+	}
+	usage, err := workspaceDiskUsage(g.Path, quotaUsage)
+	if err != nil {
+		return err
+	}
+
+	notices := make(map[string]string)
+	for i, u := range usage {
+		if i >= cfg.NotifyCount {
+			break
+		}
+		log.WithField("instanceId", u.InstanceID).WithField("bytes", u.Bytes).WithField("bvail", bvail).WithField("dryRun", cfg.DryRun).Info("notifying workspace about disk pressure")
+		notices[u.InstanceID] = fmt.Sprintf("This workspace uses %s of disk space on a node that is running out of space. Please remove files you no longer need, otherwise your workspace may be stopped.", humanize.IBytes(u.Bytes))
+	}
+	if cfg.DryRun {
+		notices = nil
+	}
+	g.Notices.set(g.Path, notices)
+
+	if bvail > cfg.StopBytesAvail {
+		g.stopRequested = nil
+		return nil
+	}
+
+	// forget about workspaces which have since been removed from this node
+	present := make(map[string]struct{}, len(usage))
+	for _, u := range usage {
+		present[u.InstanceID] = struct{}{}
+	}
+	for id := range g.stopRequested {
+		if _, ok := present[id]; !ok {
+			delete(g.stopRequested, id)
+		}
+	}
+	if g.stopRequested == nil {
+		g.stopRequested = make(map[string]struct{})
+	}
+
+	var stopped int
+	for _, u := range usage {
+		if stopped >= cfg.StopCount {
+			break
+		}
+		if _, ok := g.stopRequested[u.InstanceID]; ok {
+			// this workspace is already stopping - freeing its space takes time
+			continue
+		}
+		stopped++
+
+		log := log.WithField("instanceId", u.InstanceID).WithField("bytes", u.Bytes).WithField("bvail", bvail).WithField("dryRun", cfg.DryRun)
+		if cfg.DryRun {
+			log.Warn("would stop workspace due to disk pressure")
+			continue
+		}
+		log.Warn("stopping workspace due to disk pressure")
+
+		err := g.requestStop(ctx, u.InstanceID, fmt.Sprintf("node is running out of disk space (%s available) and this workspace uses %s", humanize.IBytes(bvail), humanize.IBytes(u.Bytes)))
+		if err != nil {
+			log.WithError(err).Error("cannot stop workspace")
+			continue
+		}
+		g.stopRequested[u.InstanceID] = struct{}{}
+	}
+
+	return nil
+}
+
+// requestStop asks ws-manager to stop the workspace by annotating its pod
+func (g *Guard) requestStop(ctx context.Context, instanceID, reason string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	pods, err := g.Clientset.CoreV1().Pods(g.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s", wsk8s.WorkspaceIDLabel, instanceID),
+	})
+	if err != nil {
+		return xerrors.Errorf("cannot find workspace pod: %w", err)
+	}
+	if len(pods.Items) == 0 {
+		return xerrors.Errorf("no pod found for workspace %s", instanceID)
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{
+				wsk8s.StopRequestedAnnotation: reason,
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	for _, pod := range pods.Items {
+		_, err = g.Clientset.CoreV1().Pods(g.Namespace).Patch(ctx, pod.Name, types.MergePatchType, patch, metav1.PatchOptions{})
+		if err != nil {
+			return xerrors.Errorf("cannot annotate pod %s: %w", pod.Name, err)
+		}
+	}
+	return nil
+}
+
+// workspaceDiskUsage computes the disk space used by each workspace in path, largest consumer first.
+// Each directory in path belongs to a workspace instance and is named after its ID. If quotaUsage holds
+// the usage of a workspace we take it from there, and only walk the content of workspaces without a quota.
+func workspaceDiskUsage(path string, quotaUsage map[string]uint64) ([]workspaceUsage, error) {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, xerrors.Errorf("cannot list %s: %w", path, err)
+	}
+
+	usage := make(map[string]uint64)
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		instanceID := strings.TrimSuffix(e.Name(), "-daemon")
+		if bytes, ok := quotaUsage[instanceID]; ok && instanceID == e.Name() {
+			usage[instanceID] += bytes
+			continue
+		}
+		usage[instanceID] += dirSize(filepath.Join(path, e.Name()))
+	}
+
+	res := make([]workspaceUsage, 0, len(usage))
+	for id, bytes := range usage {
+		res = append(res, workspaceUsage{InstanceID: id, Bytes: bytes})
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Bytes == res[j].Bytes {
+			return res[i].InstanceID < res[j].InstanceID
+		}
+		return res[i].Bytes > res[j].Bytes
+	})
+	return res, nil
+}
+
+// dirSize sums up the space allocated to all files in dir. Files which disappear while we walk
+// the directory are ignored. We do not descend into other filesystems mounted below dir, e.g. the
+// mark overlay in the daemon directory of a workspace which would count the container rootfs.
+func dirSize(dir string) (size uint64) {
+	var root syscall.Stat_t
+	if err := syscall.Stat(dir, &root); err != nil {
+		return 0
+	}
+
+	_ = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		st, ok := info.Sys().(*syscall.Stat_t)
+		if !ok {
+			return nil
+		}
+		if info.IsDir() && st.Dev != root.Dev {
+			return filepath.SkipDir
+		}
+		size += uint64(st.Blocks) * 512
+		return nil
+	})
+	return
+}
@@ -0,0 +1,168 @@
+// Copyright (c) 2021 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package diskguard
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	wsk8s "github.com/gitpod-io/gitpod/common-go/kubernetes"
+)
+
+func writeWorkspaceContent(t *testing.T, base, dir string, size int) {
+	loc := filepath.Join(base, dir)
+	err := os.MkdirAll(loc, 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(loc, "content"), make([]byte, size), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWorkspaceDiskUsage(t *testing.T) {
+	base := t.TempDir()
+	writeWorkspaceContent(t, base, "small", 4096)
+	writeWorkspaceContent(t, base, "large", 64*1024)
+	writeWorkspaceContent(t, base, "large-daemon", 64*1024)
+	err := os.WriteFile(filepath.Join(base, "large.img"), make([]byte, 1024*1024), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	usage, err := workspaceDiskUsage(base, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ids []string
+	for _, u := range usage {
+		ids = append(ids, u.InstanceID)
+	}
+	if diff := cmp.Diff([]string{"large", "small"}, ids); diff != "" {
+		t.Fatalf("unexpected workspaces (-want +got):\n%s", diff)
+	}
+	if usage[0].Bytes < 128*1024 {
+		t.Errorf("expected the workspace and its daemon directory to be accounted together, got %d bytes", usage[0].Bytes)
+	}
+}
+
+func TestWorkspaceDiskUsageFromQuota(t *testing.T) {
+	base := t.TempDir()
+	writeWorkspaceContent(t, base, "small", 4096)
+	writeWorkspaceContent(t, base, "large", 64*1024)
+	writeWorkspaceContent(t, base, "large-daemon", 4096)
+
+	// the quota reports small as the largest consumer, hence its usage must not come from walking the content
+	usage, err := workspaceDiskUsage(base, map[string]uint64{"small": 1024 * 1024})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ids []string
+	for _, u := range usage {
+		ids = append(ids, u.InstanceID)
+	}
+	if diff := cmp.Diff([]string{"small", "large"}, ids); diff != "" {
+		t.Fatalf("unexpected workspaces (-want +got):\n%s", diff)
+	}
+	if usage[0].Bytes != 1024*1024 {
+		t.Errorf("expected the usage of small to be taken from the quota, got %d bytes", usage[0].Bytes)
+	}
+}
+
+func TestRespondToPressure(t *testing.T) {
+	const (
+		notifyBelow = 100
+		stopBelow   = 10
+	)
+	tests := []struct {
+		Name          string
+		Bvail         uint64
+		DryRun        bool
+		Rounds        int
+		Notices       []string
+		StopRequested []string
+	}{
+		{Name: "no pressure", Bvail: 1000, Rounds: 1},
+		{Name: "notify", Bvail: 50, Rounds: 1, Notices: []string{"ws-large", "ws-medium"}},
+		{Name: "stop", Bvail: 5, Rounds: 1, Notices: []string{"ws-large", "ws-medium"}, StopRequested: []string{"ws-large"}},
+		{Name: "stop next largest", Bvail: 5, Rounds: 2, Notices: []string{"ws-large", "ws-medium"}, StopRequested: []string{"ws-large", "ws-medium"}},
+		{Name: "dry run", Bvail: 5, Rounds: 1, DryRun: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			base := t.TempDir()
+			writeWorkspaceContent(t, base, "ws-small", 4096)
+			writeWorkspaceContent(t, base, "ws-medium", 64*1024)
+			writeWorkspaceContent(t, base, "ws-large", 256*1024)
+
+			var pods []*corev1.Pod
+			for _, id := range []string{"ws-small", "ws-medium", "ws-large"} {
+				pods = append(pods, &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "pod-" + id,
+						Namespace: "default",
+						Labels:    map[string]string{wsk8s.WorkspaceIDLabel: id},
+					},
+				})
+			}
+			clientset := fake.NewSimpleClientset(pods[0], pods[1], pods[2])
+
+			g := &Guard{
+				Path: base,
+				Eviction: &EvictionConfig{
+					NotifyBytesAvail: notifyBelow,
+					NotifyCount:      2,
+					StopBytesAvail:   stopBelow,
+					StopCount:        1,
+					DryRun:           test.DryRun,
+				},
+				Clientset: clientset,
+				Namespace: "default",
+				Notices:   NewNotices(),
+			}
+			for i := 0; i < test.Rounds; i++ {
+				err := g.respondToPressure(context.Background(), test.Bvail)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var notices []string
+			for _, id := range []string{"ws-large", "ws-medium", "ws-small"} {
+				if g.Notices.WorkspaceNotice(id) != "" {
+					notices = append(notices, id)
+				}
+			}
+			if diff := cmp.Diff(test.Notices, notices); diff != "" {
+				t.Errorf("unexpected notices (-want +got):\n%s", diff)
+			}
+
+			var stopRequested []string
+			for _, id := range []string{"ws-large", "ws-medium", "ws-small"} {
+				pod, err := clientset.CoreV1().Pods("default").Get(context.Background(), "pod-"+id, metav1.GetOptions{})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if _, ok := pod.Annotations[wsk8s.StopRequestedAnnotation]; ok {
+					stopRequested = append(stopRequested, id)
+				}
+			}
+			if diff := cmp.Diff(test.StopRequested, stopRequested); diff != "" {
+				t.Errorf("unexpected stop requests (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
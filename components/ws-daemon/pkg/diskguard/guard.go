@@ -31,11 +31,44 @@ type Config struct {
 	Locations []struct {
 		Path          string `json:"path"`
 		MinBytesAvail uint64 `json:"minBytesAvail"`
+
+		// Eviction configures the response to disk pressure for the workspaces whose content lives in this location
+		Eviction *EvictionConfig `json:"eviction,omitempty"`
 	} `json:"locations"`
 }
 
-// FromConfig produces a set of disk space guards from the configuration
-func FromConfig(cfg Config, clientset kubernetes.Interface, nodeName string) []*Guard {
+// EvictionConfig configures the graded response to disk pressure. Once the available space falls below
+// NotifyBytesAvail, we notify the users of the largest workspaces. Should it fall below StopBytesAvail,
+// we ask ws-manager to back up and stop the largest workspaces.
+type EvictionConfig struct {
+	// NotifyBytesAvail is the available space below which we notify the largest consumers
+	NotifyBytesAvail uint64 `json:"notifyBytesAvail"`
+	// NotifyCount is the number of largest consumers we notify
+	NotifyCount int `json:"notifyCount"`
+	// StopBytesAvail is the available space below which we stop the largest consumers
+	StopBytesAvail uint64 `json:"stopBytesAvail"`
+	// StopCount is the number of largest consumers we stop per interval
+	StopCount int `json:"stopCount"`
+	// DryRun logs the actions we would take without notifying or stopping any workspace
+	DryRun bool `json:"dryRun"`
+}
+
+// EvictionEnabled returns true if any of the guarded locations responds to disk pressure by notifying or stopping workspaces
+func (c Config) EvictionEnabled() bool {
+	if !c.Enabled {
+		return false
+	}
+	for _, loc := range c.Locations {
+		if loc.Eviction != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// FromConfig produces a set of disk space guards from the configuration.
+// Notices for workspace users are published to notices.
+func FromConfig(cfg Config, clientset kubernetes.Interface, nodeName, namespace string, notices *Notices, usage UsageProvider) []*Guard {
 	if !cfg.Enabled {
 		return nil
 	}
@@ -45,9 +78,13 @@ func FromConfig(cfg Config, clientset kubernetes.Interface, nodeName string) []*
 		res[i] = &Guard{
 			Path:          loc.Path,
 			MinBytesAvail: loc.MinBytesAvail,
+			Eviction:      loc.Eviction,
 			Interval:      time.Duration(cfg.Interval),
 			Clientset:     clientset,
 			Nodename:      nodeName,
+			Namespace:     namespace,
+			Notices:       notices,
+			Usage:         usage,
 		}
 	}
 
@@ -58,12 +95,21 @@ func FromConfig(cfg Config, clientset kubernetes.Interface, nodeName string) []*
 // If the percentage of used space goes above a certain threshold,
 // we'll label the node accordingly - and remove the label once that condition
 // subsides.
+//
+// If eviction is configured, the guard also accounts for the space used by each workspace
+// in the path and notifies or stops the largest consumers.
 type Guard struct {
 	Path          string
 	MinBytesAvail uint64
+	Eviction      *EvictionConfig
 	Interval      time.Duration
 	Clientset     kubernetes.Interface
 	Nodename      string
+	Namespace     string
+	Notices       *Notices
+	Usage         UsageProvider
+
+	stopRequested map[string]struct{}
 }
 
 // Start starts the disk guard
@@ -83,6 +129,13 @@ func (g *Guard) Start() {
 			log.WithError(err).Error("cannot update node label")
 		}
 
+		if g.Eviction != nil {
+			err = g.respondToPressure(context.Background(), bvail)
+			if err != nil {
+				log.WithError(err).WithField("path", g.Path).Error("cannot respond to disk pressure")
+			}
+		}
+
 		<-t.C
 	}
 }
@@ -169,6 +169,18 @@ func (d *Dispatch) WorkspaceExistsOnNode(instanceID string) (ok bool) {
 	return
 }
 
+// Workspace returns a copy of what the dispatch knows about a workspace instance
+func (d *Dispatch) Workspace(instanceID string) (ws Workspace, ok bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	state, ok := d.ctxs[instanceID]
+	if !ok || state.Workspace == nil {
+		return Workspace{}, false
+	}
+	return *state.Workspace, true
+}
+
 func (d *Dispatch) handlePodUpdate(oldPod, newPod *corev1.Pod) {
 	workspaceID, ok := newPod.Labels[wsk8s.MetaIDLabel]
 	if !ok {
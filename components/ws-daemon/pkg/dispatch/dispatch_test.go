@@ -0,0 +1,103 @@
+// Copyright (c) 2021 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package dispatch
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	wsk8s "github.com/gitpod-io/gitpod/common-go/kubernetes"
+	"github.com/gitpod-io/gitpod/ws-daemon/pkg/container"
+)
+
+type listenerFunc func(ctx context.Context, ws *Workspace) error
+
+func (f listenerFunc) WorkspaceAdded(ctx context.Context, ws *Workspace) error {
+	return f(ctx, ws)
+}
+
+func TestDispatchWorkspaceLifecycle(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "ws-foobar",
+			Namespace: "default",
+			Labels: map[string]string{
+				wsk8s.MetaIDLabel:      "meta",
+				wsk8s.WorkspaceIDLabel: "foobar",
+			},
+		},
+		Spec: corev1.PodSpec{NodeName: "node"},
+	}
+
+	var (
+		clientset = fake.NewSimpleClientset()
+		runtime   = container.NewFake()
+		added     = make(chan context.Context, 1)
+	)
+	d, err := NewDispatch(runtime, clientset, "default", "node", listenerFunc(func(ctx context.Context, ws *Workspace) error {
+		if ws.InstanceID != "foobar" || ws.ContainerID != "cnt-foobar" {
+			t.Errorf("unexpected workspace: %+v", ws)
+		}
+		added <- ctx
+		return nil
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = d.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	ctx := context.Background()
+	_, err = clientset.CoreV1().Pods("default").Create(ctx, pod, metav1.CreateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// dispatch acts on pod updates only
+	pod.Annotations = map[string]string{"updated": "true"}
+	_, err = clientset.CoreV1().Pods("default").Update(ctx, pod, metav1.UpdateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "pod to be seen", func() bool { return d.WorkspaceExistsOnNode("foobar") })
+
+	runtime.AddContainer("foobar", container.FakeContainer{ID: "cnt-foobar"})
+	var wsctx context.Context
+	select {
+	case wsctx = <-added:
+	case <-time.After(5 * time.Second):
+		t.Fatal("listener was not called")
+	}
+
+	err = clientset.CoreV1().Pods("default").Delete(ctx, pod.Name, metav1.DeleteOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-wsctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("workspace context was not canceled when the pod was deleted")
+	}
+	if d.WorkspaceExistsOnNode("foobar") {
+		t.Error("workspace still exists after its pod was deleted")
+	}
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	for i := 0; i < 500; i++ {
+		if cond() {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %s", what)
+}
@@ -21,6 +21,7 @@ import (
 	"github.com/gitpod-io/gitpod/common-go/tracing"
 	csapi "github.com/gitpod-io/gitpod/content-service/api"
 	"github.com/gitpod-io/gitpod/content-service/pkg/git"
+	"github.com/gitpod-io/gitpod/ws-daemon/api"
 )
 
 const (
@@ -69,14 +70,54 @@ type Workspace struct {
 
 	RemoteStorageDisabled bool `json:"remoteStorageDisabled,omitempty"`
 
+	// PersistentVolumes are the names of the persistent volumes of this workspace.
+	// Their content lives next to the workspace in the working area.
+	PersistentVolumes []string `json:"persistentVolumes,omitempty"`
+
+	// QuotaID identifies the disk quota enforced on the workspace content, e.g. the XFS project ID.
+	// This field is only meaningful if a quota is enforced.
+	QuotaID int `json:"quotaID,omitempty"`
+
+	// InitSource describes where the workspace content came from, e.g. git, prebuild or backup
+	InitSource string `json:"initSource,omitempty"`
+
+	// PeriodicBackupInterval is the time between backups of the workspace content while the workspace is running.
+	// If zero, the content is only backed up during disposal.
+	PeriodicBackupInterval time.Duration `json:"periodicBackupInterval,omitempty"`
+	// LastPeriodicBackup is when the last periodic backup started. Changes made since then are not backed up yet.
+	LastPeriodicBackup time.Time `json:"lastPeriodicBackup,omitempty"`
+
+	// EgressPolicy restricts the outgoing network traffic of the workspace. If nil, the workspace can reach anything.
+	EgressPolicy *api.EgressPolicy `json:"egressPolicy,omitempty"`
+
 	NonPersistentAttrs map[string]interface{} `json:"-"`
 
 	store              *Store
 	state              WorkspaceState
 	stateLock          sync.RWMutex
 	operatingCondition *sync.Cond
+	backupProgress     *BackupProgress
 }
 
+// BackupProgress describes an in-flight backup or snapshot of a workspace
+type BackupProgress struct {
+	Phase       BackupPhase
+	StartedAt   time.Time
+	ArchiveSize int64
+}
+
+// BackupPhase is the step an in-flight backup is in
+type BackupPhase string
+
+const (
+	// BackupArchiving means the workspace content is being archived
+	BackupArchiving BackupPhase = "archiving"
+	// BackupUploading means the archive is being uploaded to remote storage
+	BackupUploading BackupPhase = "uploading"
+	// BackupUploadingManifest means the content manifest is being uploaded to remote storage
+	BackupUploadingManifest BackupPhase = "uploading manifest"
+)
+
 // OWI produces the owner, workspace, instance log metadata from the information
 // of this workspace.
 func (s *Workspace) OWI() logrus.Fields {
@@ -185,7 +226,7 @@ func (s *Workspace) WaitOrMarkForDisposal(ctx context.Context) (done bool, repo
 	s.operatingCondition.L.Lock()
 	s.operatingCondition.Wait()
 	done = true
-	repo = s.LastGitStatus
+	repo = s.GetGitStatus()
 	s.operatingCondition.L.Unlock()
 	return
 }
@@ -239,6 +280,54 @@ func (s *Workspace) IsDisposing() bool {
 	return r
 }
 
+// State returns the lifecycle state of the workspace
+func (s *Workspace) State() WorkspaceState {
+	s.stateLock.RLock()
+	defer s.stateLock.RUnlock()
+	return s.state
+}
+
+// BackupProgress returns a copy of the progress of the in-flight backup, or nil if there is none
+func (s *Workspace) BackupProgress() *BackupProgress {
+	s.stateLock.RLock()
+	defer s.stateLock.RUnlock()
+	if s.backupProgress == nil {
+		return nil
+	}
+	p := *s.backupProgress
+	return &p
+}
+
+// SetBackupProgress records the progress of an in-flight backup. Pass nil once the backup is done.
+func (s *Workspace) SetBackupProgress(p *BackupProgress) {
+	s.stateLock.Lock()
+	defer s.stateLock.Unlock()
+	s.backupProgress = p
+}
+
+// GetGitStatus returns the last known Git status of the workspace
+func (s *Workspace) GetGitStatus() *csapi.GitStatus {
+	s.stateLock.RLock()
+	defer s.stateLock.RUnlock()
+	return s.LastGitStatus
+}
+
+// GetInitSource returns where the workspace content came from
+func (s *Workspace) GetInitSource() string {
+	s.stateLock.RLock()
+	defer s.stateLock.RUnlock()
+	return s.InitSource
+}
+
+// SetInitSource sets the init source field and persists the change
+func (s *Workspace) SetInitSource(src string) error {
+	s.stateLock.Lock()
+	s.InitSource = src
+	s.stateLock.Unlock()
+
+	return s.persist()
+}
+
 // SetGitStatus sets the last git status field and persists the change
 func (s *Workspace) SetGitStatus(status *csapi.GitStatus) error {
 	s.stateLock.Lock()
@@ -248,6 +337,15 @@ func (s *Workspace) SetGitStatus(status *csapi.GitStatus) error {
 	return s.persist()
 }
 
+// SetLastPeriodicBackup sets the last periodic backup field and persists the change
+func (s *Workspace) SetLastPeriodicBackup(t time.Time) error {
+	s.stateLock.Lock()
+	s.LastPeriodicBackup = t
+	s.stateLock.Unlock()
+
+	return s.persist()
+}
+
 // UpdateGitStatus attempts to update the LastGitStatus from the workspace's local working copy.
 func (s *Workspace) UpdateGitStatus(ctx context.Context) (res *csapi.GitStatus, err error) {
 	loc := s.Location
@@ -276,15 +374,14 @@ func (s *Workspace) UpdateGitStatus(ctx context.Context) (res *csapi.GitStatus,
 		return nil, err
 	}
 
-	s.LastGitStatus = toGitStatus(stat)
-
-	err = s.persist()
+	res = toGitStatus(stat)
+	err = s.SetGitStatus(res)
 	if err != nil {
 		log.WithError(err).WithFields(s.OWI()).Warn("cannot persist latest Git status")
 		err = nil
 	}
 
-	return s.LastGitStatus, nil
+	return res, nil
 }
 
 func toGitStatus(s *git.Status) *csapi.GitStatus {
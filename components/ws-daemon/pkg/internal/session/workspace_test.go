@@ -117,6 +117,31 @@ func TestPersistAndLoadWorkspace(t *testing.T) {
 	}
 }
 
+func TestSetInitSourcePersists(t *testing.T) {
+	store, err := getTestStore()
+	if err != nil {
+		t.Fatalf("cannot create test store: %v", err)
+	}
+	ws, err := addRandomWorkspace(store)
+	if err != nil {
+		t.Fatalf("cannot create test workspace: %v", err)
+	}
+
+	// the workspace was persisted when it was created - changing the init source must not get lost on restart
+	err = ws.SetInitSource("backup")
+	if err != nil {
+		t.Fatalf("cannot set init source: %v", err)
+	}
+
+	reloadedWS, err := loadWorkspace(context.Background(), ws.persistentStateLocation())
+	if err != nil {
+		t.Fatalf("cannot load workspace: %v", err)
+	}
+	if src := reloadedWS.GetInitSource(); src != "backup" {
+		t.Errorf("unexpected init source: %q", src)
+	}
+}
+
 func TestWaitForInit(t *testing.T) {
 	tests := []struct {
 		Desc        string
@@ -0,0 +1,118 @@
+// Copyright (c) 2021 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package introspection
+
+import (
+	"context"
+	"sort"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/gitpod-io/gitpod/common-go/log"
+	"github.com/gitpod-io/gitpod/ws-daemon/api"
+	"github.com/gitpod-io/gitpod/ws-daemon/pkg/container"
+	"github.com/gitpod-io/gitpod/ws-daemon/pkg/dispatch"
+	"github.com/gitpod-io/gitpod/ws-daemon/pkg/internal/session"
+)
+
+// WorkspaceProvider provides the workspaces the daemon manages, e.g. the content service
+type WorkspaceProvider interface {
+	Workspaces() []*session.Workspace
+	DiskUsage(ws *session.Workspace) (used, limit int64, err error)
+}
+
+// ContainerProvider finds the container of a workspace, e.g. the dispatch
+type ContainerProvider interface {
+	Workspace(instanceID string) (ws dispatch.Workspace, ok bool)
+}
+
+// CPULimitProvider provides the CPU limit currently enforced on a container, e.g. the resource governer
+type CPULimitProvider interface {
+	CPULimit(id container.ID) int64
+}
+
+// Server implements the workspace introspection service
+type Server struct {
+	Workspaces WorkspaceProvider
+	Containers ContainerProvider
+	Runtime    container.Runtime
+	CPULimits  CPULimitProvider
+
+	api.UnimplementedWorkspaceIntrospectionServiceServer
+}
+
+// ListWorkspaces lists the workspaces on this node together with everything the daemon knows about them
+func (srv *Server) ListWorkspaces(ctx context.Context, req *api.ListWorkspacesRequest) (*api.ListWorkspacesResponse, error) {
+	var res []*api.WorkspaceIntrospection
+	for _, ws := range srv.Workspaces.Workspaces() {
+		if req.Id != "" && ws.InstanceID != req.Id {
+			continue
+		}
+		res = append(res, srv.introspect(ctx, ws))
+	}
+	if req.Id != "" && len(res) == 0 {
+		return nil, status.Error(codes.NotFound, "workspace does not exist")
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Id < res[j].Id })
+
+	return &api.ListWorkspacesResponse{Workspaces: res}, nil
+}
+
+// introspect gathers what we know about a workspace. Failing to find out about some aspect of a workspace
+// does not fail the introspection - we are debugging after all.
+func (srv *Server) introspect(ctx context.Context, ws *session.Workspace) *api.WorkspaceIntrospection {
+	log := log.WithFields(ws.OWI())
+
+	res := &api.WorkspaceIntrospection{
+		Id: ws.InstanceID,
+		Metadata: &api.WorkspaceMetadata{
+			Owner:  ws.Owner,
+			MetaId: ws.WorkspaceID,
+		},
+		State:               string(ws.State()),
+		InitSource:          ws.GetInitSource(),
+		LastGitStatus:       ws.GetGitStatus(),
+		FullWorkspaceBackup: ws.FullWorkspaceBackup,
+		Location:            ws.Location,
+		CreatedAt:           timestamppb.New(ws.CreatedAt),
+	}
+
+	used, limit, err := srv.Workspaces.DiskUsage(ws)
+	if err != nil {
+		log.WithError(err).Warn("cannot get disk usage for introspection")
+	}
+	res.DiskUsedBytes, res.DiskQuotaBytes = used, limit
+
+	if p := ws.BackupProgress(); p != nil {
+		res.Backup = &api.BackupProgress{
+			Phase:       string(p.Phase),
+			StartedAt:   timestamppb.New(p.StartedAt),
+			ArchiveSize: p.ArchiveSize,
+		}
+	}
+
+	if srv.Containers == nil {
+		return res
+	}
+	dws, ok := srv.Containers.Workspace(ws.InstanceID)
+	if !ok || dws.ContainerID == "" {
+		return res
+	}
+	res.ContainerId = string(dws.ContainerID)
+
+	if srv.Runtime != nil {
+		res.CgroupPath, err = srv.Runtime.ContainerCGroupPath(ctx, dws.ContainerID)
+		if err != nil {
+			log.WithError(err).Warn("cannot get cgroup path for introspection")
+		}
+	}
+	if srv.CPULimits != nil {
+		res.CpuLimit = srv.CPULimits.CPULimit(dws.ContainerID)
+	}
+
+	return res
+}
@@ -0,0 +1,107 @@
+// Copyright (c) 2021 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package introspection
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/gitpod-io/gitpod/ws-daemon/api"
+	"github.com/gitpod-io/gitpod/ws-daemon/pkg/container"
+	"github.com/gitpod-io/gitpod/ws-daemon/pkg/dispatch"
+	"github.com/gitpod-io/gitpod/ws-daemon/pkg/internal/session"
+)
+
+type fakeWorkspaces []*session.Workspace
+
+func (f fakeWorkspaces) Workspaces() []*session.Workspace { return f }
+
+func (f fakeWorkspaces) DiskUsage(ws *session.Workspace) (used, limit int64, err error) {
+	return 1024, 4096, nil
+}
+
+type fakeContainers map[string]dispatch.Workspace
+
+func (f fakeContainers) Workspace(instanceID string) (ws dispatch.Workspace, ok bool) {
+	ws, ok = f[instanceID]
+	return
+}
+
+type fakeCPULimits map[container.ID]int64
+
+func (f fakeCPULimits) CPULimit(id container.ID) int64 { return f[id] }
+
+func TestListWorkspaces(t *testing.T) {
+	backingUp := &session.Workspace{InstanceID: "b", WorkspaceID: "meta-b", Owner: "owner", InitSource: "backup"}
+	backingUp.SetBackupProgress(&session.BackupProgress{Phase: session.BackupUploading, ArchiveSize: 42})
+
+	rt := container.NewFake()
+	rt.AddContainer("a", container.FakeContainer{ID: "cnt-a", CGroupPath: "/kubepods/pod-a/cnt-a"})
+
+	srv := &Server{
+		Workspaces: fakeWorkspaces{
+			backingUp,
+			{InstanceID: "a", WorkspaceID: "meta-a", Owner: "owner", InitSource: "git"},
+		},
+		Containers: fakeContainers{"a": {InstanceID: "a", ContainerID: "cnt-a"}},
+		Runtime:    rt,
+		CPULimits:  fakeCPULimits{"cnt-a": 600},
+	}
+
+	ctx := context.Background()
+	resp, err := srv.ListWorkspaces(ctx, &api.ListWorkspacesRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type summary struct {
+		ID, InitSource, ContainerID, CGroupPath, BackupPhase string
+		CPULimit, DiskUsed, DiskQuota, ArchiveSize           int64
+	}
+	var act []summary
+	for _, ws := range resp.Workspaces {
+		s := summary{
+			ID:          ws.Id,
+			InitSource:  ws.InitSource,
+			ContainerID: ws.ContainerId,
+			CGroupPath:  ws.CgroupPath,
+			CPULimit:    ws.CpuLimit,
+			DiskUsed:    ws.DiskUsedBytes,
+			DiskQuota:   ws.DiskQuotaBytes,
+		}
+		if ws.Backup != nil {
+			s.BackupPhase = ws.Backup.Phase
+			s.ArchiveSize = ws.Backup.ArchiveSize
+		}
+		act = append(act, s)
+	}
+	exp := []summary{
+		{ID: "a", InitSource: "git", ContainerID: "cnt-a", CGroupPath: "/kubepods/pod-a/cnt-a", CPULimit: 600, DiskUsed: 1024, DiskQuota: 4096},
+		{ID: "b", InitSource: "backup", BackupPhase: "uploading", ArchiveSize: 42, DiskUsed: 1024, DiskQuota: 4096},
+	}
+	if diff := cmp.Diff(exp, act); diff != "" {
+		t.Errorf("unexpected introspection (-want +got):\n%s", diff)
+	}
+
+	resp, err = srv.ListWorkspaces(ctx, &api.ListWorkspacesRequest{Id: "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Workspaces) != 1 || resp.Workspaces[0].Id != "b" {
+		t.Errorf("filtering by ID returned the wrong workspaces: %v", resp.Workspaces)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 1*time.Second)
+	defer cancel()
+	_, err = srv.ListWorkspaces(ctx, &api.ListWorkspacesRequest{Id: "does-not-exist"})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("expected NotFound for an unknown workspace, got %v", err)
+	}
+}
@@ -0,0 +1,192 @@
+// Copyright (c) 2021 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package iws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/opentracing/opentracing-go"
+	"golang.org/x/xerrors"
+
+	"github.com/gitpod-io/gitpod/common-go/log"
+	"github.com/gitpod-io/gitpod/common-go/tracing"
+	"github.com/gitpod-io/gitpod/ws-daemon/api"
+	"github.com/gitpod-io/gitpod/ws-daemon/pkg/internal/session"
+)
+
+// EgressConfig configures the destinations workspaces with an egress policy can always reach
+type EgressConfig struct {
+	// AlwaysAllow lists the hostnames or CIDRs of the Gitpod installation, e.g. the server and IDE endpoints.
+	// Workspaces cannot function without reaching those, hence no egress policy can deny them.
+	AlwaysAllow []string `json:"alwaysAllow,omitempty"`
+}
+
+// egressPolicy is the egress policy as nsinsider's set-egress-policy command expects it
+type egressPolicy struct {
+	DefaultDeny bool         `json:"defaultDeny"`
+	Required    []egressRule `json:"required,omitempty"`
+	Allow       []egressRule `json:"allow,omitempty"`
+	Deny        []egressRule `json:"deny,omitempty"`
+}
+
+type egressRule struct {
+	CIDRs []string `json:"cidrs"`
+	Ports []uint16 `json:"ports,omitempty"`
+}
+
+// applyEgressPolicy applies the egress policy of a workspace in the network namespace of its container. PrepareForUserNS calls
+// this before ring0 starts anything within the workspace, hence the workspace never runs without its policy in place.
+// ws-manager refuses services for workspaces with an egress policy, as they'd run in the same network before that.
+func applyEgressPolicy(ctx context.Context, cfg EgressConfig, ws *session.Workspace, containerPID uint64) (err error) {
+	if ws.EgressPolicy == nil {
+		return nil
+	}
+
+	//nolint:ineffassign
+	span, ctx := opentracing.StartSpanFromContext(ctx, "iws.applyEgressPolicy")
+	defer tracing.FinishSpan(span, &err)
+
+	// we resolve the hostnames as late as possible so that the addresses are current when the workspace starts
+	policy, err := resolveEgressPolicy(ctx, net.DefaultResolver.LookupIPAddr, ws.EgressPolicy)
+	if err != nil {
+		return err
+	}
+
+	// The workspace uses the nameservers kubelet put in its resolv.conf. We read them from the container rather than
+	// ws-daemon's own resolv.conf, because ws-daemon may run with a different DNS policy.
+	resolvConf, err := os.ReadFile(fmt.Sprintf("/proc/%d/root/etc/resolv.conf", containerPID))
+	if err != nil && !os.IsNotExist(err) {
+		return xerrors.Errorf("cannot read the workspace's resolv.conf: %w", err)
+	}
+	policy.Required, err = requiredEgressRules(ctx, net.DefaultResolver.LookupIPAddr, cfg, resolvConf)
+	if err != nil {
+		return err
+	}
+	rawPolicy, err := json.Marshal(policy)
+	if err != nil {
+		return xerrors.Errorf("cannot marshal egress policy: %w", err)
+	}
+
+	err = nsinsider(ws.InstanceID, int(containerPID), func(c *exec.Cmd) {
+		c.Args = append(c.Args, "set-egress-policy", "--policy", string(rawPolicy))
+	}, enterMountNS(false), enterNetNS(true))
+	if err != nil {
+		return xerrors.Errorf("cannot apply egress policy: %w", err)
+	}
+	log.WithFields(ws.OWI()).Info("enforcing egress policy")
+	return nil
+}
+
+// requiredEgressRules produces the rules which let a workspace reach its nameservers and the Gitpod installation,
+// no matter its egress policy.
+func requiredEgressRules(ctx context.Context, lookup func(ctx context.Context, host string) ([]net.IPAddr, error), cfg EgressConfig, resolvConf []byte) ([]egressRule, error) {
+	var dns egressRule
+	for _, l := range strings.Split(string(resolvConf), "\n") {
+		fields := strings.Fields(l)
+		if len(fields) < 2 || fields[0] != "nameserver" {
+			continue
+		}
+		ip := net.ParseIP(fields[1])
+		if ip == nil {
+			return nil, xerrors.Errorf("invalid nameserver %s", fields[1])
+		}
+		dns.CIDRs = append(dns.CIDRs, hostCIDR(ip))
+	}
+
+	var res []egressRule
+	if len(dns.CIDRs) > 0 {
+		dns.Ports = []uint16{53}
+		res = append(res, dns)
+	}
+	if len(cfg.AlwaysAllow) == 0 {
+		return res, nil
+	}
+
+	var gitpod egressRule
+	for _, dst := range cfg.AlwaysAllow {
+		if _, ipnet, err := net.ParseCIDR(dst); err == nil {
+			gitpod.CIDRs = append(gitpod.CIDRs, ipnet.String())
+			continue
+		}
+		addrs, err := lookup(ctx, dst)
+		if err != nil {
+			return nil, xerrors.Errorf("cannot resolve %s: %w", dst, err)
+		}
+		for _, a := range addrs {
+			gitpod.CIDRs = append(gitpod.CIDRs, hostCIDR(a.IP))
+		}
+	}
+	return append(res, gitpod), nil
+}
+
+// hostCIDR returns the CIDR which matches exactly the given address
+func hostCIDR(ip net.IP) string {
+	addr, bits := ip.To4(), 32
+	if addr == nil {
+		addr, bits = ip, 128
+	}
+	return (&net.IPNet{IP: addr, Mask: net.CIDRMask(bits, bits)}).String()
+}
+
+// resolveEgressPolicy validates an egress policy and resolves its hostnames to addresses. A hostname which does not resolve
+// fails the policy, because we'd otherwise silently allow less - or deny less - than asked for.
+func resolveEgressPolicy(ctx context.Context, lookup func(ctx context.Context, host string) ([]net.IPAddr, error), policy *api.EgressPolicy) (*egressPolicy, error) {
+	convert := func(rules []*api.EgressRule) ([]egressRule, error) {
+		res := make([]egressRule, 0, len(rules))
+		for _, r := range rules {
+			var rule egressRule
+			for _, c := range r.Cidrs {
+				_, ipnet, err := net.ParseCIDR(c)
+				if err != nil {
+					return nil, xerrors.Errorf("invalid CIDR %s: %w", c, err)
+				}
+				rule.CIDRs = append(rule.CIDRs, ipnet.String())
+			}
+			for _, h := range r.Hostnames {
+				addrs, err := lookup(ctx, h)
+				if err != nil {
+					return nil, xerrors.Errorf("cannot resolve %s: %w", h, err)
+				}
+				if len(addrs) == 0 {
+					return nil, xerrors.Errorf("%s does not resolve to any address", h)
+				}
+				for _, a := range addrs {
+					rule.CIDRs = append(rule.CIDRs, hostCIDR(a.IP))
+				}
+			}
+			for _, p := range r.Ports {
+				if p == 0 || p > 65535 {
+					return nil, xerrors.Errorf("port %d is out of range", p)
+				}
+				rule.Ports = append(rule.Ports, uint16(p))
+			}
+			if len(rule.CIDRs) == 0 {
+				return nil, xerrors.Errorf("egress rule has neither CIDRs nor hostnames")
+			}
+			res = append(res, rule)
+		}
+		return res, nil
+	}
+
+	allow, err := convert(policy.Allow)
+	if err != nil {
+		return nil, xerrors.Errorf("invalid allow rule: %w", err)
+	}
+	deny, err := convert(policy.Deny)
+	if err != nil {
+		return nil, xerrors.Errorf("invalid deny rule: %w", err)
+	}
+	return &egressPolicy{
+		DefaultDeny: policy.DefaultDeny,
+		Allow:       allow,
+		Deny:        deny,
+	}, nil
+}
@@ -0,0 +1,174 @@
+// Copyright (c) 2021 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package iws
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/xerrors"
+
+	"github.com/gitpod-io/gitpod/ws-daemon/api"
+)
+
+func TestResolveEgressPolicy(t *testing.T) {
+	hosts := map[string][]net.IPAddr{
+		"registry.example.com": {{IP: net.ParseIP("10.1.2.3")}, {IP: net.ParseIP("fd00::3")}},
+		"nowhere.example.com":  nil,
+	}
+	lookup := func(ctx context.Context, host string) ([]net.IPAddr, error) {
+		addrs, ok := hosts[host]
+		if !ok {
+			return nil, xerrors.Errorf("no such host")
+		}
+		return addrs, nil
+	}
+
+	tests := []struct {
+		Name        string
+		Policy      *api.EgressPolicy
+		Expectation *egressPolicy
+		Error       bool
+	}{
+		{
+			Name:        "empty policy",
+			Policy:      &api.EgressPolicy{},
+			Expectation: &egressPolicy{Allow: []egressRule{}, Deny: []egressRule{}},
+		},
+		{
+			Name: "default deny",
+			Policy: &api.EgressPolicy{
+				DefaultDeny: true,
+				Allow: []*api.EgressRule{
+					{Cidrs: []string{"10.0.0.1/8"}, Ports: []uint32{443, 53}},
+					{Hostnames: []string{"registry.example.com"}},
+				},
+				Deny: []*api.EgressRule{
+					{Cidrs: []string{"fd00::/8"}},
+				},
+			},
+			Expectation: &egressPolicy{
+				DefaultDeny: true,
+				Allow: []egressRule{
+					{CIDRs: []string{"10.0.0.0/8"}, Ports: []uint16{443, 53}},
+					{CIDRs: []string{"10.1.2.3/32", "fd00::3/128"}},
+				},
+				Deny: []egressRule{
+					{CIDRs: []string{"fd00::/8"}},
+				},
+			},
+		},
+		{
+			Name:   "invalid CIDR",
+			Policy: &api.EgressPolicy{Allow: []*api.EgressRule{{Cidrs: []string{"10.0.0.300/8"}}}},
+			Error:  true,
+		},
+		{
+			Name:   "port out of range",
+			Policy: &api.EgressPolicy{Allow: []*api.EgressRule{{Cidrs: []string{"10.0.0.0/8"}, Ports: []uint32{70000}}}},
+			Error:  true,
+		},
+		{
+			Name:   "unknown host",
+			Policy: &api.EgressPolicy{Deny: []*api.EgressRule{{Hostnames: []string{"unknown.example.com"}}}},
+			Error:  true,
+		},
+		{
+			Name:   "host without addresses",
+			Policy: &api.EgressPolicy{Allow: []*api.EgressRule{{Hostnames: []string{"nowhere.example.com"}}}},
+			Error:  true,
+		},
+		{
+			Name:   "empty rule",
+			Policy: &api.EgressPolicy{Allow: []*api.EgressRule{{Ports: []uint32{443}}}},
+			Error:  true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			act, err := resolveEgressPolicy(context.Background(), lookup, test.Policy)
+			if test.Error {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(test.Expectation, act); diff != "" {
+				t.Errorf("unexpected policy (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRequiredEgressRules(t *testing.T) {
+	lookup := func(ctx context.Context, host string) ([]net.IPAddr, error) {
+		if host != "gitpod.example.com" {
+			return nil, xerrors.Errorf("no such host")
+		}
+		return []net.IPAddr{{IP: net.ParseIP("34.1.2.3")}}, nil
+	}
+
+	tests := []struct {
+		Name        string
+		Config      EgressConfig
+		ResolvConf  string
+		Expectation []egressRule
+		Error       bool
+	}{
+		{
+			Name: "no resolv.conf",
+		},
+		{
+			Name:       "nameservers",
+			ResolvConf: "search default.svc.cluster.local\nnameserver 10.0.0.10\nnameserver fd00::10\noptions ndots:5\n",
+			Expectation: []egressRule{
+				{CIDRs: []string{"10.0.0.10/32", "fd00::10/128"}, Ports: []uint16{53}},
+			},
+		},
+		{
+			Name:       "gitpod endpoints",
+			Config:     EgressConfig{AlwaysAllow: []string{"gitpod.example.com", "10.2.0.0/16"}},
+			ResolvConf: "nameserver 10.0.0.10\n",
+			Expectation: []egressRule{
+				{CIDRs: []string{"10.0.0.10/32"}, Ports: []uint16{53}},
+				{CIDRs: []string{"34.1.2.3/32", "10.2.0.0/16"}},
+			},
+		},
+		{
+			Name:       "invalid nameserver",
+			ResolvConf: "nameserver kube-dns\n",
+			Error:      true,
+		},
+		{
+			Name:   "unknown host",
+			Config: EgressConfig{AlwaysAllow: []string{"unknown.example.com"}},
+			Error:  true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			act, err := requiredEgressRules(context.Background(), lookup, test.Config, []byte(test.ResolvConf))
+			if test.Error {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(test.Expectation, act); diff != "" {
+				t.Errorf("unexpected rules (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
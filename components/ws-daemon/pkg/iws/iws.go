@@ -13,6 +13,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
@@ -24,6 +25,7 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"k8s.io/apimachinery/pkg/util/validation"
 
 	"github.com/gitpod-io/gitpod/common-go/log"
 	"github.com/gitpod-io/gitpod/common-go/tracing"
@@ -31,6 +33,7 @@ import (
 	"github.com/gitpod-io/gitpod/ws-daemon/api"
 	"github.com/gitpod-io/gitpod/ws-daemon/pkg/container"
 	"github.com/gitpod-io/gitpod/ws-daemon/pkg/internal/session"
+	"github.com/gitpod-io/gitpod/ws-daemon/pkg/quota"
 )
 
 //
@@ -75,8 +78,15 @@ var (
 	}
 )
 
-// ServeWorkspace establishes the IWS server for a workspace
-func ServeWorkspace(uidmapper *Uidmapper, fsshift api.FSShiftMethod) func(ctx context.Context, ws *session.Workspace) error {
+// NoticeProvider provides notices for the users of a workspace, e.g. when the node runs out of disk space
+type NoticeProvider interface {
+	// WorkspaceNotice returns the notice for a workspace instance, or an empty string if there is none
+	WorkspaceNotice(instanceID string) string
+}
+
+// ServeWorkspace establishes the IWS server for a workspace. quotaEnforcer can be nil if no disk quota is enforced,
+// notices can be nil if there are no notices to serve.
+func ServeWorkspace(uidmapper *Uidmapper, fsshift api.FSShiftMethod, quotaEnforcer quota.Enforcer, notices NoticeProvider, egress EgressConfig) func(ctx context.Context, ws *session.Workspace) error {
 	return func(ctx context.Context, ws *session.Workspace) (err error) {
 		if _, running := ws.NonPersistentAttrs[session.AttrWorkspaceServer]; running {
 			return nil
@@ -90,6 +100,9 @@ func ServeWorkspace(uidmapper *Uidmapper, fsshift api.FSShiftMethod) func(ctx co
 			Uidmapper: uidmapper,
 			Session:   ws,
 			FSShift:   fsshift,
+			Quota:     quotaEnforcer,
+			Notices:   notices,
+			Egress:    egress,
 		}
 		err = helper.Start()
 		if err != nil {
@@ -129,6 +142,9 @@ type InWorkspaceServiceServer struct {
 	Uidmapper *Uidmapper
 	Session   *session.Workspace
 	FSShift   api.FSShiftMethod
+	Quota     quota.Enforcer
+	Notices   NoticeProvider
+	Egress    EgressConfig
 
 	srv  *grpc.Server
 	sckt io.Closer
@@ -172,6 +188,12 @@ func (wbs *InWorkspaceServiceServer) Start() error {
 		"/iws.InWorkspaceService/Teardown": ratelimit{
 			UseOnce: true,
 		},
+		"/iws.InWorkspaceService/DiskUsage": ratelimit{
+			Limiter: rate.NewLimiter(rate.Every(5*time.Second), 2),
+		},
+		"/iws.InWorkspaceService/SetHostAliases": ratelimit{
+			Limiter: rate.NewLimiter(rate.Every(10*time.Second), 3),
+		},
 	}
 
 	wbs.srv = grpc.NewServer(grpc.ChainUnaryInterceptor(limits.UnaryInterceptor()))
@@ -215,6 +237,14 @@ func (wbs *InWorkspaceServiceServer) PrepareForUserNS(ctx context.Context, req *
 		return nil, status.Errorf(codes.Internal, "cannot find workspace rootfs")
 	}
 
+	// ring0 calls PrepareForUserNS before it starts anything within the workspace, which makes this the last point
+	// at which we can enforce the egress policy before the workspace uses the network.
+	err = applyEgressPolicy(ctx, wbs.Egress, wbs.Session, containerPID)
+	if err != nil {
+		log.WithError(err).WithFields(wbs.Session.OWI()).Error("PrepareForUserNS: cannot enforce egress policy")
+		return nil, status.Errorf(codes.Internal, "cannot enforce egress policy")
+	}
+
 	log.WithField("type", wbs.FSShift).Debug("FSShift")
 
 	// user namespace support for FUSE landed in Linux 4.18:
@@ -743,6 +773,115 @@ func (wbs *InWorkspaceServiceServer) Teardown(ctx context.Context, req *api.Tear
 	return &api.TeardownResponse{Success: success}, nil
 }
 
+// DiskUsage reports the disk space used by the workspace content and the quota enforced on it
+func (wbs *InWorkspaceServiceServer) DiskUsage(ctx context.Context, req *api.DiskUsageRequest) (*api.DiskUsageResponse, error) {
+	hasQuota := wbs.Quota != nil && !wbs.Session.FullWorkspaceBackup
+	if !hasQuota && wbs.Notices == nil {
+		return nil, status.Error(codes.FailedPrecondition, "no disk quota is enforced on this workspace")
+	}
+
+	resp := &api.DiskUsageResponse{}
+	if wbs.Notices != nil {
+		resp.Notice = wbs.Notices.WorkspaceNotice(wbs.Session.InstanceID)
+	}
+	if !hasQuota {
+		return resp, nil
+	}
+
+	used, limit, err := wbs.Quota.GetUsage(wbs.Session.Location, wbs.Session.QuotaID)
+	if err != nil {
+		log.WithError(err).WithFields(wbs.Session.OWI()).Error("cannot get disk usage")
+		return nil, status.Error(codes.Internal, "cannot get disk usage")
+	}
+	resp.UsedBytes = int64(used)
+	resp.QuotaBytes = int64(limit)
+
+	return resp, nil
+}
+
+const (
+	// hostAliasesMarker is appended to every /etc/hosts entry we add for a workspace's host aliases.
+	// It lets us replace our entries without touching anything else in the file.
+	hostAliasesMarker = " # added by ws-daemon: hostAliases"
+
+	// maxHostAliases limits the number of hostnames a workspace can alias
+	maxHostAliases = 64
+)
+
+// SetHostAliases replaces the host aliases in the workspace's /etc/hosts
+func (wbs *InWorkspaceServiceServer) SetHostAliases(ctx context.Context, req *api.SetHostAliasesRequest) (resp *api.SetHostAliasesResponse, err error) {
+	entries, err := hostAliasEntries(req.Aliases)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	defer func() {
+		if err == nil {
+			return
+		}
+
+		log.WithError(err).WithFields(wbs.Session.OWI()).Error("cannot set host aliases")
+		if _, ok := status.FromError(err); !ok {
+			err = status.Error(codes.Internal, "cannot set host aliases")
+		}
+	}()
+
+	rt := wbs.Uidmapper.Runtime
+	if rt == nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "not connected to container runtime")
+	}
+	wscontainerID, err := rt.WaitForContainer(ctx, wbs.Session.InstanceID)
+	if err != nil {
+		return nil, xerrors.Errorf("cannot find workspace container")
+	}
+	containerPID, err := rt.ContainerPID(ctx, wscontainerID)
+	if err != nil {
+		return nil, xerrors.Errorf("cannot find container PID for containerID %v: %w", wscontainerID, err)
+	}
+
+	err = nsinsider(wbs.Session.InstanceID, int(containerPID), func(c *exec.Cmd) {
+		c.Args = append(c.Args, "set-hosts", "--marker", hostAliasesMarker)
+		for _, e := range entries {
+			c.Args = append(c.Args, "--entry", e)
+		}
+	})
+	if err != nil {
+		return nil, xerrors.Errorf("cannot update /etc/hosts: %w", err)
+	}
+
+	return &api.SetHostAliasesResponse{}, nil
+}
+
+// hostAliasEntries validates host aliases and turns them into /etc/hosts lines.
+// The aliases come straight from the user, hence we only accept IP addresses and DNS names.
+func hostAliasEntries(aliases []*api.HostAlias) ([]string, error) {
+	var (
+		res   []string
+		total int
+	)
+	for _, a := range aliases {
+		ip := net.ParseIP(a.Ip)
+		if ip == nil {
+			return nil, xerrors.Errorf("invalid IP address %q", a.Ip)
+		}
+		if len(a.Hostnames) == 0 {
+			return nil, xerrors.Errorf("no hostnames for %s", a.Ip)
+		}
+		total += len(a.Hostnames)
+		if total > maxHostAliases {
+			return nil, xerrors.Errorf("too many host aliases: at most %d hostnames are supported", maxHostAliases)
+		}
+		for _, h := range a.Hostnames {
+			if errs := validation.IsDNS1123Subdomain(h); len(errs) > 0 {
+				return nil, xerrors.Errorf("invalid hostname %q: %s", h, strings.Join(errs, ", "))
+			}
+		}
+
+		res = append(res, fmt.Sprintf("%s\t%s", ip.String(), strings.Join(a.Hostnames, " ")))
+	}
+	return res, nil
+}
+
 func (wbs *InWorkspaceServiceServer) unPrepareForUserNS() error {
 	mountpoint := filepath.Join(wbs.Session.ServiceLocNode, "mark")
 	err := nsinsider(wbs.Session.InstanceID, 1, func(c *exec.Cmd) {
@@ -0,0 +1,83 @@
+// Copyright (c) 2021 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package iws
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/gitpod-io/gitpod/ws-daemon/api"
+)
+
+func TestHostAliasEntries(t *testing.T) {
+	tooMany := make([]string, maxHostAliases+1)
+	for i := range tooMany {
+		tooMany[i] = "foo.example.com"
+	}
+
+	tests := []struct {
+		Name        string
+		Aliases     []*api.HostAlias
+		Expectation []string
+		Error       bool
+	}{
+		{
+			Name:    "no aliases",
+			Aliases: nil,
+		},
+		{
+			Name: "valid aliases",
+			Aliases: []*api.HostAlias{
+				{Ip: "10.0.0.1", Hostnames: []string{"db", "db.example.com"}},
+				{Ip: "fd00::1", Hostnames: []string{"cache"}},
+			},
+			Expectation: []string{"10.0.0.1\tdb db.example.com", "fd00::1\tcache"},
+		},
+		{
+			Name:    "invalid IP",
+			Aliases: []*api.HostAlias{{Ip: "10.0.0.1 evil", Hostnames: []string{"db"}}},
+			Error:   true,
+		},
+		{
+			Name:    "newline in hostname",
+			Aliases: []*api.HostAlias{{Ip: "10.0.0.1", Hostnames: []string{"db\n10.0.0.2 other"}}},
+			Error:   true,
+		},
+		{
+			Name:    "comma in hostname",
+			Aliases: []*api.HostAlias{{Ip: "10.0.0.1", Hostnames: []string{"db,other"}}},
+			Error:   true,
+		},
+		{
+			Name:    "no hostnames",
+			Aliases: []*api.HostAlias{{Ip: "10.0.0.1"}},
+			Error:   true,
+		},
+		{
+			Name:    "too many hostnames",
+			Aliases: []*api.HostAlias{{Ip: "10.0.0.1", Hostnames: tooMany}},
+			Error:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			act, err := hostAliasEntries(test.Aliases)
+			if test.Error {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(test.Expectation, act); diff != "" {
+				t.Errorf("unexpected entries (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
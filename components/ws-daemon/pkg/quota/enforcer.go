@@ -0,0 +1,60 @@
+// Copyright (c) 2021 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package quota
+
+import (
+	"os/exec"
+
+	"golang.org/x/xerrors"
+)
+
+// Enforcer limits the disk space a directory can use
+type Enforcer interface {
+	// SetQuota limits the disk space dir can use and returns an ID which identifies the quota.
+	// The directory is created if it does not exist yet.
+	SetQuota(dir string, quota Size) (id int, err error)
+	// RemoveQuota removes a quota previously set using SetQuota
+	RemoveQuota(dir string, id int) error
+	// GetUsage returns the disk space dir currently uses and its limit
+	GetUsage(dir string, id int) (used, limit Size, err error)
+}
+
+// Mode determines how quotas are enforced
+type Mode string
+
+const (
+	// ModeNone does not enforce any quota
+	ModeNone Mode = ""
+	// ModeXFS uses XFS project quotas. The directory must be on an XFS filesystem mounted with prjquota.
+	ModeXFS Mode = "xfs"
+	// ModeLoopback places each directory on its own loopback-mounted image. This mode is meant for testing.
+	ModeLoopback Mode = "loopback"
+)
+
+// NewEnforcer produces a quota enforcer for directories within basePath.
+// If mode is ModeNone this function returns nil.
+func NewEnforcer(mode Mode, basePath string) (Enforcer, error) {
+	switch mode {
+	case ModeNone:
+		return nil, nil
+	case ModeXFS:
+		return NewXFS(basePath)
+	case ModeLoopback:
+		return NewLoopback(), nil
+	default:
+		return nil, xerrors.Errorf("unknown quota mode: %s", mode)
+	}
+}
+
+// execer runs a command and returns its combined output
+type execer func(name string, args ...string) (output string, err error)
+
+func defaultExec(name string, args ...string) (string, error) {
+	out, err := exec.Command(name, args...).CombinedOutput()
+	if err != nil {
+		return "", xerrors.Errorf("%s failed: %w: %s", name, err, string(out))
+	}
+	return string(out), nil
+}
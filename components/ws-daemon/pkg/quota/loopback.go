@@ -0,0 +1,90 @@
+// Copyright (c) 2021 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package quota
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+	"golang.org/x/xerrors"
+)
+
+// Loopback enforces quotas by mounting a size-limited ext4 image on each directory.
+// Images are sparse files next to the directory. This enforcer is meant for testing
+// environments whose working area is not on XFS.
+type Loopback struct {
+	exec execer
+}
+
+// NewLoopback creates a new loopback quota enforcer
+func NewLoopback() *Loopback {
+	return &Loopback{exec: defaultExec}
+}
+
+func loopbackImage(dir string) string {
+	return dir + ".img"
+}
+
+// SetQuota creates an image of the quota's size and mounts it on dir
+func (l *Loopback) SetQuota(dir string, quota Size) (id int, err error) {
+	img := loopbackImage(dir)
+	f, err := os.OpenFile(img, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return 0, xerrors.Errorf("cannot create image for %s: %w", dir, err)
+	}
+	err = f.Truncate(int64(quota))
+	f.Close()
+	if err != nil {
+		return 0, xerrors.Errorf("cannot create image for %s: %w", dir, err)
+	}
+	defer func() {
+		if err != nil {
+			os.Remove(img)
+		}
+	}()
+
+	_, err = l.exec("mkfs.ext4", "-q", "-F", "-m", "0", img)
+	if err != nil {
+		return 0, xerrors.Errorf("cannot create filesystem for %s: %w", dir, err)
+	}
+	err = os.MkdirAll(dir, 0755)
+	if err != nil {
+		return 0, xerrors.Errorf("cannot create %s: %w", dir, err)
+	}
+	_, err = l.exec("mount", "-o", "loop", img, dir)
+	if err != nil {
+		return 0, xerrors.Errorf("cannot mount image on %s: %w", dir, err)
+	}
+
+	// lost+found would otherwise show up as part of the workspace content
+	_ = os.Remove(dir + "/lost+found")
+
+	return 0, nil
+}
+
+// RemoveQuota unmounts the image from dir and deletes it
+func (l *Loopback) RemoveQuota(dir string, id int) error {
+	err := unix.Unmount(dir, 0)
+	if err != nil && err != unix.EINVAL {
+		return xerrors.Errorf("cannot unmount image from %s: %w", dir, err)
+	}
+	err = os.Remove(loopbackImage(dir))
+	if err != nil && !os.IsNotExist(err) {
+		return xerrors.Errorf("cannot remove image of %s: %w", dir, err)
+	}
+	return nil
+}
+
+// GetUsage reports the disk space used on the image mounted on dir
+func (l *Loopback) GetUsage(dir string, id int) (used, limit Size, err error) {
+	var st unix.Statfs_t
+	err = unix.Statfs(dir, &st)
+	if err != nil {
+		return 0, 0, xerrors.Errorf("cannot statfs %s: %w", dir, err)
+	}
+	limit = Size(st.Blocks * uint64(st.Bsize))
+	used = limit - Size(st.Bfree*uint64(st.Bsize))
+	return used, limit, nil
+}
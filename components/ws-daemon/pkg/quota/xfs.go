@@ -0,0 +1,191 @@
+// Copyright (c) 2021 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package quota
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/xerrors"
+)
+
+const (
+	// prjIDLow and prjIDHigh delimit the XFS project IDs we use for workspaces
+	prjIDLow  = 1000
+	prjIDHigh = 10000
+)
+
+// XFS enforces quotas using XFS project quotas
+type XFS struct {
+	// Dir is a directory on the XFS filesystem we manage quotas on
+	Dir string
+
+	projectIDs map[int]struct{}
+	lastID     int
+	mu         sync.Mutex
+	exec       execer
+}
+
+// NewXFS creates a new XFS quota enforcer and restores the project IDs which are already in use
+func NewXFS(dir string) (*XFS, error) {
+	res := &XFS{
+		Dir:        dir,
+		projectIDs: make(map[int]struct{}),
+		lastID:     prjIDLow - 1,
+		exec:       defaultExec,
+	}
+
+	report, err := res.report()
+	if err != nil {
+		return nil, xerrors.Errorf("cannot read XFS project quotas - is %s on XFS mounted with prjquota?: %w", dir, err)
+	}
+	for id, q := range report {
+		if q.Limit == 0 || id < prjIDLow || id >= prjIDHigh {
+			continue
+		}
+		res.projectIDs[id] = struct{}{}
+		if id > res.lastID {
+			res.lastID = id
+		}
+	}
+
+	return res, nil
+}
+
+// SetQuota sets up a new XFS project for dir and limits its size
+func (xfs *XFS) SetQuota(dir string, quota Size) (id int, err error) {
+	err = os.MkdirAll(dir, 0755)
+	if err != nil {
+		return 0, xerrors.Errorf("cannot create %s: %w", dir, err)
+	}
+
+	id, err = xfs.nextProjectID()
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			xfs.releaseProjectID(id)
+		}
+	}()
+
+	_, err = xfs.quotaCommand(fmt.Sprintf("project -s -d 1 -p %s %d", dir, id))
+	if err != nil {
+		return 0, xerrors.Errorf("cannot set up XFS project for %s: %w", dir, err)
+	}
+	_, err = xfs.quotaCommand(fmt.Sprintf("limit -p bhard=%d %d", quota, id))
+	if err != nil {
+		return 0, xerrors.Errorf("cannot set XFS project quota for %s: %w", dir, err)
+	}
+
+	return id, nil
+}
+
+// RemoveQuota removes the limit of the XFS project and frees its ID
+func (xfs *XFS) RemoveQuota(dir string, id int) error {
+	if id < prjIDLow || id >= prjIDHigh {
+		// this is not a project we have set up, e.g. because the workspace predates quota enforcement
+		return nil
+	}
+
+	_, err := xfs.quotaCommand(fmt.Sprintf("limit -p bsoft=0 bhard=0 %d", id))
+	if err != nil {
+		return xerrors.Errorf("cannot remove XFS project quota for %s: %w", dir, err)
+	}
+	xfs.releaseProjectID(id)
+	return nil
+}
+
+// GetUsage reports the disk space used by the XFS project
+func (xfs *XFS) GetUsage(dir string, id int) (used, limit Size, err error) {
+	report, err := xfs.report()
+	if err != nil {
+		return 0, 0, xerrors.Errorf("cannot get XFS project quota for %s: %w", dir, err)
+	}
+	q, ok := report[id]
+	if !ok {
+		return 0, 0, xerrors.Errorf("cannot get XFS project quota for %s: project %d not found", dir, id)
+	}
+	return q.Used, q.Limit, nil
+}
+
+// nextProjectID allocates the next free project ID. IDs are handed out round-robin so that
+// a freshly released ID (whose files might still be on disk) isn't reused right away.
+func (xfs *XFS) nextProjectID() (int, error) {
+	xfs.mu.Lock()
+	defer xfs.mu.Unlock()
+
+	for i := 0; i < prjIDHigh-prjIDLow; i++ {
+		id := xfs.lastID + 1 + i
+		if id >= prjIDHigh {
+			id = prjIDLow + (id-prjIDLow)%(prjIDHigh-prjIDLow)
+		}
+		if _, used := xfs.projectIDs[id]; used {
+			continue
+		}
+
+		xfs.projectIDs[id] = struct{}{}
+		xfs.lastID = id
+		return id, nil
+	}
+	return 0, xerrors.Errorf("no free XFS project ID left")
+}
+
+func (xfs *XFS) releaseProjectID(id int) {
+	xfs.mu.Lock()
+	defer xfs.mu.Unlock()
+
+	delete(xfs.projectIDs, id)
+}
+
+type xfsProjectQuota struct {
+	Used  Size
+	Limit Size
+}
+
+// report parses the output of "report -p -b -n -N" which lists all projects with their block usage
+// and limits in kilobytes, e.g.
+//
+//	#0         0          0          0     00 [--------]
+//	#1000    512          0    1048576     00 [--------]
+func (xfs *XFS) report() (map[int]xfsProjectQuota, error) {
+	out, err := xfs.quotaCommand("report -p -b -n -N")
+	if err != nil {
+		return nil, err
+	}
+
+	res := make(map[int]xfsProjectQuota)
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 || !strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		id, err := strconv.Atoi(strings.TrimPrefix(fields[0], "#"))
+		if err != nil {
+			return nil, xerrors.Errorf("cannot parse project ID in %q: %w", line, err)
+		}
+		used, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return nil, xerrors.Errorf("cannot parse used blocks in %q: %w", line, err)
+		}
+		hard, err := strconv.ParseInt(fields[3], 10, 64)
+		if err != nil {
+			return nil, xerrors.Errorf("cannot parse hard limit in %q: %w", line, err)
+		}
+		res[id] = xfsProjectQuota{
+			Used:  Size(used) * Kilobyte,
+			Limit: Size(hard) * Kilobyte,
+		}
+	}
+	return res, nil
+}
+
+func (xfs *XFS) quotaCommand(cmd string) (string, error) {
+	return xfs.exec("xfs_quota", "-x", "-c", cmd, xfs.Dir)
+}
@@ -0,0 +1,121 @@
+// Copyright (c) 2021 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package quota
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// fakeXFSQuota records xfs_quota commands and answers report commands with a fixed report
+type fakeXFSQuota struct {
+	Report   string
+	Commands []string
+}
+
+func (f *fakeXFSQuota) exec(name string, args ...string) (string, error) {
+	cmd := args[2]
+	if strings.HasPrefix(cmd, "report") {
+		return f.Report, nil
+	}
+	f.Commands = append(f.Commands, cmd)
+	return "", nil
+}
+
+func newTestXFS(t *testing.T, report string) (*XFS, *fakeXFSQuota) {
+	fake := &fakeXFSQuota{Report: report}
+	xfs := &XFS{
+		Dir:        t.TempDir(),
+		projectIDs: make(map[int]struct{}),
+		lastID:     prjIDLow - 1,
+		exec:       fake.exec,
+	}
+	return xfs, fake
+}
+
+func TestXFSSetQuota(t *testing.T) {
+	xfs, fake := newTestXFS(t, "")
+	xfs.projectIDs[1000] = struct{}{}
+
+	dir := filepath.Join(xfs.Dir, "ws1")
+	id, err := xfs.SetQuota(dir, 10*Gigabyte)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != 1001 {
+		t.Errorf("unexpected project ID: expected 1001, got %d", id)
+	}
+
+	expectation := []string{
+		"project -s -d 1 -p " + dir + " 1001",
+		"limit -p bhard=10737418240 1001",
+	}
+	if diff := cmp.Diff(expectation, fake.Commands); diff != "" {
+		t.Errorf("unexpected xfs_quota commands (-want +got):\n%s", diff)
+	}
+
+	err = xfs.RemoveQuota(dir, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, used := xfs.projectIDs[id]; used {
+		t.Errorf("project ID %d was not released", id)
+	}
+	// IDs are handed out round-robin, hence the released ID must not be reused immediately
+	id, err = xfs.SetQuota(dir, 10*Gigabyte)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != 1002 {
+		t.Errorf("unexpected project ID: expected 1002, got %d", id)
+	}
+}
+
+func TestXFSProjectIDWrapAround(t *testing.T) {
+	xfs, _ := newTestXFS(t, "")
+	xfs.lastID = prjIDHigh - 1
+	xfs.projectIDs[prjIDLow] = struct{}{}
+
+	id, err := xfs.nextProjectID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != prjIDLow+1 {
+		t.Errorf("unexpected project ID: expected %d, got %d", prjIDLow+1, id)
+	}
+}
+
+func TestXFSReport(t *testing.T) {
+	report := `#0              0          0          0     00 [--------]
+#1000         512          0    1048576     00 [--------]
+#1001           0          0          0     00 [--------]
+`
+	fake := &fakeXFSQuota{Report: report}
+	xfs := &XFS{Dir: t.TempDir(), projectIDs: make(map[int]struct{}), exec: fake.exec}
+
+	res, err := xfs.report()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectation := map[int]xfsProjectQuota{
+		0:    {},
+		1000: {Used: 512 * Kilobyte, Limit: Gigabyte},
+		1001: {},
+	}
+	if diff := cmp.Diff(expectation, res); diff != "" {
+		t.Errorf("unexpected report (-want +got):\n%s", diff)
+	}
+
+	used, limit, err := xfs.GetUsage("ws1", 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if used != 512*Kilobyte || limit != Gigabyte {
+		t.Errorf("unexpected usage: expected %v/%v, got %v/%v", 512*Kilobyte, Gigabyte, used, limit)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"container/ring"
 	"context"
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -29,6 +30,8 @@ type Controller struct {
 	InstanceID     string
 	CGroupBasePath string
 	CGroupPath     string
+	// UnifiedCGroups is true if the node uses the unified (cgroup v2) hierarchy
+	UnifiedCGroups bool
 
 	SamplingPeriod time.Duration
 	ControlPeriod  time.Duration
@@ -38,11 +41,24 @@ type Controller struct {
 	cpuLimiterOverride ResourceLimiter
 	cpuPrevAcct        int64
 	cpuExpenditures    *ring.Ring
+	cpuLimit           int64
 	cfsController      cfsController
 
+	ioLimiter          ResourceLimiter
+	iopsLimiter        ResourceLimiter
+	ioPrevUsage        map[string]ioDeviceUsage
+	ioExpenditures     *ring.Ring
+	iopsExpenditures   *ring.Ring
+	ioLimits           map[string]ioLimit
+	ioController       ioController
+	memoryLimiter      ResourceLimiter
+	memoryController   memoryController
+	MemoryPressureFile string
+
 	processPriorities map[ProcessType]int
 
 	Prometheus prometheus.Registerer
+	metrics    *controllerMetrics
 
 	mu       sync.RWMutex
 	stopOnce sync.Once
@@ -60,6 +76,13 @@ func WithCGroupBasePath(base string) ControllerOpt {
 	}
 }
 
+// WithUnifiedCGroups makes the controller use the unified (cgroup v2) hierarchy
+func WithUnifiedCGroups(unified bool) ControllerOpt {
+	return func(g *Controller) {
+		g.UnifiedCGroups = unified
+	}
+}
+
 // WithCPULimiter sets the resource limiter for CPUs
 func WithCPULimiter(l ResourceLimiter) ControllerOpt {
 	return func(g *Controller) {
@@ -67,6 +90,23 @@ func WithCPULimiter(l ResourceLimiter) ControllerOpt {
 	}
 }
 
+// WithIOLimiter sets the resource limiters for block IO. The bandwidth limiter decides on bytes/sec,
+// the iops limiter on operations/sec. Either can be nil.
+func WithIOLimiter(bandwidth, iops ResourceLimiter) ControllerOpt {
+	return func(g *Controller) {
+		g.ioLimiter = bandwidth
+		g.iopsLimiter = iops
+	}
+}
+
+// WithMemoryLimiter sets the resource limiter for the memory soft limit. The limiter decides on
+// the soft limit in bytes based on the node's memory pressure in percent.
+func WithMemoryLimiter(l ResourceLimiter) ControllerOpt {
+	return func(g *Controller) {
+		g.memoryLimiter = l
+	}
+}
+
 // WithGitpodIDs sets the gitpod relevant IDs
 func WithGitpodIDs(workspaceID, instanceID string) ControllerOpt {
 	return func(g *Controller) {
@@ -120,11 +160,24 @@ func NewController(containerID, instanceID string, cgroupPath string, opts ...Co
 		SamplingPeriod: 10 * time.Second,
 		ControlPeriod:  15 * time.Minute,
 		Prometheus:     prometheus.DefaultRegisterer,
+		ioLimits:       make(map[string]ioLimit),
+		metrics:        newControllerMetrics(),
+
+		MemoryPressureFile: DefaultMemoryPressureFile,
 	}
 	for _, o := range opts {
 		o(gov)
 	}
-	gov.cfsController = cgroupCFSController(filepath.Join(gov.CGroupBasePath, "cpu", gov.CGroupPath))
+	if gov.UnifiedCGroups {
+		cgroupPath := filepath.Join(gov.CGroupBasePath, gov.CGroupPath)
+		gov.cfsController = cgroupV2CFSController(cgroupPath)
+		gov.ioController = cgroupV2IOController(cgroupPath)
+		gov.memoryController = cgroupV2MemoryController(cgroupPath)
+	} else {
+		gov.cfsController = cgroupCFSController(filepath.Join(gov.CGroupBasePath, "cpu", gov.CGroupPath))
+		gov.ioController = cgroupBlkioController(filepath.Join(gov.CGroupBasePath, "blkio", gov.CGroupPath))
+		gov.memoryController = cgroupMemoryController(filepath.Join(gov.CGroupBasePath, "memory", gov.CGroupPath))
+	}
 
 	sampleCount := int(gov.ControlPeriod / gov.SamplingPeriod)
 	if sampleCount <= 0 {
@@ -136,6 +189,8 @@ func NewController(containerID, instanceID string, cgroupPath string, opts ...Co
 		sampleCount = 500
 	}
 	gov.cpuExpenditures = ring.New(sampleCount)
+	gov.ioExpenditures = ring.New(sampleCount)
+	gov.iopsExpenditures = ring.New(sampleCount)
 
 	if gov.ControlPeriod%gov.SamplingPeriod != 0 {
 		return nil, xerrors.Errorf("control period must be a multiple of sampling period")
@@ -146,9 +201,20 @@ func NewController(containerID, instanceID string, cgroupPath string, opts ...Co
 
 // Start actually starts governing. This function is meant to be called as a Go-routine.
 func (gov *Controller) Start(ctx context.Context) {
+	if gov.Prometheus != nil {
+		err := gov.metrics.Register(gov.Prometheus)
+		if err != nil {
+			gov.log.WithError(err).Warn("cannot register resource controller metrics")
+		}
+		defer gov.metrics.Unregister(gov.Prometheus)
+	}
+
 	t := time.NewTicker(gov.SamplingPeriod)
+	defer t.Stop()
 	for {
 		gov.controlCPU()
+		gov.controlIO()
+		gov.controlMemory()
 		gov.controlProcessPriorities()
 
 		// wait
@@ -229,6 +295,11 @@ func (gov *Controller) controlCPU() {
 	gov.mu.RUnlock()
 
 	_, err = gov.enforceCPULimit(newLimit)
+	if err == nil {
+		gov.mu.Lock()
+		gov.cpuLimit = newLimit
+		gov.mu.Unlock()
+	}
 	if xerrors.Is(err, os.ErrNotExist) {
 		// the cgroup doesn't exist (yet or anymore). That's ok.
 		// If the cgroup doesn't exist, we don't have much to control,
@@ -242,6 +313,13 @@ func (gov *Controller) controlCPU() {
 	}
 }
 
+// CPULimit returns the CPU limit last enforced in jiffies/sec, or zero if no limit has been enforced yet
+func (gov *Controller) CPULimit() int64 {
+	gov.mu.RLock()
+	defer gov.mu.RUnlock()
+	return gov.cpuLimit
+}
+
 // SetFixedCPULimit overrides the CPU current limiter with a fixed CPU limiter
 func (gov *Controller) SetFixedCPULimit(jiffiesPerSec int64) {
 	gov.mu.Lock()
@@ -255,12 +333,150 @@ func (gov *Controller) SetFixedCPULimit(jiffiesPerSec int64) {
 	}
 }
 
+func (gov *Controller) controlIO() {
+	if gov.ioLimiter == nil && gov.iopsLimiter == nil {
+		return
+	}
+
+	usage, err := gov.ioController.GetUsage()
+	if xerrors.Is(err, os.ErrNotExist) {
+		// the cgroup doesn't exist (yet or anymore) - see controlCPU
+		return
+	} else if err != nil {
+		gov.log.WithError(err).Warn("cannot sample IO usage")
+		return
+	}
+
+	prev := gov.ioPrevUsage
+	gov.ioPrevUsage = usage
+	if prev == nil {
+		// we haven't seen a sample before
+		return
+	}
+
+	// Unlike CPU time, IO budget is accounted for in absolute terms: bytes and operations
+	// spent during the control period, regardless of the device they were spent on.
+	var bytes, ops int64
+	for dev, u := range usage {
+		p, ok := prev[dev]
+		if !ok {
+			continue
+		}
+		bytes += u.Bytes - p.Bytes
+		ops += u.Operations - p.Operations
+	}
+	var bytesSpent, opsSpent int64
+	gov.ioExpenditures, bytesSpent = recordExpenditure(gov.ioExpenditures, bytes)
+	gov.iopsExpenditures, opsSpent = recordExpenditure(gov.iopsExpenditures, ops)
+	gov.metrics.IOBytesSpent.Set(float64(bytesSpent))
+	gov.metrics.IOOperationsSpent.Set(float64(opsSpent))
+
+	var newLimit ioLimit
+	if gov.ioLimiter != nil {
+		newLimit.BytesPerSecond = gov.ioLimiter.Limit(bytesSpent)
+	}
+	if gov.iopsLimiter != nil {
+		newLimit.OperationsPerSecond = gov.iopsLimiter.Limit(opsSpent)
+	}
+	gov.metrics.IOBandwidthLimit.Set(float64(newLimit.BytesPerSecond))
+	gov.metrics.IOPSLimit.Set(float64(newLimit.OperationsPerSecond))
+
+	for dev := range usage {
+		if gov.ioLimits[dev] == newLimit {
+			continue
+		}
+
+		err = gov.ioController.SetLimit(dev, newLimit)
+		if xerrors.Is(err, os.ErrNotExist) {
+			return
+		} else if err != nil {
+			gov.log.WithField("device", dev).WithField("newLimit", newLimit).WithField("bytesSpent", bytesSpent).WithField("opsSpent", opsSpent).
+				WithError(err).
+				Warn("cannot set new IO limit")
+			continue
+		}
+		gov.ioLimits[dev] = newLimit
+		gov.log.WithField("device", dev).WithField("bps", newLimit.BytesPerSecond).WithField("iops", newLimit.OperationsPerSecond).Info("set new IO limit")
+	}
+}
+
+// recordExpenditure adds a sample to the expenditure ring and returns the advanced ring together
+// with the total spent across all samples in the ring.
+func recordExpenditure(r *ring.Ring, sample int64) (next *ring.Ring, spent int64) {
+	r.Value = sample
+	next = r.Next()
+	next.Do(func(s interface{}) {
+		si, ok := s.(int64)
+		if !ok {
+			return
+		}
+		spent += si
+	})
+	return next, spent
+}
+
+func (gov *Controller) controlMemory() {
+	if gov.memoryLimiter == nil {
+		return
+	}
+
+	usage, err := gov.memoryController.GetUsage()
+	if xerrors.Is(err, os.ErrNotExist) {
+		// the cgroup doesn't exist (yet or anymore) - see controlCPU
+		return
+	} else if err != nil {
+		gov.log.WithError(err).Warn("cannot sample memory usage")
+		return
+	}
+	gov.metrics.MemoryUsage.Set(float64(usage))
+
+	pressure, err := readMemoryPressure(gov.MemoryPressureFile)
+	if xerrors.Is(err, os.ErrNotExist) {
+		// the kernel does not support pressure stall information - we cannot control memory
+		return
+	} else if err != nil {
+		gov.log.WithError(err).Warn("cannot sample memory pressure")
+		return
+	}
+
+	// A limit of zero or less means there's no soft limit
+	newLimit := gov.memoryLimiter.Limit(pressure)
+	if newLimit <= 0 {
+		newLimit = -1
+	}
+	gov.metrics.MemorySoftLimit.Set(float64(newLimit))
+
+	currentLimit, err := gov.memoryController.GetSoftLimit()
+	if xerrors.Is(err, os.ErrNotExist) {
+		return
+	} else if err != nil {
+		gov.log.WithError(err).Warn("cannot read memory soft limit")
+		return
+	}
+	if currentLimit == newLimit {
+		return
+	}
+
+	err = gov.memoryController.SetSoftLimit(newLimit)
+	if xerrors.Is(err, os.ErrNotExist) {
+		return
+	} else if err != nil {
+		gov.log.WithField("newLimit", newLimit).WithField("pressure", pressure).WithError(err).Warn("cannot set new memory soft limit")
+		return
+	}
+	gov.log.WithField("currentLimit", currentLimit).WithField("limit", newLimit).WithField("pressure", pressure).Info("set new memory soft limit")
+}
+
 func (gov *Controller) controlProcessPriorities() {
 	if len(gov.processPriorities) == 0 {
 		return
 	}
 
 	fn := filepath.Join(gov.CGroupBasePath, "pids", gov.CGroupPath, "tasks")
+	if gov.UnifiedCGroups {
+		// cgroup.threads is the unified hierarchy's equivalent of the tasks file
+		fn = filepath.Join(gov.CGroupBasePath, gov.CGroupPath, "cgroup.threads")
+	}
 	fc, err := os.ReadFile(fn)
 	if err != nil {
 		if !errors.Is(err, os.ErrNotExist) {
@@ -421,3 +637,151 @@ func (basePath cgroupCFSController) SetQuota(quota int64) (err error) {
 	}
 	return
 }
+
+// cgroupV2CFSController controls a cgroup's CFS settings on the unified (cgroup v2) hierarchy
+type cgroupV2CFSController string
+
+// GetUsage returns the usage_usec value from the cgroup's cpu.stat in nanoseconds,
+// i.e. in the same unit as cpuacct.usage in cgroup v1
+func (basePath cgroupV2CFSController) GetUsage() (totalJiffies int64, err error) {
+	fn := filepath.Join(string(basePath), "cpu.stat")
+	fc, err := os.ReadFile(fn)
+	if err != nil {
+		return 0, xerrors.Errorf("cannot sample cpu.stat: %w", err)
+	}
+
+	for _, line := range strings.Split(string(fc), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 || fields[0] != "usage_usec" {
+			continue
+		}
+
+		usage, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return 0, xerrors.Errorf("cannot sample cpu.stat: %w", err)
+		}
+		return usage * 1000, nil
+	}
+
+	return 0, xerrors.Errorf("cannot sample cpu.stat: no usage_usec found")
+}
+
+// GetQuota returns the current quota and period setting of the cgroup's CFS.
+// Like in cgroup v1, a quota of -1 means there's no limit.
+func (basePath cgroupV2CFSController) GetQuota() (quota, period int64, err error) {
+	fn := filepath.Join(string(basePath), "cpu.max")
+	fc, err := os.ReadFile(fn)
+	if err != nil {
+		err = xerrors.Errorf("cannot read cpu.max: %w", err)
+		return
+	}
+
+	fields := strings.Fields(string(fc))
+	if len(fields) != 2 {
+		err = xerrors.Errorf("cannot parse cpu.max: unexpected content %q", string(fc))
+		return
+	}
+	if fields[0] == "max" {
+		quota = -1
+	} else {
+		quota, err = strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			err = xerrors.Errorf("cannot parse CFS quota: %w", err)
+			return
+		}
+	}
+	period, err = strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		err = xerrors.Errorf("cannot parse CFS period: %w", err)
+		return
+	}
+	return
+}
+
+// SetQuota sets a new CFS quota on the cgroup and keeps the period. A negative quota removes the limit.
+func (basePath cgroupV2CFSController) SetQuota(quota int64) (err error) {
+	_, period, err := basePath.GetQuota()
+	if err != nil {
+		return err
+	}
+
+	val := "max"
+	if quota >= 0 {
+		val = strconv.FormatInt(quota, 10)
+	}
+
+	fn := filepath.Join(string(basePath), "cpu.max")
+	err = os.WriteFile(fn, []byte(fmt.Sprintf("%s %d", val, period)), 0644)
+	if err != nil {
+		return xerrors.Errorf("cannot set CFS quota: %w", err)
+	}
+	return
+}
+
+// controllerMetrics are the per-workspace metrics of a resource controller
+type controllerMetrics struct {
+	IOBandwidthLimit  prometheus.Gauge
+	IOPSLimit         prometheus.Gauge
+	IOBytesSpent      prometheus.Gauge
+	IOOperationsSpent prometheus.Gauge
+	MemorySoftLimit   prometheus.Gauge
+	MemoryUsage       prometheus.Gauge
+}
+
+func newControllerMetrics() *controllerMetrics {
+	return &controllerMetrics{
+		IOBandwidthLimit: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "resource_governer_io_bandwidth_limit_bytes",
+			Help: "IO bandwidth limit per device in bytes/sec enforced on the workspace (zero means unlimited)",
+		}),
+		IOPSLimit: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "resource_governer_iops_limit",
+			Help: "IO operations limit per device in operations/sec enforced on the workspace (zero means unlimited)",
+		}),
+		IOBytesSpent: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "resource_governer_io_bytes_spent",
+			Help: "Bytes read and written by the workspace during the control period",
+		}),
+		IOOperationsSpent: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "resource_governer_io_operations_spent",
+			Help: "IO operations performed by the workspace during the control period",
+		}),
+		MemorySoftLimit: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "resource_governer_memory_soft_limit_bytes",
+			Help: "Memory soft limit enforced on the workspace (-1 means unlimited)",
+		}),
+		MemoryUsage: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "resource_governer_memory_usage_bytes",
+			Help: "Memory used by the workspace",
+		}),
+	}
+}
+
+func (m *controllerMetrics) collectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		m.IOBandwidthLimit,
+		m.IOPSLimit,
+		m.IOBytesSpent,
+		m.IOOperationsSpent,
+		m.MemorySoftLimit,
+		m.MemoryUsage,
+	}
+}
+
+// Register registers all metrics with the registerer
+func (m *controllerMetrics) Register(reg prometheus.Registerer) error {
+	for _, c := range m.collectors() {
+		err := reg.Register(c)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Unregister removes all metrics from the registerer
+func (m *controllerMetrics) Unregister(reg prometheus.Registerer) {
+	for _, c := range m.collectors() {
+		reg.Unregister(c)
+	}
+}
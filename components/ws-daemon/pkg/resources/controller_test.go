@@ -12,6 +12,7 @@ import (
 	"math/rand"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
@@ -136,6 +137,256 @@ func TestControlCPU(t *testing.T) {
 	}
 }
 
+func TestCgroupV2CFSController(t *testing.T) {
+	base := t.TempDir()
+	cgroupPath := "kubepods/pod1/container1"
+	fn := filepath.Join(base, cgroupPath)
+	err := os.MkdirAll(fn, 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(fn, "cpu.stat"), []byte("usage_usec 2000\nuser_usec 1500\nsystem_usec 500\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(fn, "cpu.max"), []byte("max 100000\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gov, err := NewController("testcontainer", "instanceid", cgroupPath, WithCGroupBasePath(base), WithUnifiedCGroups(true))
+	if err != nil {
+		t.Fatalf("cannot create governer: %q", err)
+	}
+
+	usage, err := gov.cfsController.GetUsage()
+	if err != nil {
+		t.Fatalf("cannot get usage: %q", err)
+	}
+	if usage != 2000*1000 {
+		t.Errorf("unexpected usage: expected %d, got %d", 2000*1000, usage)
+	}
+
+	quota, period, err := gov.cfsController.GetQuota()
+	if err != nil {
+		t.Fatalf("cannot get quota: %q", err)
+	}
+	if quota != -1 || period != 100000 {
+		t.Errorf("unexpected quota/period: expected -1/100000, got %d/%d", quota, period)
+	}
+
+	// 500 jiffies/sec are five CPUs, i.e. five times the period
+	didChange, err := gov.enforceCPULimit(500)
+	if err != nil {
+		t.Fatalf("cannot enforce CPU limit: %q", err)
+	}
+	if !didChange {
+		t.Errorf("expected CPU limit to change")
+	}
+	fc, err := os.ReadFile(filepath.Join(fn, "cpu.max"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(fc) != "500000 100000" {
+		t.Errorf("unexpected cpu.max content: %q", string(fc))
+	}
+}
+
+func TestControlIO(t *testing.T) {
+	tests := []struct {
+		Name       string
+		Unified    bool
+		CGroupDir  string
+		Stat       func(bytes, ops int64) map[string]string
+		LimitFiles map[string]string
+	}{
+		{
+			Name:      "cgroup v1",
+			CGroupDir: "blkio",
+			Stat: func(bytes, ops int64) map[string]string {
+				return map[string]string{
+					"blkio.throttle.io_service_bytes": fmt.Sprintf("8:0 Read 0\n8:0 Write %d\n8:0 Total %d\nTotal %d\n", bytes, bytes, bytes),
+					"blkio.throttle.io_serviced":      fmt.Sprintf("8:0 Read 0\n8:0 Write %d\n8:0 Total %d\nTotal %d\n", ops, ops, ops),
+				}
+			},
+			LimitFiles: map[string]string{
+				"blkio.throttle.read_bps_device":   "8:0 1000",
+				"blkio.throttle.write_bps_device":  "8:0 1000",
+				"blkio.throttle.read_iops_device":  "8:0 10",
+				"blkio.throttle.write_iops_device": "8:0 10",
+			},
+		},
+		{
+			Name:    "cgroup v2",
+			Unified: true,
+			Stat: func(bytes, ops int64) map[string]string {
+				return map[string]string{
+					"io.stat": fmt.Sprintf("8:0 rbytes=0 wbytes=%d rios=0 wios=%d dbytes=0 dios=0\n", bytes, ops),
+				}
+			},
+			LimitFiles: map[string]string{
+				"io.max": "8:0 rbps=1000 wbps=1000 riops=10 wiops=10",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			base := t.TempDir()
+			cgroupPath := "kubepods/pod1/container1"
+			fn := filepath.Join(base, test.CGroupDir, cgroupPath)
+			err := os.MkdirAll(fn, 0755)
+			if err != nil {
+				t.Fatal(err)
+			}
+			writeStat := func(bytes, ops int64) {
+				for name, content := range test.Stat(bytes, ops) {
+					err := os.WriteFile(filepath.Join(fn, name), []byte(content), 0644)
+					if err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+
+			gov, err := NewController("testcontainer", "instanceid", cgroupPath,
+				WithCGroupBasePath(base),
+				WithUnifiedCGroups(test.Unified),
+				WithIOLimiter(
+					&ClampingBucketLimiter{Buckets: []Bucket{{Budget: 5000, Limit: 0}, {Budget: 5000, Limit: 1000}}},
+					&ClampingBucketLimiter{Buckets: []Bucket{{Budget: 50, Limit: 0}, {Budget: 50, Limit: 10}}},
+				),
+			)
+			if err != nil {
+				t.Fatalf("cannot create governer: %q", err)
+			}
+
+			// the first sample only establishes a baseline, the second one stays within the first bucket
+			writeStat(0, 0)
+			gov.controlIO()
+			writeStat(4000, 40)
+			gov.controlIO()
+			for name := range test.LimitFiles {
+				if _, err := os.Stat(filepath.Join(fn, name)); err == nil {
+					t.Fatalf("expected no IO limit to be set, but found %s", name)
+				}
+			}
+
+			// exceeding the first bucket's budget must throttle IO to the second bucket's limit
+			writeStat(8000, 80)
+			gov.controlIO()
+			for name, expectation := range test.LimitFiles {
+				fc, err := os.ReadFile(filepath.Join(fn, name))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(fc) != expectation {
+					t.Errorf("unexpected %s content: expected %q, got %q", name, expectation, string(fc))
+				}
+			}
+		})
+	}
+}
+
+func TestControlMemory(t *testing.T) {
+	tests := []struct {
+		Name          string
+		Unified       bool
+		CGroupDir     string
+		Files         map[string]string
+		LimitFile     string
+		Pressure      string
+		ExpectedLimit string
+	}{
+		{
+			Name:      "cgroup v1 below pressure",
+			CGroupDir: "memory",
+			Files: map[string]string{
+				"memory.usage_in_bytes":      "1048576",
+				"memory.soft_limit_in_bytes": "9223372036854771712",
+			},
+			LimitFile:     "memory.soft_limit_in_bytes",
+			Pressure:      "some avg10=5.00 avg60=1.00 avg300=0.00 total=1234\nfull avg10=0.00 avg60=0.00 avg300=0.00 total=0\n",
+			ExpectedLimit: "9223372036854771712",
+		},
+		{
+			Name:      "cgroup v1 under pressure",
+			CGroupDir: "memory",
+			Files: map[string]string{
+				"memory.usage_in_bytes":      "1048576",
+				"memory.soft_limit_in_bytes": "9223372036854771712",
+			},
+			LimitFile:     "memory.soft_limit_in_bytes",
+			Pressure:      "some avg10=42.50 avg60=10.00 avg300=2.00 total=1234\nfull avg10=1.00 avg60=0.00 avg300=0.00 total=0\n",
+			ExpectedLimit: "2097152",
+		},
+		{
+			Name:    "cgroup v2 under pressure",
+			Unified: true,
+			Files: map[string]string{
+				"memory.current": "1048576",
+				"memory.high":    "max\n",
+			},
+			LimitFile:     "memory.high",
+			Pressure:      "some avg10=42.50 avg60=10.00 avg300=2.00 total=1234\nfull avg10=1.00 avg60=0.00 avg300=0.00 total=0\n",
+			ExpectedLimit: "2097152",
+		},
+		{
+			Name:    "cgroup v2 pressure relieved",
+			Unified: true,
+			Files: map[string]string{
+				"memory.current": "1048576",
+				"memory.high":    "2097152\n",
+			},
+			LimitFile:     "memory.high",
+			Pressure:      "some avg10=0.00 avg60=10.00 avg300=2.00 total=1234\nfull avg10=0.00 avg60=0.00 avg300=0.00 total=0\n",
+			ExpectedLimit: "max",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			base := t.TempDir()
+			cgroupPath := "kubepods/pod1/container1"
+			fn := filepath.Join(base, test.CGroupDir, cgroupPath)
+			err := os.MkdirAll(fn, 0755)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for name, content := range test.Files {
+				err := os.WriteFile(filepath.Join(fn, name), []byte(content), 0644)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			pressureFile := filepath.Join(base, "memory.pressure")
+			err = os.WriteFile(pressureFile, []byte(test.Pressure), 0644)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			gov, err := NewController("testcontainer", "instanceid", cgroupPath,
+				WithCGroupBasePath(base),
+				WithUnifiedCGroups(test.Unified),
+				WithMemoryLimiter(BucketLimiter{{Budget: 10, Limit: 0}, {Limit: 2 * 1024 * 1024}}),
+			)
+			if err != nil {
+				t.Fatalf("cannot create governer: %q", err)
+			}
+			gov.MemoryPressureFile = pressureFile
+
+			gov.controlMemory()
+
+			fc, err := os.ReadFile(filepath.Join(fn, test.LimitFile))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if act := strings.TrimSpace(string(fc)); act != test.ExpectedLimit {
+				t.Errorf("unexpected %s content: expected %q, got %q", test.LimitFile, test.ExpectedLimit, act)
+			}
+		})
+	}
+}
+
 type sample struct {
 	T           time.Duration
 	Quota       int64
@@ -20,19 +20,32 @@ import (
 
 // Config configures the containerd resource governer dispatch
 type Config struct {
-	CPUBuckets        []Bucket            `json:"cpuBuckets"`
+	CPUBuckets []Bucket `json:"cpuBuckets"`
+	// IOBuckets limit the IO bandwidth of a workspace. Budgets are expressed in bytes read/written during the
+	// control period, limits in bytes/sec per device. If empty, IO bandwidth is not limited.
+	IOBuckets []Bucket `json:"ioBuckets,omitempty"`
+	// IOPSBuckets limit the IO operations of a workspace. Budgets are expressed in operations during the
+	// control period, limits in operations/sec per device. If empty, IO operations are not limited.
+	IOPSBuckets []Bucket `json:"iopsBuckets,omitempty"`
+	// MemoryBuckets decide on the memory soft limit (memory.high on cgroup v2) of a workspace based on the
+	// node's memory pressure. Budgets are expressed in percent of time the node stalled on memory, limits in bytes.
+	// A limit of zero removes the soft limit. If empty, no soft limit is set.
+	MemoryBuckets []Bucket `json:"memoryBuckets,omitempty"`
+
 	ControlPeriod     string              `json:"controlPeriod"`
 	SamplingPeriod    string              `json:"samplingPeriod"`
 	CGroupsBasePath   string              `json:"cgroupBasePath"`
 	ProcessPriorities map[ProcessType]int `json:"processPriorities"`
 }
 
-// NewDispatchListener creates a new resource governer dispatch listener
-func NewDispatchListener(cfg *Config, prom prometheus.Registerer) *DispatchListener {
+// NewDispatchListener creates a new resource governer dispatch listener.
+// unifiedCGroups must be true if the node uses the unified (cgroup v2) hierarchy.
+func NewDispatchListener(cfg *Config, unifiedCGroups bool, prom prometheus.Registerer) *DispatchListener {
 	d := &DispatchListener{
-		Prometheus: prom,
-		Config:     cfg,
-		governer:   make(map[container.ID]*Controller),
+		Prometheus:     prom,
+		Config:         cfg,
+		UnifiedCGroups: unifiedCGroups,
+		governer:       make(map[container.ID]*Controller),
 	}
 	prom.MustRegister(
 		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
@@ -51,8 +64,9 @@ func NewDispatchListener(cfg *Config, prom prometheus.Registerer) *DispatchListe
 
 // DispatchListener starts new resource governer using the workspace dispatch
 type DispatchListener struct {
-	Prometheus prometheus.Registerer
-	Config     *Config
+	Prometheus     prometheus.Registerer
+	Config         *Config
+	UnifiedCGroups bool
 
 	governer map[container.ID]*Controller
 	mu       sync.Mutex
@@ -99,10 +113,25 @@ func (d *DispatchListener) WorkspaceAdded(ctx context.Context, ws *dispatch.Work
 		// We'll leave cpuLimiter nil which effectively disables the CPU limiting.
 	}
 
+	var ioLimiter, iopsLimiter, memoryLimiter ResourceLimiter
+	if len(d.Config.IOBuckets) > 0 {
+		ioLimiter = &ClampingBucketLimiter{Buckets: d.Config.IOBuckets}
+	}
+	if len(d.Config.IOPSBuckets) > 0 {
+		iopsLimiter = &ClampingBucketLimiter{Buckets: d.Config.IOPSBuckets}
+	}
+	if len(d.Config.MemoryBuckets) > 0 {
+		// memory pressure is not a budget that's spent over time, hence the limit must follow it without clamping
+		memoryLimiter = BucketLimiter(d.Config.MemoryBuckets)
+	}
+
 	log := log.WithFields(wsk8s.GetOWIFromObject(&ws.Pod.ObjectMeta)).WithField("containerID", ws.ContainerID)
 	g, err := NewController(string(ws.ContainerID), ws.InstanceID, cgroupPath,
 		WithCGroupBasePath(d.Config.CGroupsBasePath),
+		WithUnifiedCGroups(d.UnifiedCGroups),
 		WithCPULimiter(cpuLimiter),
+		WithIOLimiter(ioLimiter, iopsLimiter),
+		WithMemoryLimiter(memoryLimiter),
 		WithGitpodIDs(ws.WorkspaceID, ws.InstanceID),
 		WithPrometheusRegisterer(prometheus.WrapRegistererWith(prometheus.Labels{"instanceId": ws.InstanceID}, d.Prometheus)),
 		WithProcessPriorities(d.Config.ProcessPriorities),
@@ -118,6 +147,18 @@ func (d *DispatchListener) WorkspaceAdded(ctx context.Context, ws *dispatch.Work
 	return nil
 }
 
+// CPULimit returns the CPU limit currently enforced on a container in jiffies/sec.
+// It returns zero if the container has no resource governer or no limit is enforced.
+func (d *DispatchListener) CPULimit(id container.ID) int64 {
+	d.mu.Lock()
+	gov, ok := d.governer[id]
+	d.mu.Unlock()
+	if !ok {
+		return 0
+	}
+	return gov.CPULimit()
+}
+
 // WorkspaceUpdated gets called when a workspace is updated
 func (d *DispatchListener) WorkspaceUpdated(ctx context.Context, ws *dispatch.Workspace) error {
 	d.mu.Lock()
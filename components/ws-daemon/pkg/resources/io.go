@@ -0,0 +1,165 @@
+// Copyright (c) 2021 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package resources
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+// ioDeviceUsage is the total IO a cgroup has performed on a single block device
+type ioDeviceUsage struct {
+	Bytes      int64
+	Operations int64
+}
+
+// ioLimit is the IO limit enforced on a single block device. Zero means unlimited.
+type ioLimit struct {
+	BytesPerSecond      int64
+	OperationsPerSecond int64
+}
+
+// ioController interacts with the block IO controller of the linux kernel
+type ioController interface {
+	// GetUsage returns the total IO per device, keyed by "major:minor"
+	GetUsage() (map[string]ioDeviceUsage, error)
+	// SetLimit limits read and write IO on a device. A limit of zero removes the limit.
+	SetLimit(device string, limit ioLimit) error
+}
+
+// cgroupBlkioController controls a cgroup's IO using the blkio throttling policy of cgroup v1
+type cgroupBlkioController string
+
+// GetUsage returns the total IO per device from blkio.throttle.io_service_bytes and blkio.throttle.io_serviced
+func (basePath cgroupBlkioController) GetUsage() (map[string]ioDeviceUsage, error) {
+	bytes, err := readBlkioStat(filepath.Join(string(basePath), "blkio.throttle.io_service_bytes"))
+	if err != nil {
+		return nil, xerrors.Errorf("cannot sample blkio.throttle.io_service_bytes: %w", err)
+	}
+	ops, err := readBlkioStat(filepath.Join(string(basePath), "blkio.throttle.io_serviced"))
+	if err != nil {
+		return nil, xerrors.Errorf("cannot sample blkio.throttle.io_serviced: %w", err)
+	}
+
+	res := make(map[string]ioDeviceUsage, len(bytes))
+	for dev, b := range bytes {
+		res[dev] = ioDeviceUsage{Bytes: b, Operations: ops[dev]}
+	}
+	return res, nil
+}
+
+// readBlkioStat reads the per-device "Total" lines of a blkio stat file, e.g.
+//
+//	8:0 Read 4096
+//	8:0 Write 8192
+//	8:0 Total 12288
+//	Total 12288
+func readBlkioStat(fn string) (map[string]int64, error) {
+	fc, err := os.ReadFile(fn)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make(map[string]int64)
+	for _, line := range strings.Split(string(fc), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 3 || fields[1] != "Total" {
+			continue
+		}
+
+		val, err := strconv.ParseInt(fields[2], 10, 64)
+		if err != nil {
+			return nil, xerrors.Errorf("cannot parse line %q: %w", line, err)
+		}
+		res[fields[0]] = val
+	}
+	return res, nil
+}
+
+// SetLimit writes the blkio.throttle.*_device files. Writing a limit of zero removes the rule for the device.
+func (basePath cgroupBlkioController) SetLimit(device string, limit ioLimit) error {
+	for fn, val := range map[string]int64{
+		"blkio.throttle.read_bps_device":   limit.BytesPerSecond,
+		"blkio.throttle.write_bps_device":  limit.BytesPerSecond,
+		"blkio.throttle.read_iops_device":  limit.OperationsPerSecond,
+		"blkio.throttle.write_iops_device": limit.OperationsPerSecond,
+	} {
+		if val < 0 {
+			val = 0
+		}
+		err := os.WriteFile(filepath.Join(string(basePath), fn), []byte(fmt.Sprintf("%s %d", device, val)), 0644)
+		if err != nil {
+			return xerrors.Errorf("cannot set %s: %w", fn, err)
+		}
+	}
+	return nil
+}
+
+// cgroupV2IOController controls a cgroup's IO on the unified (cgroup v2) hierarchy
+type cgroupV2IOController string
+
+// GetUsage returns the total IO per device from io.stat, e.g.
+//
+//	8:0 rbytes=4096 wbytes=8192 rios=1 wios=2 dbytes=0 dios=0
+func (basePath cgroupV2IOController) GetUsage() (map[string]ioDeviceUsage, error) {
+	fn := filepath.Join(string(basePath), "io.stat")
+	fc, err := os.ReadFile(fn)
+	if err != nil {
+		return nil, xerrors.Errorf("cannot sample io.stat: %w", err)
+	}
+
+	res := make(map[string]ioDeviceUsage)
+	for _, line := range strings.Split(string(fc), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+
+		var usage ioDeviceUsage
+		for _, f := range fields[1:] {
+			segs := strings.SplitN(f, "=", 2)
+			if len(segs) != 2 {
+				continue
+			}
+			val, err := strconv.ParseInt(segs[1], 10, 64)
+			if err != nil {
+				return nil, xerrors.Errorf("cannot parse io.stat line %q: %w", line, err)
+			}
+
+			switch segs[0] {
+			case "rbytes", "wbytes":
+				usage.Bytes += val
+			case "rios", "wios":
+				usage.Operations += val
+			}
+		}
+		res[fields[0]] = usage
+	}
+	return res, nil
+}
+
+// SetLimit writes the device's io.max entry
+func (basePath cgroupV2IOController) SetLimit(device string, limit ioLimit) error {
+	bps, iops := ioMaxValue(limit.BytesPerSecond), ioMaxValue(limit.OperationsPerSecond)
+
+	fn := filepath.Join(string(basePath), "io.max")
+	err := os.WriteFile(fn, []byte(fmt.Sprintf("%s rbps=%s wbps=%s riops=%s wiops=%s", device, bps, bps, iops, iops)), 0644)
+	if err != nil {
+		return xerrors.Errorf("cannot set io.max: %w", err)
+	}
+	return nil
+}
+
+func ioMaxValue(limit int64) string {
+	if limit <= 0 {
+		return "max"
+	}
+	return strconv.FormatInt(limit, 10)
+}
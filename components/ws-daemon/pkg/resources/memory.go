@@ -0,0 +1,144 @@
+// Copyright (c) 2021 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package resources
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+// DefaultMemoryPressureFile is where the kernel reports the node's memory pressure (PSI)
+const DefaultMemoryPressureFile = "/proc/pressure/memory"
+
+// memoryController interacts with the memory controller of the linux kernel
+type memoryController interface {
+	// GetUsage returns the memory currently used by the cgroup in bytes
+	GetUsage() (bytes int64, err error)
+	// GetSoftLimit returns the current soft limit in bytes, or -1 if there is none
+	GetSoftLimit() (bytes int64, err error)
+	// SetSoftLimit sets a new soft limit. A limit of -1 removes the soft limit.
+	SetSoftLimit(bytes int64) error
+}
+
+// cgroupMemoryController controls a cgroup's memory soft limit on cgroup v1
+type cgroupMemoryController string
+
+// memoryUnlimitedV1 is the value the kernel reports for an unlimited memory.soft_limit_in_bytes
+// (PAGE_COUNTER_MAX * PAGE_SIZE on 64 bit systems with 4k pages)
+const memoryUnlimitedV1 = 9223372036854771712
+
+// GetUsage returns the value of memory.usage_in_bytes
+func (basePath cgroupMemoryController) GetUsage() (bytes int64, err error) {
+	return readInt64File(filepath.Join(string(basePath), "memory.usage_in_bytes"))
+}
+
+// GetSoftLimit returns the value of memory.soft_limit_in_bytes
+func (basePath cgroupMemoryController) GetSoftLimit() (bytes int64, err error) {
+	bytes, err = readInt64File(filepath.Join(string(basePath), "memory.soft_limit_in_bytes"))
+	if err != nil {
+		return 0, err
+	}
+	if bytes >= memoryUnlimitedV1 {
+		return -1, nil
+	}
+	return bytes, nil
+}
+
+// SetSoftLimit writes memory.soft_limit_in_bytes
+func (basePath cgroupMemoryController) SetSoftLimit(bytes int64) error {
+	fn := filepath.Join(string(basePath), "memory.soft_limit_in_bytes")
+	err := os.WriteFile(fn, []byte(strconv.FormatInt(bytes, 10)), 0644)
+	if err != nil {
+		return xerrors.Errorf("cannot set memory soft limit: %w", err)
+	}
+	return nil
+}
+
+// cgroupV2MemoryController controls a cgroup's memory.high on the unified (cgroup v2) hierarchy
+type cgroupV2MemoryController string
+
+// GetUsage returns the value of memory.current
+func (basePath cgroupV2MemoryController) GetUsage() (bytes int64, err error) {
+	return readInt64File(filepath.Join(string(basePath), "memory.current"))
+}
+
+// GetSoftLimit returns the value of memory.high
+func (basePath cgroupV2MemoryController) GetSoftLimit() (bytes int64, err error) {
+	fn := filepath.Join(string(basePath), "memory.high")
+	fc, err := os.ReadFile(fn)
+	if err != nil {
+		return 0, xerrors.Errorf("cannot read memory.high: %w", err)
+	}
+	if strings.TrimSpace(string(fc)) == "max" {
+		return -1, nil
+	}
+	bytes, err = strconv.ParseInt(strings.TrimSpace(string(fc)), 10, 64)
+	if err != nil {
+		return 0, xerrors.Errorf("cannot parse memory.high: %w", err)
+	}
+	return bytes, nil
+}
+
+// SetSoftLimit writes memory.high
+func (basePath cgroupV2MemoryController) SetSoftLimit(bytes int64) error {
+	val := "max"
+	if bytes >= 0 {
+		val = strconv.FormatInt(bytes, 10)
+	}
+
+	fn := filepath.Join(string(basePath), "memory.high")
+	err := os.WriteFile(fn, []byte(val), 0644)
+	if err != nil {
+		return xerrors.Errorf("cannot set memory.high: %w", err)
+	}
+	return nil
+}
+
+func readInt64File(fn string) (int64, error) {
+	fc, err := os.ReadFile(fn)
+	if err != nil {
+		return 0, xerrors.Errorf("cannot read %s: %w", filepath.Base(fn), err)
+	}
+	val, err := strconv.ParseInt(strings.TrimSpace(string(fc)), 10, 64)
+	if err != nil {
+		return 0, xerrors.Errorf("cannot parse %s: %w", filepath.Base(fn), err)
+	}
+	return val, nil
+}
+
+// readMemoryPressure returns the share of time (in percent) in which some tasks stalled on memory
+// during the last ten seconds, i.e. the avg10 value of the "some" line in a PSI file, e.g.
+//
+//	some avg10=12.34 avg60=5.00 avg300=1.00 total=123456
+//	full avg10=0.00 avg60=0.00 avg300=0.00 total=0
+func readMemoryPressure(fn string) (percent int64, err error) {
+	fc, err := os.ReadFile(fn)
+	if err != nil {
+		return 0, xerrors.Errorf("cannot read memory pressure: %w", err)
+	}
+
+	for _, line := range strings.Split(string(fc), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || fields[0] != "some" {
+			continue
+		}
+		for _, f := range fields[1:] {
+			if !strings.HasPrefix(f, "avg10=") {
+				continue
+			}
+			val, err := strconv.ParseFloat(strings.TrimPrefix(f, "avg10="), 64)
+			if err != nil {
+				return 0, xerrors.Errorf("cannot parse memory pressure: %w", err)
+			}
+			return int64(val), nil
+		}
+	}
+
+	return 0, xerrors.Errorf("cannot parse memory pressure: no avg10 found")
+}
@@ -0,0 +1,31 @@
+// Copyright (c) 2021 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+// Package v1 contains the API schema definitions of the workspace.gitpod.io v1 API group
+// +kubebuilder:object:generate=true
+// +groupName=workspace.gitpod.io
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var (
+	// GroupVersion is the group version used to register these objects
+	GroupVersion = schema.GroupVersion{Group: "workspace.gitpod.io", Version: "v1"}
+
+	// SchemeBuilder is used to add Go types to the GroupVersionKind scheme
+	SchemeBuilder = runtime.NewSchemeBuilder(addKnownTypes)
+
+	// AddToScheme adds the types in this group-version to the given scheme
+	AddToScheme = SchemeBuilder.AddToScheme
+)
+
+func addKnownTypes(scheme *runtime.Scheme) error {
+	scheme.AddKnownTypes(GroupVersion, &Workspace{}, &WorkspaceList{})
+	metav1.AddToGroupVersion(scheme, GroupVersion)
+	return nil
+}
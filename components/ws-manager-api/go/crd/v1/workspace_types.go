@@ -0,0 +1,123 @@
+// Copyright (c) 2021 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// WorkspaceSpec describes the workspace instance as it was started. It does not change during the
+// lifetime of the instance.
+type WorkspaceSpec struct {
+	// Owner is the ID of the Gitpod user who owns this workspace
+	Owner string `json:"owner"`
+	// WorkspaceID is the ID of the workspace this instance belongs to
+	WorkspaceID string `json:"workspaceID"`
+	// Type is the kind of workspace, e.g. REGULAR or PREBUILD
+	Type string `json:"type"`
+	// Headless marks workspaces which are not intended for users but for automation
+	Headless bool `json:"headless,omitempty"`
+	// WorkspaceImage is the image the workspace runs
+	WorkspaceImage string `json:"workspaceImage,omitempty"`
+	// IDEImage is the image of the IDE used in this workspace
+	IDEImage string `json:"ideImage,omitempty"`
+	// Annotations are the annotations the workspace was started with
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// PortSpec describes a port exposed by a workspace
+type PortSpec struct {
+	// Port is the outward-facing port
+	Port uint32 `json:"port"`
+	// Target is the inward-facing target port
+	Target uint32 `json:"target,omitempty"`
+	// Visibility is either "public" or "private"
+	Visibility string `json:"visibility"`
+	// URL is the public-facing URL this port is available at
+	URL string `json:"url,omitempty"`
+}
+
+// Condition types of a Workspace. They mirror the conditions of the ws-manager API.
+const (
+	// WorkspaceConditionFailed is true if the workspace failed to operate
+	WorkspaceConditionFailed = "Failed"
+	// WorkspaceConditionTimeout is true if the workspace has timed out
+	WorkspaceConditionTimeout = "Timeout"
+	// WorkspaceConditionPullingImages is true while the workspace images are being pulled
+	WorkspaceConditionPullingImages = "PullingImages"
+	// WorkspaceConditionServiceExists is true if the IDE or ports service of the workspace exists
+	WorkspaceConditionServiceExists = "ServiceExists"
+	// WorkspaceConditionFinalBackupComplete is true once the last state of the workspace has been backed up
+	WorkspaceConditionFinalBackupComplete = "FinalBackupComplete"
+	// WorkspaceConditionDeployed is true if the workspace container is deployed
+	WorkspaceConditionDeployed = "Deployed"
+	// WorkspaceConditionNetworkNotReady is true if the workspace container is experiencing network problems
+	WorkspaceConditionNetworkNotReady = "NetworkNotReady"
+	// WorkspaceConditionHeadlessTaskFailed is true if the task of a headless workspace failed
+	WorkspaceConditionHeadlessTaskFailed = "HeadlessTaskFailed"
+)
+
+// WorkspaceStatus mirrors the status ws-manager computes for a workspace
+type WorkspaceStatus struct {
+	// Phase is a simple, high-level summary of where the workspace is in its lifecycle
+	Phase string `json:"phase,omitempty"`
+	// Message is a human-readable message detailing the current phase
+	Message string `json:"message,omitempty"`
+	// Conditions detail the current state of the workspace
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// URL is the external URL of the workspace
+	URL string `json:"url,omitempty"`
+	// ExposedPorts lists all ports the workspace has exposed to the outside world
+	ExposedPorts []PortSpec `json:"exposedPorts,omitempty"`
+	// Admission describes who can access the workspace and its ports
+	Admission string `json:"admission,omitempty"`
+	// Timeout is the time a workspace can be without activity before it's shut down
+	Timeout string `json:"timeout,omitempty"`
+	// Snapshot is the snapshot produced by a headless workspace prior to shutting down
+	Snapshot string `json:"snapshot,omitempty"`
+
+	// StartedAt is the time the workspace was started
+	StartedAt *metav1.Time `json:"startedAt,omitempty"`
+	// FirstUserActivity is the time the user was first active in the workspace
+	FirstUserActivity *metav1.Time `json:"firstUserActivity,omitempty"`
+
+	// NodeName is the name of the node the workspace runs on
+	NodeName string `json:"nodeName,omitempty"`
+	// PodName is the name of the pod the workspace runs in
+	PodName string `json:"podName,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:shortName=ws
+// +kubebuilder:printcolumn:name="Owner",type=string,JSONPath=`.spec.owner`
+// +kubebuilder:printcolumn:name="Type",type=string,JSONPath=`.spec.type`
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="URL",type=string,JSONPath=`.status.url`,priority=1
+// +kubebuilder:printcolumn:name="Node",type=string,JSONPath=`.status.nodeName`,priority=1
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// Workspace mirrors the state of a workspace instance managed by ws-manager. The object is named
+// after the instance ID and is owned by the workspace pod. ws-manager is the only writer of these objects;
+// modifying them has no effect on the workspace itself.
+type Workspace struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   WorkspaceSpec   `json:"spec,omitempty"`
+	Status WorkspaceStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// WorkspaceList contains a list of Workspace
+type WorkspaceList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Workspace `json:"items"`
+}
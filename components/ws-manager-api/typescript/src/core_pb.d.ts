@@ -602,6 +602,8 @@ export class PortSpec extends jspb.Message {
     setVisibility(value: PortVisibility): PortSpec;
     getUrl(): string;
     setUrl(value: string): PortSpec;
+    getProtocol(): PortProtocol;
+    setProtocol(value: PortProtocol): PortSpec;
 
     serializeBinary(): Uint8Array;
     toObject(includeInstance?: boolean): PortSpec.AsObject;
@@ -619,6 +621,7 @@ export namespace PortSpec {
         target: number,
         visibility: PortVisibility,
         url: string,
+        protocol: PortProtocol,
     }
 }
 
@@ -646,6 +649,10 @@ export class WorkspaceConditions extends jspb.Message {
     setFirstUserActivity(value?: google_protobuf_timestamp_pb.Timestamp): WorkspaceConditions;
     getHeadlessTaskFailed(): string;
     setHeadlessTaskFailed(value: string): WorkspaceConditions;
+    clearServicesList(): void;
+    getServicesList(): Array<ServiceCondition>;
+    setServicesList(value: Array<ServiceCondition>): WorkspaceConditions;
+    addServices(value?: ServiceCondition, index?: number): ServiceCondition;
 
     serializeBinary(): Uint8Array;
     toObject(includeInstance?: boolean): WorkspaceConditions.AsObject;
@@ -669,6 +676,33 @@ export namespace WorkspaceConditions {
         networkNotReady: WorkspaceConditionBool,
         firstUserActivity?: google_protobuf_timestamp_pb.Timestamp.AsObject,
         headlessTaskFailed: string,
+        servicesList: Array<ServiceCondition.AsObject>,
+    }
+}
+
+export class ServiceCondition extends jspb.Message {
+    getName(): string;
+    setName(value: string): ServiceCondition;
+    getReady(): WorkspaceConditionBool;
+    setReady(value: WorkspaceConditionBool): ServiceCondition;
+    getMessage(): string;
+    setMessage(value: string): ServiceCondition;
+
+    serializeBinary(): Uint8Array;
+    toObject(includeInstance?: boolean): ServiceCondition.AsObject;
+    static toObject(includeInstance: boolean, msg: ServiceCondition): ServiceCondition.AsObject;
+    static extensions: {[key: number]: jspb.ExtensionFieldInfo<jspb.Message>};
+    static extensionsBinary: {[key: number]: jspb.ExtensionFieldBinaryInfo<jspb.Message>};
+    static serializeBinaryToWriter(message: ServiceCondition, writer: jspb.BinaryWriter): void;
+    static deserializeBinary(bytes: Uint8Array): ServiceCondition;
+    static deserializeBinaryFromReader(message: ServiceCondition, reader: jspb.BinaryReader): ServiceCondition;
+}
+
+export namespace ServiceCondition {
+    export type AsObject = {
+        name: string,
+        ready: WorkspaceConditionBool,
+        message: string,
     }
 }
 
@@ -790,6 +824,14 @@ export class StartWorkspaceSpec extends jspb.Message {
     setTimeout(value: string): StartWorkspaceSpec;
     getAdmission(): AdmissionLevel;
     setAdmission(value: AdmissionLevel): StartWorkspaceSpec;
+    clearVolumesList(): void;
+    getVolumesList(): Array<PersistentVolumeSpec>;
+    setVolumesList(value: Array<PersistentVolumeSpec>): StartWorkspaceSpec;
+    addVolumes(value?: PersistentVolumeSpec, index?: number): PersistentVolumeSpec;
+    clearServicesList(): void;
+    getServicesList(): Array<ServiceContainerSpec>;
+    setServicesList(value: Array<ServiceContainerSpec>): StartWorkspaceSpec;
+    addServices(value?: ServiceContainerSpec, index?: number): ServiceContainerSpec;
 
     serializeBinary(): Uint8Array;
     toObject(includeInstance?: boolean): StartWorkspaceSpec.AsObject;
@@ -814,6 +856,98 @@ export namespace StartWorkspaceSpec {
         git?: GitSpec.AsObject,
         timeout: string,
         admission: AdmissionLevel,
+        volumesList: Array<PersistentVolumeSpec.AsObject>,
+        servicesList: Array<ServiceContainerSpec.AsObject>,
+    }
+}
+
+export class PersistentVolumeSpec extends jspb.Message {
+    getName(): string;
+    setName(value: string): PersistentVolumeSpec;
+    getMountPath(): string;
+    setMountPath(value: string): PersistentVolumeSpec;
+
+    serializeBinary(): Uint8Array;
+    toObject(includeInstance?: boolean): PersistentVolumeSpec.AsObject;
+    static toObject(includeInstance: boolean, msg: PersistentVolumeSpec): PersistentVolumeSpec.AsObject;
+    static extensions: {[key: number]: jspb.ExtensionFieldInfo<jspb.Message>};
+    static extensionsBinary: {[key: number]: jspb.ExtensionFieldBinaryInfo<jspb.Message>};
+    static serializeBinaryToWriter(message: PersistentVolumeSpec, writer: jspb.BinaryWriter): void;
+    static deserializeBinary(bytes: Uint8Array): PersistentVolumeSpec;
+    static deserializeBinaryFromReader(message: PersistentVolumeSpec, reader: jspb.BinaryReader): PersistentVolumeSpec;
+}
+
+export namespace PersistentVolumeSpec {
+    export type AsObject = {
+        name: string,
+        mountPath: string,
+    }
+}
+
+export class ServiceContainerSpec extends jspb.Message {
+    getName(): string;
+    setName(value: string): ServiceContainerSpec;
+    getImage(): string;
+    setImage(value: string): ServiceContainerSpec;
+    clearEnvList(): void;
+    getEnvList(): Array<EnvironmentVariable>;
+    setEnvList(value: Array<EnvironmentVariable>): ServiceContainerSpec;
+    addEnv(value?: EnvironmentVariable, index?: number): EnvironmentVariable;
+    clearPortsList(): void;
+    getPortsList(): Array<number>;
+    setPortsList(value: Array<number>): ServiceContainerSpec;
+    addPorts(value: number, index?: number): number;
+
+    hasResources(): boolean;
+    clearResources(): void;
+    getResources(): ServiceContainerResources | undefined;
+    setResources(value?: ServiceContainerResources): ServiceContainerSpec;
+    clearReadinessCommandList(): void;
+    getReadinessCommandList(): Array<string>;
+    setReadinessCommandList(value: Array<string>): ServiceContainerSpec;
+    addReadinessCommand(value: string, index?: number): string;
+
+    serializeBinary(): Uint8Array;
+    toObject(includeInstance?: boolean): ServiceContainerSpec.AsObject;
+    static toObject(includeInstance: boolean, msg: ServiceContainerSpec): ServiceContainerSpec.AsObject;
+    static extensions: {[key: number]: jspb.ExtensionFieldInfo<jspb.Message>};
+    static extensionsBinary: {[key: number]: jspb.ExtensionFieldBinaryInfo<jspb.Message>};
+    static serializeBinaryToWriter(message: ServiceContainerSpec, writer: jspb.BinaryWriter): void;
+    static deserializeBinary(bytes: Uint8Array): ServiceContainerSpec;
+    static deserializeBinaryFromReader(message: ServiceContainerSpec, reader: jspb.BinaryReader): ServiceContainerSpec;
+}
+
+export namespace ServiceContainerSpec {
+    export type AsObject = {
+        name: string,
+        image: string,
+        envList: Array<EnvironmentVariable.AsObject>,
+        portsList: Array<number>,
+        resources?: ServiceContainerResources.AsObject,
+        readinessCommandList: Array<string>,
+    }
+}
+
+export class ServiceContainerResources extends jspb.Message {
+    getCpu(): string;
+    setCpu(value: string): ServiceContainerResources;
+    getMemory(): string;
+    setMemory(value: string): ServiceContainerResources;
+
+    serializeBinary(): Uint8Array;
+    toObject(includeInstance?: boolean): ServiceContainerResources.AsObject;
+    static toObject(includeInstance: boolean, msg: ServiceContainerResources): ServiceContainerResources.AsObject;
+    static extensions: {[key: number]: jspb.ExtensionFieldInfo<jspb.Message>};
+    static extensionsBinary: {[key: number]: jspb.ExtensionFieldBinaryInfo<jspb.Message>};
+    static serializeBinaryToWriter(message: ServiceContainerResources, writer: jspb.BinaryWriter): void;
+    static deserializeBinary(bytes: Uint8Array): ServiceContainerResources;
+    static deserializeBinaryFromReader(message: ServiceContainerResources, reader: jspb.BinaryReader): ServiceContainerResources;
+}
+
+export namespace ServiceContainerResources {
+    export type AsObject = {
+        cpu: string,
+        memory: string,
     }
 }
 
@@ -878,6 +1012,11 @@ export enum PortVisibility {
     PORT_VISIBILITY_PUBLIC = 1,
 }
 
+export enum PortProtocol {
+    PORT_PROTOCOL_HTTP = 0,
+    PORT_PROTOCOL_HTTP2 = 1,
+}
+
 export enum WorkspaceConditionBool {
     FALSE = 0,
     TRUE = 1,
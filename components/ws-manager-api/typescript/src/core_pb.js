@@ -39,8 +39,13 @@ goog.exportSymbol('proto.wsman.GitSpec', null, global);
 goog.exportSymbol('proto.wsman.MarkActiveRequest', null, global);
 goog.exportSymbol('proto.wsman.MarkActiveResponse', null, global);
 goog.exportSymbol('proto.wsman.MetadataFilter', null, global);
+goog.exportSymbol('proto.wsman.PersistentVolumeSpec', null, global);
+goog.exportSymbol('proto.wsman.PortProtocol', null, global);
 goog.exportSymbol('proto.wsman.PortSpec', null, global);
 goog.exportSymbol('proto.wsman.PortVisibility', null, global);
+goog.exportSymbol('proto.wsman.ServiceCondition', null, global);
+goog.exportSymbol('proto.wsman.ServiceContainerResources', null, global);
+goog.exportSymbol('proto.wsman.ServiceContainerSpec', null, global);
 goog.exportSymbol('proto.wsman.SetTimeoutRequest', null, global);
 goog.exportSymbol('proto.wsman.SetTimeoutResponse', null, global);
 goog.exportSymbol('proto.wsman.StartWorkspaceRequest', null, global);
@@ -578,7 +583,7 @@ if (goog.DEBUG && !COMPILED) {
  * @constructor
  */
 proto.wsman.WorkspaceConditions = function(opt_data) {
-  jspb.Message.initialize(this, opt_data, 0, -1, null, null);
+  jspb.Message.initialize(this, opt_data, 0, -1, proto.wsman.WorkspaceConditions.repeatedFields_, null);
 };
 goog.inherits(proto.wsman.WorkspaceConditions, jspb.Message);
 if (goog.DEBUG && !COMPILED) {
@@ -588,6 +593,27 @@ if (goog.DEBUG && !COMPILED) {
    */
   proto.wsman.WorkspaceConditions.displayName = 'proto.wsman.WorkspaceConditions';
 }
+/**
+ * Generated by JsPbCodeGenerator.
+ * @param {Array=} opt_data Optional initial data array, typically from a
+ * server response, or constructed directly in Javascript. The array is used
+ * in place and becomes part of the constructed object. It is not cloned.
+ * If no data is provided, the constructed object will be empty, but still
+ * valid.
+ * @extends {jspb.Message}
+ * @constructor
+ */
+proto.wsman.ServiceCondition = function(opt_data) {
+  jspb.Message.initialize(this, opt_data, 0, -1, null, null);
+};
+goog.inherits(proto.wsman.ServiceCondition, jspb.Message);
+if (goog.DEBUG && !COMPILED) {
+  /**
+   * @public
+   * @override
+   */
+  proto.wsman.ServiceCondition.displayName = 'proto.wsman.ServiceCondition';
+}
 /**
  * Generated by JsPbCodeGenerator.
  * @param {Array=} opt_data Optional initial data array, typically from a
@@ -672,6 +698,69 @@ if (goog.DEBUG && !COMPILED) {
    */
   proto.wsman.StartWorkspaceSpec.displayName = 'proto.wsman.StartWorkspaceSpec';
 }
+/**
+ * Generated by JsPbCodeGenerator.
+ * @param {Array=} opt_data Optional initial data array, typically from a
+ * server response, or constructed directly in Javascript. The array is used
+ * in place and becomes part of the constructed object. It is not cloned.
+ * If no data is provided, the constructed object will be empty, but still
+ * valid.
+ * @extends {jspb.Message}
+ * @constructor
+ */
+proto.wsman.PersistentVolumeSpec = function(opt_data) {
+  jspb.Message.initialize(this, opt_data, 0, -1, null, null);
+};
+goog.inherits(proto.wsman.PersistentVolumeSpec, jspb.Message);
+if (goog.DEBUG && !COMPILED) {
+  /**
+   * @public
+   * @override
+   */
+  proto.wsman.PersistentVolumeSpec.displayName = 'proto.wsman.PersistentVolumeSpec';
+}
+/**
+ * Generated by JsPbCodeGenerator.
+ * @param {Array=} opt_data Optional initial data array, typically from a
+ * server response, or constructed directly in Javascript. The array is used
+ * in place and becomes part of the constructed object. It is not cloned.
+ * If no data is provided, the constructed object will be empty, but still
+ * valid.
+ * @extends {jspb.Message}
+ * @constructor
+ */
+proto.wsman.ServiceContainerSpec = function(opt_data) {
+  jspb.Message.initialize(this, opt_data, 0, -1, proto.wsman.ServiceContainerSpec.repeatedFields_, null);
+};
+goog.inherits(proto.wsman.ServiceContainerSpec, jspb.Message);
+if (goog.DEBUG && !COMPILED) {
+  /**
+   * @public
+   * @override
+   */
+  proto.wsman.ServiceContainerSpec.displayName = 'proto.wsman.ServiceContainerSpec';
+}
+/**
+ * Generated by JsPbCodeGenerator.
+ * @param {Array=} opt_data Optional initial data array, typically from a
+ * server response, or constructed directly in Javascript. The array is used
+ * in place and becomes part of the constructed object. It is not cloned.
+ * If no data is provided, the constructed object will be empty, but still
+ * valid.
+ * @extends {jspb.Message}
+ * @constructor
+ */
+proto.wsman.ServiceContainerResources = function(opt_data) {
+  jspb.Message.initialize(this, opt_data, 0, -1, null, null);
+};
+goog.inherits(proto.wsman.ServiceContainerResources, jspb.Message);
+if (goog.DEBUG && !COMPILED) {
+  /**
+   * @public
+   * @override
+   */
+  proto.wsman.ServiceContainerResources.displayName = 'proto.wsman.ServiceContainerResources';
+}
 /**
  * Generated by JsPbCodeGenerator.
  * @param {Array=} opt_data Optional initial data array, typically from a
@@ -4803,7 +4892,8 @@ proto.wsman.PortSpec.toObject = function(includeInstance, msg) {
     port: jspb.Message.getFieldWithDefault(msg, 1, 0),
     target: jspb.Message.getFieldWithDefault(msg, 2, 0),
     visibility: jspb.Message.getFieldWithDefault(msg, 3, 0),
-    url: jspb.Message.getFieldWithDefault(msg, 4, "")
+    url: jspb.Message.getFieldWithDefault(msg, 4, ""),
+    protocol: jspb.Message.getFieldWithDefault(msg, 5, 0)
   };
 
   if (includeInstance) {
@@ -4856,6 +4946,10 @@ proto.wsman.PortSpec.deserializeBinaryFromReader = function(msg, reader) {
       var value = /** @type {string} */ (reader.readString());
       msg.setUrl(value);
       break;
+    case 5:
+      var value = /** @type {!proto.wsman.PortProtocol} */ (reader.readEnum());
+      msg.setProtocol(value);
+      break;
     default:
       reader.skipField();
       break;
@@ -4913,6 +5007,13 @@ proto.wsman.PortSpec.serializeBinaryToWriter = function(message, writer) {
       f
     );
   }
+  f = message.getProtocol();
+  if (f !== 0.0) {
+    writer.writeEnum(
+      5,
+      f
+    );
+  }
 };
 
 
@@ -4988,6 +5089,31 @@ proto.wsman.PortSpec.prototype.setUrl = function(value) {
 };
 
 
+/**
+ * optional PortProtocol protocol = 5;
+ * @return {!proto.wsman.PortProtocol}
+ */
+proto.wsman.PortSpec.prototype.getProtocol = function() {
+  return /** @type {!proto.wsman.PortProtocol} */ (jspb.Message.getFieldWithDefault(this, 5, 0));
+};
+
+
+/**
+ * @param {!proto.wsman.PortProtocol} value
+ * @return {!proto.wsman.PortSpec} returns this
+ */
+proto.wsman.PortSpec.prototype.setProtocol = function(value) {
+  return jspb.Message.setProto3EnumField(this, 5, value);
+};
+
+
+
+/**
+ * List of repeated fields within this message type.
+ * @private {!Array<number>}
+ * @const
+ */
+proto.wsman.WorkspaceConditions.repeatedFields_ = [11];
 
 
 
@@ -5029,7 +5155,9 @@ proto.wsman.WorkspaceConditions.toObject = function(includeInstance, msg) {
     deployed: jspb.Message.getFieldWithDefault(msg, 7, 0),
     networkNotReady: jspb.Message.getFieldWithDefault(msg, 8, 0),
     firstUserActivity: (f = msg.getFirstUserActivity()) && google_protobuf_timestamp_pb.Timestamp.toObject(includeInstance, f),
-    headlessTaskFailed: jspb.Message.getFieldWithDefault(msg, 10, "")
+    headlessTaskFailed: jspb.Message.getFieldWithDefault(msg, 10, ""),
+    servicesList: jspb.Message.toObjectList(msg.getServicesList(),
+    proto.wsman.ServiceCondition.toObject, includeInstance)
   };
 
   if (includeInstance) {
@@ -5107,6 +5235,11 @@ proto.wsman.WorkspaceConditions.deserializeBinaryFromReader = function(msg, read
       var value = /** @type {string} */ (reader.readString());
       msg.setHeadlessTaskFailed(value);
       break;
+    case 11:
+      var value = new proto.wsman.ServiceCondition;
+      reader.readMessage(value,proto.wsman.ServiceCondition.deserializeBinaryFromReader);
+      msg.addServices(value);
+      break;
     default:
       reader.skipField();
       break;
@@ -5207,6 +5340,14 @@ proto.wsman.WorkspaceConditions.serializeBinaryToWriter = function(message, writ
       f
     );
   }
+  f = message.getServicesList();
+  if (f.length > 0) {
+    writer.writeRepeatedMessage(
+      11,
+      f,
+      proto.wsman.ServiceCondition.serializeBinaryToWriter
+    );
+  }
 };
 
 
@@ -5409,6 +5550,44 @@ proto.wsman.WorkspaceConditions.prototype.setHeadlessTaskFailed = function(value
 };
 
 
+/**
+ * repeated ServiceCondition services = 11;
+ * @return {!Array<!proto.wsman.ServiceCondition>}
+ */
+proto.wsman.WorkspaceConditions.prototype.getServicesList = function() {
+  return /** @type{!Array<!proto.wsman.ServiceCondition>} */ (
+    jspb.Message.getRepeatedWrapperField(this, proto.wsman.ServiceCondition, 11));
+};
+
+
+/**
+ * @param {!Array<!proto.wsman.ServiceCondition>} value
+ * @return {!proto.wsman.WorkspaceConditions} returns this
+*/
+proto.wsman.WorkspaceConditions.prototype.setServicesList = function(value) {
+  return jspb.Message.setRepeatedWrapperField(this, 11, value);
+};
+
+
+/**
+ * @param {!proto.wsman.ServiceCondition=} opt_value
+ * @param {number=} opt_index
+ * @return {!proto.wsman.ServiceCondition}
+ */
+proto.wsman.WorkspaceConditions.prototype.addServices = function(opt_value, opt_index) {
+  return jspb.Message.addToRepeatedWrapperField(this, 11, opt_value, proto.wsman.ServiceCondition, opt_index);
+};
+
+
+/**
+ * Clears the list making it empty but non-null.
+ * @return {!proto.wsman.WorkspaceConditions} returns this
+ */
+proto.wsman.WorkspaceConditions.prototype.clearServicesList = function() {
+  return this.setServicesList([]);
+};
+
+
 
 
 
@@ -5425,8 +5604,8 @@ if (jspb.Message.GENERATE_TO_OBJECT) {
  *     http://goto/soy-param-migration
  * @return {!Object}
  */
-proto.wsman.WorkspaceMetadata.prototype.toObject = function(opt_includeInstance) {
-  return proto.wsman.WorkspaceMetadata.toObject(opt_includeInstance, this);
+proto.wsman.ServiceCondition.prototype.toObject = function(opt_includeInstance) {
+  return proto.wsman.ServiceCondition.toObject(opt_includeInstance, this);
 };
 
 
@@ -5435,16 +5614,15 @@ proto.wsman.WorkspaceMetadata.prototype.toObject = function(opt_includeInstance)
  * @param {boolean|undefined} includeInstance Deprecated. Whether to include
  *     the JSPB instance for transitional soy proto support:
  *     http://goto/soy-param-migration
- * @param {!proto.wsman.WorkspaceMetadata} msg The msg instance to transform.
+ * @param {!proto.wsman.ServiceCondition} msg The msg instance to transform.
  * @return {!Object}
  * @suppress {unusedLocalVariables} f is only used for nested messages
  */
-proto.wsman.WorkspaceMetadata.toObject = function(includeInstance, msg) {
+proto.wsman.ServiceCondition.toObject = function(includeInstance, msg) {
   var f, obj = {
-    owner: jspb.Message.getFieldWithDefault(msg, 1, ""),
-    metaId: jspb.Message.getFieldWithDefault(msg, 2, ""),
-    startedAt: (f = msg.getStartedAt()) && google_protobuf_timestamp_pb.Timestamp.toObject(includeInstance, f),
-    annotationsMap: (f = msg.getAnnotationsMap()) ? f.toObject(includeInstance, undefined) : []
+    name: jspb.Message.getFieldWithDefault(msg, 1, ""),
+    ready: jspb.Message.getFieldWithDefault(msg, 2, 0),
+    message: jspb.Message.getFieldWithDefault(msg, 3, "")
   };
 
   if (includeInstance) {
@@ -5458,23 +5636,23 @@ proto.wsman.WorkspaceMetadata.toObject = function(includeInstance, msg) {
 /**
  * Deserializes binary data (in protobuf wire format).
  * @param {jspb.ByteSource} bytes The bytes to deserialize.
- * @return {!proto.wsman.WorkspaceMetadata}
+ * @return {!proto.wsman.ServiceCondition}
  */
-proto.wsman.WorkspaceMetadata.deserializeBinary = function(bytes) {
+proto.wsman.ServiceCondition.deserializeBinary = function(bytes) {
   var reader = new jspb.BinaryReader(bytes);
-  var msg = new proto.wsman.WorkspaceMetadata;
-  return proto.wsman.WorkspaceMetadata.deserializeBinaryFromReader(msg, reader);
+  var msg = new proto.wsman.ServiceCondition;
+  return proto.wsman.ServiceCondition.deserializeBinaryFromReader(msg, reader);
 };
 
 
 /**
  * Deserializes binary data (in protobuf wire format) from the
  * given reader into the given message object.
- * @param {!proto.wsman.WorkspaceMetadata} msg The message object to deserialize into.
+ * @param {!proto.wsman.ServiceCondition} msg The message object to deserialize into.
  * @param {!jspb.BinaryReader} reader The BinaryReader to use.
- * @return {!proto.wsman.WorkspaceMetadata}
+ * @return {!proto.wsman.ServiceCondition}
  */
-proto.wsman.WorkspaceMetadata.deserializeBinaryFromReader = function(msg, reader) {
+proto.wsman.ServiceCondition.deserializeBinaryFromReader = function(msg, reader) {
   while (reader.nextField()) {
     if (reader.isEndGroup()) {
       break;
@@ -5483,22 +5661,15 @@ proto.wsman.WorkspaceMetadata.deserializeBinaryFromReader = function(msg, reader
     switch (field) {
     case 1:
       var value = /** @type {string} */ (reader.readString());
-      msg.setOwner(value);
+      msg.setName(value);
       break;
     case 2:
-      var value = /** @type {string} */ (reader.readString());
-      msg.setMetaId(value);
+      var value = /** @type {!proto.wsman.WorkspaceConditionBool} */ (reader.readEnum());
+      msg.setReady(value);
       break;
     case 3:
-      var value = new google_protobuf_timestamp_pb.Timestamp;
-      reader.readMessage(value,google_protobuf_timestamp_pb.Timestamp.deserializeBinaryFromReader);
-      msg.setStartedAt(value);
-      break;
-    case 4:
-      var value = msg.getAnnotationsMap();
-      reader.readMessage(value, function(message, reader) {
-        jspb.Map.deserializeBinary(message, reader, jspb.BinaryReader.prototype.readString, jspb.BinaryReader.prototype.readString, null, "", "");
-         });
+      var value = /** @type {string} */ (reader.readString());
+      msg.setMessage(value);
       break;
     default:
       reader.skipField();
@@ -5513,9 +5684,9 @@ proto.wsman.WorkspaceMetadata.deserializeBinaryFromReader = function(msg, reader
  * Serializes the message to binary data (in protobuf wire format).
  * @return {!Uint8Array}
  */
-proto.wsman.WorkspaceMetadata.prototype.serializeBinary = function() {
+proto.wsman.ServiceCondition.prototype.serializeBinary = function() {
   var writer = new jspb.BinaryWriter();
-  proto.wsman.WorkspaceMetadata.serializeBinaryToWriter(this, writer);
+  proto.wsman.ServiceCondition.serializeBinaryToWriter(this, writer);
   return writer.getResultBuffer();
 };
 
@@ -5523,136 +5694,90 @@ proto.wsman.WorkspaceMetadata.prototype.serializeBinary = function() {
 /**
  * Serializes the given message to binary data (in protobuf wire
  * format), writing to the given BinaryWriter.
- * @param {!proto.wsman.WorkspaceMetadata} message
+ * @param {!proto.wsman.ServiceCondition} message
  * @param {!jspb.BinaryWriter} writer
  * @suppress {unusedLocalVariables} f is only used for nested messages
  */
-proto.wsman.WorkspaceMetadata.serializeBinaryToWriter = function(message, writer) {
+proto.wsman.ServiceCondition.serializeBinaryToWriter = function(message, writer) {
   var f = undefined;
-  f = message.getOwner();
+  f = message.getName();
   if (f.length > 0) {
     writer.writeString(
       1,
       f
     );
   }
-  f = message.getMetaId();
-  if (f.length > 0) {
-    writer.writeString(
+  f = message.getReady();
+  if (f !== 0.0) {
+    writer.writeEnum(
       2,
       f
     );
   }
-  f = message.getStartedAt();
-  if (f != null) {
-    writer.writeMessage(
+  f = message.getMessage();
+  if (f.length > 0) {
+    writer.writeString(
       3,
-      f,
-      google_protobuf_timestamp_pb.Timestamp.serializeBinaryToWriter
+      f
     );
   }
-  f = message.getAnnotationsMap(true);
-  if (f && f.getLength() > 0) {
-    f.serializeBinary(4, writer, jspb.BinaryWriter.prototype.writeString, jspb.BinaryWriter.prototype.writeString);
-  }
 };
 
 
 /**
- * optional string owner = 1;
+ * optional string name = 1;
  * @return {string}
  */
-proto.wsman.WorkspaceMetadata.prototype.getOwner = function() {
+proto.wsman.ServiceCondition.prototype.getName = function() {
   return /** @type {string} */ (jspb.Message.getFieldWithDefault(this, 1, ""));
 };
 
 
 /**
  * @param {string} value
- * @return {!proto.wsman.WorkspaceMetadata} returns this
+ * @return {!proto.wsman.ServiceCondition} returns this
  */
-proto.wsman.WorkspaceMetadata.prototype.setOwner = function(value) {
+proto.wsman.ServiceCondition.prototype.setName = function(value) {
   return jspb.Message.setProto3StringField(this, 1, value);
 };
 
 
 /**
- * optional string meta_id = 2;
- * @return {string}
- */
-proto.wsman.WorkspaceMetadata.prototype.getMetaId = function() {
-  return /** @type {string} */ (jspb.Message.getFieldWithDefault(this, 2, ""));
-};
-
-
-/**
- * @param {string} value
- * @return {!proto.wsman.WorkspaceMetadata} returns this
- */
-proto.wsman.WorkspaceMetadata.prototype.setMetaId = function(value) {
-  return jspb.Message.setProto3StringField(this, 2, value);
-};
-
-
-/**
- * optional google.protobuf.Timestamp started_at = 3;
- * @return {?proto.google.protobuf.Timestamp}
+ * optional WorkspaceConditionBool ready = 2;
+ * @return {!proto.wsman.WorkspaceConditionBool}
  */
-proto.wsman.WorkspaceMetadata.prototype.getStartedAt = function() {
-  return /** @type{?proto.google.protobuf.Timestamp} */ (
-    jspb.Message.getWrapperField(this, google_protobuf_timestamp_pb.Timestamp, 3));
-};
-
-
-/**
- * @param {?proto.google.protobuf.Timestamp|undefined} value
- * @return {!proto.wsman.WorkspaceMetadata} returns this
-*/
-proto.wsman.WorkspaceMetadata.prototype.setStartedAt = function(value) {
-  return jspb.Message.setWrapperField(this, 3, value);
+proto.wsman.ServiceCondition.prototype.getReady = function() {
+  return /** @type {!proto.wsman.WorkspaceConditionBool} */ (jspb.Message.getFieldWithDefault(this, 2, 0));
 };
 
 
 /**
- * Clears the message field making it undefined.
- * @return {!proto.wsman.WorkspaceMetadata} returns this
+ * @param {!proto.wsman.WorkspaceConditionBool} value
+ * @return {!proto.wsman.ServiceCondition} returns this
  */
-proto.wsman.WorkspaceMetadata.prototype.clearStartedAt = function() {
-  return this.setStartedAt(undefined);
+proto.wsman.ServiceCondition.prototype.setReady = function(value) {
+  return jspb.Message.setProto3EnumField(this, 2, value);
 };
 
 
 /**
- * Returns whether this field is set.
- * @return {boolean}
+ * optional string message = 3;
+ * @return {string}
  */
-proto.wsman.WorkspaceMetadata.prototype.hasStartedAt = function() {
-  return jspb.Message.getField(this, 3) != null;
+proto.wsman.ServiceCondition.prototype.getMessage = function() {
+  return /** @type {string} */ (jspb.Message.getFieldWithDefault(this, 3, ""));
 };
 
 
 /**
- * map<string, string> annotations = 4;
- * @param {boolean=} opt_noLazyCreate Do not create the map if
- * empty, instead returning `undefined`
- * @return {!jspb.Map<string,string>}
+ * @param {string} value
+ * @return {!proto.wsman.ServiceCondition} returns this
  */
-proto.wsman.WorkspaceMetadata.prototype.getAnnotationsMap = function(opt_noLazyCreate) {
-  return /** @type {!jspb.Map<string,string>} */ (
-      jspb.Message.getMapField(this, 4, opt_noLazyCreate,
-      null));
+proto.wsman.ServiceCondition.prototype.setMessage = function(value) {
+  return jspb.Message.setProto3StringField(this, 3, value);
 };
 
 
-/**
- * Clears values from the map. The map will be non-null.
- * @return {!proto.wsman.WorkspaceMetadata} returns this
- */
-proto.wsman.WorkspaceMetadata.prototype.clearAnnotationsMap = function() {
-  this.getAnnotationsMap().clear();
-  return this;};
-
-
 
 
 
@@ -5669,7 +5794,251 @@ if (jspb.Message.GENERATE_TO_OBJECT) {
  *     http://goto/soy-param-migration
  * @return {!Object}
  */
-proto.wsman.WorkspaceRuntimeInfo.prototype.toObject = function(opt_includeInstance) {
+proto.wsman.WorkspaceMetadata.prototype.toObject = function(opt_includeInstance) {
+  return proto.wsman.WorkspaceMetadata.toObject(opt_includeInstance, this);
+};
+
+
+/**
+ * Static version of the {@see toObject} method.
+ * @param {boolean|undefined} includeInstance Deprecated. Whether to include
+ *     the JSPB instance for transitional soy proto support:
+ *     http://goto/soy-param-migration
+ * @param {!proto.wsman.WorkspaceMetadata} msg The msg instance to transform.
+ * @return {!Object}
+ * @suppress {unusedLocalVariables} f is only used for nested messages
+ */
+proto.wsman.WorkspaceMetadata.toObject = function(includeInstance, msg) {
+  var f, obj = {
+    owner: jspb.Message.getFieldWithDefault(msg, 1, ""),
+    metaId: jspb.Message.getFieldWithDefault(msg, 2, ""),
+    startedAt: (f = msg.getStartedAt()) && google_protobuf_timestamp_pb.Timestamp.toObject(includeInstance, f),
+    annotationsMap: (f = msg.getAnnotationsMap()) ? f.toObject(includeInstance, undefined) : []
+  };
+
+  if (includeInstance) {
+    obj.$jspbMessageInstance = msg;
+  }
+  return obj;
+};
+}
+
+
+/**
+ * Deserializes binary data (in protobuf wire format).
+ * @param {jspb.ByteSource} bytes The bytes to deserialize.
+ * @return {!proto.wsman.WorkspaceMetadata}
+ */
+proto.wsman.WorkspaceMetadata.deserializeBinary = function(bytes) {
+  var reader = new jspb.BinaryReader(bytes);
+  var msg = new proto.wsman.WorkspaceMetadata;
+  return proto.wsman.WorkspaceMetadata.deserializeBinaryFromReader(msg, reader);
+};
+
+
+/**
+ * Deserializes binary data (in protobuf wire format) from the
+ * given reader into the given message object.
+ * @param {!proto.wsman.WorkspaceMetadata} msg The message object to deserialize into.
+ * @param {!jspb.BinaryReader} reader The BinaryReader to use.
+ * @return {!proto.wsman.WorkspaceMetadata}
+ */
+proto.wsman.WorkspaceMetadata.deserializeBinaryFromReader = function(msg, reader) {
+  while (reader.nextField()) {
+    if (reader.isEndGroup()) {
+      break;
+    }
+    var field = reader.getFieldNumber();
+    switch (field) {
+    case 1:
+      var value = /** @type {string} */ (reader.readString());
+      msg.setOwner(value);
+      break;
+    case 2:
+      var value = /** @type {string} */ (reader.readString());
+      msg.setMetaId(value);
+      break;
+    case 3:
+      var value = new google_protobuf_timestamp_pb.Timestamp;
+      reader.readMessage(value,google_protobuf_timestamp_pb.Timestamp.deserializeBinaryFromReader);
+      msg.setStartedAt(value);
+      break;
+    case 4:
+      var value = msg.getAnnotationsMap();
+      reader.readMessage(value, function(message, reader) {
+        jspb.Map.deserializeBinary(message, reader, jspb.BinaryReader.prototype.readString, jspb.BinaryReader.prototype.readString, null, "", "");
+         });
+      break;
+    default:
+      reader.skipField();
+      break;
+    }
+  }
+  return msg;
+};
+
+
+/**
+ * Serializes the message to binary data (in protobuf wire format).
+ * @return {!Uint8Array}
+ */
+proto.wsman.WorkspaceMetadata.prototype.serializeBinary = function() {
+  var writer = new jspb.BinaryWriter();
+  proto.wsman.WorkspaceMetadata.serializeBinaryToWriter(this, writer);
+  return writer.getResultBuffer();
+};
+
+
+/**
+ * Serializes the given message to binary data (in protobuf wire
+ * format), writing to the given BinaryWriter.
+ * @param {!proto.wsman.WorkspaceMetadata} message
+ * @param {!jspb.BinaryWriter} writer
+ * @suppress {unusedLocalVariables} f is only used for nested messages
+ */
+proto.wsman.WorkspaceMetadata.serializeBinaryToWriter = function(message, writer) {
+  var f = undefined;
+  f = message.getOwner();
+  if (f.length > 0) {
+    writer.writeString(
+      1,
+      f
+    );
+  }
+  f = message.getMetaId();
+  if (f.length > 0) {
+    writer.writeString(
+      2,
+      f
+    );
+  }
+  f = message.getStartedAt();
+  if (f != null) {
+    writer.writeMessage(
+      3,
+      f,
+      google_protobuf_timestamp_pb.Timestamp.serializeBinaryToWriter
+    );
+  }
+  f = message.getAnnotationsMap(true);
+  if (f && f.getLength() > 0) {
+    f.serializeBinary(4, writer, jspb.BinaryWriter.prototype.writeString, jspb.BinaryWriter.prototype.writeString);
+  }
+};
+
+
+/**
+ * optional string owner = 1;
+ * @return {string}
+ */
+proto.wsman.WorkspaceMetadata.prototype.getOwner = function() {
+  return /** @type {string} */ (jspb.Message.getFieldWithDefault(this, 1, ""));
+};
+
+
+/**
+ * @param {string} value
+ * @return {!proto.wsman.WorkspaceMetadata} returns this
+ */
+proto.wsman.WorkspaceMetadata.prototype.setOwner = function(value) {
+  return jspb.Message.setProto3StringField(this, 1, value);
+};
+
+
+/**
+ * optional string meta_id = 2;
+ * @return {string}
+ */
+proto.wsman.WorkspaceMetadata.prototype.getMetaId = function() {
+  return /** @type {string} */ (jspb.Message.getFieldWithDefault(this, 2, ""));
+};
+
+
+/**
+ * @param {string} value
+ * @return {!proto.wsman.WorkspaceMetadata} returns this
+ */
+proto.wsman.WorkspaceMetadata.prototype.setMetaId = function(value) {
+  return jspb.Message.setProto3StringField(this, 2, value);
+};
+
+
+/**
+ * optional google.protobuf.Timestamp started_at = 3;
+ * @return {?proto.google.protobuf.Timestamp}
+ */
+proto.wsman.WorkspaceMetadata.prototype.getStartedAt = function() {
+  return /** @type{?proto.google.protobuf.Timestamp} */ (
+    jspb.Message.getWrapperField(this, google_protobuf_timestamp_pb.Timestamp, 3));
+};
+
+
+/**
+ * @param {?proto.google.protobuf.Timestamp|undefined} value
+ * @return {!proto.wsman.WorkspaceMetadata} returns this
+*/
+proto.wsman.WorkspaceMetadata.prototype.setStartedAt = function(value) {
+  return jspb.Message.setWrapperField(this, 3, value);
+};
+
+
+/**
+ * Clears the message field making it undefined.
+ * @return {!proto.wsman.WorkspaceMetadata} returns this
+ */
+proto.wsman.WorkspaceMetadata.prototype.clearStartedAt = function() {
+  return this.setStartedAt(undefined);
+};
+
+
+/**
+ * Returns whether this field is set.
+ * @return {boolean}
+ */
+proto.wsman.WorkspaceMetadata.prototype.hasStartedAt = function() {
+  return jspb.Message.getField(this, 3) != null;
+};
+
+
+/**
+ * map<string, string> annotations = 4;
+ * @param {boolean=} opt_noLazyCreate Do not create the map if
+ * empty, instead returning `undefined`
+ * @return {!jspb.Map<string,string>}
+ */
+proto.wsman.WorkspaceMetadata.prototype.getAnnotationsMap = function(opt_noLazyCreate) {
+  return /** @type {!jspb.Map<string,string>} */ (
+      jspb.Message.getMapField(this, 4, opt_noLazyCreate,
+      null));
+};
+
+
+/**
+ * Clears values from the map. The map will be non-null.
+ * @return {!proto.wsman.WorkspaceMetadata} returns this
+ */
+proto.wsman.WorkspaceMetadata.prototype.clearAnnotationsMap = function() {
+  this.getAnnotationsMap().clear();
+  return this;};
+
+
+
+
+
+if (jspb.Message.GENERATE_TO_OBJECT) {
+/**
+ * Creates an object representation of this proto.
+ * Field names that are reserved in JavaScript and will be renamed to pb_name.
+ * Optional fields that are not set will be set to undefined.
+ * To access a reserved field use, foo.pb_<name>, eg, foo.pb_default.
+ * For the list of reserved names please see:
+ *     net/proto2/compiler/js/internal/generator.cc#kKeyword.
+ * @param {boolean=} opt_includeInstance Deprecated. whether to include the
+ *     JSPB instance for transitional soy proto support:
+ *     http://goto/soy-param-migration
+ * @return {!Object}
+ */
+proto.wsman.WorkspaceRuntimeInfo.prototype.toObject = function(opt_includeInstance) {
   return proto.wsman.WorkspaceRuntimeInfo.toObject(opt_includeInstance, this);
 };
 
@@ -6009,7 +6378,7 @@ proto.wsman.WorkspaceAuthentication.prototype.setOwnerToken = function(value) {
  * @private {!Array<number>}
  * @const
  */
-proto.wsman.StartWorkspaceSpec.repeatedFields_ = [3,5,6];
+proto.wsman.StartWorkspaceSpec.repeatedFields_ = [3,5,6,12,13];
 
 
 
@@ -6054,7 +6423,11 @@ proto.wsman.StartWorkspaceSpec.toObject = function(includeInstance, msg) {
     workspaceLocation: jspb.Message.getFieldWithDefault(msg, 8, ""),
     git: (f = msg.getGit()) && proto.wsman.GitSpec.toObject(includeInstance, f),
     timeout: jspb.Message.getFieldWithDefault(msg, 10, ""),
-    admission: jspb.Message.getFieldWithDefault(msg, 11, 0)
+    admission: jspb.Message.getFieldWithDefault(msg, 11, 0),
+    volumesList: jspb.Message.toObjectList(msg.getVolumesList(),
+    proto.wsman.PersistentVolumeSpec.toObject, includeInstance),
+    servicesList: jspb.Message.toObjectList(msg.getServicesList(),
+    proto.wsman.ServiceContainerSpec.toObject, includeInstance)
   };
 
   if (includeInstance) {
@@ -6141,6 +6514,16 @@ proto.wsman.StartWorkspaceSpec.deserializeBinaryFromReader = function(msg, reade
       var value = /** @type {!proto.wsman.AdmissionLevel} */ (reader.readEnum());
       msg.setAdmission(value);
       break;
+    case 12:
+      var value = new proto.wsman.PersistentVolumeSpec;
+      reader.readMessage(value,proto.wsman.PersistentVolumeSpec.deserializeBinaryFromReader);
+      msg.addVolumes(value);
+      break;
+    case 13:
+      var value = new proto.wsman.ServiceContainerSpec;
+      reader.readMessage(value,proto.wsman.ServiceContainerSpec.deserializeBinaryFromReader);
+      msg.addServices(value);
+      break;
     default:
       reader.skipField();
       break;
@@ -6251,15 +6634,31 @@ proto.wsman.StartWorkspaceSpec.serializeBinaryToWriter = function(message, write
       f
     );
   }
-};
-
-
-/**
- * optional string workspace_image = 1;
- * @return {string}
- */
-proto.wsman.StartWorkspaceSpec.prototype.getWorkspaceImage = function() {
-  return /** @type {string} */ (jspb.Message.getFieldWithDefault(this, 1, ""));
+  f = message.getVolumesList();
+  if (f.length > 0) {
+    writer.writeRepeatedMessage(
+      12,
+      f,
+      proto.wsman.PersistentVolumeSpec.serializeBinaryToWriter
+    );
+  }
+  f = message.getServicesList();
+  if (f.length > 0) {
+    writer.writeRepeatedMessage(
+      13,
+      f,
+      proto.wsman.ServiceContainerSpec.serializeBinaryToWriter
+    );
+  }
+};
+
+
+/**
+ * optional string workspace_image = 1;
+ * @return {string}
+ */
+proto.wsman.StartWorkspaceSpec.prototype.getWorkspaceImage = function() {
+  return /** @type {string} */ (jspb.Message.getFieldWithDefault(this, 1, ""));
 };
 
 
@@ -6549,6 +6948,82 @@ proto.wsman.StartWorkspaceSpec.prototype.setAdmission = function(value) {
 };
 
 
+/**
+ * repeated PersistentVolumeSpec volumes = 12;
+ * @return {!Array<!proto.wsman.PersistentVolumeSpec>}
+ */
+proto.wsman.StartWorkspaceSpec.prototype.getVolumesList = function() {
+  return /** @type{!Array<!proto.wsman.PersistentVolumeSpec>} */ (
+    jspb.Message.getRepeatedWrapperField(this, proto.wsman.PersistentVolumeSpec, 12));
+};
+
+
+/**
+ * @param {!Array<!proto.wsman.PersistentVolumeSpec>} value
+ * @return {!proto.wsman.StartWorkspaceSpec} returns this
+*/
+proto.wsman.StartWorkspaceSpec.prototype.setVolumesList = function(value) {
+  return jspb.Message.setRepeatedWrapperField(this, 12, value);
+};
+
+
+/**
+ * @param {!proto.wsman.PersistentVolumeSpec=} opt_value
+ * @param {number=} opt_index
+ * @return {!proto.wsman.PersistentVolumeSpec}
+ */
+proto.wsman.StartWorkspaceSpec.prototype.addVolumes = function(opt_value, opt_index) {
+  return jspb.Message.addToRepeatedWrapperField(this, 12, opt_value, proto.wsman.PersistentVolumeSpec, opt_index);
+};
+
+
+/**
+ * Clears the list making it empty but non-null.
+ * @return {!proto.wsman.StartWorkspaceSpec} returns this
+ */
+proto.wsman.StartWorkspaceSpec.prototype.clearVolumesList = function() {
+  return this.setVolumesList([]);
+};
+
+
+/**
+ * repeated ServiceContainerSpec services = 13;
+ * @return {!Array<!proto.wsman.ServiceContainerSpec>}
+ */
+proto.wsman.StartWorkspaceSpec.prototype.getServicesList = function() {
+  return /** @type{!Array<!proto.wsman.ServiceContainerSpec>} */ (
+    jspb.Message.getRepeatedWrapperField(this, proto.wsman.ServiceContainerSpec, 13));
+};
+
+
+/**
+ * @param {!Array<!proto.wsman.ServiceContainerSpec>} value
+ * @return {!proto.wsman.StartWorkspaceSpec} returns this
+*/
+proto.wsman.StartWorkspaceSpec.prototype.setServicesList = function(value) {
+  return jspb.Message.setRepeatedWrapperField(this, 13, value);
+};
+
+
+/**
+ * @param {!proto.wsman.ServiceContainerSpec=} opt_value
+ * @param {number=} opt_index
+ * @return {!proto.wsman.ServiceContainerSpec}
+ */
+proto.wsman.StartWorkspaceSpec.prototype.addServices = function(opt_value, opt_index) {
+  return jspb.Message.addToRepeatedWrapperField(this, 13, opt_value, proto.wsman.ServiceContainerSpec, opt_index);
+};
+
+
+/**
+ * Clears the list making it empty but non-null.
+ * @return {!proto.wsman.StartWorkspaceSpec} returns this
+ */
+proto.wsman.StartWorkspaceSpec.prototype.clearServicesList = function() {
+  return this.setServicesList([]);
+};
+
+
 
 
 
@@ -6565,8 +7040,8 @@ if (jspb.Message.GENERATE_TO_OBJECT) {
  *     http://goto/soy-param-migration
  * @return {!Object}
  */
-proto.wsman.GitSpec.prototype.toObject = function(opt_includeInstance) {
-  return proto.wsman.GitSpec.toObject(opt_includeInstance, this);
+proto.wsman.PersistentVolumeSpec.prototype.toObject = function(opt_includeInstance) {
+  return proto.wsman.PersistentVolumeSpec.toObject(opt_includeInstance, this);
 };
 
 
@@ -6575,14 +7050,14 @@ proto.wsman.GitSpec.prototype.toObject = function(opt_includeInstance) {
  * @param {boolean|undefined} includeInstance Deprecated. Whether to include
  *     the JSPB instance for transitional soy proto support:
  *     http://goto/soy-param-migration
- * @param {!proto.wsman.GitSpec} msg The msg instance to transform.
+ * @param {!proto.wsman.PersistentVolumeSpec} msg The msg instance to transform.
  * @return {!Object}
  * @suppress {unusedLocalVariables} f is only used for nested messages
  */
-proto.wsman.GitSpec.toObject = function(includeInstance, msg) {
+proto.wsman.PersistentVolumeSpec.toObject = function(includeInstance, msg) {
   var f, obj = {
-    username: jspb.Message.getFieldWithDefault(msg, 1, ""),
-    email: jspb.Message.getFieldWithDefault(msg, 2, "")
+    name: jspb.Message.getFieldWithDefault(msg, 1, ""),
+    mountPath: jspb.Message.getFieldWithDefault(msg, 2, "")
   };
 
   if (includeInstance) {
@@ -6596,23 +7071,23 @@ proto.wsman.GitSpec.toObject = function(includeInstance, msg) {
 /**
  * Deserializes binary data (in protobuf wire format).
  * @param {jspb.ByteSource} bytes The bytes to deserialize.
- * @return {!proto.wsman.GitSpec}
+ * @return {!proto.wsman.PersistentVolumeSpec}
  */
-proto.wsman.GitSpec.deserializeBinary = function(bytes) {
+proto.wsman.PersistentVolumeSpec.deserializeBinary = function(bytes) {
   var reader = new jspb.BinaryReader(bytes);
-  var msg = new proto.wsman.GitSpec;
-  return proto.wsman.GitSpec.deserializeBinaryFromReader(msg, reader);
+  var msg = new proto.wsman.PersistentVolumeSpec;
+  return proto.wsman.PersistentVolumeSpec.deserializeBinaryFromReader(msg, reader);
 };
 
 
 /**
  * Deserializes binary data (in protobuf wire format) from the
  * given reader into the given message object.
- * @param {!proto.wsman.GitSpec} msg The message object to deserialize into.
+ * @param {!proto.wsman.PersistentVolumeSpec} msg The message object to deserialize into.
  * @param {!jspb.BinaryReader} reader The BinaryReader to use.
- * @return {!proto.wsman.GitSpec}
+ * @return {!proto.wsman.PersistentVolumeSpec}
  */
-proto.wsman.GitSpec.deserializeBinaryFromReader = function(msg, reader) {
+proto.wsman.PersistentVolumeSpec.deserializeBinaryFromReader = function(msg, reader) {
   while (reader.nextField()) {
     if (reader.isEndGroup()) {
       break;
@@ -6621,11 +7096,11 @@ proto.wsman.GitSpec.deserializeBinaryFromReader = function(msg, reader) {
     switch (field) {
     case 1:
       var value = /** @type {string} */ (reader.readString());
-      msg.setUsername(value);
+      msg.setName(value);
       break;
     case 2:
       var value = /** @type {string} */ (reader.readString());
-      msg.setEmail(value);
+      msg.setMountPath(value);
       break;
     default:
       reader.skipField();
@@ -6640,9 +7115,9 @@ proto.wsman.GitSpec.deserializeBinaryFromReader = function(msg, reader) {
  * Serializes the message to binary data (in protobuf wire format).
  * @return {!Uint8Array}
  */
-proto.wsman.GitSpec.prototype.serializeBinary = function() {
+proto.wsman.PersistentVolumeSpec.prototype.serializeBinary = function() {
   var writer = new jspb.BinaryWriter();
-  proto.wsman.GitSpec.serializeBinaryToWriter(this, writer);
+  proto.wsman.PersistentVolumeSpec.serializeBinaryToWriter(this, writer);
   return writer.getResultBuffer();
 };
 
@@ -6650,20 +7125,20 @@ proto.wsman.GitSpec.prototype.serializeBinary = function() {
 /**
  * Serializes the given message to binary data (in protobuf wire
  * format), writing to the given BinaryWriter.
- * @param {!proto.wsman.GitSpec} message
+ * @param {!proto.wsman.PersistentVolumeSpec} message
  * @param {!jspb.BinaryWriter} writer
  * @suppress {unusedLocalVariables} f is only used for nested messages
  */
-proto.wsman.GitSpec.serializeBinaryToWriter = function(message, writer) {
+proto.wsman.PersistentVolumeSpec.serializeBinaryToWriter = function(message, writer) {
   var f = undefined;
-  f = message.getUsername();
+  f = message.getName();
   if (f.length > 0) {
     writer.writeString(
       1,
       f
     );
   }
-  f = message.getEmail();
+  f = message.getMountPath();
   if (f.length > 0) {
     writer.writeString(
       2,
@@ -6674,42 +7149,49 @@ proto.wsman.GitSpec.serializeBinaryToWriter = function(message, writer) {
 
 
 /**
- * optional string username = 1;
+ * optional string name = 1;
  * @return {string}
  */
-proto.wsman.GitSpec.prototype.getUsername = function() {
+proto.wsman.PersistentVolumeSpec.prototype.getName = function() {
   return /** @type {string} */ (jspb.Message.getFieldWithDefault(this, 1, ""));
 };
 
 
 /**
  * @param {string} value
- * @return {!proto.wsman.GitSpec} returns this
+ * @return {!proto.wsman.PersistentVolumeSpec} returns this
  */
-proto.wsman.GitSpec.prototype.setUsername = function(value) {
+proto.wsman.PersistentVolumeSpec.prototype.setName = function(value) {
   return jspb.Message.setProto3StringField(this, 1, value);
 };
 
 
 /**
- * optional string email = 2;
+ * optional string mount_path = 2;
  * @return {string}
  */
-proto.wsman.GitSpec.prototype.getEmail = function() {
+proto.wsman.PersistentVolumeSpec.prototype.getMountPath = function() {
   return /** @type {string} */ (jspb.Message.getFieldWithDefault(this, 2, ""));
 };
 
 
 /**
  * @param {string} value
- * @return {!proto.wsman.GitSpec} returns this
+ * @return {!proto.wsman.PersistentVolumeSpec} returns this
  */
-proto.wsman.GitSpec.prototype.setEmail = function(value) {
+proto.wsman.PersistentVolumeSpec.prototype.setMountPath = function(value) {
   return jspb.Message.setProto3StringField(this, 2, value);
 };
 
 
 
+/**
+ * List of repeated fields within this message type.
+ * @private {!Array<number>}
+ * @const
+ */
+proto.wsman.ServiceContainerSpec.repeatedFields_ = [3,4,6];
+
 
 
 if (jspb.Message.GENERATE_TO_OBJECT) {
@@ -6725,8 +7207,8 @@ if (jspb.Message.GENERATE_TO_OBJECT) {
  *     http://goto/soy-param-migration
  * @return {!Object}
  */
-proto.wsman.EnvironmentVariable.prototype.toObject = function(opt_includeInstance) {
-  return proto.wsman.EnvironmentVariable.toObject(opt_includeInstance, this);
+proto.wsman.ServiceContainerSpec.prototype.toObject = function(opt_includeInstance) {
+  return proto.wsman.ServiceContainerSpec.toObject(opt_includeInstance, this);
 };
 
 
@@ -6735,14 +7217,19 @@ proto.wsman.EnvironmentVariable.prototype.toObject = function(opt_includeInstanc
  * @param {boolean|undefined} includeInstance Deprecated. Whether to include
  *     the JSPB instance for transitional soy proto support:
  *     http://goto/soy-param-migration
- * @param {!proto.wsman.EnvironmentVariable} msg The msg instance to transform.
+ * @param {!proto.wsman.ServiceContainerSpec} msg The msg instance to transform.
  * @return {!Object}
  * @suppress {unusedLocalVariables} f is only used for nested messages
  */
-proto.wsman.EnvironmentVariable.toObject = function(includeInstance, msg) {
+proto.wsman.ServiceContainerSpec.toObject = function(includeInstance, msg) {
   var f, obj = {
     name: jspb.Message.getFieldWithDefault(msg, 1, ""),
-    value: jspb.Message.getFieldWithDefault(msg, 2, "")
+    image: jspb.Message.getFieldWithDefault(msg, 2, ""),
+    envList: jspb.Message.toObjectList(msg.getEnvList(),
+    proto.wsman.EnvironmentVariable.toObject, includeInstance),
+    portsList: (f = jspb.Message.getRepeatedField(msg, 4)) == null ? undefined : f,
+    resources: (f = msg.getResources()) && proto.wsman.ServiceContainerResources.toObject(includeInstance, f),
+    readinessCommandList: (f = jspb.Message.getRepeatedField(msg, 6)) == null ? undefined : f
   };
 
   if (includeInstance) {
@@ -6756,23 +7243,23 @@ proto.wsman.EnvironmentVariable.toObject = function(includeInstance, msg) {
 /**
  * Deserializes binary data (in protobuf wire format).
  * @param {jspb.ByteSource} bytes The bytes to deserialize.
- * @return {!proto.wsman.EnvironmentVariable}
+ * @return {!proto.wsman.ServiceContainerSpec}
  */
-proto.wsman.EnvironmentVariable.deserializeBinary = function(bytes) {
+proto.wsman.ServiceContainerSpec.deserializeBinary = function(bytes) {
   var reader = new jspb.BinaryReader(bytes);
-  var msg = new proto.wsman.EnvironmentVariable;
-  return proto.wsman.EnvironmentVariable.deserializeBinaryFromReader(msg, reader);
+  var msg = new proto.wsman.ServiceContainerSpec;
+  return proto.wsman.ServiceContainerSpec.deserializeBinaryFromReader(msg, reader);
 };
 
 
 /**
  * Deserializes binary data (in protobuf wire format) from the
  * given reader into the given message object.
- * @param {!proto.wsman.EnvironmentVariable} msg The message object to deserialize into.
+ * @param {!proto.wsman.ServiceContainerSpec} msg The message object to deserialize into.
  * @param {!jspb.BinaryReader} reader The BinaryReader to use.
- * @return {!proto.wsman.EnvironmentVariable}
+ * @return {!proto.wsman.ServiceContainerSpec}
  */
-proto.wsman.EnvironmentVariable.deserializeBinaryFromReader = function(msg, reader) {
+proto.wsman.ServiceContainerSpec.deserializeBinaryFromReader = function(msg, reader) {
   while (reader.nextField()) {
     if (reader.isEndGroup()) {
       break;
@@ -6785,7 +7272,27 @@ proto.wsman.EnvironmentVariable.deserializeBinaryFromReader = function(msg, read
       break;
     case 2:
       var value = /** @type {string} */ (reader.readString());
-      msg.setValue(value);
+      msg.setImage(value);
+      break;
+    case 3:
+      var value = new proto.wsman.EnvironmentVariable;
+      reader.readMessage(value,proto.wsman.EnvironmentVariable.deserializeBinaryFromReader);
+      msg.addEnv(value);
+      break;
+    case 4:
+      var values = /** @type {!Array<number>} */ (reader.isDelimited() ? reader.readPackedUint32() : [reader.readUint32()]);
+      for (var i = 0; i < values.length; i++) {
+        msg.addPorts(values[i]);
+      }
+      break;
+    case 5:
+      var value = new proto.wsman.ServiceContainerResources;
+      reader.readMessage(value,proto.wsman.ServiceContainerResources.deserializeBinaryFromReader);
+      msg.setResources(value);
+      break;
+    case 6:
+      var value = /** @type {string} */ (reader.readString());
+      msg.addReadinessCommand(value);
       break;
     default:
       reader.skipField();
@@ -6800,9 +7307,9 @@ proto.wsman.EnvironmentVariable.deserializeBinaryFromReader = function(msg, read
  * Serializes the message to binary data (in protobuf wire format).
  * @return {!Uint8Array}
  */
-proto.wsman.EnvironmentVariable.prototype.serializeBinary = function() {
+proto.wsman.ServiceContainerSpec.prototype.serializeBinary = function() {
   var writer = new jspb.BinaryWriter();
-  proto.wsman.EnvironmentVariable.serializeBinaryToWriter(this, writer);
+  proto.wsman.ServiceContainerSpec.serializeBinaryToWriter(this, writer);
   return writer.getResultBuffer();
 };
 
@@ -6810,11 +7317,11 @@ proto.wsman.EnvironmentVariable.prototype.serializeBinary = function() {
 /**
  * Serializes the given message to binary data (in protobuf wire
  * format), writing to the given BinaryWriter.
- * @param {!proto.wsman.EnvironmentVariable} message
+ * @param {!proto.wsman.ServiceContainerSpec} message
  * @param {!jspb.BinaryWriter} writer
  * @suppress {unusedLocalVariables} f is only used for nested messages
  */
-proto.wsman.EnvironmentVariable.serializeBinaryToWriter = function(message, writer) {
+proto.wsman.ServiceContainerSpec.serializeBinaryToWriter = function(message, writer) {
   var f = undefined;
   f = message.getName();
   if (f.length > 0) {
@@ -6823,13 +7330,43 @@ proto.wsman.EnvironmentVariable.serializeBinaryToWriter = function(message, writ
       f
     );
   }
-  f = message.getValue();
+  f = message.getImage();
   if (f.length > 0) {
     writer.writeString(
       2,
       f
     );
   }
+  f = message.getEnvList();
+  if (f.length > 0) {
+    writer.writeRepeatedMessage(
+      3,
+      f,
+      proto.wsman.EnvironmentVariable.serializeBinaryToWriter
+    );
+  }
+  f = message.getPortsList();
+  if (f.length > 0) {
+    writer.writePackedUint32(
+      4,
+      f
+    );
+  }
+  f = message.getResources();
+  if (f != null) {
+    writer.writeMessage(
+      5,
+      f,
+      proto.wsman.ServiceContainerResources.serializeBinaryToWriter
+    );
+  }
+  f = message.getReadinessCommandList();
+  if (f.length > 0) {
+    writer.writeRepeatedString(
+      6,
+      f
+    );
+  }
 };
 
 
@@ -6837,60 +7374,697 @@ proto.wsman.EnvironmentVariable.serializeBinaryToWriter = function(message, writ
  * optional string name = 1;
  * @return {string}
  */
-proto.wsman.EnvironmentVariable.prototype.getName = function() {
+proto.wsman.ServiceContainerSpec.prototype.getName = function() {
   return /** @type {string} */ (jspb.Message.getFieldWithDefault(this, 1, ""));
 };
 
 
 /**
  * @param {string} value
- * @return {!proto.wsman.EnvironmentVariable} returns this
+ * @return {!proto.wsman.ServiceContainerSpec} returns this
  */
-proto.wsman.EnvironmentVariable.prototype.setName = function(value) {
+proto.wsman.ServiceContainerSpec.prototype.setName = function(value) {
   return jspb.Message.setProto3StringField(this, 1, value);
 };
 
 
 /**
- * optional string value = 2;
+ * optional string image = 2;
  * @return {string}
  */
-proto.wsman.EnvironmentVariable.prototype.getValue = function() {
+proto.wsman.ServiceContainerSpec.prototype.getImage = function() {
   return /** @type {string} */ (jspb.Message.getFieldWithDefault(this, 2, ""));
 };
 
 
 /**
  * @param {string} value
- * @return {!proto.wsman.EnvironmentVariable} returns this
+ * @return {!proto.wsman.ServiceContainerSpec} returns this
  */
-proto.wsman.EnvironmentVariable.prototype.setValue = function(value) {
+proto.wsman.ServiceContainerSpec.prototype.setImage = function(value) {
   return jspb.Message.setProto3StringField(this, 2, value);
 };
 
 
 /**
- * @enum {number}
+ * repeated EnvironmentVariable env = 3;
+ * @return {!Array<!proto.wsman.EnvironmentVariable>}
  */
-proto.wsman.StopWorkspacePolicy = {
-  NORMALLY: 0,
-  IMMEDIATELY: 1
+proto.wsman.ServiceContainerSpec.prototype.getEnvList = function() {
+  return /** @type{!Array<!proto.wsman.EnvironmentVariable>} */ (
+    jspb.Message.getRepeatedWrapperField(this, proto.wsman.EnvironmentVariable, 3));
+};
+
+
+/**
+ * @param {!Array<!proto.wsman.EnvironmentVariable>} value
+ * @return {!proto.wsman.ServiceContainerSpec} returns this
+*/
+proto.wsman.ServiceContainerSpec.prototype.setEnvList = function(value) {
+  return jspb.Message.setRepeatedWrapperField(this, 3, value);
 };
 
+
 /**
- * @enum {number}
+ * @param {!proto.wsman.EnvironmentVariable=} opt_value
+ * @param {number=} opt_index
+ * @return {!proto.wsman.EnvironmentVariable}
  */
-proto.wsman.AdmissionLevel = {
-  ADMIT_OWNER_ONLY: 0,
-  ADMIT_EVERYONE: 1
+proto.wsman.ServiceContainerSpec.prototype.addEnv = function(opt_value, opt_index) {
+  return jspb.Message.addToRepeatedWrapperField(this, 3, opt_value, proto.wsman.EnvironmentVariable, opt_index);
 };
 
+
 /**
- * @enum {number}
+ * Clears the list making it empty but non-null.
+ * @return {!proto.wsman.ServiceContainerSpec} returns this
  */
-proto.wsman.PortVisibility = {
-  PORT_VISIBILITY_PRIVATE: 0,
-  PORT_VISIBILITY_PUBLIC: 1
+proto.wsman.ServiceContainerSpec.prototype.clearEnvList = function() {
+  return this.setEnvList([]);
+};
+
+
+/**
+ * repeated uint32 ports = 4;
+ * @return {!Array<number>}
+ */
+proto.wsman.ServiceContainerSpec.prototype.getPortsList = function() {
+  return /** @type {!Array<number>} */ (jspb.Message.getRepeatedField(this, 4));
+};
+
+
+/**
+ * @param {!Array<number>} value
+ * @return {!proto.wsman.ServiceContainerSpec} returns this
+ */
+proto.wsman.ServiceContainerSpec.prototype.setPortsList = function(value) {
+  return jspb.Message.setField(this, 4, value || []);
+};
+
+
+/**
+ * @param {number} value
+ * @param {number=} opt_index
+ * @return {!proto.wsman.ServiceContainerSpec} returns this
+ */
+proto.wsman.ServiceContainerSpec.prototype.addPorts = function(value, opt_index) {
+  return jspb.Message.addToRepeatedField(this, 4, value, opt_index);
+};
+
+
+/**
+ * Clears the list making it empty but non-null.
+ * @return {!proto.wsman.ServiceContainerSpec} returns this
+ */
+proto.wsman.ServiceContainerSpec.prototype.clearPortsList = function() {
+  return this.setPortsList([]);
+};
+
+
+/**
+ * optional ServiceContainerResources resources = 5;
+ * @return {?proto.wsman.ServiceContainerResources}
+ */
+proto.wsman.ServiceContainerSpec.prototype.getResources = function() {
+  return /** @type{?proto.wsman.ServiceContainerResources} */ (
+    jspb.Message.getWrapperField(this, proto.wsman.ServiceContainerResources, 5));
+};
+
+
+/**
+ * @param {?proto.wsman.ServiceContainerResources|undefined} value
+ * @return {!proto.wsman.ServiceContainerSpec} returns this
+*/
+proto.wsman.ServiceContainerSpec.prototype.setResources = function(value) {
+  return jspb.Message.setWrapperField(this, 5, value);
+};
+
+
+/**
+ * Clears the message field making it undefined.
+ * @return {!proto.wsman.ServiceContainerSpec} returns this
+ */
+proto.wsman.ServiceContainerSpec.prototype.clearResources = function() {
+  return this.setResources(undefined);
+};
+
+
+/**
+ * Returns whether this field is set.
+ * @return {boolean}
+ */
+proto.wsman.ServiceContainerSpec.prototype.hasResources = function() {
+  return jspb.Message.getField(this, 5) != null;
+};
+
+
+/**
+ * repeated string readiness_command = 6;
+ * @return {!Array<string>}
+ */
+proto.wsman.ServiceContainerSpec.prototype.getReadinessCommandList = function() {
+  return /** @type {!Array<string>} */ (jspb.Message.getRepeatedField(this, 6));
+};
+
+
+/**
+ * @param {!Array<string>} value
+ * @return {!proto.wsman.ServiceContainerSpec} returns this
+ */
+proto.wsman.ServiceContainerSpec.prototype.setReadinessCommandList = function(value) {
+  return jspb.Message.setField(this, 6, value || []);
+};
+
+
+/**
+ * @param {string} value
+ * @param {number=} opt_index
+ * @return {!proto.wsman.ServiceContainerSpec} returns this
+ */
+proto.wsman.ServiceContainerSpec.prototype.addReadinessCommand = function(value, opt_index) {
+  return jspb.Message.addToRepeatedField(this, 6, value, opt_index);
+};
+
+
+/**
+ * Clears the list making it empty but non-null.
+ * @return {!proto.wsman.ServiceContainerSpec} returns this
+ */
+proto.wsman.ServiceContainerSpec.prototype.clearReadinessCommandList = function() {
+  return this.setReadinessCommandList([]);
+};
+
+
+
+
+
+if (jspb.Message.GENERATE_TO_OBJECT) {
+/**
+ * Creates an object representation of this proto.
+ * Field names that are reserved in JavaScript and will be renamed to pb_name.
+ * Optional fields that are not set will be set to undefined.
+ * To access a reserved field use, foo.pb_<name>, eg, foo.pb_default.
+ * For the list of reserved names please see:
+ *     net/proto2/compiler/js/internal/generator.cc#kKeyword.
+ * @param {boolean=} opt_includeInstance Deprecated. whether to include the
+ *     JSPB instance for transitional soy proto support:
+ *     http://goto/soy-param-migration
+ * @return {!Object}
+ */
+proto.wsman.ServiceContainerResources.prototype.toObject = function(opt_includeInstance) {
+  return proto.wsman.ServiceContainerResources.toObject(opt_includeInstance, this);
+};
+
+
+/**
+ * Static version of the {@see toObject} method.
+ * @param {boolean|undefined} includeInstance Deprecated. Whether to include
+ *     the JSPB instance for transitional soy proto support:
+ *     http://goto/soy-param-migration
+ * @param {!proto.wsman.ServiceContainerResources} msg The msg instance to transform.
+ * @return {!Object}
+ * @suppress {unusedLocalVariables} f is only used for nested messages
+ */
+proto.wsman.ServiceContainerResources.toObject = function(includeInstance, msg) {
+  var f, obj = {
+    cpu: jspb.Message.getFieldWithDefault(msg, 1, ""),
+    memory: jspb.Message.getFieldWithDefault(msg, 2, "")
+  };
+
+  if (includeInstance) {
+    obj.$jspbMessageInstance = msg;
+  }
+  return obj;
+};
+}
+
+
+/**
+ * Deserializes binary data (in protobuf wire format).
+ * @param {jspb.ByteSource} bytes The bytes to deserialize.
+ * @return {!proto.wsman.ServiceContainerResources}
+ */
+proto.wsman.ServiceContainerResources.deserializeBinary = function(bytes) {
+  var reader = new jspb.BinaryReader(bytes);
+  var msg = new proto.wsman.ServiceContainerResources;
+  return proto.wsman.ServiceContainerResources.deserializeBinaryFromReader(msg, reader);
+};
+
+
+/**
+ * Deserializes binary data (in protobuf wire format) from the
+ * given reader into the given message object.
+ * @param {!proto.wsman.ServiceContainerResources} msg The message object to deserialize into.
+ * @param {!jspb.BinaryReader} reader The BinaryReader to use.
+ * @return {!proto.wsman.ServiceContainerResources}
+ */
+proto.wsman.ServiceContainerResources.deserializeBinaryFromReader = function(msg, reader) {
+  while (reader.nextField()) {
+    if (reader.isEndGroup()) {
+      break;
+    }
+    var field = reader.getFieldNumber();
+    switch (field) {
+    case 1:
+      var value = /** @type {string} */ (reader.readString());
+      msg.setCpu(value);
+      break;
+    case 2:
+      var value = /** @type {string} */ (reader.readString());
+      msg.setMemory(value);
+      break;
+    default:
+      reader.skipField();
+      break;
+    }
+  }
+  return msg;
+};
+
+
+/**
+ * Serializes the message to binary data (in protobuf wire format).
+ * @return {!Uint8Array}
+ */
+proto.wsman.ServiceContainerResources.prototype.serializeBinary = function() {
+  var writer = new jspb.BinaryWriter();
+  proto.wsman.ServiceContainerResources.serializeBinaryToWriter(this, writer);
+  return writer.getResultBuffer();
+};
+
+
+/**
+ * Serializes the given message to binary data (in protobuf wire
+ * format), writing to the given BinaryWriter.
+ * @param {!proto.wsman.ServiceContainerResources} message
+ * @param {!jspb.BinaryWriter} writer
+ * @suppress {unusedLocalVariables} f is only used for nested messages
+ */
+proto.wsman.ServiceContainerResources.serializeBinaryToWriter = function(message, writer) {
+  var f = undefined;
+  f = message.getCpu();
+  if (f.length > 0) {
+    writer.writeString(
+      1,
+      f
+    );
+  }
+  f = message.getMemory();
+  if (f.length > 0) {
+    writer.writeString(
+      2,
+      f
+    );
+  }
+};
+
+
+/**
+ * optional string cpu = 1;
+ * @return {string}
+ */
+proto.wsman.ServiceContainerResources.prototype.getCpu = function() {
+  return /** @type {string} */ (jspb.Message.getFieldWithDefault(this, 1, ""));
+};
+
+
+/**
+ * @param {string} value
+ * @return {!proto.wsman.ServiceContainerResources} returns this
+ */
+proto.wsman.ServiceContainerResources.prototype.setCpu = function(value) {
+  return jspb.Message.setProto3StringField(this, 1, value);
+};
+
+
+/**
+ * optional string memory = 2;
+ * @return {string}
+ */
+proto.wsman.ServiceContainerResources.prototype.getMemory = function() {
+  return /** @type {string} */ (jspb.Message.getFieldWithDefault(this, 2, ""));
+};
+
+
+/**
+ * @param {string} value
+ * @return {!proto.wsman.ServiceContainerResources} returns this
+ */
+proto.wsman.ServiceContainerResources.prototype.setMemory = function(value) {
+  return jspb.Message.setProto3StringField(this, 2, value);
+};
+
+
+
+
+
+if (jspb.Message.GENERATE_TO_OBJECT) {
+/**
+ * Creates an object representation of this proto.
+ * Field names that are reserved in JavaScript and will be renamed to pb_name.
+ * Optional fields that are not set will be set to undefined.
+ * To access a reserved field use, foo.pb_<name>, eg, foo.pb_default.
+ * For the list of reserved names please see:
+ *     net/proto2/compiler/js/internal/generator.cc#kKeyword.
+ * @param {boolean=} opt_includeInstance Deprecated. whether to include the
+ *     JSPB instance for transitional soy proto support:
+ *     http://goto/soy-param-migration
+ * @return {!Object}
+ */
+proto.wsman.GitSpec.prototype.toObject = function(opt_includeInstance) {
+  return proto.wsman.GitSpec.toObject(opt_includeInstance, this);
+};
+
+
+/**
+ * Static version of the {@see toObject} method.
+ * @param {boolean|undefined} includeInstance Deprecated. Whether to include
+ *     the JSPB instance for transitional soy proto support:
+ *     http://goto/soy-param-migration
+ * @param {!proto.wsman.GitSpec} msg The msg instance to transform.
+ * @return {!Object}
+ * @suppress {unusedLocalVariables} f is only used for nested messages
+ */
+proto.wsman.GitSpec.toObject = function(includeInstance, msg) {
+  var f, obj = {
+    username: jspb.Message.getFieldWithDefault(msg, 1, ""),
+    email: jspb.Message.getFieldWithDefault(msg, 2, "")
+  };
+
+  if (includeInstance) {
+    obj.$jspbMessageInstance = msg;
+  }
+  return obj;
+};
+}
+
+
+/**
+ * Deserializes binary data (in protobuf wire format).
+ * @param {jspb.ByteSource} bytes The bytes to deserialize.
+ * @return {!proto.wsman.GitSpec}
+ */
+proto.wsman.GitSpec.deserializeBinary = function(bytes) {
+  var reader = new jspb.BinaryReader(bytes);
+  var msg = new proto.wsman.GitSpec;
+  return proto.wsman.GitSpec.deserializeBinaryFromReader(msg, reader);
+};
+
+
+/**
+ * Deserializes binary data (in protobuf wire format) from the
+ * given reader into the given message object.
+ * @param {!proto.wsman.GitSpec} msg The message object to deserialize into.
+ * @param {!jspb.BinaryReader} reader The BinaryReader to use.
+ * @return {!proto.wsman.GitSpec}
+ */
+proto.wsman.GitSpec.deserializeBinaryFromReader = function(msg, reader) {
+  while (reader.nextField()) {
+    if (reader.isEndGroup()) {
+      break;
+    }
+    var field = reader.getFieldNumber();
+    switch (field) {
+    case 1:
+      var value = /** @type {string} */ (reader.readString());
+      msg.setUsername(value);
+      break;
+    case 2:
+      var value = /** @type {string} */ (reader.readString());
+      msg.setEmail(value);
+      break;
+    default:
+      reader.skipField();
+      break;
+    }
+  }
+  return msg;
+};
+
+
+/**
+ * Serializes the message to binary data (in protobuf wire format).
+ * @return {!Uint8Array}
+ */
+proto.wsman.GitSpec.prototype.serializeBinary = function() {
+  var writer = new jspb.BinaryWriter();
+  proto.wsman.GitSpec.serializeBinaryToWriter(this, writer);
+  return writer.getResultBuffer();
+};
+
+
+/**
+ * Serializes the given message to binary data (in protobuf wire
+ * format), writing to the given BinaryWriter.
+ * @param {!proto.wsman.GitSpec} message
+ * @param {!jspb.BinaryWriter} writer
+ * @suppress {unusedLocalVariables} f is only used for nested messages
+ */
+proto.wsman.GitSpec.serializeBinaryToWriter = function(message, writer) {
+  var f = undefined;
+  f = message.getUsername();
+  if (f.length > 0) {
+    writer.writeString(
+      1,
+      f
+    );
+  }
+  f = message.getEmail();
+  if (f.length > 0) {
+    writer.writeString(
+      2,
+      f
+    );
+  }
+};
+
+
+/**
+ * optional string username = 1;
+ * @return {string}
+ */
+proto.wsman.GitSpec.prototype.getUsername = function() {
+  return /** @type {string} */ (jspb.Message.getFieldWithDefault(this, 1, ""));
+};
+
+
+/**
+ * @param {string} value
+ * @return {!proto.wsman.GitSpec} returns this
+ */
+proto.wsman.GitSpec.prototype.setUsername = function(value) {
+  return jspb.Message.setProto3StringField(this, 1, value);
+};
+
+
+/**
+ * optional string email = 2;
+ * @return {string}
+ */
+proto.wsman.GitSpec.prototype.getEmail = function() {
+  return /** @type {string} */ (jspb.Message.getFieldWithDefault(this, 2, ""));
+};
+
+
+/**
+ * @param {string} value
+ * @return {!proto.wsman.GitSpec} returns this
+ */
+proto.wsman.GitSpec.prototype.setEmail = function(value) {
+  return jspb.Message.setProto3StringField(this, 2, value);
+};
+
+
+
+
+
+if (jspb.Message.GENERATE_TO_OBJECT) {
+/**
+ * Creates an object representation of this proto.
+ * Field names that are reserved in JavaScript and will be renamed to pb_name.
+ * Optional fields that are not set will be set to undefined.
+ * To access a reserved field use, foo.pb_<name>, eg, foo.pb_default.
+ * For the list of reserved names please see:
+ *     net/proto2/compiler/js/internal/generator.cc#kKeyword.
+ * @param {boolean=} opt_includeInstance Deprecated. whether to include the
+ *     JSPB instance for transitional soy proto support:
+ *     http://goto/soy-param-migration
+ * @return {!Object}
+ */
+proto.wsman.EnvironmentVariable.prototype.toObject = function(opt_includeInstance) {
+  return proto.wsman.EnvironmentVariable.toObject(opt_includeInstance, this);
+};
+
+
+/**
+ * Static version of the {@see toObject} method.
+ * @param {boolean|undefined} includeInstance Deprecated. Whether to include
+ *     the JSPB instance for transitional soy proto support:
+ *     http://goto/soy-param-migration
+ * @param {!proto.wsman.EnvironmentVariable} msg The msg instance to transform.
+ * @return {!Object}
+ * @suppress {unusedLocalVariables} f is only used for nested messages
+ */
+proto.wsman.EnvironmentVariable.toObject = function(includeInstance, msg) {
+  var f, obj = {
+    name: jspb.Message.getFieldWithDefault(msg, 1, ""),
+    value: jspb.Message.getFieldWithDefault(msg, 2, "")
+  };
+
+  if (includeInstance) {
+    obj.$jspbMessageInstance = msg;
+  }
+  return obj;
+};
+}
+
+
+/**
+ * Deserializes binary data (in protobuf wire format).
+ * @param {jspb.ByteSource} bytes The bytes to deserialize.
+ * @return {!proto.wsman.EnvironmentVariable}
+ */
+proto.wsman.EnvironmentVariable.deserializeBinary = function(bytes) {
+  var reader = new jspb.BinaryReader(bytes);
+  var msg = new proto.wsman.EnvironmentVariable;
+  return proto.wsman.EnvironmentVariable.deserializeBinaryFromReader(msg, reader);
+};
+
+
+/**
+ * Deserializes binary data (in protobuf wire format) from the
+ * given reader into the given message object.
+ * @param {!proto.wsman.EnvironmentVariable} msg The message object to deserialize into.
+ * @param {!jspb.BinaryReader} reader The BinaryReader to use.
+ * @return {!proto.wsman.EnvironmentVariable}
+ */
+proto.wsman.EnvironmentVariable.deserializeBinaryFromReader = function(msg, reader) {
+  while (reader.nextField()) {
+    if (reader.isEndGroup()) {
+      break;
+    }
+    var field = reader.getFieldNumber();
+    switch (field) {
+    case 1:
+      var value = /** @type {string} */ (reader.readString());
+      msg.setName(value);
+      break;
+    case 2:
+      var value = /** @type {string} */ (reader.readString());
+      msg.setValue(value);
+      break;
+    default:
+      reader.skipField();
+      break;
+    }
+  }
+  return msg;
+};
+
+
+/**
+ * Serializes the message to binary data (in protobuf wire format).
+ * @return {!Uint8Array}
+ */
+proto.wsman.EnvironmentVariable.prototype.serializeBinary = function() {
+  var writer = new jspb.BinaryWriter();
+  proto.wsman.EnvironmentVariable.serializeBinaryToWriter(this, writer);
+  return writer.getResultBuffer();
+};
+
+
+/**
+ * Serializes the given message to binary data (in protobuf wire
+ * format), writing to the given BinaryWriter.
+ * @param {!proto.wsman.EnvironmentVariable} message
+ * @param {!jspb.BinaryWriter} writer
+ * @suppress {unusedLocalVariables} f is only used for nested messages
+ */
+proto.wsman.EnvironmentVariable.serializeBinaryToWriter = function(message, writer) {
+  var f = undefined;
+  f = message.getName();
+  if (f.length > 0) {
+    writer.writeString(
+      1,
+      f
+    );
+  }
+  f = message.getValue();
+  if (f.length > 0) {
+    writer.writeString(
+      2,
+      f
+    );
+  }
+};
+
+
+/**
+ * optional string name = 1;
+ * @return {string}
+ */
+proto.wsman.EnvironmentVariable.prototype.getName = function() {
+  return /** @type {string} */ (jspb.Message.getFieldWithDefault(this, 1, ""));
+};
+
+
+/**
+ * @param {string} value
+ * @return {!proto.wsman.EnvironmentVariable} returns this
+ */
+proto.wsman.EnvironmentVariable.prototype.setName = function(value) {
+  return jspb.Message.setProto3StringField(this, 1, value);
+};
+
+
+/**
+ * optional string value = 2;
+ * @return {string}
+ */
+proto.wsman.EnvironmentVariable.prototype.getValue = function() {
+  return /** @type {string} */ (jspb.Message.getFieldWithDefault(this, 2, ""));
+};
+
+
+/**
+ * @param {string} value
+ * @return {!proto.wsman.EnvironmentVariable} returns this
+ */
+proto.wsman.EnvironmentVariable.prototype.setValue = function(value) {
+  return jspb.Message.setProto3StringField(this, 2, value);
+};
+
+
+/**
+ * @enum {number}
+ */
+proto.wsman.StopWorkspacePolicy = {
+  NORMALLY: 0,
+  IMMEDIATELY: 1
+};
+
+/**
+ * @enum {number}
+ */
+proto.wsman.AdmissionLevel = {
+  ADMIT_OWNER_ONLY: 0,
+  ADMIT_EVERYONE: 1
+};
+
+/**
+ * @enum {number}
+ */
+proto.wsman.PortVisibility = {
+  PORT_VISIBILITY_PRIVATE: 0,
+  PORT_VISIBILITY_PUBLIC: 1
+};
+
+/**
+ * @enum {number}
+ */
+proto.wsman.PortProtocol = {
+  PORT_PROTOCOL_HTTP: 0,
+  PORT_PROTOCOL_HTTP2: 1
 };
 
 /**
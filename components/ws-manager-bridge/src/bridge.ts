@@ -6,8 +6,8 @@
 
 import { inject, injectable } from "inversify";
 import { MessageBusIntegration } from "./messagebus-integration";
-import { Disposable, WorkspaceInstance, Queue, WorkspaceInstancePort, PortVisibility, RunningWorkspaceInfo } from "@gitpod/gitpod-protocol";
-import { WorkspaceStatus, WorkspacePhase, GetWorkspacesRequest, WorkspaceConditionBool, PortVisibility as WsManPortVisibility, WorkspaceType, PromisifiedWorkspaceManagerClient } from "@gitpod/ws-manager/lib";
+import { Disposable, WorkspaceInstance, Queue, WorkspaceInstancePort, PortVisibility, PortProtocol, RunningWorkspaceInfo } from "@gitpod/gitpod-protocol";
+import { WorkspaceStatus, WorkspacePhase, GetWorkspacesRequest, WorkspaceConditionBool, PortVisibility as WsManPortVisibility, PortProtocol as WsManPortProtocol, WorkspaceType, PromisifiedWorkspaceManagerClient } from "@gitpod/ws-manager/lib";
 import { WorkspaceDB } from "@gitpod/gitpod-db/lib/workspace-db";
 import { UserDB } from "@gitpod/gitpod-db/lib/user-db";
 import { log } from '@gitpod/gitpod-protocol/lib/util/logging';
@@ -140,6 +140,7 @@ export class WorkspaceManagerBridge implements Disposable {
                         port: p.port,
                         targetPort: !!p.target ? p.target : undefined,
                         visibility: mapPortVisibility(p.visibility),
+                        protocol: mapPortProtocol(p.protocol),
                         url: p.url,
                     };
                 });
@@ -364,6 +365,17 @@ const mapPortVisibility = (visibility: WsManPortVisibility | undefined): PortVis
     }
 };
 
+const mapPortProtocol = (protocol: WsManPortProtocol | undefined): PortProtocol | undefined => {
+    switch (protocol) {
+        case undefined:
+            return undefined;
+        case WsManPortProtocol.PORT_PROTOCOL_HTTP:
+            return "http";
+        case WsManPortProtocol.PORT_PROTOCOL_HTTP2:
+            return "http2";
+    }
+};
+
 const durationLongerThanSeconds = (time: number, durationSeconds: number, now: number = Date.now()) => {
     return (now - time) / 1000 > durationSeconds;
 };
\ No newline at end of file
@@ -7,6 +7,7 @@ package manager
 import (
 	"context"
 	"strings"
+	"time"
 
 	"golang.org/x/xerrors"
 	"k8s.io/client-go/util/retry"
@@ -74,6 +75,14 @@ const (
 	// workspaceAdmissionAnnotation determines the user admission to a workspace, i.e. if it can be accessed by everyone without token
 	workspaceAdmissionAnnotation = "gitpod/admission"
 
+	// workspacePersistentVolumesAnnotation contains a comma-separated list of the names of the persistent volumes of a workspace.
+	// We need to keep this around post-request as we'll pass the names on to ws-daemon during content initialization.
+	workspacePersistentVolumesAnnotation = "gitpod/persistentVolumes"
+
+	// workspaceEgressPolicyAnnotation contains the protobuf serialized egress policy in base64 encoding.
+	// We need to keep this around post-request as we'll pass the policy on to ws-daemon during content initialization.
+	workspaceEgressPolicyAnnotation = "gitpod/egressPolicy"
+
 	// gitpodFinalizerName is the name of the Gitpod finalizer we use to clean up a workspace
 	gitpodFinalizerName = "gitpod.io/finalizer"
 
@@ -83,6 +92,12 @@ const (
 	// nodeNameAnnotation contains the name of the node the pod ran on. We use this to remeber the name in case the pod gets evicted.
 	nodeNameAnnotation = "gitpod.io/nodeName"
 
+	// contentInitAnnotation contains the outcome of the workspace content initialization. We use this to compute the start timings.
+	contentInitAnnotation = "gitpod.io/contentInit"
+
+	// ideReadyAnnotation contains the time at which the IDE of a workspace first became ready
+	ideReadyAnnotation = "gitpod.io/ideReady"
+
 	// workspaceAnnotationPrefix prefixes pod annotations that contain annotations specified during the workspaces start request
 	workspaceAnnotationPrefix = "gitpod.io/annotation."
 )
@@ -161,6 +176,14 @@ type workspaceDisposalStatus struct {
 	GitStatus      *csapi.GitStatus `json:"gitStatus,omitempty"`
 }
 
+// workspaceContentInitStatus describes the workspace content initialization
+type workspaceContentInitStatus struct {
+	Initializer string    `json:"initializer"`
+	StartedAt   time.Time `json:"startedAt"`
+	FinishedAt  time.Time `json:"finishedAt"`
+	ContentSize uint64    `json:"contentSize,omitempty"`
+}
+
 func (m *Manager) modifyFinalizer(ctx context.Context, workspaceID string, finalizer string, add bool) error {
 	// Retry on failure. Sometimes this doesn't work because of concurrent modification. The Kuberentes way is to just try again after waiting a bit.
 	return retry.RetryOnConflict(retry.DefaultBackoff, func() (err error) {
@@ -75,8 +75,14 @@ type Configuration struct {
 	ReconnectionInterval util.Duration `json:"reconnectionInterval"`
 	// DryRun prevents us from ever stopping a pod. It is considered equivalent to a listener mode
 	DryRun bool `json:"dryRun,omitempty"`
+	// EnableWorkspaceCRD makes us mirror the status of every workspace to a Workspace custom resource.
+	// The workspace.gitpod.io CRD must be installed in the cluster for this to work.
+	EnableWorkspaceCRD bool `json:"enableWorkspaceCRD,omitempty"`
 	// WorkspaceDaemon configures our connection to the workspace sync daemons runnin on the nodes
 	WorkspaceDaemon WorkspaceDaemonConfiguration `json:"wsdaemon"`
+	// PeriodicBackupInterval makes ws-daemon back up the content of running regular workspaces at this interval,
+	// provided it changed. Zero disables periodic backups.
+	PeriodicBackupInterval util.Duration `json:"periodicBackupInterval,omitempty"`
 	// RegistryFacadeHost is the host (possibly including port) on which the registry facade resolves
 	RegistryFacadeHost string `json:"registryFacadeHost"`
 	// Cluster host under which workspaces are served, e.g. ws-eu11.gitpod.io
@@ -86,6 +92,16 @@ type Configuration struct {
 // AllContainerConfiguration contains the configuration for all container in a workspace pod
 type AllContainerConfiguration struct {
 	Workspace ContainerConfiguration `json:"workspace"`
+	// Services configures the service containers which run next to a workspace
+	Services ServiceContainerConfiguration `json:"services,omitempty"`
+}
+
+// ServiceContainerConfiguration configures the resources of service containers
+type ServiceContainerConfiguration struct {
+	// Requests are used for services which do not request resources themselves
+	Requests ResourceConfiguration `json:"requests"`
+	// Limits cap the resources a service can request
+	Limits ResourceConfiguration `json:"limits"`
 }
 
 // WorkspaceTimeoutConfiguration configures the timeout behaviour of workspaces
@@ -156,8 +172,15 @@ func (c *Configuration) Validate() error {
 	if err := c.Container.Workspace.Validate(); err != nil {
 		return xerrors.Errorf("container.workspace: %w", err)
 	}
+	err := validation.ValidateStruct(&c.Container.Services,
+		validation.Field(&c.Container.Services.Requests, validResourceConfig),
+		validation.Field(&c.Container.Services.Limits, validResourceConfig),
+	)
+	if err != nil {
+		return xerrors.Errorf("container.services: %w", err)
+	}
 
-	err := validation.ValidateStruct(&c.Timeouts,
+	err = validation.ValidateStruct(&c.Timeouts,
 		validation.Field(&c.Timeouts.AfterClose, validation.Required),
 		validation.Field(&c.Timeouts.HeadlessWorkspace, validation.Required),
 		validation.Field(&c.Timeouts.Initialization, validation.Required),
@@ -224,7 +224,16 @@ func (m *Manager) createDefiniteWorkspacePod(startContext *startWorkspaceContext
 	// However: the whole user workload now runs in a user namespace, which makes this acceptable.
 	workspaceContainer.SecurityContext.AllowPrivilegeEscalation = &boolTrue
 
-	workspaceVolume, err := m.createWorkspaceVolumes(startContext)
+	serviceContainers := make([]corev1.Container, 0, len(req.Spec.Services))
+	for _, svc := range req.Spec.Services {
+		c, err := m.createServiceContainer(svc)
+		if err != nil {
+			return nil, xerrors.Errorf("cannot create container for service %s: %w", svc.Name, err)
+		}
+		serviceContainers = append(serviceContainers, *c)
+	}
+
+	workspaceVolume, persistentVolumes, err := m.createWorkspaceVolumes(startContext)
 	if err != nil {
 		return nil, xerrors.Errorf("cannot create workspace volumes: %w", err)
 	}
@@ -303,6 +312,20 @@ func (m *Manager) createDefiniteWorkspacePod(startContext *startWorkspaceContext
 	for k, v := range req.Metadata.Annotations {
 		annotations[workspaceAnnotationPrefix+k] = v
 	}
+	if len(req.Spec.Volumes) > 0 {
+		names := make([]string, len(req.Spec.Volumes))
+		for i, v := range req.Spec.Volumes {
+			names[i] = v.Name
+		}
+		annotations[workspacePersistentVolumesAnnotation] = strings.Join(names, ",")
+	}
+	if req.Spec.EgressPolicy != nil {
+		egressPolicy, err := proto.Marshal(req.Spec.EgressPolicy)
+		if err != nil {
+			return nil, xerrors.Errorf("cannot remarshal egress policy: %w", err)
+		}
+		annotations[workspaceEgressPolicyAnnotation] = base64.StdEncoding.EncodeToString(egressPolicy)
+	}
 
 	// By default we embue our workspace pods with some tolerance towards pressure taints,
 	// see https://kubernetes.io/docs/concepts/configuration/taint-and-toleration/#taint-based-evictions
@@ -331,11 +354,11 @@ func (m *Manager) createDefiniteWorkspacePod(startContext *startWorkspaceContext
 			ServiceAccountName:           "workspace",
 			SchedulerName:                m.Config.SchedulerName,
 			EnableServiceLinks:           &boolFalse,
-			Containers: []corev1.Container{
+			Containers: append([]corev1.Container{
 				*workspaceContainer,
-			},
+			}, serviceContainers...),
 			RestartPolicy: corev1.RestartPolicyNever,
-			Volumes: []corev1.Volume{
+			Volumes: append([]corev1.Volume{
 				workspaceVolume,
 				{
 					Name: daemonVolumeName,
@@ -346,7 +369,7 @@ func (m *Manager) createDefiniteWorkspacePod(startContext *startWorkspaceContext
 						},
 					},
 				},
-			},
+			}, persistentVolumes...),
 			Tolerations: []corev1.Toleration{
 				{
 					Key:      "node.kubernetes.io/disk-pressure",
@@ -472,6 +495,27 @@ func (m *Manager) createWorkspaceContainer(startContext *startWorkspaceContext)
 
 	image := fmt.Sprintf("%s/%s/%s", m.Config.RegistryFacadeHost, regapi.ProviderPrefixRemote, startContext.Request.Id)
 
+	volumeMounts := []corev1.VolumeMount{
+		{
+			Name:             workspaceVolumeName,
+			MountPath:        workspaceDir,
+			ReadOnly:         false,
+			MountPropagation: &mountPropagation,
+		},
+		{
+			MountPath:        "/.workspace",
+			Name:             "daemon-mount",
+			MountPropagation: &mountPropagation,
+		},
+	}
+	for _, v := range startContext.Request.Spec.Volumes {
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{
+			Name:             persistentVolumeName(v.Name),
+			MountPath:        v.MountPath,
+			MountPropagation: &mountPropagation,
+		})
+	}
+
 	return &corev1.Container{
 		Name:            "workspace",
 		Image:           image,
@@ -484,19 +528,7 @@ func (m *Manager) createWorkspaceContainer(startContext *startWorkspaceContext)
 			Limits:   limits,
 			Requests: requests,
 		},
-		VolumeMounts: []corev1.VolumeMount{
-			{
-				Name:             workspaceVolumeName,
-				MountPath:        workspaceDir,
-				ReadOnly:         false,
-				MountPropagation: &mountPropagation,
-			},
-			{
-				MountPath:        "/.workspace",
-				Name:             "daemon-mount",
-				MountPropagation: &mountPropagation,
-			},
-		},
+		VolumeMounts:             volumeMounts,
 		ReadinessProbe:           readinessProbe,
 		Env:                      env,
 		Command:                  command,
@@ -561,6 +593,17 @@ func (m *Manager) createWorkspaceEnvironment(startContext *startWorkspaceContext
 		result = append(result, corev1.EnvVar{Name: "GITPOD_HEADLESS", Value: "true"})
 	}
 
+	// supervisor makes the ports of services known to its ports manager
+	if len(spec.Services) > 0 {
+		var servicePorts []string
+		for _, svc := range spec.Services {
+			for _, p := range svc.Ports {
+				servicePorts = append(servicePorts, fmt.Sprintf("%s:%d", svc.Name, p))
+			}
+		}
+		result = append(result, corev1.EnvVar{Name: "GITPOD_SERVICE_PORTS", Value: strings.Join(servicePorts, ",")})
+	}
+
 	// remove empty env vars
 	cleanResult := make([]corev1.EnvVar, 0)
 	for _, v := range result {
@@ -574,7 +617,7 @@ func (m *Manager) createWorkspaceEnvironment(startContext *startWorkspaceContext
 	return cleanResult, nil
 }
 
-func (m *Manager) createWorkspaceVolumes(startContext *startWorkspaceContext) (workspace corev1.Volume, err error) {
+func (m *Manager) createWorkspaceVolumes(startContext *startWorkspaceContext) (workspace corev1.Volume, persistent []corev1.Volume, err error) {
 	// silly protobuf structure design - this needs to be a reference to a string,
 	// so we have to assign it to a variable first to take the address
 	hostPathOrCreate := corev1.HostPathDirectoryOrCreate
@@ -589,10 +632,115 @@ func (m *Manager) createWorkspaceVolumes(startContext *startWorkspaceContext) (w
 		},
 	}
 
+	// Persistent volumes live next to the workspace content on the node. ws-daemon restores their
+	// content during initialization and backs them up during disposal.
+	for _, v := range startContext.Request.Spec.Volumes {
+		persistent = append(persistent, corev1.Volume{
+			Name: persistentVolumeName(v.Name),
+			VolumeSource: corev1.VolumeSource{
+				HostPath: &corev1.HostPathVolumeSource{
+					Path: filepath.Join(m.Config.WorkspaceHostPath, fmt.Sprintf("%s-vol-%s", startContext.Request.Id, v.Name)),
+					Type: &hostPathOrCreate,
+				},
+			},
+		})
+	}
+
 	err = nil
 	return
 }
 
+// persistentVolumeName produces the name of the pod volume backing a persistent volume
+func persistentVolumeName(name string) string {
+	return "vol-persistent-" + name
+}
+
+// serviceContainerPrefix is the prefix of the names of all service containers in a workspace pod
+const serviceContainerPrefix = "service-"
+
+// serviceContainerName produces the name of the container running a service
+func serviceContainerName(name string) string {
+	return serviceContainerPrefix + name
+}
+
+// isServiceContainer returns true if the container runs a service rather than the workspace itself
+func isServiceContainer(containerName string) bool {
+	return strings.HasPrefix(containerName, serviceContainerPrefix)
+}
+
+// createServiceContainer creates a sidecar container running a service next to the workspace
+func (m *Manager) createServiceContainer(svc *api.ServiceContainerSpec) (*corev1.Container, error) {
+	limits, err := m.Config.Container.Services.Limits.ResourceList()
+	if err != nil {
+		return nil, xerrors.Errorf("cannot parse service container limits: %w", err)
+	}
+	reqcfg := m.Config.Container.Services.Requests
+	if svc.Resources != nil {
+		reqcfg = ResourceConfiguration{CPU: svc.Resources.Cpu, Memory: svc.Resources.Memory}
+	}
+	requests, err := reqcfg.ResourceList()
+	if err != nil {
+		return nil, xerrors.Errorf("cannot parse service container requests: %w", err)
+	}
+	for k, l := range limits {
+		if r, ok := requests[k]; ok && r.Cmp(l) > 0 {
+			return nil, xerrors.Errorf("service requests %s %s which exceeds the limit of %s", r.String(), k, l.String())
+		}
+	}
+
+	env := make([]corev1.EnvVar, 0, len(svc.Env))
+	for _, e := range svc.Env {
+		env = append(env, corev1.EnvVar{Name: e.Name, Value: e.Value})
+	}
+	ports := make([]corev1.ContainerPort, 0, len(svc.Ports))
+	for _, p := range svc.Ports {
+		ports = append(ports, corev1.ContainerPort{ContainerPort: int32(p), Protocol: corev1.ProtocolTCP})
+	}
+
+	var readinessProbe *corev1.Probe
+	if len(svc.ReadinessCommand) > 0 {
+		readinessProbe = &corev1.Probe{
+			Handler: corev1.Handler{
+				Exec: &corev1.ExecAction{Command: svc.ReadinessCommand},
+			},
+			FailureThreshold: 3,
+			PeriodSeconds:    2,
+			SuccessThreshold: 1,
+			TimeoutSeconds:   1,
+		}
+	}
+
+	// Unlike the workspace, services do not run in a user namespace. Hence we run them as unprivileged
+	// user without any capabilities, irrespective of the user their image asks for. Images which need
+	// root are not supported - they fail and the service condition reports why.
+	gitpodUID := int64(33333)
+	sec := &corev1.SecurityContext{
+		AllowPrivilegeEscalation: &boolFalse,
+		Capabilities: &corev1.Capabilities{
+			Drop: []corev1.Capability{"ALL"},
+		},
+		Privileged:   &boolFalse,
+		RunAsGroup:   &gitpodUID,
+		RunAsNonRoot: &boolTrue,
+		RunAsUser:    &gitpodUID,
+	}
+
+	return &corev1.Container{
+		Name:            serviceContainerName(svc.Name),
+		Image:           svc.Image,
+		ImagePullPolicy: corev1.PullIfNotPresent,
+		SecurityContext: sec,
+		Ports:           ports,
+		Env:             env,
+		Resources: corev1.ResourceRequirements{
+			Limits:   limits,
+			Requests: requests,
+		},
+		ReadinessProbe:           readinessProbe,
+		TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
+	}, nil
+}
+
 func (m *Manager) createDefaultSecurityContext() (*corev1.SecurityContext, error) {
 	gitpodGUID := int64(33333)
 
@@ -635,9 +783,10 @@ func (m *Manager) createPortsService(workspaceID string, metaID string, serviceP
 	servicePorts := make([]corev1.ServicePort, len(ports))
 	for i, p := range ports {
 		servicePorts[i] = corev1.ServicePort{
-			Port:     int32(p.Port),
-			Protocol: corev1.ProtocolTCP,
-			Name:     portSpecToName(p),
+			Port:        int32(p.Port),
+			Protocol:    corev1.ProtocolTCP,
+			Name:        portSpecToName(p),
+			AppProtocol: portSpecToAppProtocol(p),
 		}
 		if p.Target != 0 {
 			servicePorts[i].TargetPort = intstr.FromInt(int(p.Target))
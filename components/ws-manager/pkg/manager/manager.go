@@ -14,6 +14,7 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"sync"
 	"time"
@@ -32,7 +33,9 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/clock"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	k8svalidation "k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/client-go/kubernetes"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -58,12 +61,19 @@ type Manager struct {
 	activity sync.Map
 
 	wsdaemonPool *grpcpool.Pool
+	// wsdaemonClient replaces the connection to ws-daemon if set. This is meant for testing only.
+	wsdaemonClient wsdaemon.WorkspaceContentServiceClient
+
+	clock clock.Clock
 
 	subscribers    map[string]chan *api.SubscribeResponse
 	subscriberLock sync.RWMutex
 
 	metrics *metrics
 
+	// crdMirror writes the workspace status to the Workspace custom resources if they're enabled
+	crdMirror *workspaceCRDMirror
+
 	api.UnimplementedWorkspaceManagerServer
 	regapi.UnimplementedSpecProviderServer
 }
@@ -116,9 +126,14 @@ func New(config Configuration, client client.Client, rawClient kubernetes.Interf
 		Content:      cp,
 		subscribers:  make(map[string]chan *api.SubscribeResponse),
 		wsdaemonPool: grpcpool.New(wsdaemonConnfactory),
+		clock:        clock.RealClock{},
 	}
 	m.metrics = newMetrics(m)
 	m.OnChange = m.onChange
+	if config.EnableWorkspaceCRD {
+		m.crdMirror = newWorkspaceCRDMirror(m.mirrorWorkspaceStatus)
+		m.crdMirror.Start(workspaceCRDMirrorWorkers)
+	}
 	return m, nil
 }
 
@@ -132,6 +147,9 @@ func Register(grpcServer *grpc.Server, manager *Manager) {
 // to function properly anymore.
 func (m *Manager) Close() {
 	m.wsdaemonPool.Close()
+	if m.crdMirror != nil {
+		m.crdMirror.Stop()
+	}
 }
 
 // StartWorkspace creates a new running workspace within the manager's cluster
@@ -260,10 +278,17 @@ func validateStartWorkspaceRequest(req *api.StartWorkspaceRequest) error {
 		validation.Field(&req.Spec.Ports, validation.By(areValidPorts)),
 		validation.Field(&req.Spec.Initializer, validation.Required),
 		validation.Field(&req.Spec.FeatureFlags, validation.By(areValidFeatureFlags)),
+		validation.Field(&req.Spec.Volumes, validation.By(areValidPersistentVolumes)),
+		validation.Field(&req.Spec.Services, validation.By(areValidServices)),
+		validation.Field(&req.Spec.EgressPolicy, validation.By(isValidEgressPolicy)),
 	)
 	if err != nil {
 		return xerrors.Errorf("invalid request: %w", err)
 	}
+	// Services start next to the workspace, before ws-daemon can enforce the egress policy in the network they share.
+	if req.Spec.EgressPolicy != nil && len(req.Spec.Services) > 0 {
+		return xerrors.Errorf("invalid request: services cannot run in workspaces with an egress policy")
+	}
 
 	rules := make([]*validation.FieldRules, 0)
 	rules = append(rules, validation.Field(&req.Id, validation.Required))
@@ -329,6 +354,147 @@ func areValidFeatureFlags(value interface{}) error {
 	return nil
 }
 
+// specNameRegexp matches valid persistent volume and service names. These names end up in Kubernetes volume
+// and container names, and remote storage object names, hence must be DNS label compatible. The limit of
+// 32 characters matches the one of the gitpod.yml schema.
+var specNameRegexp = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,30}[a-z0-9])?$`)
+
+func areValidPersistentVolumes(value interface{}) error {
+	s, ok := value.([]*api.PersistentVolumeSpec)
+	if !ok {
+		return xerrors.Errorf("value is not a persistent volume spec list")
+	}
+
+	var (
+		names = make(map[string]struct{}, len(s))
+		paths = make(map[string]struct{}, len(s))
+	)
+	for _, v := range s {
+		if !specNameRegexp.MatchString(v.Name) {
+			return xerrors.Errorf("volume name \"%s\" is invalid", v.Name)
+		}
+		if _, exists := names[v.Name]; exists {
+			return xerrors.Errorf("volume name \"%s\" is not unique", v.Name)
+		}
+		names[v.Name] = struct{}{}
+
+		if !filepath.IsAbs(v.MountPath) || filepath.Clean(v.MountPath) != v.MountPath {
+			return xerrors.Errorf("mount path of volume %s must be an absolute, clean path", v.Name)
+		}
+		for _, reserved := range []string{"/", workspaceDir, "/.workspace", "/.supervisor"} {
+			if v.MountPath == reserved || (reserved != "/" && strings.HasPrefix(v.MountPath, reserved+"/")) {
+				return xerrors.Errorf("volume %s cannot be mounted at %s", v.Name, v.MountPath)
+			}
+		}
+		if _, exists := paths[v.MountPath]; exists {
+			return xerrors.Errorf("mount path %s is not unique", v.MountPath)
+		}
+		paths[v.MountPath] = struct{}{}
+	}
+
+	return nil
+}
+
+// maxServiceContainers is the maximum number of service containers a workspace can have
+const maxServiceContainers = 5
+
+// reservedServicePorts are ports used by supervisor and the IDE in the workspace container.
+// Services share the network of the workspace and hence must not listen on those.
+var reservedServicePorts = map[uint32]struct{}{
+	22999: {}, // supervisor API
+	23000: {}, // IDE
+	23001: {}, // supervisor SSH
+}
+
+func areValidServices(value interface{}) error {
+	s, ok := value.([]*api.ServiceContainerSpec)
+	if !ok {
+		return xerrors.Errorf("value is not a service container spec list")
+	}
+	if len(s) > maxServiceContainers {
+		return xerrors.Errorf("workspaces cannot have more than %d services", maxServiceContainers)
+	}
+
+	var (
+		names = make(map[string]struct{}, len(s))
+		ports = make(map[uint32]struct{}, len(s))
+	)
+	for _, svc := range s {
+		if !specNameRegexp.MatchString(svc.Name) {
+			return xerrors.Errorf("service name \"%s\" is invalid", svc.Name)
+		}
+		if _, exists := names[svc.Name]; exists {
+			return xerrors.Errorf("service name \"%s\" is not unique", svc.Name)
+		}
+		names[svc.Name] = struct{}{}
+
+		if svc.Image == "" {
+			return xerrors.Errorf("service %s has no image", svc.Name)
+		}
+		for _, p := range svc.Ports {
+			if p == 0 || p > 65535 {
+				return xerrors.Errorf("port %d of service %s is out of range", p, svc.Name)
+			}
+			if _, reserved := reservedServicePorts[p]; reserved {
+				return xerrors.Errorf("service %s cannot use reserved port %d", svc.Name, p)
+			}
+			if _, exists := ports[p]; exists {
+				return xerrors.Errorf("port %d of service %s is not unique", p, svc.Name)
+			}
+			ports[p] = struct{}{}
+		}
+		if r := svc.Resources; r != nil {
+			if _, err := (&ResourceConfiguration{CPU: r.Cpu, Memory: r.Memory}).ResourceList(); err != nil {
+				return xerrors.Errorf("invalid resources of service %s: %w", svc.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// maxEgressRuleDestinations is the maximum number of CIDRs and hostnames an egress policy can contain.
+// Hostnames are resolved by ws-daemon while the workspace starts, hence we keep their number small.
+const maxEgressRuleDestinations = 256
+
+func isValidEgressPolicy(value interface{}) error {
+	p, ok := value.(*api.EgressPolicy)
+	if !ok {
+		return xerrors.Errorf("value is not an egress policy")
+	}
+	if p == nil {
+		return nil
+	}
+
+	var n int
+	for _, r := range append(append([]*api.EgressRule{}, p.Allow...), p.Deny...) {
+		if len(r.Cidrs) == 0 && len(r.Hostnames) == 0 {
+			return xerrors.Errorf("egress rule has neither CIDRs nor hostnames")
+		}
+		for _, c := range r.Cidrs {
+			if _, _, err := net.ParseCIDR(c); err != nil {
+				return xerrors.Errorf("egress rule CIDR \"%s\" is invalid", c)
+			}
+		}
+		for _, h := range r.Hostnames {
+			if errs := k8svalidation.IsDNS1123Subdomain(h); len(errs) > 0 {
+				return xerrors.Errorf("egress rule hostname \"%s\" is invalid: %s", h, strings.Join(errs, "; "))
+			}
+		}
+		for _, port := range r.Ports {
+			if port == 0 || port > 65535 {
+				return xerrors.Errorf("egress rule port %d is out of range", port)
+			}
+		}
+		n += len(r.Cidrs) + len(r.Hostnames)
+	}
+	if n > maxEgressRuleDestinations {
+		return xerrors.Errorf("egress policies cannot have more than %d CIDRs and hostnames", maxEgressRuleDestinations)
+	}
+
+	return nil
+}
+
 // StopWorkspace stops a running workspace
 func (m *Manager) StopWorkspace(ctx context.Context, req *api.StopWorkspaceRequest) (res *api.StopWorkspaceResponse, err error) {
 	span, ctx := tracing.FromContext(ctx, "StopWorkspace")
@@ -501,7 +667,7 @@ func (m *Manager) MarkActive(ctx context.Context, req *api.MarkActiveRequest) (r
 
 	// We do not keep the last activity as annotation on the workspace to limit the load we're placing
 	// on the K8S master in check. Thus, this state lives locally in a map.
-	now := time.Now().UTC()
+	now := m.clock.Now().UTC()
 	m.activity.Store(req.Id, &now)
 
 	// We do however maintain the the "closed" flag as annotation on the workspace. This flag should not change
@@ -527,6 +693,11 @@ func (m *Manager) MarkActive(ctx context.Context, req *api.MarkActiveRequest) (r
 	return &api.MarkActiveResponse{}, nil
 }
 
+// after waits for the duration to elapse on the manager's clock and then sends the current time on the returned channel
+func (m *Manager) after(d time.Duration) <-chan time.Time {
+	return m.clock.After(d)
+}
+
 func (m *Manager) getWorkspaceActivity(workspaceID string) *time.Time {
 	lastActivity, hasActivity := m.activity.Load(workspaceID)
 	if hasActivity {
@@ -554,7 +725,7 @@ func (m *Manager) markAllWorkspacesActive() error {
 			continue
 		}
 
-		now := time.Now().UTC()
+		now := m.clock.Now().UTC()
 		m.activity.Store(wsid, &now)
 	}
 	return nil
@@ -672,9 +843,10 @@ func (m *Manager) ControlPort(ctx context.Context, req *api.ControlPortRequest)
 	if req.Expose && existingPortSpecIdx < 0 {
 		// port is not exposed yet - patch the service
 		portSpec := corev1.ServicePort{
-			Name:     portSpecToName(req.Spec),
-			Port:     port,
-			Protocol: corev1.ProtocolTCP,
+			Name:        portSpecToName(req.Spec),
+			Port:        port,
+			Protocol:    corev1.ProtocolTCP,
+			AppProtocol: portSpecToAppProtocol(req.Spec),
 		}
 		if req.Spec.Target != 0 {
 			portSpec.TargetPort = intstr.FromInt(int(req.Spec.Target))
@@ -683,6 +855,7 @@ func (m *Manager) ControlPort(ctx context.Context, req *api.ControlPortRequest)
 	} else if req.Expose && existingPortSpecIdx >= 0 {
 		service.Spec.Ports[existingPortSpecIdx].TargetPort = intstr.FromInt(int(req.Spec.Target))
 		service.Spec.Ports[existingPortSpecIdx].Name = portSpecToName(req.Spec)
+		service.Spec.Ports[existingPortSpecIdx].AppProtocol = portSpecToAppProtocol(req.Spec)
 	} else if !req.Expose && existingPortSpecIdx < 0 {
 		// port isn't exposed already - we're done here
 		return &api.ControlPortResponse{}, nil
@@ -763,6 +936,26 @@ func portNameToVisibility(s string) api.PortVisibility {
 	return api.PortVisibility(i32Value)
 }
 
+// appProtocolH2C is the Kubernetes application protocol of services which speak HTTP/2 without TLS
+const appProtocolH2C = "kubernetes.io/h2c"
+
+// portSpecToAppProtocol returns the application protocol of the service port of the given PortSpec
+func portSpecToAppProtocol(spec *api.PortSpec) *string {
+	if spec.Protocol != api.PortProtocol_PORT_PROTOCOL_HTTP2 {
+		return nil
+	}
+	p := appProtocolH2C
+	return &p
+}
+
+// appProtocolToPortProtocol is the inverse of portSpecToAppProtocol
+func appProtocolToPortProtocol(appProtocol *string) api.PortProtocol {
+	if appProtocol != nil && *appProtocol == appProtocolH2C {
+		return api.PortProtocol_PORT_PROTOCOL_HTTP2
+	}
+	return api.PortProtocol_PORT_PROTOCOL_HTTP
+}
+
 // DescribeWorkspace investigates a workspace and returns its status, and configuration
 func (m *Manager) DescribeWorkspace(ctx context.Context, req *api.DescribeWorkspaceRequest) (res *api.DescribeWorkspaceResponse, err error) {
 	//nolint:ineffassign
@@ -985,6 +1178,10 @@ func (m *Manager) onChange(ctx context.Context, status *api.WorkspaceStatus) {
 
 	m.metrics.OnChange(status)
 
+	if m.crdMirror != nil {
+		m.crdMirror.Mirror(status)
+	}
+
 	// There are some conditions we'd like to get notified about, for example while running experiements or because
 	// they represent out-of-the-ordinary situations.
 	// We attempt to use the GCP Error Reporting for this, hence log these situations as errors.
@@ -1124,6 +1321,9 @@ func (m *Manager) connectToWorkspaceDaemon(ctx context.Context, wso workspaceObj
 	if nodeName == "" {
 		return nil, xerrors.Errorf("no nodeName found")
 	}
+	if m.wsdaemonClient != nil {
+		return m.wsdaemonClient, nil
+	}
 
 	// Get all the ws-daemon endpoints (headless)
 	// NOTE: we could do a DNS lookup but currently keeping it k8s-centric
@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/protobuf/types/known/durationpb"
 	corev1 "k8s.io/api/core/v1"
 
 	wsk8s "github.com/gitpod-io/gitpod/common-go/kubernetes"
@@ -33,6 +34,8 @@ type metrics struct {
 	manager *Manager
 
 	startupTimeHistVec    *prometheus.HistogramVec
+	startPhaseHistVec     *prometheus.HistogramVec
+	contentSizeHistVec    *prometheus.HistogramVec
 	totalStartsCounterVec *prometheus.CounterVec
 	totalStopsCounterVec  *prometheus.CounterVec
 	totalOpenPortGauge    prometheus.GaugeFunc
@@ -53,6 +56,21 @@ func newMetrics(m *Manager) *metrics {
 			// same as components/ws-manager-bridge/src/prometheus-metrics-exporter.ts#L15
 			Buckets: prometheus.ExponentialBuckets(2, 2, 10),
 		}, []string{"type"}),
+		startPhaseHistVec: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsWorkspaceSubsystem,
+			Name:      "workspace_start_phase_seconds",
+			Help:      "time it took for the individual phases of a workspace startup to complete",
+			Buckets:   prometheus.ExponentialBuckets(0.5, 2, 12),
+		}, []string{"phase", "type", "initializer"}),
+		contentSizeHistVec: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsWorkspaceSubsystem,
+			Name:      "workspace_content_init_bytes",
+			Help:      "size of the workspace content after initialization",
+			// 1MiB to 256GiB
+			Buckets: prometheus.ExponentialBuckets(1024*1024, 4, 10),
+		}, []string{"type", "initializer"}),
 		totalStartsCounterVec: prometheus.NewCounterVec(prometheus.CounterOpts{
 			Namespace: metricsNamespace,
 			Subsystem: metricsWorkspaceSubsystem,
@@ -113,6 +131,8 @@ func newTotalOpenPortGaugeHandler(m *Manager) func() float64 {
 func (m *metrics) Register(reg prometheus.Registerer) error {
 	collectors := []prometheus.Collector{
 		m.startupTimeHistVec,
+		m.startPhaseHistVec,
+		m.contentSizeHistVec,
 		newPhaseTotalVec(m.manager),
 		newWorkspaceActivityVec(m.manager),
 		newTimeoutSettingsVec(m.manager),
@@ -173,6 +193,8 @@ func (m *metrics) OnChange(status *api.WorkspaceStatus) {
 		}
 		hist.Observe(time.Since(t).Seconds())
 
+		m.observeStartTimings(tpe, status.StartTimings)
+
 	case api.WorkspacePhase_STOPPED:
 		var reason string
 		if strings.Contains(status.Message, string(activityClosed)) {
@@ -195,6 +217,48 @@ func (m *metrics) OnChange(status *api.WorkspaceStatus) {
 	}
 }
 
+// observeStartTimings records the duration of the individual workspace startup phases
+func (m *metrics) observeStartTimings(tpe string, timings *api.WorkspaceStartTimings) {
+	if timings == nil {
+		return
+	}
+
+	initializer := timings.Initializer
+	if initializer == "" {
+		initializer = "unknown"
+	}
+
+	phases := map[string]*durationpb.Duration{
+		"scheduling":   timings.Scheduling,
+		"image_pull":   timings.ImagePull,
+		"content_init": timings.ContentInit,
+		"ide_ready":    timings.IdeReady,
+		"total":        timings.Total,
+	}
+	for phase, d := range phases {
+		if d == nil {
+			continue
+		}
+
+		hist, err := m.startPhaseHistVec.GetMetricWithLabelValues(phase, tpe, initializer)
+		if err != nil {
+			log.WithError(err).WithField("phase", phase).Warn("cannot get start phase histogram metric")
+			continue
+		}
+		hist.Observe(d.AsDuration().Seconds())
+	}
+
+	// ws-daemon only knows the content size if it enforces a disk quota on the workspace
+	if timings.ContentInit != nil && timings.ContentBytes > 0 {
+		hist, err := m.contentSizeHistVec.GetMetricWithLabelValues(tpe, initializer)
+		if err != nil {
+			log.WithError(err).Warn("cannot get content size histogram metric")
+			return
+		}
+		hist.Observe(float64(timings.ContentBytes))
+	}
+}
+
 // phaseTotalVec returns a gauge vector counting the workspaces per phase
 type phaseTotalVec struct {
 	name    string
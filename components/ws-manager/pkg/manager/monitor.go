@@ -21,10 +21,12 @@ import (
 	"google.golang.org/grpc/codes"
 	grpc_status "google.golang.org/grpc/status"
 	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/clock"
 	"k8s.io/apimachinery/pkg/watch"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -63,7 +65,7 @@ type Monitor struct {
 
 	manager   *Manager
 	eventpool *workpool.EventWorkerPool
-	ticker    *time.Ticker
+	ticker    clock.Ticker
 
 	probeMap     map[string]context.CancelFunc
 	probeMapLock sync.Mutex
@@ -89,7 +91,7 @@ func (m *Manager) CreateMonitor() (*Monitor, error) {
 	log.WithField("interval", monitorInterval).Info("starting workspace monitor")
 	res := Monitor{
 		manager:        m,
-		ticker:         time.NewTicker(monitorInterval),
+		ticker:         m.clock.NewTicker(monitorInterval),
 		probeMap:       make(map[string]context.CancelFunc),
 		initializerMap: make(map[string]struct{}),
 		finalizerMap:   make(map[string]context.CancelFunc),
@@ -111,7 +113,7 @@ func (m *Manager) CreateMonitor() (*Monitor, error) {
 // Use Stop() to stop the monitor gracefully.
 func (m *Monitor) Start() error {
 	// mark startup so that we can do proper workspace timeouting
-	m.startup = time.Now().UTC()
+	m.startup = m.manager.clock.Now().UTC()
 
 	m.eventpool.Start(eventpoolWorkers)
 
@@ -124,7 +126,7 @@ func (m *Monitor) Start() error {
 	}
 
 	go func() {
-		for range m.ticker.C {
+		for range m.ticker.C() {
 			m.doHousekeeping(context.Background())
 		}
 	}()
@@ -201,21 +203,21 @@ func (m *Monitor) onPodEvent(evt watch.Event) error {
 	span := m.traceWorkspace("handle-"+status.Phase.String(), wso)
 	ctx = opentracing.ContextWithSpan(context.Background(), span)
 	onChangeDone := make(chan bool)
-	go func() {
+	m.act.async(func() {
 		// We call OnChange in a Go routine to make sure it doesn't block our internal handling of events.
 		m.manager.OnChange(ctx, status)
 		onChangeDone <- true
-	}()
+	})
 
-	m.writeEventTraceLog(status, wso)
+	m.writeEventTraceLog(evt.Type, status, wso)
 	err = actOnPodEvent(ctx, m.act, status, wso)
 
 	// To make the tracing work though we have to re-sync with OnChange. But we don't want OnChange to block our event
 	// handling, thus we wait for it to finish in a Go routine.
-	go func() {
+	m.act.async(func() {
 		<-onChangeDone
 		span.Finish()
-	}()
+	})
 
 	return err
 }
@@ -289,9 +291,21 @@ func actOnPodEvent(ctx context.Context, m actingManager, status *api.WorkspaceSt
 		return nil
 	}
 
+	if reason, ok := pod.Annotations[wsk8s.StopRequestedAnnotation]; ok && !isPodBeingDeleted(pod) {
+		// someone else on the data plane (e.g. ws-daemon running out of disk space) asked us to stop this workspace.
+		// We stop it normally so that its content is backed up.
+		log.WithField("reason", reason).Info("stopping workspace on request")
+		err := m.stopWorkspace(ctx, workspaceID, stopWorkspaceNormallyGracePeriod)
+		if err != nil && !isKubernetesObjNotFoundError(err) {
+			return xerrors.Errorf("cannot stop workspace: %w", err)
+		}
+
+		return nil
+	}
+
 	if status.Phase == api.WorkspacePhase_CREATING {
 		// The workspace has been scheduled on the cluster which means that we can start initializing it
-		go func() {
+		m.async(func() {
 			err := m.initializeWorkspaceContent(ctx, pod)
 
 			if err != nil {
@@ -301,14 +315,14 @@ func actOnPodEvent(ctx context.Context, m actingManager, status *api.WorkspaceSt
 					log.WithError(err).Warn("was unable to mark workspace as failed")
 				}
 			}
-		}()
+		})
 	}
 
 	if status.Phase == api.WorkspacePhase_INITIALIZING {
 		// workspace is initializing (i.e. running but without the ready annotation yet). Start probing and depending on
 		// the result add the appropriate annotation or stop the workspace. waitForWorkspaceReady takes care that it does not
 		// run for the same workspace multiple times.
-		go func() {
+		m.async(func() {
 			err := m.waitForWorkspaceReady(ctx, pod)
 
 			if err != nil {
@@ -318,7 +332,7 @@ func actOnPodEvent(ctx context.Context, m actingManager, status *api.WorkspaceSt
 					log.WithError(err).Warn("was unable to mark workspace as failed")
 				}
 			}
-		}()
+		})
 	}
 
 	if status.Phase == api.WorkspacePhase_RUNNING {
@@ -365,7 +379,7 @@ func actOnPodEvent(ctx context.Context, m actingManager, status *api.WorkspaceSt
 		if terminated || gone {
 			// workaround for https://github.com/containerd/containerd/pull/4214 which can prevent pod status
 			// propagation. ws-daemon observes the pods and propagates this state out-of-band via the annotation.
-			go m.finalizeWorkspaceContent(ctx, wso)
+			m.async(func() { m.finalizeWorkspaceContent(ctx, wso) })
 		} else {
 			// add an additional wait time on top of a deletionGracePeriod
 			// to make sure the changes propagate on the data plane.
@@ -375,10 +389,10 @@ func actOnPodEvent(ctx context.Context, m actingManager, status *api.WorkspaceSt
 			}
 			ttl := time.Duration(gracePeriod) * time.Second * 2
 
-			go func() {
-				time.Sleep(ttl)
+			m.async(func() {
+				<-m.after(ttl)
 				m.finalizeWorkspaceContent(ctx, wso)
-			}()
+			})
 		}
 	}
 
@@ -400,6 +414,16 @@ type actingManager interface {
 	initializeWorkspaceContent(ctx context.Context, pod *corev1.Pod) (err error)
 	finalizeWorkspaceContent(ctx context.Context, wso *workspaceObjects)
 	modifyFinalizer(ctx context.Context, workspaceID string, finalizer string, add bool) error
+
+	// after waits for the duration to elapse and then sends the current time on the returned channel
+	after(d time.Duration) <-chan time.Time
+	// async runs f in a new goroutine
+	async(f func())
+}
+
+// async runs f in a new goroutine
+func (m *Monitor) async(f func()) {
+	go f()
 }
 
 func (m *Monitor) clearInitializerFromMap(podName string) {
@@ -424,10 +448,18 @@ func (m *Monitor) doHousekeeping(ctx context.Context) {
 	}
 }
 
+// eventTraceEntry is a single line of the event trace log
+type eventTraceEntry struct {
+	Time    string               `json:"time"`
+	Type    watch.EventType      `json:"type,omitempty"`
+	Status  *api.WorkspaceStatus `json:"status"`
+	Objects workspaceObjects     `json:"objects"`
+}
+
 // writeEventTraceLog writes an event trace log if one is configured. This function is written in
 // such a way that it does not fail - and if it fails it fails silently. This is on purpose.
 // The event trace log is for debugging only and has no operational significance.
-func (m *Monitor) writeEventTraceLog(status *api.WorkspaceStatus, wso *workspaceObjects) {
+func (m *Monitor) writeEventTraceLog(evtType watch.EventType, status *api.WorkspaceStatus, wso *workspaceObjects) {
 	// make sure we recover from a panic in this function - not that we expect this to ever happen
 	//nolint:errcheck
 	defer recover()
@@ -461,12 +493,7 @@ func (m *Monitor) writeEventTraceLog(status *api.WorkspaceStatus, wso *workspace
 			}
 		}
 	}
-	type eventTraceEntry struct {
-		Time    string               `json:"time"`
-		Status  *api.WorkspaceStatus `json:"status"`
-		Objects workspaceObjects     `json:"objects"`
-	}
-	entry := eventTraceEntry{Time: time.Now().UTC().Format(time.RFC3339Nano), Status: status, Objects: twso}
+	entry := eventTraceEntry{Time: m.manager.clock.Now().UTC().Format(time.RFC3339Nano), Type: evtType, Status: status, Objects: twso}
 
 	if m.manager.Config.EventTraceLog == "-" {
 		//nolint:errcheck
@@ -533,6 +560,7 @@ func (m *Monitor) waitForWorkspaceReady(ctx context.Context, pod *corev1.Pod) (e
 	}
 
 	span.LogKV("event", "probeDone")
+	ideReadyAt := m.manager.clock.Now()
 	probeResult := *r
 	if probeResult == WorkspaceProbeStopped {
 		// Workspace probe was stopped most likely because the workspace itself was stopped.
@@ -580,7 +608,10 @@ func (m *Monitor) waitForWorkspaceReady(ctx context.Context, pod *corev1.Pod) (e
 	span.LogKV("event", "contentInitDone")
 
 	// workspace is ready - mark it as such
-	err = m.manager.markWorkspace(ctx, workspaceID, deleteMark(workspaceNeverReadyAnnotation))
+	err = m.manager.markWorkspace(ctx, workspaceID,
+		deleteMark(workspaceNeverReadyAnnotation),
+		addMark(ideReadyAnnotation, ideReadyAt.UTC().Format(time.RFC3339Nano)),
+	)
 	if err != nil {
 		return xerrors.Errorf("cannot workspace: %w", err)
 	}
@@ -667,6 +698,7 @@ func (m *Monitor) initializeWorkspaceContent(ctx context.Context, pod *corev1.Po
 
 	var (
 		initializer     csapi.WorkspaceInitializer
+		egressPolicy    *wsdaemon.EgressPolicy
 		snc             wsdaemon.WorkspaceContentServiceClient
 		contentManifest []byte
 	)
@@ -699,6 +731,11 @@ func (m *Monitor) initializeWorkspaceContent(ctx context.Context, pod *corev1.Po
 			return xerrors.Errorf("cannot unmarshal init config: %w", err)
 		}
 
+		egressPolicy, err = getEgressPolicy(pod)
+		if err != nil {
+			return err
+		}
+
 		if fullWorkspaceBackup {
 			_, mf, err := m.manager.Content.GetContentLayer(ctx, workspaceMeta.Owner, workspaceMeta.MetaId, &initializer)
 			if err != nil {
@@ -731,30 +768,51 @@ func (m *Monitor) initializeWorkspaceContent(ctx context.Context, pod *corev1.Po
 		return nil
 	}
 
+	var (
+		initStartedAt = m.manager.clock.Now()
+		initResp      *wsdaemon.InitWorkspaceResponse
+	)
 	err = retryIfUnavailable(ctx, func(ctx context.Context) error {
-		_, err = snc.InitWorkspace(ctx, &wsdaemon.InitWorkspaceRequest{
+		initResp, err = snc.InitWorkspace(ctx, &wsdaemon.InitWorkspaceRequest{
 			Id: workspaceID,
 			Metadata: &wsdaemon.WorkspaceMetadata{
 				Owner:  workspaceMeta.Owner,
 				MetaId: workspaceMeta.MetaId,
 			},
-			Initializer:           &initializer,
-			FullWorkspaceBackup:   fullWorkspaceBackup,
-			ContentManifest:       contentManifest,
-			RemoteStorageDisabled: shouldDisableRemoteStorage(pod),
+			Initializer:            &initializer,
+			FullWorkspaceBackup:    fullWorkspaceBackup,
+			ContentManifest:        contentManifest,
+			RemoteStorageDisabled:  shouldDisableRemoteStorage(pod),
+			PersistentVolumes:      getPersistentVolumes(pod),
+			PeriodicBackupInterval: getPeriodicBackupInterval(pod, &m.manager.Config, fullWorkspaceBackup),
+			EgressPolicy:           egressPolicy,
 		})
 		return err
 	})
 	if st, ok := grpc_status.FromError(err); ok && st.Code() == codes.AlreadyExists {
 		// we're already initializing, things are good - we'll wait for it later
-		err = nil
-	} else {
-		err = handleGRPCError(ctx, err)
+		return nil
 	}
+	err = handleGRPCError(ctx, err)
 	if err != nil {
 		return xerrors.Errorf("cannot initialize workspace: %w", err)
 	}
 
+	// The content init status only feeds into the start timings. If we fail to record it,
+	// the workspace is still perfectly usable.
+	initStatus, err := json.Marshal(workspaceContentInitStatus{
+		Initializer: getInitializerType(&initializer),
+		StartedAt:   initStartedAt.UTC(),
+		FinishedAt:  m.manager.clock.Now().UTC(),
+		ContentSize: initResp.GetContentSize(),
+	})
+	if err == nil {
+		err = m.manager.markWorkspace(ctx, workspaceID, addMark(contentInitAnnotation, string(initStatus)))
+	}
+	if err != nil {
+		log.WithFields(wsk8s.GetOWIFromObject(&pod.ObjectMeta)).WithError(err).Warn("cannot record content init status - start timings will be incomplete")
+	}
+
 	return nil
 }
 
@@ -783,6 +841,73 @@ func retryIfUnavailable(ctx context.Context, op func(ctx context.Context) error)
 	return grpc_status.Error(codes.Unavailable, "workspace content initialization is currently unavailable")
 }
 
+// getPersistentVolumes returns the names of the persistent volumes of a workspace pod
+func getPersistentVolumes(pod *corev1.Pod) []string {
+	vols, ok := pod.Annotations[workspacePersistentVolumesAnnotation]
+	if !ok || vols == "" {
+		return nil
+	}
+	return strings.Split(vols, ",")
+}
+
+// getEgressPolicy returns the egress policy of a workspace pod in the form ws-daemon expects, or nil if the workspace has none
+func getEgressPolicy(pod *corev1.Pod) (*wsdaemon.EgressPolicy, error) {
+	raw, ok := pod.Annotations[workspaceEgressPolicyAnnotation]
+	if !ok {
+		return nil, nil
+	}
+	pb, err := base64.StdEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, xerrors.Errorf("cannot decode egress policy: %w", err)
+	}
+	var policy api.EgressPolicy
+	err = proto.Unmarshal(pb, &policy)
+	if err != nil {
+		return nil, xerrors.Errorf("cannot unmarshal egress policy: %w", err)
+	}
+
+	convert := func(rules []*api.EgressRule) []*wsdaemon.EgressRule {
+		res := make([]*wsdaemon.EgressRule, 0, len(rules))
+		for _, r := range rules {
+			res = append(res, &wsdaemon.EgressRule{
+				Cidrs:     r.Cidrs,
+				Hostnames: r.Hostnames,
+				Ports:     r.Ports,
+			})
+		}
+		return res
+	}
+	return &wsdaemon.EgressPolicy{
+		DefaultDeny: policy.DefaultDeny,
+		Allow:       convert(policy.Allow),
+		Deny:        convert(policy.Deny),
+	}, nil
+}
+
+// getInitializerType returns a short name of the content initializer, e.g. git or prebuild
+func getInitializerType(initializer *csapi.WorkspaceInitializer) string {
+	switch initializer.Spec.(type) {
+	case *csapi.WorkspaceInitializer_Empty:
+		return "empty"
+	case *csapi.WorkspaceInitializer_Git:
+		return "git"
+	case *csapi.WorkspaceInitializer_Snapshot:
+		return "snapshot"
+	case *csapi.WorkspaceInitializer_Prebuild:
+		return "prebuild"
+	case *csapi.WorkspaceInitializer_Composite:
+		return "composite"
+	case *csapi.WorkspaceInitializer_Download:
+		return "download"
+	case *csapi.WorkspaceInitializer_Backup:
+		return "backup"
+	case *csapi.WorkspaceInitializer_Oci:
+		return "oci"
+	default:
+		return "unknown"
+	}
+}
+
 func shouldDisableRemoteStorage(pod *corev1.Pod) bool {
 	wso := &workspaceObjects{Pod: pod}
 	tpe, err := wso.WorkspaceType()
@@ -798,6 +923,21 @@ func shouldDisableRemoteStorage(pod *corev1.Pod) bool {
 	}
 }
 
+// getPeriodicBackupInterval returns how often ws-daemon backs up the content of a running workspace, or nil if it should not.
+// Only regular workspaces get periodic backups: all other workspaces are either short-lived or never restored from a backup.
+func getPeriodicBackupInterval(pod *corev1.Pod, cfg *Configuration, fullWorkspaceBackup bool) *durationpb.Duration {
+	if cfg.PeriodicBackupInterval == 0 || fullWorkspaceBackup {
+		return nil
+	}
+
+	wso := &workspaceObjects{Pod: pod}
+	tpe, err := wso.WorkspaceType()
+	if err != nil || tpe != api.WorkspaceType_REGULAR {
+		return nil
+	}
+	return durationpb.New(time.Duration(cfg.PeriodicBackupInterval))
+}
+
 // finalizeWorkspaceContent talks to a ws-daemon daemon on the node of the pod and creates a backup of the workspace content.
 func (m *Monitor) finalizeWorkspaceContent(ctx context.Context, wso *workspaceObjects) {
 	span, ctx := tracing.FromContext(ctx, "finalizeWorkspaceContent")
@@ -938,7 +1078,7 @@ func (m *Monitor) finalizeWorkspaceContent(ctx context.Context, wso *workspaceOb
 			st.Code() == codes.Unavailable ||
 			st.Code() == codes.Canceled {
 			// service is currently unavailable or we did not finish in time - let's wait some time and try again
-			time.Sleep(wsdaemonRetryInterval)
+			<-m.act.after(wsdaemonRetryInterval)
 			continue
 		}
 
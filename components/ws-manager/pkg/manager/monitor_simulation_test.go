@@ -0,0 +1,622 @@
+// Copyright (c) 2021 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package manager
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/xerrors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	grpc_status "google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	corev1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/apimachinery/pkg/watch"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	ctesting "github.com/gitpod-io/gitpod/common-go/testing"
+	"github.com/gitpod-io/gitpod/common-go/util"
+	csapi "github.com/gitpod-io/gitpod/content-service/api"
+	"github.com/gitpod-io/gitpod/content-service/pkg/layer"
+	"github.com/gitpod-io/gitpod/content-service/pkg/storage"
+	wsdaemon "github.com/gitpod-io/gitpod/ws-daemon/api"
+	"github.com/gitpod-io/gitpod/ws-manager/api"
+)
+
+// The monitor simulation replays event traces (the format written to the event trace log) against a monitor which
+// runs on a fake Kubernetes client, a fake clock and a fake ws-daemon. For every step of the trace the golden file
+// lists what the monitor did in response: the Kubernetes modifications, ws-daemon calls and status updates.
+//
+// To add a new scenario, configure the event trace log (eventTraceLog in the ws-manager config), reproduce the
+// behaviour and copy the resulting log to testdata/simulation_<name>.trace.jsonl. Then add a
+// testdata/simulation_<name>.json fixture which references that trace and run the test with -update.
+
+// simulationFixture configures a single simulation run
+type simulationFixture struct {
+	// Trace is the event trace log to replay, relative to the testdata directory
+	Trace string `json:"trace"`
+	// WorkspaceDaemon lists the gRPC status codes the fake ws-daemon responds with per method,
+	// e.g. {"DisposeWorkspace": ["UNAVAILABLE"]}. Once a list is exhausted, all calls succeed.
+	WorkspaceDaemon map[string][]codes.Code `json:"wsdaemon,omitempty"`
+	// Advance lets time pass after the trace was replayed, e.g. to trigger delayed content finalization
+	Advance []util.Duration `json:"advance,omitempty"`
+}
+
+type simulationResult struct {
+	Steps []simulationStep `json:"steps"`
+}
+
+type simulationStep struct {
+	Time    string   `json:"time"`
+	Event   string   `json:"event,omitempty"`
+	Advance string   `json:"advance,omitempty"`
+	Actions []string `json:"actions,omitempty"`
+	Error   string   `json:"error,omitempty"`
+}
+
+func TestMonitorSimulation(t *testing.T) {
+	test := ctesting.FixtureTest{
+		T:    t,
+		Path: "testdata/simulation_*.json",
+		Test: func(t *testing.T, input interface{}) interface{} {
+			fixture := input.(*simulationFixture)
+
+			trace, err := loadEventTrace(filepath.Join("testdata", fixture.Trace))
+			if err != nil {
+				t.Errorf("cannot load event trace: %v", err)
+				return nil
+			}
+			if len(trace) == 0 {
+				t.Errorf("event trace %s is empty", fixture.Trace)
+				return nil
+			}
+
+			sim, err := newMonitorSimulation(t, trace[0].Time, fixture.WorkspaceDaemon)
+			if err != nil {
+				t.Errorf("cannot create simulation: %v", err)
+				return nil
+			}
+
+			var result simulationResult
+			for _, entry := range trace {
+				step, err := sim.Replay(entry)
+				if err != nil {
+					t.Errorf("cannot replay event trace entry: %v", err)
+					return nil
+				}
+				result.Steps = append(result.Steps, *step)
+			}
+			for _, d := range fixture.Advance {
+				result.Steps = append(result.Steps, *sim.Advance(time.Duration(d)))
+			}
+			return &result
+		},
+		Fixture: func() interface{} { return &simulationFixture{} },
+		Gold:    func() interface{} { return &simulationResult{} },
+	}
+	test.Run()
+}
+
+// loadEventTrace reads an event trace log as written by the monitor
+func loadEventTrace(fn string) ([]eventTraceEntry, error) {
+	f, err := os.Open(fn)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var res []eventTraceEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(strings.TrimSpace(string(line))) == 0 {
+			continue
+		}
+
+		var entry eventTraceEntry
+		err := json.Unmarshal(line, &entry)
+		if err != nil {
+			return nil, xerrors.Errorf("cannot unmarshal %s: %w", fn, err)
+		}
+		if entry.Objects.Pod == nil {
+			return nil, xerrors.Errorf("%s contains an entry without pod", fn)
+		}
+		res = append(res, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// monitorSimulation drives a monitor through an event trace
+type monitorSimulation struct {
+	Manager *Manager
+	Monitor *Monitor
+
+	clock     *clock.FakeClock
+	client    client.Client
+	rawClient *k8sfake.Clientset
+
+	mu      sync.Mutex
+	actions []string
+	timers  []simulationTimer
+
+	// busy counts the goroutines the monitor started which are neither done nor waiting for a timer
+	busy sync.WaitGroup
+}
+
+// simulationTimer is a pending after() call of the monitor
+type simulationTimer struct {
+	At time.Time
+	C  chan time.Time
+}
+
+func newMonitorSimulation(t *testing.T, start string, wsdaemonResponses map[string][]codes.Code) (*monitorSimulation, error) {
+	startTime, err := time.Parse(time.RFC3339Nano, start)
+	if err != nil {
+		return nil, xerrors.Errorf("cannot parse trace time: %w", err)
+	}
+
+	// the integration tests disable the ws-daemon retry interval, but the simulation replays the timing of production
+	retryInterval := wsdaemonRetryInterval
+	wsdaemonRetryInterval = 5 * time.Second
+	t.Cleanup(func() { wsdaemonRetryInterval = retryInterval })
+
+	sim := &monitorSimulation{
+		clock:     clock.NewFakeClock(startTime),
+		client:    ctrlfake.NewClientBuilder().WithScheme(scheme).Build(),
+		rawClient: k8sfake.NewSimpleClientset(),
+	}
+
+	m, err := New(forTestingOnlyManagerConfig(), &recordingClient{Client: sim.client, record: sim.record}, sim.rawClient, &layer.Provider{Storage: &storage.PresignedNoopStorage{}})
+	if err != nil {
+		return nil, err
+	}
+	// we don't have propr DNS resolution and network access - and we cannot mock it
+	m.Config.InitProbe.Disabled = true
+	m.clock = sim.clock
+	m.wsdaemonClient = &fakeWorkspaceDaemon{Responses: wsdaemonResponses, record: sim.record}
+	m.OnChange = func(ctx context.Context, status *api.WorkspaceStatus) {
+		sim.record(fmt.Sprintf("onChange: phase=%s message=%q", status.Phase, status.Message))
+	}
+	sim.Manager = m
+
+	mon, err := m.CreateMonitor()
+	if err != nil {
+		return nil, err
+	}
+	mon.OnError = func(err error) {
+		sim.record(fmt.Sprintf("onError: %v", err))
+	}
+	t.Cleanup(mon.Stop)
+	mon.act = &simulationActor{actingManager: mon.act, sim: sim}
+	sim.Monitor = mon
+
+	return sim, nil
+}
+
+// Replay lets time pass until the entry was recorded, updates the Kubernetes objects and hands the
+// pod event to the monitor.
+func (sim *monitorSimulation) Replay(entry eventTraceEntry) (*simulationStep, error) {
+	ts, err := time.Parse(time.RFC3339Nano, entry.Time)
+	if err != nil {
+		return nil, xerrors.Errorf("cannot parse trace time: %w", err)
+	}
+	if ts.After(sim.clock.Now()) {
+		sim.clock.SetTime(ts)
+	}
+	sim.housekeeping()
+
+	pod := entry.Objects.Pod.DeepCopy()
+	if pod.Annotations[workspaceInitializerAnnotation] == "[redacted]" {
+		// The event trace log redacts the initializer. The fake ws-daemon does not care which one we use,
+		// but the monitor needs to be able to unmarshal it.
+		init, err := proto.Marshal(&csapi.WorkspaceInitializer{Spec: &csapi.WorkspaceInitializer_Empty{Empty: &csapi.EmptyInitializer{}}})
+		if err != nil {
+			return nil, err
+		}
+		pod.Annotations[workspaceInitializerAnnotation] = base64.StdEncoding.EncodeToString(init)
+	}
+
+	ctx := context.Background()
+	evtType := entry.Type
+	if evtType == "" {
+		evtType = watch.Modified
+	}
+	if evtType == watch.Deleted {
+		err = sim.remove(ctx, pod)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		err = sim.upsert(ctx, pod)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, obj := range []struct {
+		Name    string
+		Service *corev1.Service
+	}{
+		{getTheiaServiceName(pod.Annotations[servicePrefixAnnotation]), entry.Objects.TheiaService},
+		{getPortsServiceName(pod.Annotations[servicePrefixAnnotation]), entry.Objects.PortsService},
+	} {
+		if obj.Service == nil {
+			err = sim.remove(ctx, &corev1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: pod.Namespace, Name: obj.Name}})
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+		err = sim.upsert(ctx, obj.Service.DeepCopy())
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, evt := range entry.Objects.Events {
+		evt := evt.DeepCopy()
+		evt.ResourceVersion = ""
+		_, err = sim.rawClient.CoreV1().Events(evt.Namespace).Update(ctx, evt, metav1.UpdateOptions{})
+		if k8serr.IsNotFound(err) {
+			_, err = sim.rawClient.CoreV1().Events(evt.Namespace).Create(ctx, evt, metav1.CreateOptions{})
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = sim.Monitor.onPodEvent(watch.Event{Type: evtType, Object: pod})
+	step := sim.collect()
+	step.Event = fmt.Sprintf("%s %s", evtType, pod.Name)
+	if err != nil {
+		step.Error = err.Error()
+	}
+	return step, nil
+}
+
+// Advance lets time pass without any new pod event
+func (sim *monitorSimulation) Advance(d time.Duration) *simulationStep {
+	sim.clock.Step(d)
+	sim.housekeeping()
+
+	step := sim.collect()
+	step.Advance = d.String()
+	return step
+}
+
+// housekeeping fires the timers which are due, gives them a chance to act and runs the periodic timeout check
+func (sim *monitorSimulation) housekeeping() {
+	for sim.fireTimers() {
+		sim.settle()
+	}
+
+	err := sim.Monitor.markTimedoutWorkspaces(context.Background())
+	if err != nil {
+		sim.record(fmt.Sprintf("onError: %v", err))
+	}
+}
+
+// upsert creates or updates an object with the trace's version of it
+func (sim *monitorSimulation) upsert(ctx context.Context, obj client.Object) error {
+	existing := newObjectOfKind(obj)
+	err := sim.client.Get(ctx, client.ObjectKeyFromObject(obj), existing)
+	if k8serr.IsNotFound(err) {
+		obj.SetResourceVersion("")
+		return sim.client.Create(ctx, obj)
+	}
+	if err != nil {
+		return err
+	}
+
+	obj.SetResourceVersion(existing.GetResourceVersion())
+	return sim.client.Update(ctx, obj)
+}
+
+// remove deletes an object for good, i.e. irrespective of its finalizers
+func (sim *monitorSimulation) remove(ctx context.Context, obj client.Object) error {
+	existing := newObjectOfKind(obj)
+	err := sim.client.Get(ctx, client.ObjectKeyFromObject(obj), existing)
+	if k8serr.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	err = sim.client.Delete(ctx, existing)
+	if err != nil && !k8serr.IsNotFound(err) {
+		return err
+	}
+	if len(existing.GetFinalizers()) == 0 {
+		return nil
+	}
+
+	// the object is marked for deletion now - removing its finalizers lets it go
+	err = sim.client.Get(ctx, client.ObjectKeyFromObject(obj), existing)
+	if err != nil {
+		return err
+	}
+	existing.SetFinalizers(nil)
+	return sim.client.Update(ctx, existing)
+}
+
+// settle waits until the monitor has stopped acting, i.e. all goroutines it started are done or wait for a timer
+func (sim *monitorSimulation) settle() {
+	sim.busy.Wait()
+}
+
+// fireTimers wakes the goroutines whose timers are due and reports whether there were any
+func (sim *monitorSimulation) fireTimers() bool {
+	now := sim.clock.Now()
+
+	sim.mu.Lock()
+	var due, pending []simulationTimer
+	for _, t := range sim.timers {
+		if t.At.After(now) {
+			pending = append(pending, t)
+			continue
+		}
+		due = append(due, t)
+	}
+	sim.timers = pending
+	sim.mu.Unlock()
+
+	for _, t := range due {
+		// the goroutine waiting for this timer is busy again until it is done or waits for the next timer
+		sim.busy.Add(1)
+		t.C <- now
+	}
+	return len(due) > 0
+}
+
+// collect waits for the monitor to settle and returns all actions recorded since the last call
+func (sim *monitorSimulation) collect() *simulationStep {
+	sim.settle()
+
+	sim.mu.Lock()
+	actions := sim.actions
+	sim.actions = nil
+	sim.mu.Unlock()
+
+	// actions happen in parallel, hence their order is not deterministic
+	sort.Strings(actions)
+	return &simulationStep{
+		Time:    sim.clock.Now().UTC().Format(time.RFC3339Nano),
+		Actions: actions,
+	}
+}
+
+func (sim *monitorSimulation) record(action string) {
+	sim.mu.Lock()
+	sim.actions = append(sim.actions, action)
+	sim.mu.Unlock()
+}
+
+// simulationActor lets the simulation keep track of the goroutines the monitor starts and replaces
+// timers with ones the simulation fires explicitly, so that we know when the monitor has settled.
+type simulationActor struct {
+	actingManager
+	sim *monitorSimulation
+}
+
+func (a *simulationActor) async(f func()) {
+	a.sim.busy.Add(1)
+	go func() {
+		defer a.sim.busy.Done()
+		f()
+	}()
+}
+
+// after must only be called from goroutines started using async which wait for the returned channel right away
+func (a *simulationActor) after(d time.Duration) <-chan time.Time {
+	c := make(chan time.Time, 1)
+	a.sim.mu.Lock()
+	a.sim.timers = append(a.sim.timers, simulationTimer{At: a.sim.clock.Now().Add(d), C: c})
+	a.sim.mu.Unlock()
+
+	// the calling goroutine is idle until the timer fires
+	a.sim.busy.Done()
+	return c
+}
+
+// recordingClient records all modifications made through it
+type recordingClient struct {
+	client.Client
+	record func(action string)
+}
+
+func (c *recordingClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	err := c.Client.Create(ctx, obj, opts...)
+	if err != nil {
+		return err
+	}
+	c.record(fmt.Sprintf("k8s: create %s %s", kindOf(obj), obj.GetName()))
+	return nil
+}
+
+func (c *recordingClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	old := newObjectOfKind(obj)
+	err := c.Client.Get(ctx, client.ObjectKeyFromObject(obj), old)
+	if err != nil {
+		old = nil
+	}
+
+	err = c.Client.Update(ctx, obj, opts...)
+	if err != nil {
+		return err
+	}
+	c.record(fmt.Sprintf("k8s: update %s %s%s", kindOf(obj), obj.GetName(), describeMetadataChange(old, obj)))
+	return nil
+}
+
+func (c *recordingClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	err := c.Client.Patch(ctx, obj, patch, opts...)
+	if err != nil {
+		return err
+	}
+	c.record(fmt.Sprintf("k8s: patch %s %s", kindOf(obj), obj.GetName()))
+	return nil
+}
+
+func (c *recordingClient) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+	err := c.Client.Delete(ctx, obj, opts...)
+	if err != nil {
+		return err
+	}
+
+	var do client.DeleteOptions
+	do.ApplyOptions(opts)
+	var gracePeriod string
+	if do.GracePeriodSeconds != nil {
+		gracePeriod = fmt.Sprintf(" gracePeriod=%ds", *do.GracePeriodSeconds)
+	}
+	c.record(fmt.Sprintf("k8s: delete %s %s%s", kindOf(obj), obj.GetName(), gracePeriod))
+	return nil
+}
+
+func (c *recordingClient) DeleteAllOf(ctx context.Context, obj client.Object, opts ...client.DeleteAllOfOption) error {
+	err := c.Client.DeleteAllOf(ctx, obj, opts...)
+	if err != nil {
+		return err
+	}
+	c.record(fmt.Sprintf("k8s: delete all of %s", kindOf(obj)))
+	return nil
+}
+
+// newObjectOfKind returns a new, empty object of the same kind as obj
+func newObjectOfKind(obj client.Object) client.Object {
+	return reflect.New(reflect.TypeOf(obj).Elem()).Interface().(client.Object)
+}
+
+func kindOf(obj client.Object) string {
+	return reflect.TypeOf(obj).Elem().Name()
+}
+
+// describeMetadataChange lists the annotations and finalizers which were added or removed
+func describeMetadataChange(old, obj client.Object) string {
+	var (
+		oldAnnotations map[string]string
+		oldFinalizers  []string
+	)
+	if old != nil {
+		oldAnnotations = old.GetAnnotations()
+		oldFinalizers = old.GetFinalizers()
+	}
+
+	var changes []string
+	for k, v := range obj.GetAnnotations() {
+		if ov, ok := oldAnnotations[k]; ok && ov == v {
+			continue
+		}
+		changes = append(changes, fmt.Sprintf("+annotation %s=%s", k, v))
+	}
+	for k := range oldAnnotations {
+		if _, ok := obj.GetAnnotations()[k]; !ok {
+			changes = append(changes, fmt.Sprintf("-annotation %s", k))
+		}
+	}
+
+	has := func(l []string, s string) bool {
+		for _, e := range l {
+			if e == s {
+				return true
+			}
+		}
+		return false
+	}
+	for _, f := range obj.GetFinalizers() {
+		if !has(oldFinalizers, f) {
+			changes = append(changes, fmt.Sprintf("+finalizer %s", f))
+		}
+	}
+	for _, f := range oldFinalizers {
+		if !has(obj.GetFinalizers(), f) {
+			changes = append(changes, fmt.Sprintf("-finalizer %s", f))
+		}
+	}
+
+	if len(changes) == 0 {
+		return ""
+	}
+	sort.Strings(changes)
+	return ": " + strings.Join(changes, ", ")
+}
+
+// fakeWorkspaceDaemon records all calls and responds as configured
+type fakeWorkspaceDaemon struct {
+	Responses map[string][]codes.Code
+
+	mu     sync.Mutex
+	record func(action string)
+}
+
+func (d *fakeWorkspaceDaemon) respond(method, req string) error {
+	d.mu.Lock()
+	code := codes.OK
+	if rs := d.Responses[method]; len(rs) > 0 {
+		code = rs[0]
+		d.Responses[method] = rs[1:]
+	}
+	d.mu.Unlock()
+
+	d.record(fmt.Sprintf("wsdaemon: %s %s: %s", method, req, code))
+	if code == codes.OK {
+		return nil
+	}
+	return grpc_status.Error(code, "simulated failure")
+}
+
+func (d *fakeWorkspaceDaemon) InitWorkspace(ctx context.Context, in *wsdaemon.InitWorkspaceRequest, opts ...grpc.CallOption) (*wsdaemon.InitWorkspaceResponse, error) {
+	err := d.respond("InitWorkspace", fmt.Sprintf("id=%s fullWorkspaceBackup=%v remoteStorageDisabled=%v", in.Id, in.FullWorkspaceBackup, in.RemoteStorageDisabled))
+	if err != nil {
+		return nil, err
+	}
+	return &wsdaemon.InitWorkspaceResponse{}, nil
+}
+
+func (d *fakeWorkspaceDaemon) WaitForInit(ctx context.Context, in *wsdaemon.WaitForInitRequest, opts ...grpc.CallOption) (*wsdaemon.WaitForInitResponse, error) {
+	err := d.respond("WaitForInit", fmt.Sprintf("id=%s", in.Id))
+	if err != nil {
+		return nil, err
+	}
+	return &wsdaemon.WaitForInitResponse{}, nil
+}
+
+func (d *fakeWorkspaceDaemon) TakeSnapshot(ctx context.Context, in *wsdaemon.TakeSnapshotRequest, opts ...grpc.CallOption) (*wsdaemon.TakeSnapshotResponse, error) {
+	err := d.respond("TakeSnapshot", fmt.Sprintf("id=%s", in.Id))
+	if err != nil {
+		return nil, err
+	}
+	return &wsdaemon.TakeSnapshotResponse{Url: fmt.Sprintf("snapshot://%s", in.Id)}, nil
+}
+
+func (d *fakeWorkspaceDaemon) DisposeWorkspace(ctx context.Context, in *wsdaemon.DisposeWorkspaceRequest, opts ...grpc.CallOption) (*wsdaemon.DisposeWorkspaceResponse, error) {
+	err := d.respond("DisposeWorkspace", fmt.Sprintf("id=%s backup=%v backupLogs=%v", in.Id, in.Backup, in.BackupLogs))
+	if err != nil {
+		return nil, err
+	}
+	return &wsdaemon.DisposeWorkspaceResponse{GitStatus: &csapi.GitStatus{Branch: "main"}}, nil
+}
+
+var _ wsdaemon.WorkspaceContentServiceClient = &fakeWorkspaceDaemon{}
@@ -134,4 +134,13 @@ func (r *actRecorder) modifyFinalizer(ctx context.Context, workspaceID string, f
 	return nil
 }
 
+func (r *actRecorder) after(d time.Duration) <-chan time.Time {
+	// we never let time pass while recording actions
+	return make(chan time.Time)
+}
+
+func (r *actRecorder) async(f func()) {
+	go f()
+}
+
 var _ actingManager = &actRecorder{}
@@ -14,6 +14,7 @@ import (
 
 	"github.com/sirupsen/logrus"
 	"golang.org/x/xerrors"
+	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -291,6 +292,8 @@ func (m *Manager) getWorkspaceStatus(wso workspaceObjects) (*api.WorkspaceStatus
 	if err != nil {
 		return nil, xerrors.Errorf("cannot get workspace status: %w", err)
 	}
+	status.Conditions.Services = getServiceConditions(wso.Pod)
+	status.StartTimings = getStartTimings(wso.Pod)
 
 	exposedPorts := []*api.PortSpec{}
 	if wso.PortsService != nil {
@@ -302,6 +305,7 @@ func (m *Manager) getWorkspaceStatus(wso workspaceObjects) (*api.WorkspaceStatus
 				Target:     uint32(p.TargetPort.IntValue()),
 				Visibility: portNameToVisibility(p.Name),
 				Url:        service.Annotations[fmt.Sprintf("gitpod/port-url-%d", p.Port)],
+				Protocol:   appProtocolToPortProtocol(p.AppProtocol),
 			}
 
 			// enforce the cannonical form where target defaults to port
@@ -376,6 +380,9 @@ func (m *Manager) extractStatusFromPod(result *api.WorkspaceStatus, wso workspac
 	}
 	if wso.IsWorkspaceHeadless() {
 		for _, cs := range pod.Status.ContainerStatuses {
+			if isServiceContainer(cs.Name) {
+				continue
+			}
 			if cs.State.Terminated != nil && cs.State.Terminated.Message != "" {
 				result.Conditions.HeadlessTaskFailed = cs.State.Terminated.Message
 				break
@@ -466,6 +473,10 @@ func (m *Manager) extractStatusFromPod(result *api.WorkspaceStatus, wso workspac
 		result.Phase = api.WorkspacePhase_PENDING
 		result.Message = "pod is pending"
 		return nil
+	} else if isCompletedHeadless(&wso) {
+		result.Phase = api.WorkspacePhase_STOPPING
+		result.Message = "headless workspace is stopping"
+		return nil
 	} else if status.Phase == corev1.PodRunning {
 		if firstUserActivity, ok := wso.Pod.Annotations[firstUserActivityAnnotation]; ok {
 			t, err := time.Parse(time.RFC3339Nano, firstUserActivity)
@@ -477,6 +488,11 @@ func (m *Manager) extractStatusFromPod(result *api.WorkspaceStatus, wso workspac
 		}
 
 		for _, cs := range status.ContainerStatuses {
+			if isServiceContainer(cs.Name) {
+				// services don't determine the workspace phase - their state is reported in the service conditions
+				continue
+			}
+
 			// containers that were terminated are not ready, but may have been
 			if cs.State.Terminated != nil && cs.State.Terminated.ExitCode == containerUnknownExitCode {
 				// the container was stopped for an unknown reason.
@@ -544,10 +560,6 @@ func (m *Manager) extractStatusFromPod(result *api.WorkspaceStatus, wso workspac
 		result.Phase = api.WorkspacePhase_INITIALIZING
 		result.Message = "workspace initializer is running"
 		return nil
-	} else if isCompletedHeadless(&wso) {
-		result.Phase = api.WorkspacePhase_STOPPING
-		result.Message = "headless workspace is stopping"
-		return nil
 	} else if status.Phase == corev1.PodUnknown {
 		result.Phase = api.WorkspacePhase_UNKNOWN
 		result.Message = "Kubernetes reports workspace phase as unknown"
@@ -568,6 +580,14 @@ func (m *Manager) extractStatusFromPod(result *api.WorkspaceStatus, wso workspac
 	return nil
 }
 
+// isImageNotVerified returns true if a pull error of the workspace image stems from registry-facade refusing to serve it,
+// because its base image is not signed by a trusted key. registry-facade answers such requests with the IMAGE_NOT_VERIFIED
+// error code. We must not rely on the 403 Forbidden status alone, as other failures, e.g. expired pre-signed layer URLs,
+// produce that status as well.
+func isImageNotVerified(msg string) bool {
+	return strings.Contains(msg, "IMAGE_NOT_VERIFIED")
+}
+
 // extractFailure returns a pod failure reason and possibly a phase. If phase is nil then
 // one should extract the phase themselves. If the pod has not failed, this function returns "", nil.
 func extractFailure(wso workspaceObjects) (string, *api.WorkspacePhase) {
@@ -601,10 +621,18 @@ func extractFailure(wso workspaceObjects) (string, *api.WorkspacePhase) {
 					c := api.WorkspacePhase_CREATING
 					res = &c
 				}
+				if cs.Name == "workspace" && isImageNotVerified(cs.State.Waiting.Message) {
+					return fmt.Sprintf("image not verified: the base image is not signed by a trusted key (%s)", cs.State.Waiting.Message), res
+				}
 				return fmt.Sprintf("cannot pull image: %s", cs.State.Waiting.Message), res
 			}
 		}
 
+		if isServiceContainer(cs.Name) {
+			// A terminated service does not fail the workspace - it's reported in the service conditions.
+			continue
+		}
+
 		terminationState := cs.State.Terminated
 		if terminationState == nil {
 			terminationState = cs.LastTerminationState.Terminated
@@ -714,7 +742,151 @@ func isPodBeingDeleted(pod *corev1.Pod) bool {
 
 // isCompletedHeadless returns true if the pod is a headless workspace and either succeeded or failed (e.g., ran to completion)
 func isCompletedHeadless(wso *workspaceObjects) bool {
-	return wso.IsWorkspaceHeadless() && (wso.Pod.Status.Phase == corev1.PodSucceeded || wso.Pod.Status.Phase == corev1.PodFailed)
+	if !wso.IsWorkspaceHeadless() {
+		return false
+	}
+	if wso.Pod.Status.Phase == corev1.PodSucceeded || wso.Pod.Status.Phase == corev1.PodFailed {
+		return true
+	}
+
+	// Service containers keep the pod running even though the headless workspace is done already
+	if wso.Pod.Status.Phase != corev1.PodRunning {
+		return false
+	}
+	var hasServices bool
+	for _, c := range wso.Pod.Spec.Containers {
+		if isServiceContainer(c.Name) {
+			hasServices = true
+			break
+		}
+	}
+	if !hasServices {
+		return false
+	}
+	for _, cs := range wso.Pod.Status.ContainerStatuses {
+		if cs.Name == "workspace" {
+			return cs.State.Terminated != nil
+		}
+	}
+	return false
+}
+
+// getServiceConditions reports the state of all service containers of a workspace pod
+func getServiceConditions(pod *corev1.Pod) []*api.ServiceCondition {
+	var res []*api.ServiceCondition
+	for _, c := range pod.Spec.Containers {
+		if !isServiceContainer(c.Name) {
+			continue
+		}
+
+		cond := &api.ServiceCondition{
+			Name:    strings.TrimPrefix(c.Name, serviceContainerPrefix),
+			Ready:   api.WorkspaceConditionBool_FALSE,
+			Message: "service is not running yet",
+		}
+		for _, cs := range pod.Status.ContainerStatuses {
+			if cs.Name != c.Name {
+				continue
+			}
+
+			switch {
+			case cs.Ready:
+				cond.Ready = api.WorkspaceConditionBool_TRUE
+				cond.Message = ""
+			case cs.State.Waiting != nil:
+				cond.Message = strings.TrimSuffix(fmt.Sprintf("%s: %s", cs.State.Waiting.Reason, cs.State.Waiting.Message), ": ")
+			case cs.State.Terminated != nil:
+				cond.Message = strings.TrimSuffix(fmt.Sprintf("service terminated with exit code %d: %s", cs.State.Terminated.ExitCode, cs.State.Terminated.Message), ": ")
+			case cs.State.Running != nil:
+				cond.Message = "service is not ready yet"
+			}
+			break
+		}
+		res = append(res, cond)
+	}
+	return res
+}
+
+// getStartTimings computes the duration of the workspace startup phases from the pod status and the
+// annotations the monitor places during content initialization and readiness probing.
+func getStartTimings(pod *corev1.Pod) *api.WorkspaceStartTimings {
+	var (
+		res        api.WorkspaceStartTimings
+		hasTimings bool
+		createdAt  = pod.CreationTimestamp.Time
+		readyAt    time.Time
+	)
+	since := func(start, end time.Time) *durationpb.Duration {
+		hasTimings = true
+		d := end.Sub(start)
+		if d < 0 {
+			// timestamps in the pod status have second precision only
+			d = 0
+		}
+		return durationpb.New(d)
+	}
+
+	var scheduledAt time.Time
+	for _, c := range pod.Status.Conditions {
+		if c.Type == corev1.PodScheduled && c.Status == corev1.ConditionTrue {
+			scheduledAt = c.LastTransitionTime.Time
+			break
+		}
+	}
+	if !scheduledAt.IsZero() {
+		res.Scheduling = since(createdAt, scheduledAt)
+	}
+
+	var containerStartedAt time.Time
+	for _, cs := range pod.Status.ContainerStatuses {
+		if cs.Name != "workspace" {
+			continue
+		}
+		if cs.State.Running != nil {
+			containerStartedAt = cs.State.Running.StartedAt.Time
+		} else if cs.State.Terminated != nil {
+			containerStartedAt = cs.State.Terminated.StartedAt.Time
+		}
+		break
+	}
+	if !scheduledAt.IsZero() && !containerStartedAt.IsZero() {
+		res.ImagePull = since(scheduledAt, containerStartedAt)
+	}
+
+	if rawInit, ok := pod.Annotations[contentInitAnnotation]; ok {
+		var initStatus workspaceContentInitStatus
+		err := json.Unmarshal([]byte(rawInit), &initStatus)
+		if err != nil {
+			log.WithFields(wsk8s.GetOWIFromObject(&pod.ObjectMeta)).WithError(err).Warn("cannot unmarshal content init status")
+		} else {
+			res.ContentInit = since(initStatus.StartedAt, initStatus.FinishedAt)
+			res.Initializer = initStatus.Initializer
+			res.ContentBytes = initStatus.ContentSize
+			readyAt = initStatus.FinishedAt
+		}
+	}
+
+	if rawReady, ok := pod.Annotations[ideReadyAnnotation]; ok {
+		ideReadyAt, err := time.Parse(time.RFC3339Nano, rawReady)
+		if err != nil {
+			log.WithFields(wsk8s.GetOWIFromObject(&pod.ObjectMeta)).WithError(err).Warn("cannot parse IDE ready time")
+		} else {
+			if !containerStartedAt.IsZero() {
+				res.IdeReady = since(containerStartedAt, ideReadyAt)
+			}
+			if ideReadyAt.After(readyAt) {
+				readyAt = ideReadyAt
+			}
+			// The IDE ready annotation is placed once the workspace is ready as a whole,
+			// i.e. the content is initialized, too.
+			res.Total = since(createdAt, readyAt)
+		}
+	}
+
+	if !hasTimings {
+		return nil
+	}
+	return &res
 }
 
 type activity string
@@ -748,7 +920,7 @@ func (m *Manager) isWorkspaceTimedOut(wso workspaceObjects) (reason string, err
 
 	decide := func(start time.Time, timeout util.Duration, activity activity) (string, error) {
 		td := time.Duration(timeout)
-		inactivity := time.Since(start)
+		inactivity := m.clock.Since(start)
 		if inactivity < td {
 			return "", nil
 		}
@@ -14,6 +14,7 @@ import (
 
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/clock"
 
 	ctesting "github.com/gitpod-io/gitpod/common-go/testing"
 	"github.com/gitpod-io/gitpod/common-go/util"
@@ -51,6 +52,7 @@ func TestIsWorkspaceTimedout(t *testing.T) {
 						Interrupted:         util.Duration(5 * time.Minute),
 					},
 				},
+				clock: clock.RealClock{},
 			}
 			if fixture.Activity != "" {
 				dt, err := time.ParseDuration(fixture.Activity)
@@ -26,9 +26,9 @@ import (
 //    Because we need to modify package internal state
 //
 
-// forTestingOnlyGetManager creates a workspace manager instance for testing purposes
-func forTestingOnlyGetManager(t *testing.T, objects ...client.Object) *Manager {
-	config := Configuration{
+// forTestingOnlyManagerConfig produces a valid manager configuration for testing purposes
+func forTestingOnlyManagerConfig() Configuration {
+	return Configuration{
 		Namespace:                "default",
 		SchedulerName:            "workspace-scheduler",
 		SeccompProfile:           "localhost/workspace-default",
@@ -63,6 +63,11 @@ func forTestingOnlyGetManager(t *testing.T, objects ...client.Object) *Manager {
 			Interrupted:         util.Duration(5 * time.Minute),
 		},
 	}
+}
+
+// forTestingOnlyGetManager creates a workspace manager instance for testing purposes
+func forTestingOnlyGetManager(t *testing.T, objects ...client.Object) *Manager {
+	config := forTestingOnlyManagerConfig()
 
 	testEnv := &envtest.Environment{}
 	cfg, err := testEnv.Start()
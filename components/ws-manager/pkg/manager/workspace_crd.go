@@ -0,0 +1,281 @@
+// Copyright (c) 2021 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package manager
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/xerrors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/client-go/util/workqueue"
+
+	wsk8s "github.com/gitpod-io/gitpod/common-go/kubernetes"
+	"github.com/gitpod-io/gitpod/common-go/log"
+	"github.com/gitpod-io/gitpod/common-go/tracing"
+	"github.com/gitpod-io/gitpod/ws-manager/api"
+	workspacev1 "github.com/gitpod-io/gitpod/ws-manager/api/crd/v1"
+)
+
+const (
+	// workspaceCRDMirrorWorkers is the number of workers which write workspace status to the custom resources
+	workspaceCRDMirrorWorkers = 5
+
+	// workspaceCRDMirrorMaxRetries is the number of times we retry writing a workspace status before we give up on it
+	workspaceCRDMirrorMaxRetries = 5
+)
+
+// workspaceCRDMirror writes workspace status to the Workspace custom resources in the background, so that the
+// Kubernetes API calls do not hold up the status updates. The writes are coalesced per workspace: while the status
+// of a workspace waits to be written, a newer status replaces it and only the latest one is written.
+type workspaceCRDMirror struct {
+	write func(ctx context.Context, status *api.WorkspaceStatus) error
+
+	queue   workqueue.RateLimitingInterface
+	pending map[string]*api.WorkspaceStatus
+	mu      sync.Mutex
+}
+
+func newWorkspaceCRDMirror(write func(ctx context.Context, status *api.WorkspaceStatus) error) *workspaceCRDMirror {
+	return &workspaceCRDMirror{
+		write:   write,
+		queue:   workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+		pending: make(map[string]*api.WorkspaceStatus),
+	}
+}
+
+// Start starts a number of workers which write the status
+func (c *workspaceCRDMirror) Start(workers int) {
+	for i := 0; i < workers; i++ {
+		go func() {
+			for c.processNextItem() {
+			}
+		}()
+	}
+}
+
+// Stop stops the workers. Status which wasn't written yet is dropped.
+func (c *workspaceCRDMirror) Stop() {
+	c.queue.ShutDown()
+}
+
+// Mirror schedules writing the status of a workspace to its custom resource
+func (c *workspaceCRDMirror) Mirror(status *api.WorkspaceStatus) {
+	c.mu.Lock()
+	c.pending[status.Id] = status
+	c.mu.Unlock()
+
+	// the queue holds each workspace at most once, no matter how often we add it
+	c.queue.Add(status.Id)
+}
+
+func (c *workspaceCRDMirror) processNextItem() bool {
+	key, shutdown := c.queue.Get()
+	if shutdown {
+		return false
+	}
+	defer c.queue.Done(key)
+	id := key.(string)
+
+	c.mu.Lock()
+	status, ok := c.pending[id]
+	delete(c.pending, id)
+	c.mu.Unlock()
+	if !ok {
+		return true
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), kubernetesOperationTimeout)
+	err := c.write(ctx, status)
+	cancel()
+	if err == nil {
+		c.queue.Forget(id)
+		return true
+	}
+
+	log := log.WithFields(log.OWI(status.Metadata.Owner, status.Metadata.MetaId, status.Id)).WithError(err)
+	if c.queue.NumRequeues(id) >= workspaceCRDMirrorMaxRetries {
+		log.Warn("cannot mirror workspace status to custom resource")
+		c.queue.Forget(id)
+		return true
+	}
+
+	log.Debug("cannot mirror workspace status to custom resource - will retry")
+	c.mu.Lock()
+	if _, newer := c.pending[id]; !newer {
+		c.pending[id] = status
+	}
+	c.mu.Unlock()
+	c.queue.AddRateLimited(id)
+	return true
+}
+
+// mirrorWorkspaceStatus writes the status of a workspace to its Workspace custom resource.
+// The custom resource is owned by the workspace pod, hence Kubernetes removes it once the pod is gone.
+func (m *Manager) mirrorWorkspaceStatus(ctx context.Context, status *api.WorkspaceStatus) (err error) {
+	span, ctx := tracing.FromContext(ctx, "mirrorWorkspaceStatus")
+	defer tracing.FinishSpan(span, &err)
+
+	if status.Runtime == nil || status.Runtime.PodName == "" {
+		return xerrors.Errorf("workspace status has no pod name")
+	}
+
+	return retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		var ws workspacev1.Workspace
+		err := m.Clientset.Get(ctx, types.NamespacedName{Namespace: m.Config.Namespace, Name: status.Id}, &ws)
+		if errors.IsNotFound(err) {
+			return m.createWorkspaceCRD(ctx, status)
+		}
+		if err != nil {
+			return err
+		}
+
+		updateWorkspaceCRD(&ws, status, m.clock.Now())
+		return m.Clientset.Status().Update(ctx, &ws)
+	})
+}
+
+// createWorkspaceCRD creates the Workspace custom resource for a workspace and sets its initial status
+func (m *Manager) createWorkspaceCRD(ctx context.Context, status *api.WorkspaceStatus) error {
+	if status.Phase == api.WorkspacePhase_STOPPED {
+		// The workspace is gone and so is the pod which would own the custom resource.
+		// There's no point in creating it now.
+		return nil
+	}
+
+	var pod corev1.Pod
+	err := m.Clientset.Get(ctx, types.NamespacedName{Namespace: m.Config.Namespace, Name: status.Runtime.PodName}, &pod)
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return xerrors.Errorf("cannot get workspace pod: %w", err)
+	}
+
+	ws := workspacev1.Workspace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      status.Id,
+			Namespace: m.Config.Namespace,
+			Labels: map[string]string{
+				wsk8s.WorkspaceIDLabel: status.Id,
+				wsk8s.OwnerLabel:       status.Metadata.Owner,
+				wsk8s.MetaIDLabel:      status.Metadata.MetaId,
+			},
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion: "v1",
+					Kind:       "Pod",
+					Name:       pod.Name,
+					UID:        pod.UID,
+				},
+			},
+		},
+	}
+	updateWorkspaceCRD(&ws, status, m.clock.Now())
+
+	// The status is a subresource and ignored during creation
+	wsStatus := ws.Status
+	err = m.Clientset.Create(ctx, &ws)
+	if err != nil {
+		return xerrors.Errorf("cannot create workspace custom resource: %w", err)
+	}
+	ws.Status = wsStatus
+	return m.Clientset.Status().Update(ctx, &ws)
+}
+
+// updateWorkspaceCRD makes a Workspace custom resource reflect the workspace status.
+// Conditions whose status changed get now as transition time.
+func updateWorkspaceCRD(ws *workspacev1.Workspace, status *api.WorkspaceStatus, now time.Time) {
+	ws.Spec = workspacev1.WorkspaceSpec{
+		Owner:       status.Metadata.Owner,
+		WorkspaceID: status.Metadata.MetaId,
+		Annotations: status.Metadata.Annotations,
+	}
+	if spec := status.Spec; spec != nil {
+		ws.Spec.Type = spec.Type.String()
+		ws.Spec.Headless = spec.Headless
+		ws.Spec.WorkspaceImage = spec.WorkspaceImage
+		ws.Spec.IDEImage = spec.IdeImage
+	}
+
+	s := &ws.Status
+	s.Phase = status.Phase.String()
+	s.Message = status.Message
+	if spec := status.Spec; spec != nil {
+		s.URL = spec.Url
+		s.Timeout = spec.Timeout
+		s.ExposedPorts = nil
+		for _, p := range spec.ExposedPorts {
+			s.ExposedPorts = append(s.ExposedPorts, workspacev1.PortSpec{
+				Port:       p.Port,
+				Target:     p.Target,
+				Visibility: strings.ToLower(strings.TrimPrefix(p.Visibility.String(), "PORT_VISIBILITY_")),
+				URL:        p.Url,
+			})
+		}
+	}
+	if status.Auth != nil {
+		// we deliberately do not mirror the owner token
+		s.Admission = status.Auth.Admission.String()
+	}
+	if status.Metadata.StartedAt != nil {
+		t := metav1.NewTime(status.Metadata.StartedAt.AsTime())
+		s.StartedAt = &t
+	}
+	if status.Runtime != nil {
+		s.NodeName = status.Runtime.NodeName
+		s.PodName = status.Runtime.PodName
+	}
+
+	conds := status.Conditions
+	if conds == nil {
+		conds = &api.WorkspaceConditions{}
+	}
+	s.Snapshot = conds.Snapshot
+	if conds.FirstUserActivity != nil {
+		t := metav1.NewTime(conds.FirstUserActivity.AsTime())
+		s.FirstUserActivity = &t
+	}
+
+	setStringCondition := func(tpe, val string) {
+		cond := metav1.Condition{Type: tpe, Status: metav1.ConditionFalse, Reason: "Unset", LastTransitionTime: metav1.NewTime(now)}
+		if val != "" {
+			cond.Status = metav1.ConditionTrue
+			cond.Reason = tpe
+			cond.Message = val
+		}
+		meta.SetStatusCondition(&s.Conditions, cond)
+	}
+	setBoolCondition := func(tpe string, val api.WorkspaceConditionBool) {
+		cond := metav1.Condition{Type: tpe, LastTransitionTime: metav1.NewTime(now)}
+		switch val {
+		case api.WorkspaceConditionBool_TRUE:
+			cond.Status = metav1.ConditionTrue
+			cond.Reason = tpe
+		case api.WorkspaceConditionBool_FALSE:
+			cond.Status = metav1.ConditionFalse
+			cond.Reason = "Unset"
+		default:
+			meta.RemoveStatusCondition(&s.Conditions, tpe)
+			return
+		}
+		meta.SetStatusCondition(&s.Conditions, cond)
+	}
+	setStringCondition(workspacev1.WorkspaceConditionFailed, conds.Failed)
+	setStringCondition(workspacev1.WorkspaceConditionTimeout, conds.Timeout)
+	setStringCondition(workspacev1.WorkspaceConditionHeadlessTaskFailed, conds.HeadlessTaskFailed)
+	setBoolCondition(workspacev1.WorkspaceConditionPullingImages, conds.PullingImages)
+	setBoolCondition(workspacev1.WorkspaceConditionServiceExists, conds.ServiceExists)
+	setBoolCondition(workspacev1.WorkspaceConditionFinalBackupComplete, conds.FinalBackupComplete)
+	setBoolCondition(workspacev1.WorkspaceConditionDeployed, conds.Deployed)
+	setBoolCondition(workspacev1.WorkspaceConditionNetworkNotReady, conds.NetworkNotReady)
+}
@@ -0,0 +1,128 @@
+// Copyright (c) 2021 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package manager
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/xerrors"
+	"k8s.io/apimachinery/pkg/api/meta"
+
+	ctesting "github.com/gitpod-io/gitpod/common-go/testing"
+	"github.com/gitpod-io/gitpod/ws-manager/api"
+	workspacev1 "github.com/gitpod-io/gitpod/ws-manager/api/crd/v1"
+)
+
+func TestUpdateWorkspaceCRD(t *testing.T) {
+	now := time.Date(2021, 9, 1, 10, 5, 0, 0, time.UTC)
+
+	test := ctesting.FixtureTest{
+		T:    t,
+		Path: "testdata/workspaceCRD_*.json",
+		Test: func(t *testing.T, input interface{}) interface{} {
+			status := input.(*api.WorkspaceStatus)
+
+			var ws workspacev1.Workspace
+			updateWorkspaceCRD(&ws, status, now)
+			return &ws
+		},
+		Fixture: func() interface{} { return &api.WorkspaceStatus{} },
+		Gold:    func() interface{} { return &workspacev1.Workspace{} },
+	}
+	test.Run()
+}
+
+func TestUpdateWorkspaceCRDTransitionTime(t *testing.T) {
+	var (
+		created = time.Date(2021, 9, 1, 10, 0, 0, 0, time.UTC)
+		stopped = created.Add(10 * time.Minute)
+		ws      workspacev1.Workspace
+	)
+	updateWorkspaceCRD(&ws, &api.WorkspaceStatus{
+		Metadata: &api.WorkspaceMetadata{},
+		Phase:    api.WorkspacePhase_RUNNING,
+		Conditions: &api.WorkspaceConditions{
+			Deployed:      api.WorkspaceConditionBool_TRUE,
+			ServiceExists: api.WorkspaceConditionBool_TRUE,
+		},
+	}, created)
+	updateWorkspaceCRD(&ws, &api.WorkspaceStatus{
+		Metadata: &api.WorkspaceMetadata{},
+		Phase:    api.WorkspacePhase_STOPPING,
+		Conditions: &api.WorkspaceConditions{
+			Deployed:      api.WorkspaceConditionBool_FALSE,
+			ServiceExists: api.WorkspaceConditionBool_TRUE,
+		},
+	}, stopped)
+
+	tests := []struct {
+		Type           string
+		TransitionTime time.Time
+	}{
+		{workspacev1.WorkspaceConditionDeployed, stopped},
+		{workspacev1.WorkspaceConditionServiceExists, created},
+		{workspacev1.WorkspaceConditionFailed, created},
+	}
+	for _, test := range tests {
+		t.Run(test.Type, func(t *testing.T) {
+			cond := meta.FindStatusCondition(ws.Status.Conditions, test.Type)
+			if cond == nil {
+				t.Fatalf("condition %s is missing", test.Type)
+			}
+			if !cond.LastTransitionTime.Time.Equal(test.TransitionTime) {
+				t.Errorf("unexpected transition time: expected %v, got %v", test.TransitionTime, cond.LastTransitionTime.Time)
+			}
+		})
+	}
+}
+
+func TestWorkspaceCRDMirrorCoalesces(t *testing.T) {
+	var (
+		writing = make(chan struct{})
+		release = make(chan struct{})
+		written = make(chan string, 10)
+		failed  bool
+	)
+	mirror := newWorkspaceCRDMirror(func(ctx context.Context, status *api.WorkspaceStatus) error {
+		if status.Message == "first" {
+			writing <- struct{}{}
+			<-release
+		}
+		if status.Message == "flaky" && !failed {
+			failed = true
+			return xerrors.Errorf("conflict")
+		}
+		written <- status.Message
+		return nil
+	})
+	mirror.Start(1)
+	defer mirror.Stop()
+
+	status := func(msg string) *api.WorkspaceStatus {
+		return &api.WorkspaceStatus{Id: "foobar", Message: msg, Metadata: &api.WorkspaceMetadata{}}
+	}
+	mirror.Mirror(status("first"))
+	<-writing
+	// the first status is being written - the following ones must be coalesced
+	mirror.Mirror(status("second"))
+	mirror.Mirror(status("flaky"))
+	close(release)
+
+	var act []string
+	for len(act) < 2 {
+		select {
+		case msg := <-written:
+			act = append(act, msg)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timeout waiting for status writes, got %v", act)
+		}
+	}
+	if diff := cmp.Diff([]string{"first", "flaky"}, act); diff != "" {
+		t.Errorf("unexpected status writes (-want +got):\n%s", diff)
+	}
+}
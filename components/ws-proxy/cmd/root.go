@@ -61,6 +61,7 @@ type Config struct {
 	PrometheusAddr              string                            `json:"prometheusAddr"`
 	ReadinessProbeAddr          string                            `json:"readinessProbeAddr"`
 	WSManagerProxy              WSManagerProxyConfig              `json:"wsManagerProxy"`
+	TCPProxy                    TCPProxyConfig                    `json:"tcpProxy"`
 }
 
 // Validate validates the configuration to catch issues during startup and not at runtime
@@ -77,6 +78,9 @@ func (c *Config) Validate() error {
 	if err := c.WSManagerProxy.Validate(); err != nil {
 		return err
 	}
+	if err := c.TCPProxy.Validate(); err != nil {
+		return err
+	}
 
 	return nil
 }
@@ -95,6 +99,23 @@ func (c *WSManagerProxyConfig) Validate() error {
 	return nil
 }
 
+// TCPProxyConfig configures the proxy which exposes workspace ports as raw TCP using TLS and SNI
+type TCPProxyConfig struct {
+	ListenAddress string `json:"listenAddress"`
+	// ClientCA is the path to a PEM encoded CA certificate. If set, clients can authenticate using
+	// certificates issued by this CA for the workspace ID instead of the owner token.
+	ClientCA    string            `json:"clientCA,omitempty"`
+	RateLimiter RateLimiterConfig `json:"rateLimiter"`
+}
+
+// Validate validates this config
+func (c *TCPProxyConfig) Validate() error {
+	if c != nil && len(c.ListenAddress) > 0 {
+		return c.RateLimiter.Validate()
+	}
+	return nil
+}
+
 // RateLimiterConfig configures a rate limiter
 type RateLimiterConfig struct {
 	RefillInterval util.Duration `json:"refillInterval"`
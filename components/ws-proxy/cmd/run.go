@@ -6,6 +6,7 @@ package cmd
 
 import (
 	"context"
+	"crypto/x509"
 	"net"
 	"net/http"
 	"os"
@@ -17,6 +18,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/spf13/cobra"
+	"golang.org/x/xerrors"
 
 	"github.com/gitpod-io/gitpod/common-go/log"
 	"github.com/gitpod-io/gitpod/common-go/pprof"
@@ -56,7 +58,11 @@ var runCmd = &cobra.Command{
 		}
 		log.Infof("workspace info provider started")
 
-		go proxy.NewWorkspaceProxy(cfg.Ingress, cfg.Proxy, proxy.HostBasedRouter(cfg.Ingress.Header, cfg.Proxy.GitpodInstallation.WorkspaceHostSuffix, cfg.Proxy.GitpodInstallation.WorkspaceHostSuffixRegex), workspaceInfoProvider).MustServe()
+		workspaceRouter := proxy.HostBasedRouter(cfg.Ingress.Header, cfg.Proxy.GitpodInstallation.WorkspaceHostSuffix, cfg.Proxy.GitpodInstallation.WorkspaceHostSuffixRegex)
+		if cfg.Proxy.WorkspaceRouting == proxy.WorkspaceRoutingPath {
+			workspaceRouter = proxy.PathBasedRouter(cfg.Ingress.Header, cfg.Proxy.WorkspacePortHost)
+		}
+		go proxy.NewWorkspaceProxy(cfg.Ingress, cfg.Proxy, workspaceRouter, workspaceInfoProvider).MustServe()
 		log.Infof("started proxying on %s", cfg.Ingress.HttpAddress)
 
 		if cfg.PProfAddr != "" {
@@ -110,6 +116,15 @@ var runCmd = &cobra.Command{
 			}()
 		}
 
+		if cfg.TCPProxy.ListenAddress != "" {
+			go func() {
+				err := startTCPProxy(ctx, &cfg.Proxy, &cfg.TCPProxy, workspaceInfoProvider)
+				if err != nil {
+					log.WithError(err).Fatal("starting TCP proxy failed")
+				}
+			}()
+		}
+
 		log.Info("🚪 ws-proxy is up and running")
 		sigChan := make(chan os.Signal, 1)
 		signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
@@ -144,3 +159,44 @@ func startWSManagerTCPProxy(ctx context.Context, listenAddr string, wsManagerAdd
 	log.Infof("Forwarding ws-manager traffic: %s -> %s\n", listenAddr, wsManagerAddr)
 	return p.Run()
 }
+
+func startTCPProxy(ctx context.Context, proxyCfg *proxy.Config, cfg *TCPProxyConfig, infoProvider proxy.WorkspaceInfoProvider) error {
+	var clientCAs *x509.CertPool
+	if cfg.ClientCA != "" {
+		pem, err := os.ReadFile(cfg.ClientCA)
+		if err != nil {
+			return xerrors.Errorf("cannot read client CA: %w", err)
+		}
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(pem) {
+			return xerrors.Errorf("no certificates found in client CA %s", cfg.ClientCA)
+		}
+	}
+
+	p, err := proxy.NewTCPProxy(proxyCfg, infoProvider, clientCAs)
+	if err != nil {
+		return err
+	}
+
+	var l net.Listener
+	refillInterval := time.Duration(cfg.RateLimiter.RefillInterval)
+	if refillInterval != 0 && cfg.RateLimiter.BucketSize != 0 {
+		l, err = ratelimit.NewListener(ctx, "tcp", cfg.ListenAddress, refillInterval, cfg.RateLimiter.BucketSize)
+	} else {
+		l, err = net.Listen("tcp", cfg.ListenAddress)
+	}
+	if err != nil {
+		return err
+	}
+	go func() {
+		<-ctx.Done()
+		l.Close()
+	}()
+
+	log.WithField("addr", cfg.ListenAddress).Info("started TCP proxy")
+	err = p.Serve(l)
+	if ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
@@ -27,8 +27,24 @@ type Config struct {
 	WorkspacePodConfig *WorkspacePodConfig `json:"workspacePodConfig"`
 
 	BuiltinPages BuiltinPagesConfig `json:"builtinPages"`
+
+	// WorkspaceRouting determines how requests are routed to workspaces. Defaults to WorkspaceRoutingHost.
+	WorkspaceRouting WorkspaceRouting `json:"workspaceRouting,omitempty"`
+	// WorkspacePortHost is the host workspace ports and foreign content are served from when routing by path.
+	// It must differ from the host the IDEs are served from, so that they do not share an origin.
+	WorkspacePortHost string `json:"workspacePortHost,omitempty"`
 }
 
+// WorkspaceRouting determines how requests are routed to workspaces
+type WorkspaceRouting string
+
+const (
+	// WorkspaceRoutingHost serves each workspace and port from its own subdomain of the workspace host suffix
+	WorkspaceRoutingHost WorkspaceRouting = "host"
+	// WorkspaceRoutingPath serves all workspaces from a single host, each below its own path prefix
+	WorkspaceRoutingPath WorkspaceRouting = "path"
+)
+
 // Validate validates the configuration to catch issues during startup and not at runtime
 func (c *Config) Validate() error {
 	type validatable interface {
@@ -46,6 +62,19 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	err := validation.Validate(c.WorkspaceRouting, validation.In(WorkspaceRoutingHost, WorkspaceRoutingPath))
+	if err != nil {
+		return xerrors.Errorf("invalid workspaceRouting: %w", err)
+	}
+	if c.WorkspaceRouting == WorkspaceRoutingPath {
+		if c.WorkspacePortHost == "" {
+			return xerrors.Errorf("workspacePortHost is mandatory when routing by path")
+		}
+		if c.WorkspacePortHost == c.GitpodInstallation.HostName {
+			return xerrors.Errorf("workspacePortHost must differ from the Gitpod host")
+		}
+	}
+
 	return nil
 }
 
@@ -5,17 +5,21 @@
 package proxy
 
 import (
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"net"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
+	"regexp"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/sirupsen/logrus"
+	"golang.org/x/net/http2"
 	"golang.org/x/xerrors"
 
 	"github.com/gitpod-io/gitpod/common-go/log"
@@ -27,6 +31,7 @@ type proxyPassConfig struct {
 	ResponseHandler []responseHandler
 	ErrorHandler    errorHandler
 	Transport       http.RoundTripper
+	FlushInterval   time.Duration
 }
 
 func (ppc *proxyPassConfig) appendResponseHandler(handler responseHandler) {
@@ -78,6 +83,7 @@ func proxyPass(config *RouteHandlerConfig, resolver targetResolver, opts ...prox
 		// TODO(cw): we should cache the proxy for some time for each target URL
 		proxy := httputil.NewSingleHostReverseProxy(targetURL)
 		proxy.Transport = h.Transport
+		proxy.FlushInterval = h.FlushInterval
 		proxy.ModifyResponse = func(resp *http.Response) error {
 			url := resp.Request.URL
 			if url == nil {
@@ -174,6 +180,39 @@ func createDefaultTransport(config *TransportConfig) *http.Transport {
 	}
 }
 
+// createH2CTransport creates a transport which speaks HTTP/2 without TLS (h2c) to its upstreams
+func createH2CTransport(config *TransportConfig) *http2.Transport {
+	dialer := &net.Dialer{
+		Timeout:   time.Duration(config.ConnectTimeout),
+		KeepAlive: 30 * time.Second,
+	}
+	return &http2.Transport{
+		AllowHTTP: true,
+		// the upstream doesn't speak TLS, hence we dial a plain connection instead
+		DialTLS: func(network, addr string, cfg *tls.Config) (net.Conn, error) {
+			return dialer.Dial(network, addr)
+		},
+		// detect dead upstream connections, which otherwise would stall all streams multiplexed over them
+		ReadIdleTimeout: time.Duration(config.IdleConnTimeout),
+		PingTimeout:     15 * time.Second,
+	}
+}
+
+// withH2CTransport proxies to upstreams which speak HTTP/2 without TLS, e.g. gRPC servers
+func withH2CTransport(transport http.RoundTripper) proxyPassOpt {
+	return func(h *proxyPassConfig) {
+		h.Transport = transport
+		h.FlushInterval = -1
+	}
+}
+
+// withStreaming flushes the response after each write, which is what streaming (e.g. gRPC-web) responses need
+func withStreaming() proxyPassOpt {
+	return func(h *proxyPassConfig) {
+		h.FlushInterval = -1
+	}
+}
+
 // tell the browser to cache for 1 year and don't ask the server during this period
 func withLongTermCaching() proxyPassOpt {
 	return func(cfg *proxyPassConfig) {
@@ -195,6 +234,65 @@ func withXFrameOptionsFilter() proxyPassOpt {
 	}
 }
 
+// withPathPrefixRewrite makes redirects and cookies of workspace responses point below the path prefix
+// the workspace is served from when routing by path. It does nothing when routing by host.
+func withPathPrefixRewrite() proxyPassOpt {
+	return func(cfg *proxyPassConfig) {
+		cfg.appendResponseHandler(func(resp *http.Response, req *http.Request) error {
+			prefix := mux.Vars(req)[workspacePathPrefixIdentifier]
+			if prefix == "" {
+				return nil
+			}
+
+			if loc := resp.Header.Get("Location"); loc != "" {
+				resp.Header.Set("Location", prefixLocation(loc, req.Host, prefix))
+			}
+			if cookies := resp.Header.Values("Set-Cookie"); len(cookies) > 0 {
+				resp.Header.Del("Set-Cookie")
+				for _, c := range cookies {
+					resp.Header.Add("Set-Cookie", prefixCookiePath(c, prefix))
+				}
+			}
+			return nil
+		})
+	}
+}
+
+// prefixLocation adds the path prefix to a redirect location that points to the workspace itself
+func prefixLocation(location, host, prefix string) string {
+	u, err := url.Parse(location)
+	if err != nil {
+		return location
+	}
+	if u.Host != "" && u.Host != host {
+		// redirect to another site
+		return location
+	}
+	if !strings.HasPrefix(u.Path, "/") || u.Path == prefix || strings.HasPrefix(u.Path, prefix+"/") {
+		// relative or already prefixed location
+		return location
+	}
+
+	u.Path = prefix + u.Path
+	if u.RawPath != "" {
+		u.RawPath = prefix + u.RawPath
+	}
+	return u.String()
+}
+
+var cookiePathRegex = regexp.MustCompile(`(?i)(;\s*path=)(/[^;]*)`)
+
+// prefixCookiePath adds the path prefix to the path attribute of a Set-Cookie header value
+func prefixCookiePath(cookie, prefix string) string {
+	return cookiePathRegex.ReplaceAllStringFunc(cookie, func(attr string) string {
+		m := cookiePathRegex.FindStringSubmatch(attr)
+		if m[2] == prefix || strings.HasPrefix(m[2], prefix+"/") {
+			return attr
+		}
+		return m[1] + strings.TrimSuffix(prefix+m[2], "/")
+	})
+}
+
 type workspaceTransport struct {
 	transport http.RoundTripper
 }
@@ -5,6 +5,7 @@
 package proxy
 
 import (
+	"crypto/tls"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -48,7 +49,17 @@ func (p *WorkspaceProxy) MustServe() {
 		log.WithError(err).Fatal("cannot initialize proxy - this is likely a configuration issue")
 		return
 	}
-	srv := &http.Server{Addr: p.Ingress.HttpsAddress, Handler: handler}
+	srv := &http.Server{
+		Addr:    p.Ingress.HttpsAddress,
+		Handler: handler,
+		TLSConfig: &tls.Config{
+			MinVersion: tls.VersionTLS12,
+			// offer HTTP/2 so that clients can reach gRPC services in workspaces. Ingress in front of ws-proxy must
+			// forward requests using HTTP/2 as well (see the workspace_transport of the proxy component), otherwise
+			// trailers and bidirectional streaming are lost on the way.
+			NextProtos: []string{"h2", "http/1.1"},
+		},
+	}
 
 	var (
 		crt = p.Config.HTTPS.Certificate
@@ -83,9 +94,11 @@ func (p *WorkspaceProxy) Handler() (http.Handler, error) {
 	}
 	ideRouter, portRouter, blobserveRouter := p.WorkspaceRouter(r, p.WorkspaceInfoProvider)
 	installWorkspaceRoutes(ideRouter, handlerConfig, p.WorkspaceInfoProvider)
-	err = installWorkspacePortRoutes(portRouter, handlerConfig)
-	if err != nil {
-		return nil, err
+	if portRouter != nil {
+		err = installWorkspacePortRoutes(portRouter, handlerConfig, p.WorkspaceInfoProvider)
+		if err != nil {
+			return nil, err
+		}
 	}
 	installBlobserveRoutes(blobserveRouter, handlerConfig)
 	return r, nil
@@ -26,12 +26,14 @@ import (
 	"golang.org/x/xerrors"
 
 	"github.com/gitpod-io/gitpod/common-go/log"
+	"github.com/gitpod-io/gitpod/ws-manager/api"
 )
 
 // RouteHandlerConfig configures a RouteHandler
 type RouteHandlerConfig struct {
 	Config               *Config
 	DefaultTransport     http.RoundTripper
+	H2CTransport         http.RoundTripper
 	CorsHandler          mux.MiddlewareFunc
 	WorkspaceAuthHandler mux.MiddlewareFunc
 }
@@ -56,6 +58,7 @@ func NewRouteHandlerConfig(config *Config, opts ...RouteHandlerConfigOpt) (*Rout
 	cfg := &RouteHandlerConfig{
 		Config:               config,
 		DefaultTransport:     createDefaultTransport(config.TransportConfig),
+		H2CTransport:         createH2CTransport(config.TransportConfig),
 		CorsHandler:          corsHandler,
 		WorkspaceAuthHandler: func(h http.Handler) http.Handler { return h },
 	}
@@ -140,7 +143,7 @@ func (ir *ideRoutes) HandleDirectIDERoute(route *mux.Route) {
 	r.Use(ir.Config.WorkspaceAuthHandler)
 	r.Use(ir.workspaceMustExistHandler)
 
-	r.NewRoute().HandlerFunc(proxyPass(ir.Config, workspacePodResolver, withWorkspaceTransport()))
+	r.NewRoute().HandlerFunc(proxyPass(ir.Config, workspacePodResolver, withWorkspaceTransport(), withPathPrefixRewrite()))
 }
 
 func (ir *ideRoutes) HandleDirectSupervisorRoute(route *mux.Route, authenticated bool) {
@@ -152,7 +155,7 @@ func (ir *ideRoutes) HandleDirectSupervisorRoute(route *mux.Route, authenticated
 		r.Use(ir.Config.WorkspaceAuthHandler)
 	}
 
-	r.NewRoute().HandlerFunc(proxyPass(ir.Config, workspacePodSupervisorResolver))
+	r.NewRoute().HandlerFunc(proxyPass(ir.Config, workspacePodSupervisorResolver, withPathPrefixRewrite()))
 }
 
 func (ir *ideRoutes) HandleSupervisorFrontendRoute(route *mux.Route) {
@@ -302,7 +305,7 @@ func installBlobserveRoutes(r *mux.Router, config *RouteHandlerConfig) {
 }
 
 // installWorkspacePortRoutes configures routing for exposed ports
-func installWorkspacePortRoutes(r *mux.Router, config *RouteHandlerConfig) error {
+func installWorkspacePortRoutes(r *mux.Router, config *RouteHandlerConfig, ip WorkspaceInfoProvider) error {
 	showPortNotFoundPage, err := servePortNotFoundPage(config.Config)
 	if err != nil {
 		return err
@@ -326,19 +329,39 @@ func installWorkspacePortRoutes(r *mux.Router, config *RouteHandlerConfig) error
 			}
 			r.Header.Add("X-Forwarded-Proto", "https")
 			r.Header.Add("X-Forwarded-Host", r.Host+":443")
-			proxyPass(
-				config,
-				workspacePodPortResolver,
+
+			opts := []proxyPassOpt{
 				withHTTPErrorHandler(showPortNotFoundPage),
 				withXFrameOptionsFilter(),
-				withWorkspaceTransport(),
-			)(rw, r)
+			}
+			if getPortProtocol(r, ip) == api.PortProtocol_PORT_PROTOCOL_HTTP2 {
+				opts = append(opts, withH2CTransport(config.H2CTransport))
+			} else if strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc-web") {
+				opts = append(opts, withStreaming())
+			}
+			opts = append(opts, withWorkspaceTransport())
+			proxyPass(config, workspacePodPortResolver, opts...)(rw, r)
 		},
 	)
 
 	return nil
 }
 
+// getPortProtocol returns the protocol of the workspace port a request is for
+func getPortProtocol(req *http.Request, ip WorkspaceInfoProvider) api.PortProtocol {
+	coords := getWorkspaceCoords(req)
+	info := ip.WorkspaceInfo(req.Context(), coords.ID)
+	if info == nil {
+		return api.PortProtocol_PORT_PROTOCOL_HTTP
+	}
+	for i := range info.Ports {
+		if fmt.Sprint(info.Ports[i].Port) == coords.Port {
+			return info.Ports[i].Protocol
+		}
+	}
+	return api.PortProtocol_PORT_PROTOCOL_HTTP
+}
+
 // workspacePodResolver resolves to the workspace pod's url from the given request
 func workspacePodResolver(config *Config, req *http.Request) (url *url.URL, err error) {
 	coords := getWorkspaceCoords(req)
@@ -684,6 +707,10 @@ func (t *blobserveTransport) redirect(image string, req *http.Request) (*http.Re
 }
 
 func (t *blobserveTransport) asBlobserveURL(image string, path string) string {
+	if t.Config.WorkspaceRouting == WorkspaceRoutingPath {
+		// blobserve is served from the same host as the workspace
+		return fmt.Sprintf("%s/%s%s%s", blobservePathPrefix, image, imagePathSeparator, path)
+	}
 	return fmt.Sprintf("%s://%s%s/%s%s%s",
 		t.Config.GitpodInstallation.Scheme,
 		"blobserve",
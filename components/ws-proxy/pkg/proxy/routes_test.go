@@ -18,6 +18,8 @@ import (
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/sirupsen/logrus"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 
 	"github.com/gitpod-io/gitpod/common-go/log"
 	"github.com/gitpod-io/gitpod/common-go/util"
@@ -691,6 +693,65 @@ func TestRoutes(t *testing.T) {
 	}
 }
 
+func TestH2CPortRoute(t *testing.T) {
+	const port = 28081
+	infos := []WorkspaceInfo{workspaces[0]}
+	infos[0].Ports = []PortInfo{
+		{PortSpec: api.PortSpec{Port: port, Visibility: api.PortVisibility_PORT_VISIBILITY_PUBLIC, Protocol: api.PortProtocol_PORT_PROTOCOL_HTTP2}},
+	}
+
+	l, err := net.Listen("tcp", fmt.Sprintf("localhost:%d", port))
+	if err != nil {
+		t.Fatal(err)
+	}
+	upstream := &http.Server{Handler: h2c.NewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor != 2 {
+			w.WriteHeader(http.StatusHTTPVersionNotSupported)
+			return
+		}
+		w.Header().Set("Content-Type", "application/grpc")
+		w.Header().Set("Trailer", "Grpc-Status")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, "grpc hit")
+		w.Header().Set("Grpc-Status", "0")
+	}), &http2.Server{})}
+	go upstream.Serve(l)
+	defer upstream.Close()
+
+	proxy := NewWorkspaceProxy(HostBasedIngressConfig{}, config, HostBasedRouter(hostBasedHeader, wsHostSuffix, wsHostNameRegex), &fakeWsInfoProvider{infos: infos})
+	handler, err := proxy.Handler()
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewUnstartedServer(handler)
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	defer srv.Close()
+
+	req, err := http.NewRequest(http.MethodPost, srv.URL+"/helloworld.Greeter/SayHello", strings.NewReader(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set(hostBasedHeader, fmt.Sprintf("%d-amaranth-smelt-9ba20cc1%s", port, wsHostSuffix))
+	req.Header.Set("Content-Type", "application/grpc")
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if resp.ProtoMajor != 2 {
+		t.Errorf("client did not negotiate HTTP/2: %s", resp.Proto)
+	}
+	if resp.StatusCode != http.StatusOK || string(body) != "grpc hit" {
+		t.Errorf("unexpected response: %d %q", resp.StatusCode, body)
+	}
+	if act := resp.Trailer.Get("Grpc-Status"); act != "0" {
+		t.Errorf("trailers were not forwarded: %v", resp.Trailer)
+	}
+}
+
 type fakeWsInfoProvider struct {
 	infos []WorkspaceInfo
 }
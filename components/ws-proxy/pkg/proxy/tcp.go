@@ -0,0 +1,255 @@
+// Copyright (c) 2021 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package proxy
+
+import (
+	"bufio"
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/gitpod-io/gitpod/common-go/log"
+	"github.com/gitpod-io/gitpod/ws-manager/api"
+)
+
+const (
+	// tcpHandshakeTimeout is the time a client has to complete the TLS handshake and authenticate
+	tcpHandshakeTimeout = 10 * time.Second
+
+	// tcpOwnerTokenPreamble starts the line a client sends to authenticate using the workspace's owner token,
+	// before any data is forwarded to the workspace. It mirrors the header the HTTP routes accept the token in.
+	tcpOwnerTokenPreamble = "x-gitpod-owner-token:"
+
+	// tcpMaxPreambleSize limits the size of the authentication line
+	tcpMaxPreambleSize = 1024
+)
+
+// tcpHalfCloseTimeout is the time the workspace has to finish sending once the client stopped sending.
+// Without it, workspaces which ignore the half-close would keep the connection open forever.
+var tcpHalfCloseTimeout = 30 * time.Second
+
+// TCPProxy exposes workspace ports as raw TCP. Clients connect using TLS and choose the workspace port using
+// SNI, i.e. <port>-<workspaceID><workspace host suffix>. The TLS connection is terminated by the proxy.
+//
+// Public ports and workspaces admitting everyone need no authentication. Otherwise clients authenticate by
+// either presenting a client certificate issued for the workspace ID by the configured CA, or by sending
+// "x-gitpod-owner-token: <token>\n" as first line. Standard clients (e.g. openssl s_client or stunnel) can only
+// use certificates, as neither the Gitpod CLI nor the local app send the owner token line.
+type TCPProxy struct {
+	Config       *Config
+	InfoProvider WorkspaceInfoProvider
+
+	tlsConfig *tls.Config
+	sni       *regexp.Regexp
+	dialer    net.Dialer
+}
+
+// NewTCPProxy creates a new TCP proxy which serves the HTTPS certificate of the proxy config.
+// If clientCAs is not nil, clients can authenticate using certificates issued by those CAs.
+func NewTCPProxy(config *Config, infoProvider WorkspaceInfoProvider, clientCAs *x509.CertPool) (*TCPProxy, error) {
+	var (
+		crt = config.HTTPS.Certificate
+		key = config.HTTPS.Key
+	)
+	if tproot := os.Getenv("TELEPRESENCE_ROOT"); tproot != "" {
+		crt = filepath.Join(tproot, crt)
+		key = filepath.Join(tproot, key)
+	}
+	cert, err := tls.LoadX509KeyPair(crt, key)
+	if err != nil {
+		return nil, xerrors.Errorf("cannot load certificate: %w", err)
+	}
+
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if clientCAs != nil {
+		tlsConfig.ClientCAs = clientCAs
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+
+	hostSuffixRegex := config.GitpodInstallation.WorkspaceHostSuffixRegex
+	if hostSuffixRegex == "" {
+		hostSuffixRegex = regexp.QuoteMeta(config.GitpodInstallation.WorkspaceHostSuffix)
+	}
+	sni, err := regexp.Compile("^" + workspacePortRegex + workspaceIDRegex + hostSuffixRegex + "$")
+	if err != nil {
+		return nil, xerrors.Errorf("cannot compile SNI pattern: %w", err)
+	}
+
+	return &TCPProxy{
+		Config:       config,
+		InfoProvider: infoProvider,
+		tlsConfig:    tlsConfig,
+		sni:          sni,
+		dialer: net.Dialer{
+			Timeout:   time.Duration(config.TransportConfig.ConnectTimeout),
+			KeepAlive: 30 * time.Second,
+		},
+	}, nil
+}
+
+// Serve accepts connections on the listener until it fails, e.g. because it was closed
+func (p *TCPProxy) Serve(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go p.handleConn(conn)
+	}
+}
+
+func (p *TCPProxy) handleConn(conn net.Conn) {
+	defer conn.Close()
+
+	client := tls.Server(conn, p.tlsConfig)
+	_ = client.SetDeadline(time.Now().Add(tcpHandshakeTimeout))
+	err := client.Handshake()
+	if err != nil {
+		log.WithError(err).WithField("remoteAddr", conn.RemoteAddr()).Debug("TLS handshake failed")
+		return
+	}
+
+	state := client.ConnectionState()
+	coords, err := p.resolve(state.ServerName)
+	if err != nil {
+		log.WithError(err).WithField("serverName", state.ServerName).Debug("cannot resolve workspace port")
+		return
+	}
+	log := log.WithFields(log.OWI("", coords.ID, "")).WithField("port", coords.Port)
+
+	ctx, cancel := context.WithTimeout(context.Background(), tcpHandshakeTimeout)
+	info := p.InfoProvider.WorkspaceInfo(ctx, coords.ID)
+	cancel()
+	if info == nil {
+		log.Debug("did not find workspace info")
+		return
+	}
+	port, ok := findPort(info, coords.Port)
+	if !ok {
+		log.Debug("port is not exposed")
+		return
+	}
+
+	var clientReader io.Reader = client
+	if needsTCPAuthentication(info, port) && !isAuthorizedByCertificate(state, info) {
+		br := bufio.NewReaderSize(client, tcpMaxPreambleSize)
+		err = authenticateOwnerToken(br, info)
+		if err != nil {
+			log.WithError(err).Warn("unauthorized TCP connection")
+			return
+		}
+		clientReader = br
+	}
+	_ = client.SetDeadline(time.Time{})
+
+	target, err := buildWorkspacePodURL(p.Config.WorkspacePodConfig.PortServiceTemplate, coords.ID, coords.Port)
+	if err != nil {
+		log.WithError(err).Error("cannot build workspace port URL")
+		return
+	}
+	upstream, err := p.dialer.Dial("tcp", target.Host)
+	if err != nil {
+		log.WithError(err).Debug("cannot connect to workspace port")
+		return
+	}
+	defer upstream.Close()
+
+	pipe(client, clientReader, upstream)
+}
+
+// resolve extracts the workspace coordinates from the SNI server name
+func (p *TCPProxy) resolve(serverName string) (*WorkspaceCoords, error) {
+	matches := p.sni.FindStringSubmatch(serverName)
+	if matches == nil {
+		return nil, xerrors.Errorf("server name does not denote a workspace port")
+	}
+	return &WorkspaceCoords{
+		ID:   matches[p.sni.SubexpIndex(workspaceIDIdentifier)],
+		Port: matches[p.sni.SubexpIndex(workspacePortIdentifier)],
+	}, nil
+}
+
+func findPort(info *WorkspaceInfo, port string) (*PortInfo, bool) {
+	prt, err := strconv.ParseUint(port, 10, 16)
+	if err != nil {
+		return nil, false
+	}
+	for i := range info.Ports {
+		if info.Ports[i].Port == uint32(prt) {
+			return &info.Ports[i], true
+		}
+	}
+	return nil, false
+}
+
+// needsTCPAuthentication applies the same access policy as the WorkspaceAuthHandler does for HTTP ports
+func needsTCPAuthentication(info *WorkspaceInfo, port *PortInfo) bool {
+	if info.Auth != nil && info.Auth.Admission == api.AdmissionLevel_ADMIT_EVERYONE {
+		return false
+	}
+	return port.Visibility != api.PortVisibility_PORT_VISIBILITY_PUBLIC
+}
+
+// isAuthorizedByCertificate checks if the client presented a certificate issued for the workspace.
+// The TLS handshake has already verified the certificate against our client CAs.
+func isAuthorizedByCertificate(state tls.ConnectionState, info *WorkspaceInfo) bool {
+	if len(state.VerifiedChains) == 0 {
+		return false
+	}
+	cn := state.VerifiedChains[0][0].Subject.CommonName
+	return cn != "" && (cn == info.WorkspaceID || cn == info.InstanceID)
+}
+
+// authenticateOwnerToken reads the owner token line and compares it to the workspace's owner token
+func authenticateOwnerToken(r *bufio.Reader, info *WorkspaceInfo) error {
+	line, isPrefix, err := r.ReadLine()
+	if err != nil {
+		return xerrors.Errorf("cannot read owner token: %w", err)
+	}
+	if isPrefix {
+		return xerrors.Errorf("owner token line is too long")
+	}
+	tkn := string(line)
+	if len(tkn) < len(tcpOwnerTokenPreamble) || !strings.EqualFold(tkn[:len(tcpOwnerTokenPreamble)], tcpOwnerTokenPreamble) {
+		return xerrors.Errorf("no owner token present")
+	}
+	tkn = strings.TrimSpace(tkn[len(tcpOwnerTokenPreamble):])
+
+	if info.Auth == nil || info.Auth.OwnerToken == "" || subtle.ConstantTimeCompare([]byte(tkn), []byte(info.Auth.OwnerToken)) != 1 {
+		return xerrors.Errorf("owner token mismatch")
+	}
+	return nil
+}
+
+// pipe copies data in both directions until the workspace is done sending. The client may stop sending earlier,
+// which we forward to the workspace as a half-close, after which the workspace has tcpHalfCloseTimeout to finish.
+// If the client connection fails, we close the upstream connection right away. The caller closes both connections afterwards.
+func pipe(client net.Conn, clientReader io.Reader, upstream net.Conn) {
+	go func() {
+		_, err := io.Copy(upstream, clientReader)
+		c, ok := upstream.(interface{ CloseWrite() error })
+		if err != nil || !ok {
+			_ = upstream.Close()
+			return
+		}
+		_ = c.CloseWrite()
+		_ = upstream.SetReadDeadline(time.Now().Add(tcpHalfCloseTimeout))
+	}()
+	_, _ = io.Copy(client, upstream)
+}
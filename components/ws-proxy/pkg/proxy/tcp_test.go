@@ -0,0 +1,245 @@
+// Copyright (c) 2021 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package proxy
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gitpod-io/gitpod/ws-manager/api"
+)
+
+// newTestCertificate creates a certificate signed by parent, or a self-signed CA if parent is nil
+func newTestCertificate(t *testing.T, cn string, dnsNames []string, parent *tls.Certificate) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		DNSNames:     dnsNames,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	var (
+		signer    = tmpl
+		signerKey = key
+	)
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+	} else {
+		signer = parent.Leaf
+		signerKey = parent.PrivateKey.(*ecdsa.PrivateKey)
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
+
+func TestTCPProxy(t *testing.T) {
+	const (
+		port      = 28082
+		publicWS  = "amaranth-smelt-9ba20cc1"
+		privateWS = "blue-panda-00000000"
+	)
+	var (
+		serverName = func(ws string) string { return fmt.Sprintf("%d-%s.ws.test-domain.com", port, ws) }
+		ca         = newTestCertificate(t, "ca", nil, nil)
+		serverCert = newTestCertificate(t, "ws-proxy", []string{"*.ws.test-domain.com"}, &ca)
+		clientCert = newTestCertificate(t, privateWS, nil, &ca)
+		otherCert  = newTestCertificate(t, publicWS, nil, &ca)
+	)
+
+	dir := t.TempDir()
+	crtFn, keyFn := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	keyDER, err := x509.MarshalECPrivateKey(serverCert.PrivateKey.(*ecdsa.PrivateKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(crtFn, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: serverCert.Certificate[0]}), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(keyFn, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the workspace port echos everything back
+	upstream, err := net.Listen("tcp", fmt.Sprintf("localhost:%d", port))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer upstream.Close()
+	go func() {
+		for {
+			conn, err := upstream.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_, _ = io.Copy(conn, conn)
+			}()
+		}
+	}()
+
+	cfg := config
+	cfg.HTTPS.Certificate = crtFn
+	cfg.HTTPS.Key = keyFn
+	infos := []WorkspaceInfo{
+		{
+			WorkspaceID: publicWS,
+			Auth:        &api.WorkspaceAuthentication{Admission: api.AdmissionLevel_ADMIT_OWNER_ONLY, OwnerToken: "public-token"},
+			Ports:       []PortInfo{{PortSpec: api.PortSpec{Port: port, Visibility: api.PortVisibility_PORT_VISIBILITY_PUBLIC}}},
+		},
+		{
+			WorkspaceID: privateWS,
+			Auth:        &api.WorkspaceAuthentication{Admission: api.AdmissionLevel_ADMIT_OWNER_ONLY, OwnerToken: "owner-token"},
+			Ports:       []PortInfo{{PortSpec: api.PortSpec{Port: port, Visibility: api.PortVisibility_PORT_VISIBILITY_PRIVATE}}},
+		},
+	}
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(ca.Leaf)
+	p, err := NewTCPProxy(&cfg, &fakeWsInfoProvider{infos: infos}, clientCAs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go p.Serve(l)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.Leaf)
+
+	tests := []struct {
+		Name        string
+		ServerName  string
+		Preamble    string
+		Certificate *tls.Certificate
+		Expectation bool
+	}{
+		{Name: "public port", ServerName: serverName(publicWS), Expectation: true},
+		{Name: "private port without auth", ServerName: serverName(privateWS)},
+		{Name: "private port with owner token", ServerName: serverName(privateWS), Preamble: "x-gitpod-owner-token: owner-token\n", Expectation: true},
+		{Name: "private port with wrong owner token", ServerName: serverName(privateWS), Preamble: "x-gitpod-owner-token: public-token\n"},
+		{Name: "private port with client certificate", ServerName: serverName(privateWS), Certificate: &clientCert, Expectation: true},
+		{Name: "private port with certificate of another workspace", ServerName: serverName(privateWS), Certificate: &otherCert},
+		{Name: "port not exposed", ServerName: fmt.Sprintf("%d-%s.ws.test-domain.com", port+1, publicWS)},
+		{Name: "unknown workspace", ServerName: serverName("unknown-workspace-00000000")},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			tlsCfg := &tls.Config{ServerName: test.ServerName, RootCAs: roots}
+			if test.Certificate != nil {
+				tlsCfg.Certificates = []tls.Certificate{*test.Certificate}
+			}
+			conn, err := tls.Dial("tcp", l.Addr().String(), tlsCfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer conn.Close()
+			_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+			_, err = io.WriteString(conn, test.Preamble+"ping\n")
+			if err != nil {
+				t.Fatal(err)
+			}
+			buf := make([]byte, 5)
+			_, err = io.ReadFull(conn, buf)
+			if test.Expectation {
+				if err != nil || string(buf) != "ping\n" {
+					t.Errorf("expected echo, got %q: %v", buf, err)
+				}
+				return
+			}
+			if err == nil {
+				t.Errorf("expected the connection to be closed, got %q", buf)
+			}
+		})
+	}
+}
+
+func TestTCPPipeHalfClose(t *testing.T) {
+	defer func(timeout time.Duration) { tcpHalfCloseTimeout = timeout }(tcpHalfCloseTimeout)
+	tcpHalfCloseTimeout = 100 * time.Millisecond
+
+	// the workspace port reads everything, but never closes the connection
+	upstreamListener, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer upstreamListener.Close()
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		conn, err := upstreamListener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		_, _ = io.Copy(io.Discard, conn)
+		<-stop
+	}()
+	upstream, err := net.Dial("tcp", upstreamListener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer upstream.Close()
+
+	clientListener, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer clientListener.Close()
+	remote, err := net.Dial("tcp", clientListener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := clientListener.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	done := make(chan struct{})
+	go func() {
+		pipe(client, client, upstream)
+		close(done)
+	}()
+
+	remote.Close()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("pipe did not finish after the client disconnected")
+	}
+}
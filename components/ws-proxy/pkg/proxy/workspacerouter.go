@@ -6,6 +6,7 @@ package proxy
 
 import (
 	"net/http"
+	"net/url"
 	"regexp"
 	"strings"
 
@@ -27,6 +28,12 @@ const (
 	// Used as key for storing the path to fetch foreign content
 	foreignPathIdentifier = "foreignPath"
 
+	// Used as key for storing the path prefix a workspace is served from when routing by path
+	workspacePathPrefixIdentifier = "workspacePathPrefix"
+
+	// Used as key for storing the path prefix blobserve is served from when routing by path
+	blobservePathPrefixIdentifier = "blobservePathPrefix"
+
 	// The header that is used to communicate the "Host" from proxy -> ws-proxy in scenarios where ws-proxy is _not_ directly exposed
 	forwardedHostnameHeader = "x-wsproxy-host"
 
@@ -50,14 +57,7 @@ func HostBasedRouter(header, wsHostSuffix string, wsHostSuffixRegex string) Work
 		}
 
 		var (
-			getHostHeader = func(req *http.Request) string {
-				if header == "Host" {
-					parts := strings.Split(req.Host, ":")
-					return parts[0]
-				}
-
-				return req.Header.Get(header)
-			}
+			getHostHeader   = newHostHeaderProvider(header)
 			blobserveRouter = r.MatcherFunc(matchBlobserveHostHeader(wsHostSuffix, getHostHeader)).Subrouter()
 			portRouter      = r.MatcherFunc(matchWorkspaceHostHeader(wsHostSuffix, getHostHeader, true)).Subrouter()
 			ideRouter       = r.MatcherFunc(matchWorkspaceHostHeader(allClusterWsHostSuffixRegex, getHostHeader, false)).Subrouter()
@@ -72,6 +72,232 @@ func HostBasedRouter(header, wsHostSuffix string, wsHostSuffixRegex string) Work
 	}
 }
 
+// PathBasedRouter is a WorkspaceRouter that routes based on the request path, so that all workspaces can be served from
+// a single host plus a separate port host:
+//
+//	<any host but the port host>/ws/<workspaceID>/...        the IDE
+//	<any host but the port host>/blobserve/...               blobserve
+//	<port host>/ws/<workspaceID>/ports/<port>/...             workspace ports
+//	<port host>/ws/<workspaceID>/foreign/...                  foreign content, e.g. webviews
+//
+// The path prefix is removed before the request is routed any further, except for foreign content which is routed by
+// its foreign path just like when routing by host. Ports and foreign content serve arbitrary content, hence they must
+// not share an origin with the IDE: the port host serves nothing else, and the IDE host refuses /ws/<workspaceID>/ports/.
+// The port host must be a subdomain of the Gitpod host, so that the owner cookie is sent along with private port requests.
+//
+// All IDEs share a single origin, and so do all ports. Owner cookies must be scoped to the workspace path prefix and
+// cross-origin requests to the IDEs are refused, but a browser still lets script of one IDE read any other IDE the user
+// owns, and the content of one port any other port. Use this router only if the IDEs served are trusted.
+func PathBasedRouter(header, portHost string) WorkspaceRouter {
+	return func(r *mux.Router, wsInfoProvider WorkspaceInfoProvider) (*mux.Router, *mux.Router, *mux.Router) {
+		var (
+			getHostHeader = newHostHeaderProvider(header)
+			isPortHost    = func(req *http.Request) bool { return getHostHeader(req) == portHost }
+		)
+
+		// relative URLs only work below the workspace path prefix if it ends with a slash
+		r.MatcherFunc(matchWorkspacePathPrefixWithoutSlash(isPortHost)).HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			u := *req.URL
+			u.Path += "/"
+			u.RawPath = ""
+			http.Redirect(w, req, u.String(), http.StatusMovedPermanently)
+		})
+		r.MatcherFunc(func(req *http.Request, m *mux.RouteMatch) bool {
+			return !isPortHost(req) && workspacePortPathPrefixRegex.MatchString(req.URL.Path)
+		}).HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			log.Debugf("refusing port access for path %s on the IDE host", req.URL.Path)
+			http.Error(w, "workspace ports are served from "+portHost, http.StatusForbidden)
+		})
+
+		var (
+			portRouter      = r.MatcherFunc(matchWorkspacePortPathPrefix(isPortHost)).Subrouter()
+			blobserveRouter = r.MatcherFunc(matchBlobservePathPrefix(isPortHost)).Subrouter()
+			ideRouter       = r.MatcherFunc(matchWorkspacePathPrefix(isPortHost)).Subrouter()
+		)
+		blobserveRouter.Use(sameOriginHandler)
+		ideRouter.Use(sameOriginHandler)
+
+		r.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			log.Debugf("no match for path %s, host: %s", req.URL.Path, getHostHeader(req))
+			w.WriteHeader(404)
+		})
+		return ideRouter, portRouter, blobserveRouter
+	}
+}
+
+// newHostHeaderProvider returns the host of a request, either from the header ws-proxy is configured with or from the
+// "Host" header itself
+func newHostHeaderProvider(header string) hostHeaderProvider {
+	return func(req *http.Request) string {
+		if header == "Host" {
+			parts := strings.Split(req.Host, ":")
+			return parts[0]
+		}
+
+		return req.Header.Get(header)
+	}
+}
+
+const (
+	workspacePathPrefix = "/ws/"
+	blobservePathPrefix = "/blobserve"
+
+	// foreignContentOrigin marks foreign content served from the port host when routing by path
+	foreignContentOrigin = "foreign-"
+)
+
+var (
+	workspacePathPrefixWithoutSlashRegex = regexp.MustCompile("^" + workspacePathPrefix + workspaceIDRegex + "$")
+	workspacePortPathPrefixRegex         = regexp.MustCompile("^" + workspacePathPrefix + workspaceIDRegex + "/ports(/|$)")
+	workspacePathPrefixRegex             = regexp.MustCompile("^(" + workspacePathPrefix + workspaceIDRegex + ")/")
+
+	portHostPathPrefixWithoutSlashRegex = regexp.MustCompile("^" + workspacePathPrefix + workspaceIDRegex + "/(ports/[0-9]+|foreign)$")
+	portPathPrefixRegex                 = regexp.MustCompile("^(" + workspacePathPrefix + workspaceIDRegex + "/ports/(?P<" + workspacePortIdentifier + ">[0-9]+))/")
+	foreignPathPrefixRegex              = regexp.MustCompile("^(" + workspacePathPrefix + workspaceIDRegex + "/foreign)(/.*)$")
+)
+
+func matchWorkspacePathPrefixWithoutSlash(isPortHost func(req *http.Request) bool) mux.MatcherFunc {
+	return func(req *http.Request, m *mux.RouteMatch) bool {
+		if isPortHost(req) {
+			return portHostPathPrefixWithoutSlashRegex.MatchString(req.URL.Path)
+		}
+		return workspacePathPrefixWithoutSlashRegex.MatchString(req.URL.Path)
+	}
+}
+
+func matchWorkspacePortPathPrefix(isPortHost func(req *http.Request) bool) mux.MatcherFunc {
+	return func(req *http.Request, m *mux.RouteMatch) bool {
+		if m.Vars[workspacePathPrefixIdentifier] != "" {
+			// The routes of a subrouter inherit its matchers, hence we're called again after we've stripped the prefix
+			return true
+		}
+		if !isPortHost(req) {
+			return false
+		}
+
+		r := portPathPrefixRegex
+		matches := r.FindStringSubmatch(req.URL.Path)
+		if matches == nil {
+			return false
+		}
+
+		if m.Vars == nil {
+			m.Vars = make(map[string]string)
+		}
+		m.Vars[workspaceIDIdentifier] = matches[r.SubexpIndex(workspaceIDIdentifier)]
+		m.Vars[workspacePortIdentifier] = matches[r.SubexpIndex(workspacePortIdentifier)]
+		m.Vars[workspacePathPrefixIdentifier] = matches[1]
+		stripPathPrefix(req, matches[1])
+		return true
+	}
+}
+
+func matchWorkspacePathPrefix(isPortHost func(req *http.Request) bool) mux.MatcherFunc {
+	return func(req *http.Request, m *mux.RouteMatch) bool {
+		if m.Vars[workspacePathPrefixIdentifier] != "" {
+			// The routes of a subrouter inherit its matchers, hence we're called again after we've stripped the prefix
+			return true
+		}
+
+		if isPortHost(req) {
+			// The port host serves foreign content only. We keep the path so that it's routed like foreign content
+			// is when routing by host, and the workspace transport sends the foreign path to the IDE.
+			r := foreignPathPrefixRegex
+			matches := r.FindStringSubmatch(req.URL.Path)
+			if matches == nil {
+				return false
+			}
+
+			if m.Vars == nil {
+				m.Vars = make(map[string]string)
+			}
+			m.Vars[workspaceIDIdentifier] = matches[r.SubexpIndex(workspaceIDIdentifier)]
+			m.Vars[workspacePathPrefixIdentifier] = matches[1]
+			m.Vars[foreignOriginIdentifier] = foreignContentOrigin
+			m.Vars[foreignPathIdentifier] = matches[len(matches)-1]
+			return true
+		}
+
+		r := workspacePathPrefixRegex
+		matches := r.FindStringSubmatch(req.URL.Path)
+		if matches == nil {
+			return false
+		}
+
+		if m.Vars == nil {
+			m.Vars = make(map[string]string)
+		}
+		m.Vars[workspaceIDIdentifier] = matches[r.SubexpIndex(workspaceIDIdentifier)]
+		m.Vars[workspacePathPrefixIdentifier] = matches[1]
+
+		// mux offers no way to modify the request between matching this route and matching the routes of its subrouter.
+		// We strip the prefix here so that the workspace routes see the same paths they see when routing by host.
+		// This is safe because the workspace routes match any path, hence the match cannot fail anymore.
+		stripPathPrefix(req, matches[1])
+		return true
+	}
+}
+
+// sameOriginHandler refuses requests which other sites issue on behalf of the user, except for top-level navigation.
+// When routing by path all workspaces share one origin, hence the owner cookie must not be sent along with
+// requests whose responses script of another origin can read, or which change state.
+func sameOriginHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if !isSameOriginRequest(req) {
+			log.WithField("origin", req.Header.Get("Origin")).WithField("secFetchSite", req.Header.Get("Sec-Fetch-Site")).Debug("refusing cross-origin request")
+			http.Error(w, "cross-origin requests are not allowed", http.StatusForbidden)
+			return
+		}
+		h.ServeHTTP(w, req)
+	})
+}
+
+func isSameOriginRequest(req *http.Request) bool {
+	navigation := req.Method == http.MethodGet && req.Header.Get("Sec-Fetch-Mode") == "navigate"
+	switch req.Header.Get("Sec-Fetch-Site") {
+	case "", "same-origin", "none":
+	default:
+		if !navigation {
+			return false
+		}
+	}
+
+	origin := req.Header.Get("Origin")
+	if origin == "" || navigation {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return u.Host == req.Host
+}
+
+func matchBlobservePathPrefix(isPortHost func(req *http.Request) bool) mux.MatcherFunc {
+	return func(req *http.Request, m *mux.RouteMatch) bool {
+		if m.Vars[blobservePathPrefixIdentifier] != "" {
+			return true
+		}
+		if isPortHost(req) || !strings.HasPrefix(req.URL.Path, blobservePathPrefix+"/") {
+			return false
+		}
+
+		if m.Vars == nil {
+			m.Vars = make(map[string]string)
+		}
+		m.Vars[blobservePathPrefixIdentifier] = blobservePathPrefix
+		stripPathPrefix(req, blobservePathPrefix)
+		return true
+	}
+}
+
+func stripPathPrefix(req *http.Request, prefix string) {
+	req.URL.Path = strings.TrimPrefix(req.URL.Path, prefix)
+	if req.URL.RawPath != "" {
+		req.URL.RawPath = strings.TrimPrefix(req.URL.RawPath, prefix)
+	}
+}
+
 type hostHeaderProvider func(req *http.Request) string
 
 func matchWorkspaceHostHeader(wsHostSuffix string, headerProvider hostHeaderProvider, matchPort bool) mux.MatcherFunc {
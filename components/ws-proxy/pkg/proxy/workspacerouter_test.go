@@ -17,6 +17,7 @@ import (
 func TestWorkspaceRouter(t *testing.T) {
 	const wsHostRegex = "\\.ws\\.gitpod\\.dev"
 	const wsHostSuffix = ".ws.gitpod.dev"
+	const portHost = "ports.gitpod.dev"
 	type Expectation struct {
 		WorkspaceID        string
 		WorkspacePort      string
@@ -75,6 +76,164 @@ func TestWorkspaceRouter(t *testing.T) {
 				URL:    "http://blobserve.ws.gitpod.dev/image:version:/foo/main.js",
 			},
 		},
+		{
+			Name:   "path-based workspace access",
+			URL:    "http://gitpod.dev/ws/amaranth-smelt-9ba20cc1/services",
+			Router: PathBasedRouter("Host", portHost),
+			Expected: Expectation{
+				WorkspaceID: "amaranth-smelt-9ba20cc1",
+				Status:      http.StatusOK,
+				URL:         "http://gitpod.dev/services",
+			},
+		},
+		{
+			Name:   "path-based port access",
+			URL:    "http://gitpod.dev/ws/amaranth-smelt-9ba20cc1/ports/1234/",
+			Router: PathBasedRouter("Host", portHost),
+			Expected: Expectation{
+				Status:             http.StatusForbidden,
+				AdditionalHitCount: -1,
+			},
+		},
+		{
+			Name:   "path-based port access on the port host",
+			URL:    "http://ports.gitpod.dev/ws/amaranth-smelt-9ba20cc1/ports/1234/",
+			Router: PathBasedRouter("Host", portHost),
+			Expected: Expectation{
+				WorkspaceID:   "amaranth-smelt-9ba20cc1",
+				WorkspacePort: "1234",
+				Status:        http.StatusOK,
+				URL:           "http://ports.gitpod.dev/",
+			},
+		},
+		{
+			Name:   "path-based port access without slash on the port host",
+			URL:    "http://ports.gitpod.dev/ws/amaranth-smelt-9ba20cc1/ports/1234",
+			Router: PathBasedRouter("Host", portHost),
+			Expected: Expectation{
+				Status:             http.StatusMovedPermanently,
+				AdditionalHitCount: -1,
+			},
+		},
+		{
+			Name:   "path-based foreign content access on the port host",
+			URL:    "http://ports.gitpod.dev/ws/amaranth-smelt-9ba20cc1/foreign/webview/index.html",
+			Router: PathBasedRouter("Host", portHost),
+			Expected: Expectation{
+				WorkspaceID: "amaranth-smelt-9ba20cc1",
+				Status:      http.StatusOK,
+				URL:         "http://ports.gitpod.dev/ws/amaranth-smelt-9ba20cc1/foreign/webview/index.html",
+			},
+		},
+		{
+			Name:   "path-based workspace access on the port host",
+			URL:    "http://ports.gitpod.dev/ws/amaranth-smelt-9ba20cc1/services",
+			Router: PathBasedRouter("Host", portHost),
+			Expected: Expectation{
+				Status:             http.StatusNotFound,
+				AdditionalHitCount: -1,
+			},
+		},
+		{
+			Name:   "path-based blobserve access on the port host",
+			URL:    "http://ports.gitpod.dev/blobserve/image:version:/foo/main.js",
+			Router: PathBasedRouter("Host", portHost),
+			Expected: Expectation{
+				Status:             http.StatusNotFound,
+				AdditionalHitCount: -1,
+			},
+		},
+		{
+			Name: "path-based cross-site port access",
+			URL:  "http://ports.gitpod.dev/ws/amaranth-smelt-9ba20cc1/ports/1234/",
+			Headers: map[string]string{
+				"Sec-Fetch-Site": "cross-site",
+				"Sec-Fetch-Mode": "cors",
+				"Origin":         "http://example.com",
+			},
+			Router: PathBasedRouter("Host", portHost),
+			Expected: Expectation{
+				WorkspaceID:   "amaranth-smelt-9ba20cc1",
+				WorkspacePort: "1234",
+				Status:        http.StatusOK,
+				URL:           "http://ports.gitpod.dev/",
+			},
+		},
+		{
+			Name:   "path-based blobserve access",
+			URL:    "http://gitpod.dev/blobserve/image:version:/foo/main.js",
+			Router: PathBasedRouter("Host", portHost),
+			Expected: Expectation{
+				Status: http.StatusOK,
+				URL:    "http://gitpod.dev/image:version:/foo/main.js",
+			},
+		},
+		{
+			Name: "path-based supervisor access without prefix",
+			URL:  "http://gitpod.dev/_supervisor/v1/status/ide",
+			Headers: map[string]string{
+				"Referer": "http://gitpod.dev/ws/amaranth-smelt-9ba20cc1/",
+			},
+			Router: PathBasedRouter("Host", portHost),
+			Expected: Expectation{
+				Status:             http.StatusNotFound,
+				AdditionalHitCount: -1,
+			},
+		},
+		{
+			Name: "path-based cross-site request",
+			URL:  "http://gitpod.dev/ws/amaranth-smelt-9ba20cc1/services",
+			Headers: map[string]string{
+				"Sec-Fetch-Site": "cross-site",
+				"Sec-Fetch-Mode": "cors",
+				"Origin":         "http://evil.com",
+			},
+			Router: PathBasedRouter("Host", portHost),
+			Expected: Expectation{
+				Status:             http.StatusForbidden,
+				AdditionalHitCount: -1,
+			},
+		},
+		{
+			Name: "path-based cross-origin request without fetch metadata",
+			URL:  "http://gitpod.dev/ws/amaranth-smelt-9ba20cc1/services",
+			Headers: map[string]string{
+				"Origin": "http://evil.com",
+			},
+			Router: PathBasedRouter("Host", portHost),
+			Expected: Expectation{
+				Status:             http.StatusForbidden,
+				AdditionalHitCount: -1,
+			},
+		},
+		{
+			Name: "path-based cross-site navigation",
+			URL:  "http://gitpod.dev/ws/amaranth-smelt-9ba20cc1/services",
+			Headers: map[string]string{
+				"Sec-Fetch-Site": "cross-site",
+				"Sec-Fetch-Mode": "navigate",
+			},
+			Router: PathBasedRouter("Host", portHost),
+			Expected: Expectation{
+				WorkspaceID: "amaranth-smelt-9ba20cc1",
+				Status:      http.StatusOK,
+				URL:         "http://gitpod.dev/services",
+			},
+		},
+		{
+			Name: "path-based same-origin request",
+			URL:  "http://gitpod.dev/ws/amaranth-smelt-9ba20cc1/services",
+			Headers: map[string]string{
+				"Sec-Fetch-Site": "same-origin",
+				"Origin":         "http://gitpod.dev",
+			},
+			Router: PathBasedRouter("Host", portHost),
+			Expected: Expectation{
+				WorkspaceID: "amaranth-smelt-9ba20cc1",
+				Status:      http.StatusOK,
+				URL:         "http://gitpod.dev/services",
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -99,6 +258,10 @@ func TestWorkspaceRouter(t *testing.T) {
 			if ideRouter != nil {
 				ideRouter.HandleFunc("/", actRecorder)
 				ideRouter.HandleFunc("/services", actRecorder)
+				ideRouter.HandleFunc("/_supervisor/v1/status/ide", actRecorder)
+				ideRouter.MatcherFunc(func(req *http.Request, m *mux.RouteMatch) bool {
+					return m.Vars != nil && m.Vars[foreignOriginIdentifier] != ""
+				}).HandlerFunc(actRecorder)
 			}
 			if portRouter != nil {
 				portRouter.HandleFunc("/", actRecorder)
@@ -316,3 +479,37 @@ func TestMatchWorkspaceHostHeader(t *testing.T) {
 		})
 	}
 }
+
+func TestPathPrefixRewrite(t *testing.T) {
+	const prefix = "/ws/amaranth-smelt-9ba20cc1/ports/3000"
+	locations := []struct {
+		Location    string
+		Expectation string
+	}{
+		{Location: "/login?next=%2F", Expectation: prefix + "/login?next=%2F"},
+		{Location: "http://gitpod.dev/login", Expectation: "http://gitpod.dev" + prefix + "/login"},
+		{Location: "https://github.com/login", Expectation: "https://github.com/login"},
+		{Location: "login", Expectation: "login"},
+		{Location: prefix + "/login", Expectation: prefix + "/login"},
+	}
+	for _, test := range locations {
+		if act := prefixLocation(test.Location, "gitpod.dev", prefix); act != test.Expectation {
+			t.Errorf("location %s: expected %s, got %s", test.Location, test.Expectation, act)
+		}
+	}
+
+	cookies := []struct {
+		Cookie      string
+		Expectation string
+	}{
+		{Cookie: "session=foo; Path=/; HttpOnly", Expectation: "session=foo; Path=" + prefix + "; HttpOnly"},
+		{Cookie: "session=foo; path=/api", Expectation: "session=foo; path=" + prefix + "/api"},
+		{Cookie: "session=foo; Path=" + prefix + "/api", Expectation: "session=foo; Path=" + prefix + "/api"},
+		{Cookie: "session=foo", Expectation: "session=foo"},
+	}
+	for _, test := range cookies {
+		if act := prefixCookiePath(test.Cookie, prefix); act != test.Expectation {
+			t.Errorf("cookie %s: expected %s, got %s", test.Cookie, test.Expectation, act)
+		}
+	}
+}
@@ -60,6 +60,21 @@ Conditions:
 Spec:
   Image:	{{ .Spec.WorkspaceImage }}
   URL:	{{ .Spec.Url }}
+{{- with .StartTimings }}
+Start Timings:
+{{- with .Scheduling }}
+  Scheduling:	{{ .AsDuration }}{{ end }}
+{{- with .ImagePull }}
+  Image Pull:	{{ .AsDuration }}{{ end }}
+{{- with .ContentInit }}
+  Content Init:	{{ .AsDuration }}{{ end }}
+{{- if .Initializer }}
+  Initializer:	{{ .Initializer }} ({{ .ContentBytes }} bytes){{ end }}
+{{- with .IdeReady }}
+  IDE Ready:	{{ .AsDuration }}{{ end }}
+{{- with .Total }}
+  Total:	{{ .AsDuration }}{{ end }}
+{{- end }}
 `
 		err = getOutputFormat(tpl, "{.id}").Print(status)
 		if err != nil {